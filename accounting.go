@@ -0,0 +1,154 @@
+package oas
+
+import (
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// ByteAccounting returns a middleware that counts the bytes of request and
+// response bodies of operations and publishes them as BytesTransferred
+// event when the request is served, e.g. to collect bandwidth metrics with
+// BandwidthMeter or to bill the usage. Request bytes are the bytes read
+// from the request body by the downstream handlers, so bodies that are not
+// read are not counted.
+//
+// The principal of the event is taken from the request, so the middleware
+// must come after the authenticator for requests to be attributed.
+func (b *ResolvingBasis) ByteAccounting() Middleware {
+	return func(next http.Handler) http.Handler {
+		return &byteAccounting{
+			next:   next,
+			strict: b.strict,
+		}
+	}
+}
+
+// byteAccounting is a middleware that resolves operation context from the
+// request and counts request and response bytes.
+type byteAccounting struct {
+	next http.Handler
+
+	// strict enforces accounting. If false, then requests without
+	// operation context are passed.
+	strict bool
+}
+
+func (mw *byteAccounting) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	oi, ok := getOperationInfo(req)
+	if !ok {
+		if mw.strict {
+			panic("byte accounting middleware: cannot find operation info in the request context")
+		}
+		mw.next.ServeHTTP(w, req)
+		return
+	}
+
+	if !hasSubscribers() {
+		mw.next.ServeHTTP(w, req)
+		return
+	}
+
+	var body *countingReader
+	if req.Body != nil && req.Body != http.NoBody {
+		body = &countingReader{ReadCloser: req.Body}
+		req.Body = body
+	}
+
+	rr := newWrapResponseWriter(w, req.ProtoMajor)
+	mw.next.ServeHTTP(rr, req)
+
+	e := BytesTransferred{
+		OperationID:   oi.operation.ID,
+		Status:        rr.Status(),
+		ResponseBytes: int64(rr.BytesWritten()),
+	}
+	if e.Status == 0 {
+		e.Status = http.StatusOK
+	}
+	if body != nil {
+		e.RequestBytes = body.count()
+	}
+	if p, ok := GetPrincipal(req); ok {
+		e.Principal = p.ID
+	}
+	publish(e)
+}
+
+// countingReader is a request body that counts the bytes read from it.
+type countingReader struct {
+	io.ReadCloser
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	atomic.AddInt64(&r.n, int64(n))
+	return n, err
+}
+
+// count returns the number of bytes read.
+func (r *countingReader) count() int64 {
+	return atomic.LoadInt64(&r.n)
+}
+
+// OperationBandwidth is the bandwidth usage of an operation.
+type OperationBandwidth struct {
+	OperationID   string
+	Requests      int64
+	RequestBytes  int64
+	ResponseBytes int64
+}
+
+// BandwidthMeter is a Subscriber that aggregates BytesTransferred events
+// per operation:
+//
+//  meter := oas.NewBandwidthMeter()
+//  defer oas.Subscribe(meter)()
+//
+// Other events are ignored.
+type BandwidthMeter struct {
+	mx         sync.Mutex
+	operations map[string]*OperationBandwidth
+}
+
+// NewBandwidthMeter returns a new bandwidth meter.
+func NewBandwidthMeter() *BandwidthMeter {
+	return &BandwidthMeter{operations: make(map[string]*OperationBandwidth)}
+}
+
+// HandleEvent implements Subscriber.
+func (m *BandwidthMeter) HandleEvent(e Event) {
+	bt, ok := e.(BytesTransferred)
+	if !ok {
+		return
+	}
+
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	ob, ok := m.operations[bt.OperationID]
+	if !ok {
+		ob = &OperationBandwidth{OperationID: bt.OperationID}
+		m.operations[bt.OperationID] = ob
+	}
+	ob.Requests++
+	ob.RequestBytes += bt.RequestBytes
+	ob.ResponseBytes += bt.ResponseBytes
+}
+
+// Snapshot returns the bandwidth usage of operations, sorted by operation
+// id.
+func (m *BandwidthMeter) Snapshot() []OperationBandwidth {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	obs := make([]OperationBandwidth, 0, len(m.operations))
+	for _, ob := range m.operations {
+		obs = append(obs, *ob)
+	}
+	sort.Slice(obs, func(i, j int) bool { return obs[i].OperationID < obs[j].OperationID })
+	return obs
+}
@@ -0,0 +1,77 @@
+package oas
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolvingBasis_ByteAccounting(t *testing.T) {
+	doc := loadDocBytes([]byte(`
+swagger: "2.0"
+info:
+  title: Test
+  version: 1.0.0
+paths:
+  /echo:
+    post:
+      operationId: echo
+      responses:
+        200:
+          description: OK
+  /ping:
+    get:
+      operationId: ping
+      responses:
+        200:
+          description: OK
+`))
+
+	var events []BytesTransferred
+	unsubscribe := Subscribe(SubscriberFunc(func(e Event) {
+		if bt, ok := e.(BytesTransferred); ok {
+			events = append(events, bt)
+		}
+	}))
+	defer unsubscribe()
+
+	meter := NewBandwidthMeter()
+	defer Subscribe(meter)()
+
+	basis := NewResolvingBasis(SpecAdapterName, doc, BasisStrict(false))
+	h := SpecMatcherMiddleware(doc)(
+		basis.ByteAccounting()(
+			http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if req.Method == http.MethodGet {
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+				data, _ := ioutil.ReadAll(req.Body)
+				w.Write(append(data, data...)) // nolint: errcheck
+			}),
+		),
+	)
+
+	serve := func(req *http.Request) {
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	serve(WithPrincipal(httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader("hello")), Principal{ID: "alice"}))
+	serve(httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader("hi")))
+	serve(httptest.NewRequest(http.MethodGet, "/ping", nil))
+
+	assert.Equal(t, []BytesTransferred{
+		{OperationID: "echo", Principal: "alice", Status: 200, RequestBytes: 5, ResponseBytes: 10},
+		{OperationID: "echo", Status: 200, RequestBytes: 2, ResponseBytes: 4},
+		{OperationID: "ping", Status: 204},
+	}, events)
+
+	assert.Equal(t, []OperationBandwidth{
+		{OperationID: "echo", Requests: 2, RequestBytes: 7, ResponseBytes: 14},
+		{OperationID: "ping", Requests: 1},
+	}, meter.Snapshot())
+}
@@ -47,6 +47,39 @@ func TestOperationRouter(t *testing.T) {
 	assert.ElementsMatch(t, []string{"addPet", "loginUser"}, notHandledOps)
 }
 
+func TestOperationRouter_routingPolicies(t *testing.T) {
+	doc, err := oas.LoadFile("testdata/petstore.yml")
+	assert.NoError(t, err)
+
+	r := chi.NewRouter()
+	basis := oas.NewResolvingBasis("chi", doc)
+
+	err = basis.OperationRouter(r).
+		WithOperationHandlers(map[string]http.Handler{
+			"getPetById": getPetHandler{},
+		}).
+		WithMiddleware(basis.PathParamsContext()).
+		Build()
+	assert.NoError(t, err)
+
+	h := oas.RoutingPolicies(doc, oas.MatchCaseInsensitive(true))(r)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/V2/Pet/12/", nil)
+	h.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	h = oas.RoutingPolicies(doc, oas.MatchTrailingSlash(oas.TrailingSlashPermanentRedirect))(r)
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/v2/pet/12/", nil)
+	h.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusPermanentRedirect, w.Code)
+	assert.Equal(t, "/v2/pet/12", w.Header().Get("Location"))
+}
+
 type getPetHandler struct{}
 
 func (h getPetHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
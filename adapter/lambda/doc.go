@@ -0,0 +1,3 @@
+// Package oas_lambda provides an adapter that allows to serve oas routers
+// in AWS Lambda functions behind Amazon API Gateway.
+package oas_lambda
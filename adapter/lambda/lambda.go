@@ -0,0 +1,164 @@
+package oas_lambda
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// Request represents an Amazon API Gateway proxy request event.
+//
+// It is compatible with events.APIGatewayProxyRequest from
+// github.com/aws/aws-lambda-go, so it can be used directly in a Lambda
+// handler signature without importing the SDK.
+type Request struct {
+	Resource                        string              `json:"resource"`
+	Path                            string              `json:"path"`
+	HTTPMethod                      string              `json:"httpMethod"`
+	Headers                         map[string]string   `json:"headers"`
+	MultiValueHeaders               map[string][]string `json:"multiValueHeaders"`
+	QueryStringParameters           map[string]string   `json:"queryStringParameters"`
+	MultiValueQueryStringParameters map[string][]string `json:"multiValueQueryStringParameters"`
+	PathParameters                  map[string]string   `json:"pathParameters"`
+	Body                            string              `json:"body"`
+	IsBase64Encoded                 bool                `json:"isBase64Encoded,omitempty"`
+}
+
+// Response represents an Amazon API Gateway proxy response.
+//
+// It is compatible with events.APIGatewayProxyResponse from
+// github.com/aws/aws-lambda-go.
+type Response struct {
+	StatusCode        int                 `json:"statusCode"`
+	Headers           map[string]string   `json:"headers"`
+	MultiValueHeaders map[string][]string `json:"multiValueHeaders"`
+	Body              string              `json:"body"`
+	IsBase64Encoded   bool                `json:"isBase64Encoded,omitempty"`
+}
+
+// HandlerFunc is a Lambda function handler.
+type HandlerFunc func(ctx context.Context, event Request) (Response, error)
+
+// NewHandler returns a Lambda function handler that serves API Gateway events
+// using h, which is usually a router built with oas. This way the same
+// spec-driven validation code runs in Lambda without an HTTP listener:
+//
+//  lambda.Start(oas_lambda.NewHandler(router))
+func NewHandler(h http.Handler) HandlerFunc {
+	return func(ctx context.Context, event Request) (Response, error) {
+		req, err := NewRequest(ctx, event)
+		if err != nil {
+			return Response{}, err
+		}
+
+		w := newResponseWriter()
+		h.ServeHTTP(w, req)
+		return w.response(), nil
+	}
+}
+
+// NewRequest translates API Gateway proxy request event to *http.Request.
+func NewRequest(ctx context.Context, event Request) (*http.Request, error) {
+	query := url.Values{}
+	for k, v := range event.QueryStringParameters {
+		query.Set(k, v)
+	}
+	for k, vv := range event.MultiValueQueryStringParameters {
+		query[k] = vv
+	}
+
+	u := url.URL{
+		Path:     event.Path,
+		RawQuery: query.Encode(),
+	}
+
+	body := []byte(event.Body)
+	if event.IsBase64Encoded {
+		b, err := base64.StdEncoding.DecodeString(event.Body)
+		if err != nil {
+			return nil, errors.Wrap(err, "decode base64 request body")
+		}
+		body = b
+	}
+
+	req, err := http.NewRequest(event.HTTPMethod, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrap(err, "create request")
+	}
+	if len(body) == 0 {
+		req.Body = http.NoBody
+	}
+
+	for k, v := range event.Headers {
+		req.Header.Set(k, v)
+	}
+	for k, vv := range event.MultiValueHeaders {
+		req.Header.Del(k)
+		for _, v := range vv {
+			req.Header.Add(k, v)
+		}
+	}
+
+	req.Host = req.Header.Get("Host")
+	req.RequestURI = u.RequestURI()
+
+	return req.WithContext(ctx), nil
+}
+
+// responseWriter is a http.ResponseWriter that collects the response.
+type responseWriter struct {
+	header http.Header
+	code   int
+	body   bytes.Buffer
+}
+
+func newResponseWriter() *responseWriter {
+	return &responseWriter{header: make(http.Header)}
+}
+
+func (w *responseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(b)
+}
+
+// response translates collected response to API Gateway proxy response.
+func (w *responseWriter) response() Response {
+	resp := Response{
+		StatusCode:        w.code,
+		Headers:           make(map[string]string, len(w.header)),
+		MultiValueHeaders: make(map[string][]string, len(w.header)),
+	}
+	if resp.StatusCode == 0 {
+		resp.StatusCode = http.StatusOK
+	}
+
+	for k, vv := range w.header {
+		resp.Headers[k] = strings.Join(vv, ",")
+		resp.MultiValueHeaders[k] = vv
+	}
+
+	if utf8.Valid(w.body.Bytes()) {
+		resp.Body = w.body.String()
+	} else {
+		resp.Body = base64.StdEncoding.EncodeToString(w.body.Bytes())
+		resp.IsBase64Encoded = true
+	}
+
+	return resp
+}
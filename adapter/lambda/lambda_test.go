@@ -0,0 +1,51 @@
+package oas_lambda
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewHandler(t *testing.T) {
+	h := NewHandler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		b, err := ioutil.ReadAll(req.Body)
+		assert.NoError(t, err)
+
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(req.Method + " " + req.URL.String() + " " + req.Header.Get("X-Foo") + " " + string(b)))
+	}))
+
+	resp, err := h(context.Background(), Request{
+		Path:       "/v2/pet",
+		HTTPMethod: http.MethodPost,
+		Headers:    map[string]string{"X-Foo": "bar"},
+		MultiValueQueryStringParameters: map[string][]string{
+			"tag": {"a", "b"},
+		},
+		Body:            "eyJuYW1lIjoiS2l0dHkifQ==",
+		IsBase64Encoded: true,
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "text/plain", resp.Headers["Content-Type"])
+	assert.Equal(t, `POST /v2/pet?tag=a&tag=b bar {"name":"Kitty"}`, resp.Body)
+	assert.False(t, resp.IsBase64Encoded)
+}
+
+func TestNewHandler_binaryResponse(t *testing.T) {
+	h := NewHandler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte{0xff, 0xfe})
+	}))
+
+	resp, err := h(context.Background(), Request{Path: "/", HTTPMethod: http.MethodGet})
+	assert.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "//4=", resp.Body)
+	assert.True(t, resp.IsBase64Encoded)
+}
@@ -0,0 +1,250 @@
+package oas
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// AdminAuthFunc authorizes requests to the admin handler. It returns false
+// for requests that must be rejected.
+type AdminAuthFunc func(req *http.Request) bool
+
+// Admin is an opt-in handler for runtime inspection of the service built on
+// a basis: it lists routes, reports validation statistics and the current
+// validation profile, and toggles validator modes per operation. It is meant
+// to be mounted under a separate mux, e.g. on an internal port:
+//
+//  admin := oas.NewAdmin(basis, func(req *http.Request) bool {
+//      return req.Header.Get("Authorization") == "Bearer "+adminToken
+//  })
+//  opts := []oas.MiddlewareOption{oas.WithFlagProvider(admin)}
+//  h := basis.OperationContext()(admin.Middleware()(basis.QueryValidator(opts...)(mux)))
+//  adminMux.Handle("/admin/", http.StripPrefix("/admin", admin))
+//
+// The handler serves the following endpoints:
+//
+//  GET /routes                  operations with methods and paths
+//  GET /stats                   validation statistics per operation
+//  GET /profile                 the validation profile of the basis
+//  GET /toggles                 validator modes per operation
+//  PUT /toggles/{operationId}   sets the validator mode, e.g. {"mode":"observe"}
+//  GET /stores/{name}           entries of the store, see RegisterStore
+//  DELETE /stores/{name}        purges entries of the store
+//
+// Statistics are collected by Admin.Middleware, and toggles take effect for
+// validators that use Admin as the FlagProvider.
+//
+// Admin is safe for concurrent use.
+type Admin struct {
+	basis *ResolvingBasis
+	auth  AdminAuthFunc
+
+	mx     sync.RWMutex
+	modes  map[string]ValidatorMode
+	stats  map[string]*AdminOperationStats
+	stores map[string]StoreInspector
+}
+
+// AdminOperationStats describes validation statistics of an operation.
+type AdminOperationStats struct {
+	// Requests is the number of requests.
+	Requests int64 `json:"requests"`
+
+	// Rejected is the number of requests with at least one failed check.
+	Rejected int64 `json:"rejected"`
+
+	// Failures are the numbers of failed checks by check names.
+	Failures map[string]int64 `json:"failures,omitempty"`
+}
+
+// validatorModeNames are the names of validator modes used by Admin.
+var validatorModeNames = map[ValidatorMode]string{
+	ValidatorEnforce: "enforce",
+	ValidatorObserve: "observe",
+	ValidatorOff:     "off",
+}
+
+// NewAdmin returns a new Admin for the basis. Requests that are not
+// authorized by auth are rejected with 401 Unauthorized. If auth is nil,
+// it panics.
+func NewAdmin(b *ResolvingBasis, auth AdminAuthFunc) *Admin {
+	if auth == nil {
+		panic("oas: NewAdmin auth is nil")
+	}
+
+	return &Admin{
+		basis: b,
+		auth:  auth,
+		modes: make(map[string]ValidatorMode),
+		stats: make(map[string]*AdminOperationStats),
+	}
+}
+
+// ValidatorMode implements FlagProvider. It returns the mode toggled for
+// the operation of the request, or ValidatorEnforce if the mode was not
+// toggled.
+func (a *Admin) ValidatorMode(req *http.Request, check string) ValidatorMode {
+	oi, ok := getOperationInfo(req)
+	if !ok {
+		return ValidatorEnforce
+	}
+
+	a.mx.RLock()
+	defer a.mx.RUnlock()
+	return a.modes[oi.operation.ID]
+}
+
+// Middleware returns a middleware that collects validation statistics.
+// It must come after the operation context middleware and before the
+// validators.
+func (a *Admin) Middleware() Middleware {
+	return func(next http.Handler) http.Handler {
+		return ValidationReportContext()(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			next.ServeHTTP(w, req)
+
+			oi, ok := getOperationInfo(req)
+			if !ok {
+				return
+			}
+			report, _ := GetValidationReport(req)
+			a.record(oi.operation.ID, report.Checks())
+		}))
+	}
+}
+
+func (a *Admin) record(id string, checks []ValidationCheck) {
+	a.mx.Lock()
+	defer a.mx.Unlock()
+
+	s, ok := a.stats[id]
+	if !ok {
+		s = &AdminOperationStats{Failures: make(map[string]int64)}
+		a.stats[id] = s
+	}
+
+	s.Requests++
+	rejected := false
+	for _, c := range checks {
+		if !c.Passed {
+			s.Failures[c.Name]++
+			rejected = true
+		}
+	}
+	if rejected {
+		s.Rejected++
+	}
+}
+
+// ServeHTTP implements http.Handler.
+func (a *Admin) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if !a.auth(req) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	path := strings.TrimSuffix(req.URL.Path, "/")
+	switch {
+	case path == "/routes" && req.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, a.routes())
+	case path == "/stats" && req.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, a.statsSnapshot())
+	case path == "/profile" && req.Method == http.MethodGet:
+		profile := a.basis.profileName
+		if profile == "" {
+			profile = ProfileStrict
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"profile": profile})
+	case path == "/toggles" && req.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, a.toggles())
+	case strings.HasPrefix(path, "/toggles/") && req.Method == http.MethodPut:
+		a.toggle(w, req, strings.TrimPrefix(path, "/toggles/"))
+	case strings.HasPrefix(path, "/stores/"):
+		a.serveStore(w, req, strings.TrimPrefix(path, "/stores/"))
+	default:
+		http.NotFound(w, req)
+	}
+}
+
+// AdminRoute describes an operation route.
+type AdminRoute struct {
+	OperationID string `json:"operationId"`
+	Method      string `json:"method"`
+	Path        string `json:"path"`
+}
+
+func (a *Admin) routes() []AdminRoute {
+	var routes []AdminRoute
+	for method, paths := range a.basis.doc.Analyzer.Operations() {
+		for _, op := range paths {
+			routes = append(routes, AdminRoute{
+				OperationID: op.ID,
+				Method:      strings.ToUpper(method),
+				Path:        a.basis.cache[op.ID].path,
+			})
+		}
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		return routes[i].OperationID < routes[j].OperationID
+	})
+	return routes
+}
+
+func (a *Admin) statsSnapshot() map[string]AdminOperationStats {
+	a.mx.RLock()
+	defer a.mx.RUnlock()
+
+	stats := make(map[string]AdminOperationStats, len(a.stats))
+	for id, s := range a.stats {
+		c := *s
+		c.Failures = make(map[string]int64, len(s.Failures))
+		for k, v := range s.Failures {
+			c.Failures[k] = v
+		}
+		stats[id] = c
+	}
+	return stats
+}
+
+func (a *Admin) toggles() map[string]string {
+	a.mx.RLock()
+	defer a.mx.RUnlock()
+
+	toggles := make(map[string]string, len(a.basis.cache))
+	for id := range a.basis.cache {
+		toggles[id] = validatorModeNames[a.modes[id]]
+	}
+	return toggles
+}
+
+func (a *Admin) toggle(w http.ResponseWriter, req *http.Request, id string) {
+	if _, ok := a.basis.cache[id]; !ok {
+		http.Error(w, "operation "+id+" is not found in the spec", http.StatusNotFound)
+		return
+	}
+
+	var body struct {
+		Mode string `json:"mode"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		http.Error(w, "request body contains invalid json: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	for mode, name := range validatorModeNames {
+		if name != body.Mode {
+			continue
+		}
+
+		a.mx.Lock()
+		a.modes[id] = mode
+		a.mx.Unlock()
+
+		writeJSON(w, http.StatusOK, map[string]string{id: name})
+		return
+	}
+
+	http.Error(w, "unknown validator mode "+body.Mode+", must be one of enforce, observe, off", http.StatusBadRequest)
+}
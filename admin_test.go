@@ -0,0 +1,72 @@
+package oas
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdmin(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+	b := NewResolvingBasis(SpecAdapterName, doc, BasisProfile(ProfileDev))
+
+	admin := NewAdmin(b, func(req *http.Request) bool {
+		return req.Header.Get("Authorization") == "Bearer secret"
+	})
+	h := b.OperationContext()(admin.Middleware()(b.QueryValidator(WithFlagProvider(admin))(http.HandlerFunc(handleUserLogin))))
+
+	login := func() int {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v2/user/login?username=johndoe", nil))
+		return w.Code
+	}
+	call := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer secret")
+		w := httptest.NewRecorder()
+		admin.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("unauthorized", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		admin.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/routes", nil))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("routes", func(t *testing.T) {
+		w := call(http.MethodGet, "/routes", "")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `{"operationId":"loginUser","method":"GET","path":"/v2/user/login"}`)
+	})
+
+	t.Run("profile", func(t *testing.T) {
+		w := call(http.MethodGet, "/profile", "")
+		assert.JSONEq(t, `{"profile":"dev"}`, w.Body.String())
+	})
+
+	t.Run("stats", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, login())
+
+		w := call(http.MethodGet, "/stats", "")
+		assert.JSONEq(t, `{"loginUser":{"requests":1,"rejected":1,"failures":{"query":1}}}`, w.Body.String())
+	})
+
+	t.Run("toggles", func(t *testing.T) {
+		w := call(http.MethodPut, "/toggles/loginUser", `{"mode":"off"}`)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, http.StatusOK, login())
+
+		w = call(http.MethodGet, "/toggles", "")
+		assert.JSONEq(t, `{"addPet":"enforce","getPetById":"enforce","loginUser":"off"}`, w.Body.String())
+
+		w = call(http.MethodPut, "/toggles/loginUser", `{"mode":"loose"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = call(http.MethodPut, "/toggles/unknown", `{"mode":"off"}`)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -0,0 +1,259 @@
+package oas
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-openapi/jsonpointer"
+)
+
+// AuditExtension is the operation extension that marks the operation as
+// audited and declares the fields of audit records, emitted by AuditLogger,
+// as JSON Pointers into the request:
+//
+//  paths:
+//    /pet/{petId}:
+//      post:
+//        operationId: updatePetWithForm
+//        x-oas-audit:
+//          petId: /path/petId
+//          status: /body/status
+//          requestId: /header/X-Request-ID
+//
+// Pointers start with the request part: "/path", "/query" and "/header"
+// are followed by the parameter name, and "/body" is followed by a JSON
+// Pointer into the JSON body, so "/body" alone is the whole body.
+const AuditExtension = "x-oas-audit"
+
+// AuditRecord is a record of an audited request.
+type AuditRecord struct {
+	Time        time.Time `json:"time"`
+	OperationID string    `json:"operationId"`
+	Principal   string    `json:"principal,omitempty"`
+	ClientIP    string    `json:"clientIp,omitempty"`
+	Method      string    `json:"method"`
+	URL         string    `json:"url"`
+	Status      int       `json:"status"`
+
+	// Fields are the values extracted from the request by pointers of
+	// AuditExtension. Fields missing in the request are omitted.
+	Fields map[string]interface{} `json:"fields,omitempty"`
+}
+
+// AuditSink receives audit records, e.g. to store them in an append-only
+// log. Audit is called synchronously after the request is handled, so slow
+// sinks should hand the record off to a goroutine.
+type AuditSink interface {
+	Audit(r AuditRecord)
+}
+
+// AuditSinkFunc is a function that implements AuditSink.
+type AuditSinkFunc func(r AuditRecord)
+
+// Audit implements AuditSink.
+func (f AuditSinkFunc) Audit(r AuditRecord) {
+	f(r)
+}
+
+// auditField is a field of audit records.
+type auditField struct {
+	name    string
+	in      string
+	param   string
+	pointer jsonpointer.Pointer
+}
+
+// compileAuditFields compiles the value of AuditExtension.
+func compileAuditFields(ext interface{}) ([]auditField, error) {
+	m, ok := ext.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("value is not an object")
+	}
+
+	fields := make([]auditField, 0, len(m))
+	for name, v := range m {
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("field %s: pointer is not a string", name)
+		}
+
+		parts := strings.SplitN(s, "/", 3)
+		if len(parts) < 2 || parts[0] != "" {
+			return nil, fmt.Errorf("field %s: pointer %s must start with /", name, s)
+		}
+
+		f := auditField{name: name, in: parts[1]}
+		switch f.in {
+		case "path", "query", "header":
+			if len(parts) < 3 || parts[2] == "" {
+				return nil, fmt.Errorf("field %s: pointer %s has no parameter name", name, s)
+			}
+			f.param = parts[2]
+		case "body":
+			ptr, err := jsonpointer.New(strings.TrimPrefix(s, "/body"))
+			if err != nil {
+				return nil, fmt.Errorf("field %s: pointer %s: %s", name, s, err)
+			}
+			f.pointer = ptr
+		default:
+			return nil, fmt.Errorf("field %s: pointer %s must start with /path, /query, /header or /body", name, s)
+		}
+		fields = append(fields, f)
+	}
+
+	sort.Slice(fields, func(i, j int) bool { return fields[i].name < fields[j].name })
+	return fields, nil
+}
+
+// AuditLogger returns a middleware that emits an audit record to the sink
+// for every handled request to operations with AuditExtension. Mount it
+// after validators, so only validated requests are audited with the status
+// the handler responded with. Path parameters are taken from the request
+// context, so the middleware must come after PathParamExtractor for path
+// fields to be extracted.
+//
+// It panics if the sink is nil or any audit fields in the spec are invalid.
+func (b *ResolvingBasis) AuditLogger(sink AuditSink) Middleware {
+	if sink == nil {
+		panic("oas: AuditLogger sink is nil")
+	}
+
+	operations := make(map[string][]auditField)
+	for id, oi := range b.cache {
+		ext, ok := oi.operation.Extensions[AuditExtension]
+		if !ok {
+			continue
+		}
+		fields, err := compileAuditFields(ext)
+		if err != nil {
+			panic(fmt.Sprintf("oas: operation %s: invalid %s: %s", id, AuditExtension, err))
+		}
+		operations[id] = fields
+	}
+
+	return func(next http.Handler) http.Handler {
+		return &auditLogger{
+			next:       next,
+			sink:       sink,
+			operations: operations,
+			strict:     b.strict,
+		}
+	}
+}
+
+// auditLogger is a middleware that resolves operation context from the
+// request and emits audit records.
+type auditLogger struct {
+	next       http.Handler
+	sink       AuditSink
+	operations map[string][]auditField
+
+	// strict enforces auditing. If false, then requests without operation
+	// context are passed.
+	strict bool
+}
+
+func (mw *auditLogger) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	oi, ok := getOperationInfo(req)
+	if !ok {
+		if mw.strict {
+			panic("audit logger middleware: cannot find operation info in the request context")
+		}
+		mw.next.ServeHTTP(w, req)
+		return
+	}
+
+	fields, ok := mw.operations[oi.operation.ID]
+	if !ok {
+		mw.next.ServeHTTP(w, req)
+		return
+	}
+
+	r := AuditRecord{
+		Time:        time.Now(),
+		OperationID: oi.operation.ID,
+		Method:      req.Method,
+		URL:         req.URL.String(),
+		Fields:      extractAuditFields(req, fields),
+	}
+	if p, ok := GetPrincipal(req); ok {
+		r.Principal = p.ID
+	}
+	if ip := GetClientIP(req); ip != nil {
+		r.ClientIP = ip.String()
+	}
+
+	rr := newWrapResponseWriter(w, req.ProtoMajor)
+	mw.next.ServeHTTP(rr, req)
+
+	r.Status = rr.Status()
+	if r.Status == 0 {
+		r.Status = http.StatusOK
+	}
+	mw.sink.Audit(r)
+}
+
+// extractAuditFields extracts the fields from the request. The request body
+// is read and restored, so it is left intact for the handler.
+func extractAuditFields(req *http.Request, fields []auditField) map[string]interface{} {
+	values := make(map[string]interface{}, len(fields))
+
+	var body interface{}
+	bodyRead := false
+
+	for _, f := range fields {
+		switch f.in {
+		case "path":
+			if v := GetPathParam(req, f.param); v != nil {
+				values[f.name] = v
+			}
+		case "query":
+			if q := req.URL.Query(); q[f.param] != nil {
+				values[f.name] = q.Get(f.param)
+			}
+		case "header":
+			if h := req.Header.Get(f.param); h != "" {
+				values[f.name] = h
+			}
+		case "body":
+			if !bodyRead {
+				body = readAuditBody(req)
+				bodyRead = true
+			}
+			if body == nil {
+				continue
+			}
+			if v, _, err := f.pointer.Get(body); err == nil {
+				values[f.name] = v
+			}
+		}
+	}
+
+	return values
+}
+
+// readAuditBody reads and decodes the JSON request body, restoring it for
+// the handler. It returns nil if the body is missing or is not valid JSON.
+func readAuditBody(req *http.Request) interface{} {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil
+	}
+
+	data, err := ioutil.ReadAll(req.Body)
+	req.Body.Close() // nolint: errcheck
+	req.Body = ioutil.NopCloser(bytes.NewReader(data))
+	if err != nil {
+		return nil
+	}
+
+	var body interface{}
+	if err := currentJSONAPI().Unmarshal(data, &body); err != nil {
+		return nil
+	}
+	return body
+}
@@ -0,0 +1,118 @@
+package oas
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditLogger(t *testing.T) {
+	doc := loadDocBytes([]byte(`
+swagger: "2.0"
+info:
+  title: Test
+  version: 1.0.0
+basePath: /v2
+paths:
+  /pets/{petId}:
+    put:
+      operationId: updatePet
+      x-oas-audit:
+        petId: /path/petId
+        status: /body/status
+        tag: /body/tags/0
+        requestId: /header/X-Request-ID
+        dryRun: /query/dryRun
+      parameters:
+      - name: petId
+        in: path
+        type: integer
+        required: true
+      - name: body
+        in: body
+        schema:
+          type: object
+      responses:
+        204:
+          description: OK
+    get:
+      operationId: getPet
+      parameters:
+      - name: petId
+        in: path
+        type: integer
+        required: true
+      responses:
+        200:
+          description: OK
+`))
+
+	var records []AuditRecord
+	sink := AuditSinkFunc(func(r AuditRecord) { records = append(records, r) })
+
+	basis := NewResolvingBasis(SpecAdapterName, doc, BasisStrict(false))
+	h := SpecMatcherMiddleware(doc)(
+		http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			req = WithPrincipal(WithPathParam(req, "petId", int64(12)), Principal{ID: "alice"})
+			basis.AuditLogger(sink)(
+				http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+					if req.Method == http.MethodPut {
+						b, _ := ioutil.ReadAll(req.Body)
+						assert.Equal(t, `{"status":"sold","tags":["cat"]}`, string(b), "body must be restored")
+						w.WriteHeader(http.StatusNoContent)
+					}
+				}),
+			).ServeHTTP(w, req)
+		}),
+	)
+
+	req := httptest.NewRequest(http.MethodPut, "/v2/pets/12", strings.NewReader(`{"status":"sold","tags":["cat"]}`))
+	req.Header.Set("X-Request-ID", "abc")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	req = httptest.NewRequest(http.MethodGet, "/v2/pets/12", nil)
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	if !assert.Len(t, records, 1, "only operations with audit extension must be audited") {
+		return
+	}
+	r := records[0]
+	assert.Equal(t, "updatePet", r.OperationID)
+	assert.Equal(t, "alice", r.Principal)
+	assert.Equal(t, http.MethodPut, r.Method)
+	assert.Equal(t, "/v2/pets/12", r.URL)
+	assert.Equal(t, http.StatusNoContent, r.Status)
+	assert.Equal(t, map[string]interface{}{
+		"petId":     int64(12),
+		"status":    "sold",
+		"tag":       "cat",
+		"requestId": "abc",
+	}, r.Fields)
+}
+
+func TestAuditLogger_invalid(t *testing.T) {
+	doc := loadDocBytes([]byte(`
+swagger: "2.0"
+info:
+  title: Test
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      operationId: findPets
+      x-oas-audit:
+        status: /cookie/status
+      responses:
+        200:
+          description: OK
+`))
+
+	basis := NewResolvingBasis(SpecAdapterName, doc, BasisStrict(false))
+	assert.PanicsWithValue(t, "oas: operation findPets: invalid x-oas-audit: field status: pointer /cookie/status must start with /path, /query, /header or /body", func() {
+		basis.AuditLogger(AuditSinkFunc(func(AuditRecord) {}))
+	})
+}
@@ -3,6 +3,7 @@ package oas
 import (
 	"fmt"
 	"net/http"
+	"sort"
 )
 
 // Resolver resolves operation id from the request.
@@ -10,12 +11,49 @@ type Resolver interface {
 	Resolve(req *http.Request) (string, bool)
 }
 
+// BasisOption is an option to use when creating a basis.
+type BasisOption func(*ResolvingBasis)
+
+// BasisStrict returns a basis option that defines if middleware derived from
+// the basis should panic on requests without operation context. The basis is
+// strict by default. Non-strict middleware just pass such requests through,
+// which is useful when the middleware is applied to routes that are not
+// described in the spec.
+func BasisStrict(strict bool) BasisOption {
+	return func(b *ResolvingBasis) {
+		b.strict = strict
+	}
+}
+
+// BasisCheckErrorResponses returns a basis option that checks, when
+// validators are derived from the basis, that the operations they validate
+// declare the error responses for the status codes, e.g. 400 or 422. By
+// default, only 400 is checked. Validators respond with these status codes,
+// so operations without such responses (and without the default response)
+// are documented incompletely. The fn is called once for every operation
+// and code that is missing; it may log a warning or panic.
+//
+// Use SpecWithErrorResponses to add the missing responses to the served spec.
+func BasisCheckErrorResponses(fn func(operationID string, code int), codes ...int) BasisOption {
+	if len(codes) == 0 {
+		codes = []int{http.StatusBadRequest}
+	}
+	return func(b *ResolvingBasis) {
+		b.errorResponsesCheck = fn
+		b.errorResponseCodes = codes
+	}
+}
+
 // NewResolvingBasis returns a new resolving basis.
-func NewResolvingBasis(name string, doc *Document) *ResolvingBasis {
+func NewResolvingBasis(name string, doc *Document, opts ...BasisOption) *ResolvingBasis {
 	b := &ResolvingBasis{
 		adapter: mustGetAdapter(name),
 		doc:     doc,
 		strict:  true,
+		hooks:   make(map[HookStage][]RequestHook),
+	}
+	for _, opt := range opts {
+		opt(b)
 	}
 
 	b.initCache()
@@ -32,25 +70,78 @@ type ResolvingBasis struct {
 
 	cache map[string]operationInfo
 
+	errorResponsesCheck func(operationID string, code int)
+	errorResponseCodes  []int
+	errorResponsesSeen  map[string]bool
+
+	validators    []validatorPlugin
+	hooks         map[HookStage][]RequestHook
+	responseHooks []ResponseHook
+
 	// common options for derived middlewares
 
-	strict bool
+	strict      bool
+	profile     []MiddlewareOption
+	profileName string
+}
+
+// middlewareOptions parses the options of a derived middleware, which take
+// precedence over the basis profile options.
+func (b *ResolvingBasis) middlewareOptions(opts []MiddlewareOption) MiddlewareOptions {
+	return parseMiddlewareOptions(append(append([]MiddlewareOption(nil), b.profile...), opts...)...)
+}
+
+// checkErrorResponses checks that the operations validated by a validator
+// declare the error responses. The validates reports if the validator
+// validates the operation.
+func (b *ResolvingBasis) checkErrorResponses(validates func(oi operationInfo) bool) {
+	if b.errorResponsesCheck == nil {
+		return
+	}
+	if b.errorResponsesSeen == nil {
+		b.errorResponsesSeen = make(map[string]bool)
+	}
+
+	ids := make([]string, 0, len(b.cache))
+	for id := range b.cache {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		oi := b.cache[id]
+		if !validates(oi) {
+			continue
+		}
+		for _, code := range b.errorResponseCodes {
+			key := fmt.Sprintf("%s %d", id, code)
+			if b.errorResponsesSeen[key] || declaresResponse(oi.operation, code) {
+				continue
+			}
+			b.errorResponsesSeen[key] = true
+			b.errorResponsesCheck(id, code)
+		}
+	}
+}
+
+// hasParamsIn checks if the operation has parameters in any of the locations.
+func hasParamsIn(oi operationInfo, in ...string) bool {
+	for _, p := range oi.params {
+		for _, loc := range in {
+			if p.In == loc {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 func (b *ResolvingBasis) initCache() {
 	b.cache = make(map[string]operationInfo)
 	// _ is method
 	for _, pathOps := range b.doc.Analyzer.Operations() {
-		// _ is path
-		for _, operation := range pathOps {
-			key := operation.ID
-			value := operationInfo{
-				operation: operation,
-				params:    b.doc.Analyzer.ParametersFor(operation.ID),
-				consumes:  b.doc.Analyzer.ConsumesFor(operation),
-				produces:  b.doc.Analyzer.ProducesFor(operation),
-			}
-			b.cache[key] = value
+		for path, operation := range pathOps {
+			b.cache[operation.ID] = newOperationInfo(b.doc, path, operation)
 		}
 	}
 }
@@ -59,8 +150,8 @@ func (b *ResolvingBasis) initCache() {
 // adapter. This router is already configured to use basis oas document and
 // OperationContext middleware.
 func (b *ResolvingBasis) OperationRouter(meta interface{}) OperationRouter {
-	return b.adapter.
-		OperationRouter(meta).
+	r := &eventsRouter{router: b.adapter.OperationRouter(meta)}
+	return r.
 		WithDocument(b.doc).
 		WithMiddleware(b.OperationContext())
 }
@@ -139,7 +230,7 @@ type resolvingPathParamExtractor struct {
 }
 
 func (mw *resolvingPathParamExtractor) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	oi, ok := getOperationInfo(req)
+	params, ok := ParamsFromContext(req.Context())
 	if !ok {
 		if mw.strict {
 			panic("path params context middleware: cannot find operation info in the request context")
@@ -148,24 +239,58 @@ func (mw *resolvingPathParamExtractor) ServeHTTP(w http.ResponseWriter, req *htt
 		return
 	}
 
-	mw.next.ServeHTTP(w, req, oi.params, true)
+	mw.next.ServeHTTP(w, req, params, true)
 }
 
-// QueryValidator returns a middleware that validates request query parameters.
+// QueryValidator returns a middleware that validates request query parameters,
+// including the constraints between them declared with DependenciesExtension
+// or registered with RegisterQueryDependency. It panics if any
+// DependenciesExtension in the spec is invalid.
 func (b *ResolvingBasis) QueryValidator(opts ...MiddlewareOption) Middleware {
-	options := parseMiddlewareOptions(opts...)
+	options := b.middlewareOptions(opts)
 	if options.problemHandler == nil {
 		options.problemHandler = newProblemHandlerErrorResponder()
+		if options.hideProblemDetails {
+			options.problemHandler = newProblemHandlerDetailsHider(options.problemHandler)
+		}
+	}
+
+	b.checkErrorResponses(func(oi operationInfo) bool {
+		return hasParamsIn(oi, "query")
+	})
+
+	dependencies := make(map[string][]QueryDependencyFunc)
+	for id, oi := range b.cache {
+		fns, err := compileQueryDependencies(oi.operation, oi.params)
+		if err != nil {
+			panic(fmt.Sprintf("oas: operation %s: invalid %s: %s", id, DependenciesExtension, err))
+		}
+		if len(fns) > 0 {
+			dependencies[id] = fns
+		}
 	}
 
 	return func(next http.Handler) http.Handler {
+		qv := &queryValidator{
+			next:              next,
+			problemHandler:    options.problemHandler,
+			continueOnProblem: options.continueOnProblem,
+			allowUnknown:      options.allowUnknownQuery,
+			decimalComma:      options.decimalComma,
+			duplicates:        options.duplicateParams,
+			literalPlus:       options.literalPlus,
+			doubleDecoding:    options.doubleDecoding,
+			normalizeUnicode:  options.normalizeUnicode,
+			dependencies:      dependencies,
+		}
+		observe := *qv
+		observe.continueOnProblem = true
+
 		return &resolvingQueryValidator{
-			qv: &queryValidator{
-				next:              next,
-				problemHandler:    options.problemHandler,
-				continueOnProblem: options.continueOnProblem,
-			},
-			strict: b.strict,
+			qv:      qv,
+			observe: &observe,
+			flags:   options.flagProvider,
+			strict:  b.strict,
 		}
 	}
 }
@@ -175,13 +300,18 @@ func (b *ResolvingBasis) QueryValidator(opts ...MiddlewareOption) Middleware {
 type resolvingQueryValidator struct {
 	qv *queryValidator
 
+	// observe is the validator for requests in observe mode.
+	observe *queryValidator
+
+	flags FlagProvider
+
 	// strict enforces validation. If false, then validation is not
 	// applied to requests without operation context.
 	strict bool
 }
 
 func (mw *resolvingQueryValidator) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	oi, ok := getOperationInfo(req)
+	params, ok := ParamsFromContext(req.Context())
 	if !ok {
 		if mw.strict {
 			panic("query validator middleware: cannot find operation info in the request context")
@@ -190,7 +320,90 @@ func (mw *resolvingQueryValidator) ServeHTTP(w http.ResponseWriter, req *http.Re
 		return
 	}
 
-	mw.qv.ServeHTTP(w, req, oi.params, true)
+	switch validatorMode(mw.flags, req, CheckQuery) {
+	case ValidatorOff:
+		mw.qv.ServeHTTP(w, req, nil, false)
+	case ValidatorObserve:
+		mw.observe.ServeHTTP(w, req, params, true)
+	default:
+		mw.qv.ServeHTTP(w, req, params, true)
+	}
+}
+
+// ParamTransformer returns a middleware that transforms query and header
+// parameter values by their TransformExtension. Mount it before validators,
+// so they validate the transformed values. It panics if any transformation
+// in the spec is invalid.
+func (b *ResolvingBasis) ParamTransformer() Middleware {
+	operations := make(map[string][]paramTransforms)
+	for id, oi := range b.cache {
+		pts, err := compileParamTransforms(oi.params)
+		if err != nil {
+			panic(fmt.Sprintf("oas: operation %s: %s", id, err))
+		}
+		if len(pts) > 0 {
+			operations[id] = pts
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return &resolvingParamTransformer{
+			pt: &paramTransformer{
+				next:       next,
+				operations: operations,
+			},
+			strict: b.strict,
+		}
+	}
+}
+
+// resolvingParamTransformer is a middleware that resolves operation context
+// from the request and transforms request parameters.
+type resolvingParamTransformer struct {
+	pt *paramTransformer
+
+	// strict enforces transformation. If false, then transformation is not
+	// applied to requests without operation context.
+	strict bool
+}
+
+func (mw *resolvingParamTransformer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	oi, ok := getOperationInfo(req)
+	if !ok {
+		if mw.strict {
+			panic("param transformer middleware: cannot find operation info in the request context")
+		}
+		mw.pt.ServeHTTP(w, req, operationInfo{}, false)
+		return
+	}
+
+	mw.pt.ServeHTTP(w, req, oi, true)
+}
+
+// HostValidator returns a middleware that validates the request host and
+// scheme against the spec host and schemes. This is useful when a single
+// process serves several virtual-hosted APIs and must not answer for the
+// wrong host.
+//
+// Unlike other middleware, this one does not require operation context.
+// In case of validation error, this middleware responds with 421 by default.
+func (b *ResolvingBasis) HostValidator(opts ...MiddlewareOption) Middleware {
+	options := parseMiddlewareOptions(opts...)
+	if options.problemHandler == nil {
+		options.problemHandler = newProblemHandlerStatusResponder(statusMisdirectedRequest)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return &hostValidator{
+			next:              next,
+			host:              b.doc.Spec().Host,
+			schemes:           b.doc.Spec().Schemes,
+			trustForwarded:    options.trustForwarded,
+			problemHandler:    options.problemHandler,
+			continueOnProblem: options.continueOnProblem,
+			flags:             options.flagProvider,
+		}
+	}
 }
 
 // RequestContentTypeValidator returns a middleware that validates
@@ -199,11 +412,18 @@ func (mw *resolvingQueryValidator) ServeHTTP(w http.ResponseWriter, req *http.Re
 // In case of validation error, this middleware will respond with
 // either 406 or 415.
 func (b *ResolvingBasis) RequestContentTypeValidator(opts ...MiddlewareOption) Middleware {
+	options := b.middlewareOptions(opts)
+
 	return func(next http.Handler) http.Handler {
 		return &resolvingRequestContentTypeValidator{
 			rctv: &requestContentTypeValidator{
 				next: next,
 			},
+			observe: &requestContentTypeValidator{
+				next:              next,
+				continueOnProblem: true,
+			},
+			flags:  options.flagProvider,
 			strict: b.strict,
 		}
 	}
@@ -212,6 +432,11 @@ func (b *ResolvingBasis) RequestContentTypeValidator(opts ...MiddlewareOption) M
 type resolvingRequestContentTypeValidator struct {
 	rctv *requestContentTypeValidator
 
+	// observe is the validator for requests in observe mode.
+	observe *requestContentTypeValidator
+
+	flags FlagProvider
+
 	// strict enforces validation. If false, then validation is not
 	// applied to requests without operation context.
 	strict bool
@@ -227,25 +452,51 @@ func (mw *resolvingRequestContentTypeValidator) ServeHTTP(w http.ResponseWriter,
 		return
 	}
 
-	mw.rctv.ServeHTTP(w, req, oi.consumes, oi.produces, true)
+	switch validatorMode(mw.flags, req, CheckRequestContentType) {
+	case ValidatorOff:
+		mw.rctv.ServeHTTP(w, req, nil, nil, false)
+	case ValidatorObserve:
+		mw.observe.ServeHTTP(w, req, oi.consumes, oi.produces, true)
+	default:
+		mw.rctv.ServeHTTP(w, req, oi.consumes, oi.produces, true)
+	}
 }
 
-// RequestBodyValidator returns a middleware that validates request body.
+// RequestBodyValidator returns a middleware that validates request body by
+// the schema and then by the rules registered with RegisterBodyRule.
 func (b *ResolvingBasis) RequestBodyValidator(opts ...MiddlewareOption) Middleware {
-	options := parseMiddlewareOptions(opts...)
+	options := b.middlewareOptions(opts)
 	if options.problemHandler == nil {
 		options.problemHandler = newProblemHandlerErrorResponder()
+		if options.hideProblemDetails {
+			options.problemHandler = newProblemHandlerDetailsHider(options.problemHandler)
+		}
 	}
 
+	b.checkErrorResponses(func(oi operationInfo) bool {
+		return hasParamsIn(oi, "body")
+	})
+
 	return func(next http.Handler) http.Handler {
+		rbv := &requestBodyValidator{
+			next:              next,
+			jsonSelectors:     options.jsonSelectors,
+			transcodeLatin1:   options.transcodeLatin1,
+			useNumber:         options.useNumber,
+			maxBodySize:       options.maxBodySize,
+			normalizeUnicode:  options.normalizeUnicode,
+			load:              options.validationLoad,
+			problemHandler:    options.problemHandler,
+			continueOnProblem: options.continueOnProblem,
+		}
+		observe := *rbv
+		observe.continueOnProblem = true
+
 		return &resolvingRequestBodyValidator{
-			rbv: &requestBodyValidator{
-				next:              next,
-				jsonSelectors:     options.jsonSelectors,
-				problemHandler:    options.problemHandler,
-				continueOnProblem: options.continueOnProblem,
-			},
-			strict: b.strict,
+			rbv:     rbv,
+			observe: &observe,
+			flags:   options.flagProvider,
+			strict:  b.strict,
 		}
 	}
 }
@@ -253,13 +504,18 @@ func (b *ResolvingBasis) RequestBodyValidator(opts ...MiddlewareOption) Middlewa
 type resolvingRequestBodyValidator struct {
 	rbv *requestBodyValidator
 
+	// observe is the validator for requests in observe mode.
+	observe *requestBodyValidator
+
+	flags FlagProvider
+
 	// strict enforces validation. If false, then validation is not
 	// applied to requests without operation context.
 	strict bool
 }
 
 func (mw *resolvingRequestBodyValidator) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	oi, ok := getOperationInfo(req)
+	params, ok := ParamsFromContext(req.Context())
 	if !ok {
 		if mw.strict {
 			panic("request body validator middleware: cannot find operation info in the request context")
@@ -268,13 +524,23 @@ func (mw *resolvingRequestBodyValidator) ServeHTTP(w http.ResponseWriter, req *h
 		return
 	}
 
-	mw.rbv.ServeHTTP(w, req, oi.params, true)
+	switch validatorMode(mw.flags, req, CheckRequestBody) {
+	case ValidatorOff:
+		mw.rbv.ServeHTTP(w, req, nil, false)
+	case ValidatorObserve:
+		mw.observe.ServeHTTP(w, req, params, true)
+	default:
+		mw.rbv.ServeHTTP(w, req, params, true)
+	}
 }
 
 // ResponseContentTypeValidator returns a middleware that validates
 // Content-Type header of the response.
 func (b *ResolvingBasis) ResponseContentTypeValidator(opts ...MiddlewareOption) Middleware {
-	options := parseMiddlewareOptions(opts...)
+	options := b.middlewareOptions(opts)
+	if options.skipResponseValidation {
+		return passThrough
+	}
 	if options.problemHandler == nil {
 		options.problemHandler = newProblemHandlerWarnLogger("response")
 	}
@@ -285,6 +551,7 @@ func (b *ResolvingBasis) ResponseContentTypeValidator(opts ...MiddlewareOption)
 				next:           next,
 				problemHandler: options.problemHandler,
 			},
+			flags:  options.flagProvider,
 			strict: b.strict,
 		}
 	}
@@ -293,6 +560,8 @@ func (b *ResolvingBasis) ResponseContentTypeValidator(opts ...MiddlewareOption)
 type resolvingResponseContentTypeValidator struct {
 	rctv *responseContentTypeValidator
 
+	flags FlagProvider
+
 	// strict enforces validation. If false, then validation is not
 	// applied to requests without operation context.
 	strict bool
@@ -308,12 +577,22 @@ func (mw *resolvingResponseContentTypeValidator) ServeHTTP(w http.ResponseWriter
 		return
 	}
 
+	if validatorMode(mw.flags, req, CheckResponseContentType) == ValidatorOff {
+		mw.rctv.ServeHTTP(w, req, nil, false)
+		return
+	}
+
 	mw.rctv.ServeHTTP(w, req, oi.produces, true)
 }
 
 // ResponseBodyValidator returns a middleware that validates response body.
+// It also validates the response headers declared in the spec, see
+// WithResponseHeaderSeverity.
 func (b *ResolvingBasis) ResponseBodyValidator(opts ...MiddlewareOption) Middleware {
-	options := parseMiddlewareOptions(opts...)
+	options := b.middlewareOptions(opts)
+	if options.skipResponseValidation {
+		return passThrough
+	}
 	if options.problemHandler == nil {
 		options.problemHandler = newProblemHandlerWarnLogger("response")
 	}
@@ -321,10 +600,15 @@ func (b *ResolvingBasis) ResponseBodyValidator(opts ...MiddlewareOption) Middlew
 	return func(next http.Handler) http.Handler {
 		return &resolvingResponseBodyValidator{
 			rbv: &responseBodyValidator{
-				next:           next,
-				jsonSelectors:  options.jsonSelectors,
-				problemHandler: options.problemHandler,
+				next:             next,
+				jsonSelectors:    options.jsonSelectors,
+				problemHandler:   options.problemHandler,
+				sampler:          options.responseSampler,
+				headerSeverities: options.headerSeverities,
+				useNumber:        options.useNumber,
+				hooks:            b.responseHooks,
 			},
+			flags:  options.flagProvider,
 			strict: b.strict,
 		}
 	}
@@ -333,6 +617,8 @@ func (b *ResolvingBasis) ResponseBodyValidator(opts ...MiddlewareOption) Middlew
 type resolvingResponseBodyValidator struct {
 	rbv *responseBodyValidator
 
+	flags FlagProvider
+
 	// strict enforces validation. If false, then validation is not
 	// applied to requests without operation context.
 	strict bool
@@ -348,9 +634,58 @@ func (mw *resolvingResponseBodyValidator) ServeHTTP(w http.ResponseWriter, req *
 		return
 	}
 
+	if validatorMode(mw.flags, req, CheckResponseBody) == ValidatorOff {
+		mw.rbv.ServeHTTP(w, req, nil, false)
+		return
+	}
+
+	if mw.rbv.sampler != nil && !mw.rbv.sampler.sample(oi.operation.ID) {
+		mw.rbv.ServeHTTP(w, req, nil, false)
+		return
+	}
+
 	mw.rbv.ServeHTTP(w, req, oi.operation.Responses, true)
 }
 
+// UndeclaredStatusDetector returns a middleware that calls fn when the
+// handler responds with a status code that is declared for the operation
+// neither explicitly nor by the default response, e.g. 503 when only 200,
+// 400 and 404 are declared. Unlike ResponseBodyValidator, it does not buffer
+// the response, so it is cheap enough to track undocumented statuses even
+// when response validation is disabled.
+func (b *ResolvingBasis) UndeclaredStatusDetector(fn UndeclaredStatusFunc) Middleware {
+	return func(next http.Handler) http.Handler {
+		return &resolvingUndeclaredStatusDetector{
+			usd: &undeclaredStatusDetector{
+				next: next,
+				fn:   fn,
+			},
+			strict: b.strict,
+		}
+	}
+}
+
+type resolvingUndeclaredStatusDetector struct {
+	usd *undeclaredStatusDetector
+
+	// strict enforces detection. If false, then detection is not
+	// applied to requests without operation context.
+	strict bool
+}
+
+func (mw *resolvingUndeclaredStatusDetector) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	oi, ok := getOperationInfo(req)
+	if !ok {
+		if mw.strict {
+			panic("undeclared status detector middleware: cannot find operation info in the request context")
+		}
+		mw.usd.ServeHTTP(w, req, operationInfo{}, false)
+		return
+	}
+
+	mw.usd.ServeHTTP(w, req, oi, true)
+}
+
 // ContextualMiddleware represents a middleware that works based on request
 // operation context.
 type ContextualMiddleware interface {
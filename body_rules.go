@@ -0,0 +1,94 @@
+package oas
+
+import (
+	"strings"
+	"sync"
+)
+
+// BodyRule checks a semantic rule of the request body of an operation that
+// cannot be expressed by the schema, e.g. that the end date is after the
+// start date. It returns the violations, typically BodyRuleError values.
+type BodyRule func(body map[string]interface{}) []error
+
+// BodyRuleError is a violation of a BodyRule.
+type BodyRuleError struct {
+	// Name is the name of the violating body field, with nested fields
+	// separated by dots, e.g. "period.end".
+	Name string
+
+	Message string
+}
+
+// Error implements error.
+func (e BodyRuleError) Error() string {
+	return e.Message
+}
+
+// Field implements validate.ValidationError.
+func (e BodyRuleError) Field() string {
+	return e.Name
+}
+
+// Value implements validate.ValidationError.
+func (e BodyRuleError) Value() interface{} {
+	return nil
+}
+
+// Pointer implements validate.PointerError.
+func (e BodyRuleError) Pointer() string {
+	if e.Name == "" {
+		return ""
+	}
+	return "/" + strings.Replace(e.Name, ".", "/", -1)
+}
+
+var (
+	bodyRulesMx sync.RWMutex
+	bodyRules   = make(map[string][]BodyRule)
+)
+
+// RegisterBodyRule registers the rule of the request body of the operation,
+// e.g.:
+//
+//  oas.RegisterBodyRule("createBooking", func(body map[string]interface{}) []error {
+//      if body["endDate"].(string) <= body["startDate"].(string) {
+//          return []error{oas.BodyRuleError{
+//              Name:    "endDate",
+//              Message: "endDate must be after startDate",
+//          }}
+//      }
+//      return nil
+//  })
+//
+// Rules are executed by RequestBodyValidator after the body matches the
+// schema, so they can rely on the schema, and their violations are handled
+// by the problem handler the same way as schema violations are. Rules apply
+// only to bodies that are JSON objects. It panics if fn is nil.
+func RegisterBodyRule(operationID string, fn BodyRule) {
+	bodyRulesMx.Lock()
+	defer bodyRulesMx.Unlock()
+
+	if fn == nil {
+		panic("oas: RegisterBodyRule fn is nil")
+	}
+	bodyRules[operationID] = append(bodyRules[operationID], fn)
+}
+
+// bodyRuleErrors executes the rules registered for the operation on the
+// body.
+func bodyRuleErrors(operationID string, body interface{}) []error {
+	obj, ok := body.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	bodyRulesMx.RLock()
+	rules := bodyRules[operationID]
+	bodyRulesMx.RUnlock()
+
+	var errs []error
+	for _, fn := range rules {
+		errs = append(errs, fn(obj)...)
+	}
+	return errs
+}
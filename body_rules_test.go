@@ -0,0 +1,87 @@
+package oas
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hypnoglow/oas2/validate"
+)
+
+func TestRegisterBodyRule(t *testing.T) {
+	doc := loadDocBytes([]byte(`
+swagger: "2.0"
+info:
+  title: Test
+  version: 1.0.0
+paths:
+  /bookings:
+    post:
+      operationId: createBookingWithRules
+      consumes:
+      - application/json
+      parameters:
+      - name: body
+        in: body
+        required: true
+        schema:
+          type: object
+          required: [startDate, endDate]
+          properties:
+            startDate:
+              type: string
+            endDate:
+              type: string
+      responses:
+        200:
+          description: OK
+`))
+
+	RegisterBodyRule("createBookingWithRules", func(body map[string]interface{}) []error {
+		if body["endDate"].(string) <= body["startDate"].(string) {
+			return []error{BodyRuleError{Name: "endDate", Message: "endDate must be after startDate"}}
+		}
+		return nil
+	})
+
+	var problem error
+	basis := NewResolvingBasis(SpecAdapterName, doc, BasisStrict(false))
+	h := SpecMatcherMiddleware(doc)(
+		basis.RequestBodyValidator(WithProblemHandler(ProblemHandlerFunc(func(p Problem) {
+			problem = p.Cause()
+			p.ResponseWriter().WriteHeader(http.StatusUnprocessableEntity)
+		})))(
+			http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}),
+		),
+	)
+
+	serve := func(body string) int {
+		problem = nil
+		req := httptest.NewRequest(http.MethodPost, "/bookings", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, serve(`{"startDate":"2020-01-01","endDate":"2020-01-02"}`))
+
+	assert.Equal(t, http.StatusUnprocessableEntity, serve(`{"startDate":"2020-01-02","endDate":"2020-01-01"}`))
+	if me, ok := problem.(MultiError); assert.True(t, ok) {
+		assert.Equal(t, "request body violates the operation rules", me.Message())
+		if assert.Len(t, me.Errors(), 1) {
+			err := me.Errors()[0]
+			assert.Equal(t, "endDate must be after startDate", err.Error())
+			assert.Equal(t, "endDate", err.(validate.ValidationError).Field())
+			assert.Equal(t, "/endDate", err.(validate.PointerError).Pointer())
+		}
+	}
+
+	assert.Equal(t, http.StatusUnprocessableEntity, serve(`{"startDate":"2020-01-02"}`))
+	if me, ok := problem.(MultiError); assert.True(t, ok) {
+		assert.Equal(t, "request body does not match the schema", me.Message(), "rules are executed only on valid bodies")
+	}
+}
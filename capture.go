@@ -0,0 +1,212 @@
+package oas
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// CapturedRequest is a sanitized copy of a request that failed validation.
+type CapturedRequest struct {
+	Time        time.Time   `json:"time"`
+	OperationID string      `json:"operationId,omitempty"`
+	ClientIP    string      `json:"clientIp,omitempty"`
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	Header      http.Header `json:"header,omitempty"`
+	Body        []byte      `json:"body,omitempty"`
+	Truncated   bool        `json:"truncated,omitempty"`
+	Error       string      `json:"error"`
+}
+
+// Sink receives captured requests, e.g. to store them in a file, S3 or Kafka
+// for later debugging. Capture is called synchronously while handling the
+// problem, so slow sinks should hand the request off to a goroutine.
+type Sink interface {
+	Capture(c CapturedRequest)
+}
+
+// SinkFunc is a function that implements Sink.
+type SinkFunc func(c CapturedRequest)
+
+// Capture implements Sink.
+func (f SinkFunc) Capture(c CapturedRequest) {
+	f(c)
+}
+
+// NewWriterSink returns a Sink that writes captured requests to w as JSON
+// lines. Writes are serialized, so w does not need to be safe for concurrent
+// use. Write errors are ignored.
+func NewWriterSink(w io.Writer) Sink {
+	s := &writerSink{enc: json.NewEncoder(w)}
+	return SinkFunc(func(c CapturedRequest) {
+		s.mx.Lock()
+		defer s.mx.Unlock()
+		s.enc.Encode(c) // nolint: errcheck
+	})
+}
+
+type writerSink struct {
+	mx  sync.Mutex
+	enc *json.Encoder
+}
+
+// CaptureOption is an option for NewCapturingProblemHandler.
+type CaptureOption func(*capturingProblemHandler)
+
+// CaptureRedactHeaders returns an option that sets the headers whose values
+// are redacted in captured requests. By default, Authorization,
+// Proxy-Authorization and Cookie headers are redacted.
+func CaptureRedactHeaders(names ...string) CaptureOption {
+	return func(h *capturingProblemHandler) {
+		h.redact = make(map[string]bool, len(names))
+		for _, name := range names {
+			h.redact[http.CanonicalHeaderKey(name)] = true
+		}
+	}
+}
+
+// CaptureMaxBody returns an option that sets the maximum number of body bytes
+// to capture; longer bodies are truncated. By default, 4096 bytes are
+// captured.
+func CaptureMaxBody(n int) CaptureOption {
+	return func(h *capturingProblemHandler) {
+		h.maxBody = n
+	}
+}
+
+// CaptureLimit returns an option that limits captures to n per interval per
+// operation, to control the volume. By default, 10 requests per minute are
+// captured per operation.
+func CaptureLimit(n int, interval time.Duration) CaptureOption {
+	return func(h *capturingProblemHandler) {
+		h.limit = n
+		h.interval = interval
+	}
+}
+
+// NewCapturingProblemHandler returns a ProblemHandler that captures the
+// sanitized request to the sink and then passes the problem to the next
+// handler. Captured headers are redacted and the body is truncated. Request
+// body can be read again later.
+func NewCapturingProblemHandler(next ProblemHandler, sink Sink, opts ...CaptureOption) ProblemHandler {
+	h := &capturingProblemHandler{
+		next:     next,
+		sink:     sink,
+		maxBody:  4096,
+		limit:    10,
+		interval: time.Minute,
+		now:      time.Now,
+		windows:  make(map[string]*captureWindow),
+	}
+	CaptureRedactHeaders("Authorization", "Proxy-Authorization", "Cookie")(h)
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+type capturingProblemHandler struct {
+	next ProblemHandler
+	sink Sink
+
+	redact   map[string]bool
+	maxBody  int
+	limit    int
+	interval time.Duration
+
+	now func() time.Time
+
+	mx      sync.Mutex
+	windows map[string]*captureWindow
+}
+
+// captureWindow counts captures of an operation in the current interval.
+type captureWindow struct {
+	start time.Time
+	count int
+}
+
+func (h *capturingProblemHandler) HandleProblem(p Problem) {
+	var id string
+	if oi, ok := getOperationInfo(p.Request()); ok {
+		id = oi.operation.ID
+	}
+
+	if h.allow(id) {
+		h.sink.Capture(h.capture(p.Request(), id, p.Cause()))
+	}
+
+	h.next.HandleProblem(p)
+}
+
+// allow checks if the request of the operation can be captured within
+// the limit.
+func (h *capturingProblemHandler) allow(id string) bool {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+
+	now := h.now()
+	w, ok := h.windows[id]
+	if !ok || now.Sub(w.start) >= h.interval {
+		w = &captureWindow{start: now}
+		h.windows[id] = w
+	}
+	if w.count >= h.limit {
+		return false
+	}
+	w.count++
+	return true
+}
+
+// capture returns the sanitized copy of the request.
+func (h *capturingProblemHandler) capture(req *http.Request, id string, err error) CapturedRequest {
+	c := CapturedRequest{
+		Time:        h.now(),
+		OperationID: id,
+		Method:      req.Method,
+		URL:         req.URL.String(),
+		Header:      make(http.Header, len(req.Header)),
+	}
+	if ip := GetClientIP(req); ip != nil {
+		c.ClientIP = ip.String()
+	}
+	if err != nil {
+		c.Error = err.Error()
+	}
+
+	for name, vals := range req.Header {
+		if h.redact[http.CanonicalHeaderKey(name)] {
+			c.Header[name] = []string{"REDACTED"}
+			continue
+		}
+		c.Header[name] = append([]string(nil), vals...)
+	}
+
+	if req.Body != nil && req.Body != http.NoBody {
+		// Read one byte more than needed to detect truncation, and put
+		// the read bytes back so the body can be read again.
+		body, _ := ioutil.ReadAll(io.LimitReader(req.Body, int64(h.maxBody)+1))
+		req.Body = readCloser{
+			Reader: io.MultiReader(bytes.NewReader(body), req.Body),
+			Closer: req.Body,
+		}
+		if len(body) > h.maxBody {
+			body = body[:h.maxBody]
+			c.Truncated = true
+		}
+		c.Body = body
+	}
+
+	return c
+}
+
+// readCloser combines a reader and a closer.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
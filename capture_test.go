@@ -0,0 +1,66 @@
+package oas
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCapturingProblemHandler(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+
+	var captured []CapturedRequest
+	sink := SinkFunc(func(c CapturedRequest) { captured = append(captured, c) })
+
+	var handled int
+	next := ProblemHandlerFunc(func(p Problem) {
+		handled++
+		// Body must be readable after capture.
+		b, _ := ioutil.ReadAll(p.Request().Body)
+		assert.Equal(t, `{"name":"Kitty"}`, string(b))
+	})
+
+	ph := NewCapturingProblemHandler(next, sink, CaptureMaxBody(8), CaptureLimit(1, time.Hour))
+
+	basis := NewResolvingBasis(SpecAdapterName, doc, BasisStrict(false))
+	h := SpecMatcherMiddleware(doc)(basis.RequestBodyValidator(WithProblemHandler(ph))(http.HandlerFunc(handleAddPet)))
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/v2/pet", strings.NewReader(`{"name":"Kitty"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer secret")
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	assert.Equal(t, 2, handled)
+	if assert.Len(t, captured, 1) {
+		c := captured[0]
+		assert.Equal(t, "addPet", c.OperationID)
+		assert.Equal(t, http.MethodPost, c.Method)
+		assert.Equal(t, "/v2/pet", c.URL)
+		assert.Equal(t, "REDACTED", c.Header.Get("Authorization"))
+		assert.Equal(t, "application/json", c.Header.Get("Content-Type"))
+		assert.Equal(t, `{"name":`, string(c.Body))
+		assert.True(t, c.Truncated)
+		assert.Contains(t, c.Error, "request body does not match the schema")
+	}
+}
+
+func TestNewWriterSink(t *testing.T) {
+	buf := &bytes.Buffer{}
+	sink := NewWriterSink(buf)
+
+	sink.Capture(CapturedRequest{Method: http.MethodGet, URL: "/foo", Error: "bar"})
+
+	var c CapturedRequest
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &c))
+	assert.Equal(t, "/foo", c.URL)
+	assert.Equal(t, "bar", c.Error)
+}
@@ -0,0 +1,116 @@
+package oas
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// charsetLatin1 is the canonical name of ISO-8859-1 charset.
+const charsetLatin1 = "iso-8859-1"
+
+// charsetAliases map charset names and aliases to canonical names of
+// the charsets that are recognized for JSON bodies.
+var charsetAliases = map[string]string{
+	"":           "utf-8",
+	"utf-8":      "utf-8",
+	"utf8":       "utf-8",
+	"us-ascii":   "utf-8", // ASCII is a subset of UTF-8.
+	"ascii":      "utf-8",
+	"iso-8859-1": charsetLatin1,
+	"iso8859-1":  charsetLatin1,
+	"iso_8859-1": charsetLatin1,
+	"latin1":     charsetLatin1,
+	"l1":         charsetLatin1,
+}
+
+// mediaTypeCharset returns the canonical name of the charset parameter of
+// the media type. Unknown charsets are returned as is.
+func mediaTypeCharset(mediaType string) string {
+	_, params, err := mime.ParseMediaType(mediaType)
+	if err != nil {
+		return ""
+	}
+	cs := strings.ToLower(params["charset"])
+	if canonical, ok := charsetAliases[cs]; ok {
+		return canonical
+	}
+	return cs
+}
+
+// bareMediaType returns the media type without parameters.
+func bareMediaType(mediaType string) string {
+	if i := strings.IndexByte(mediaType, ';'); i >= 0 {
+		mediaType = mediaType[:i]
+	}
+	return strings.TrimSpace(mediaType)
+}
+
+// supportedCharset checks if the JSON request body in the charset can be
+// validated.
+func supportedCharset(cs string, transcodeLatin1 bool) bool {
+	return cs == "utf-8" || (cs == charsetLatin1 && transcodeLatin1)
+}
+
+// normalizeBodyCharset makes sure that the JSON request body is valid UTF-8.
+// If the body is declared with ISO-8859-1 charset, the body is transcoded
+// to UTF-8 and the Content-Type charset is changed accordingly. Request body
+// can be read again later.
+func normalizeBodyCharset(req *http.Request) error {
+	data, err := ioutil.ReadAll(req.Body)
+	req.Body.Close() // nolint: errcheck
+	req.Body = ioutil.NopCloser(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("read request body: %s", err)
+	}
+
+	ct := req.Header.Get("Content-Type")
+	if mediaTypeCharset(ct) == charsetLatin1 {
+		data = latin1ToUTF8(data)
+		req.Body = ioutil.NopCloser(bytes.NewReader(data))
+		req.ContentLength = int64(len(data))
+		req.Header.Set("Content-Length", strconv.Itoa(len(data)))
+		req.Header.Set("Content-Type", bareMediaType(ct)+"; charset=utf-8")
+		return nil
+	}
+
+	if !utf8.Valid(data) {
+		return newUTF8Error(data)
+	}
+	return nil
+}
+
+// newUTF8Error returns an error that points to the first invalid UTF-8
+// sequence in the data.
+func newUTF8Error(data []byte) error {
+	offset := 0
+	for offset < len(data) {
+		r, size := utf8.DecodeRune(data[offset:])
+		if r == utf8.RuneError && size <= 1 {
+			break
+		}
+		offset += size
+	}
+	return fmt.Errorf("request body contains invalid UTF-8 sequence at offset %d", offset)
+}
+
+// latin1ToUTF8 transcodes ISO-8859-1 encoded data to UTF-8. Every
+// ISO-8859-1 byte is the Unicode code point of the same value.
+func latin1ToUTF8(data []byte) []byte {
+	buf := make([]byte, 0, len(data))
+	for _, b := range data {
+		if b < utf8.RuneSelf {
+			buf = append(buf, b)
+			continue
+		}
+		var enc [utf8.UTFMax]byte
+		n := utf8.EncodeRune(enc[:], rune(b))
+		buf = append(buf, enc[:n]...)
+	}
+	return buf
+}
@@ -0,0 +1,131 @@
+package oas
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/go-openapi/spec"
+)
+
+// CheckQueryStruct statically checks that `oas` tags of the struct v match
+// query parameters of the operation, so that DecodeQuery can populate the
+// struct. It reports tags referencing unknown parameters, query parameters
+// without corresponding fields, and field types that cannot hold the values
+// of the parameter type and format, e.g. int32 field for int64 parameter.
+//
+// Only query parameters are checked, since DecodeQuery fills a struct from
+// `oas` tags by all of them. Path parameters are read one at a time with
+// GetPathParam, headers are not decoded into structs, and the body is
+// decoded with encoding/json, so their Go types are not tied to `oas` tags.
+// Structs for DecodeFormBody are not checked either.
+//
+// v is a struct or a pointer to struct; its value is not used, so the check
+// works without type parameters. With Go 1.18, Validate takes the struct as
+// a type parameter instead. This check is meant to be run at startup
+// or in tests to catch drift between the spec and the code before runtime:
+//
+//  if err := oas.CheckQueryStruct(doc, "findPets", findPetsQuery{}); err != nil {
+//      log.Fatal(err)
+//  }
+func CheckQueryStruct(doc *Document, operationID string, v interface{}) error {
+	_, path, op, ok := doc.Analyzer.OperationForName(operationID)
+	if !ok {
+		return fmt.Errorf("operation %s is not found in the spec", operationID)
+	}
+
+	rt := reflect.TypeOf(v)
+	if rt != nil && rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+	if rt == nil || rt.Kind() != reflect.Struct {
+		return fmt.Errorf("value of type %T is not a struct or a pointer to struct", v)
+	}
+
+	fields := fieldMap(reflect.New(rt).Elem(), false)
+	fieldNames := make(map[string]string, len(fields))
+	for name, f := range fields {
+		fieldNames[f.Name] = name
+	}
+	params := make(map[string]spec.Parameter)
+
+	var errs []error
+	for _, p := range doc.parametersFor(path, op) {
+		if p.In != "query" {
+			continue
+		}
+		params[p.Name] = p
+
+		f, ok := fields[p.Name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("query parameter %s has no corresponding struct field", p.Name))
+			continue
+		}
+
+		pt, err := parameterGoType(p.Type, p.Format, p.Items)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("field %s: parameter %s: %s", f.Name, p.Name, err))
+			continue
+		}
+
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if !pt.AssignableTo(ft) {
+			errs = append(errs, fmt.Errorf("field %s of type %s cannot hold parameter %s of type %s", f.Name, f.Type, p.Name, pt))
+		}
+	}
+
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		if _, ok := f.Tag.Lookup(tag); !ok {
+			continue
+		}
+		name := fieldNames[f.Name]
+		if _, ok := params[name]; !ok {
+			errs = append(errs, fmt.Errorf("field %s is tagged with unknown query parameter %s", f.Name, name))
+		}
+	}
+
+	if len(errs) > 0 {
+		return newMultiError(fmt.Sprintf("struct %s does not match operation %s", rt, operationID), errs...)
+	}
+	return nil
+}
+
+// parameterGoType returns the Go type of values produced by convert package
+// for the type and format.
+func parameterGoType(typ, format string, items *spec.Items) (reflect.Type, error) {
+	switch typ {
+	case "string":
+		return reflect.TypeOf(""), nil
+	case "boolean":
+		return reflect.TypeOf(false), nil
+	case "integer":
+		if format == "int32" {
+			return reflect.TypeOf(int32(0)), nil
+		}
+		return reflect.TypeOf(int64(0)), nil
+	case "number":
+		if format == "float" {
+			return reflect.TypeOf(float32(0)), nil
+		}
+		return reflect.TypeOf(float64(0)), nil
+	case "array":
+		if items == nil {
+			return nil, fmt.Errorf("type array has no `items` field")
+		}
+		switch items.Type {
+		case "string", "integer", "number":
+		default:
+			return nil, fmt.Errorf("unsupported items type %s for type array", items.Type)
+		}
+		it, err := parameterGoType(items.Type, items.Format, nil)
+		if err != nil {
+			return nil, err
+		}
+		return reflect.SliceOf(it), nil
+	default:
+		return nil, fmt.Errorf("unsupported type %s", typ)
+	}
+}
@@ -0,0 +1,15 @@
+//go:build go1.18
+// +build go1.18
+
+package oas
+
+// Validate is CheckQueryStruct for the struct type T, which is handy where
+// there is no value of the type at hand:
+//
+//  if err := oas.Validate[findPetsQuery](doc, "findPets"); err != nil {
+//      log.Fatal(err)
+//  }
+func Validate[T any](doc *Document, operationID string) error {
+	var v T
+	return CheckQueryStruct(doc, operationID, v)
+}
@@ -0,0 +1,28 @@
+//go:build go1.18
+// +build go1.18
+
+package oas
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+
+	type query struct {
+		Username string  `oas:"username"`
+		Password *string `oas:"password"`
+	}
+
+	assert.NoError(t, Validate[query](doc, "loginUser"))
+	assert.NoError(t, Validate[*query](doc, "loginUser"))
+	assert.EqualError(t, Validate[query](doc, "getPetById"),
+		"struct oas.query does not match operation getPetById: "+
+			"query parameter debug has no corresponding struct field, "+
+			"field Username is tagged with unknown query parameter username, "+
+			"field Password is tagged with unknown query parameter password",
+	)
+}
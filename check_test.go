@@ -0,0 +1,44 @@
+package oas
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckQueryStruct(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+
+	t.Run("matching struct", func(t *testing.T) {
+		type query struct {
+			Username string  `oas:"username"`
+			Password *string `oas:"password"`
+			Other    int
+		}
+
+		assert.NoError(t, CheckQueryStruct(doc, "loginUser", query{}))
+		assert.NoError(t, CheckQueryStruct(doc, "loginUser", &query{}))
+	})
+
+	t.Run("mismatching struct", func(t *testing.T) {
+		type query struct {
+			Username int32 `oas:"username"`
+			Remember bool  `oas:"remember"`
+		}
+
+		err := CheckQueryStruct(doc, "loginUser", query{})
+		assert.EqualError(t, err, "struct oas.query does not match operation loginUser: "+
+			"field Username of type int32 cannot hold parameter username of type string, "+
+			"query parameter password has no corresponding struct field, "+
+			"field Remember is tagged with unknown query parameter remember",
+		)
+	})
+
+	t.Run("unknown operation", func(t *testing.T) {
+		assert.EqualError(t, CheckQueryStruct(doc, "foo", struct{}{}), "operation foo is not found in the spec")
+	})
+
+	t.Run("not a struct", func(t *testing.T) {
+		assert.EqualError(t, CheckQueryStruct(doc, "loginUser", 1), "value of type int is not a struct or a pointer to struct")
+	})
+}
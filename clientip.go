@@ -0,0 +1,120 @@
+package oas
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+type contextKeyClientIP struct{}
+
+// ClientIPResolver resolves the address of the client that sent a request,
+// with respect to trusted proxies. For requests from trusted proxies, the
+// address is taken from Forwarded header, or from X-Forwarded-For header if
+// there is no Forwarded one: it is the rightmost address that is not
+// a trusted proxy. Addresses in the headers of requests from untrusted
+// peers are ignored, as the headers can be forged by clients.
+type ClientIPResolver struct {
+	trustedProxies []*net.IPNet
+}
+
+// NewClientIPResolver returns a new ClientIPResolver that trusts the proxies,
+// given as IP addresses or CIDR ranges, e.g. "10.0.0.0/8".
+func NewClientIPResolver(trustedProxies ...string) (*ClientIPResolver, error) {
+	nets, err := parseIPNets(trustedProxies)
+	if err != nil {
+		return nil, fmt.Errorf("trusted proxies: %s", err)
+	}
+	return &ClientIPResolver{trustedProxies: nets}, nil
+}
+
+// ClientIP returns the address of the client that sent the request, or nil
+// if the address cannot be determined.
+func (r *ClientIPResolver) ClientIP(req *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !containsIP(r.trustedProxies, ip) {
+		return ip
+	}
+
+	forwarded := forwardedFor(req.Header)
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		fip := net.ParseIP(forwarded[i])
+		if fip == nil {
+			break
+		}
+		ip = fip
+		if !containsIP(r.trustedProxies, ip) {
+			break
+		}
+	}
+	return ip
+}
+
+// forwardedFor returns the addresses of the proxy chain from Forwarded
+// header, or from X-Forwarded-For header if there is no Forwarded one.
+// Ports and IPv6 brackets are stripped, and unknown or obfuscated
+// identifiers are returned as is.
+func forwardedFor(h http.Header) []string {
+	var addrs []string
+
+	if values := h["Forwarded"]; len(values) > 0 {
+		for _, v := range values {
+			for _, elem := range strings.Split(v, ",") {
+				for _, pair := range strings.Split(elem, ";") {
+					kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+					if len(kv) != 2 || !strings.EqualFold(kv[0], "for") {
+						continue
+					}
+					addrs = append(addrs, stripPort(strings.Trim(kv[1], `"`)))
+				}
+			}
+		}
+		return addrs
+	}
+
+	for _, v := range h["X-Forwarded-For"] {
+		for _, addr := range strings.Split(v, ",") {
+			addrs = append(addrs, stripPort(strings.TrimSpace(addr)))
+		}
+	}
+	return addrs
+}
+
+// stripPort strips the port and IPv6 brackets from the address.
+func stripPort(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]")
+}
+
+// ClientIPContext returns a middleware that resolves the client address of
+// the request by the resolver and adds it to the request context, so that
+// all components, e.g. IPFilter, AuditLogger and problem capturing, use the
+// same address. Use GetClientIP to get it.
+func ClientIPContext(r *ClientIPResolver) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if ip := r.ClientIP(req); ip != nil {
+				req = req.WithContext(context.WithValue(req.Context(), contextKeyClientIP{}, ip))
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// GetClientIP returns the client address of the request resolved by
+// ClientIPContext middleware. If the middleware is not mounted, the address
+// of the peer is returned, as no proxies are trusted.
+func GetClientIP(req *http.Request) net.IP {
+	if ip, ok := req.Context().Value(contextKeyClientIP{}).(net.IP); ok {
+		return ip
+	}
+	return (&ClientIPResolver{}).ClientIP(req)
+}
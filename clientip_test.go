@@ -0,0 +1,65 @@
+package oas
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientIPResolver(t *testing.T) {
+	resolver, err := NewClientIPResolver("10.0.0.0/8", "::1")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	cases := map[string]struct {
+		remoteAddr string
+		header     http.Header
+		expected   string
+	}{
+		"untrusted peer": {
+			remoteAddr: "203.0.113.7:1234",
+			header:     http.Header{"X-Forwarded-For": {"198.51.100.1"}},
+			expected:   "203.0.113.7",
+		},
+		"x-forwarded-for": {
+			remoteAddr: "10.0.0.1:1234",
+			header:     http.Header{"X-Forwarded-For": {"198.51.100.1, 192.0.2.60, 10.0.0.2"}},
+			expected:   "192.0.2.60",
+		},
+		"forwarded": {
+			remoteAddr: "[::1]:1234",
+			header: http.Header{
+				"Forwarded":       {`for=192.0.2.60;proto=http, for="[2001:db8:cafe::17]:4711"`},
+				"X-Forwarded-For": {"198.51.100.1"},
+			},
+			expected: "2001:db8:cafe::17",
+		},
+		"obfuscated identifier": {
+			remoteAddr: "10.0.0.1:1234",
+			header:     http.Header{"Forwarded": {"for=_hidden"}},
+			expected:   "10.0.0.1",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = c.remoteAddr
+			req.Header = c.header
+
+			assert.Equal(t, c.expected, resolver.ClientIP(req).String())
+
+			var actual string
+			ClientIPContext(resolver)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				actual = GetClientIP(req).String()
+			})).ServeHTTP(httptest.NewRecorder(), req)
+			assert.Equal(t, c.expected, actual)
+		})
+	}
+
+	_, err = NewClientIPResolver("10.0.0.0/33")
+	assert.EqualError(t, err, "trusted proxies: 10.0.0.0/33 is not a CIDR range")
+}
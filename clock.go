@@ -0,0 +1,95 @@
+package oas
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells the current time to the middleware and the stores that check
+// expiration: signature dates, nonces, quotas and sessions. Inject a
+// FrozenClock in tests to freeze time and simulate expiries
+// deterministically.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc is a function that implements Clock.
+type ClockFunc func() time.Time
+
+// Now implements Clock.
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// SystemClock is the Clock that tells the system time. It is the default
+// clock.
+var SystemClock Clock = ClockFunc(time.Now)
+
+// FrozenClock is a Clock that tells the time it is set to, which changes
+// only when it is set or advanced. It is safe for concurrent use.
+type FrozenClock struct {
+	mx sync.Mutex
+	t  time.Time
+}
+
+// NewFrozenClock returns a new FrozenClock frozen at the time.
+func NewFrozenClock(t time.Time) *FrozenClock {
+	return &FrozenClock{t: t}
+}
+
+// Now implements Clock.
+func (c *FrozenClock) Now() time.Time {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	return c.t
+}
+
+// Set sets the time of the clock.
+func (c *FrozenClock) Set(t time.Time) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	c.t = t
+}
+
+// Advance moves the time of the clock forward by d.
+func (c *FrozenClock) Advance(d time.Duration) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	c.t = c.t.Add(d)
+}
+
+// WithClock returns a middleware option that sets the clock of the
+// middleware that checks time: QuotaLimiter, NonceValidator and
+// SignatureVerifier. By default, SystemClock is used.
+func WithClock(c Clock) MiddlewareOption {
+	return func(opts *MiddlewareOptions) {
+		opts.clock = c
+	}
+}
+
+// StoreOption is an option for the in-memory stores, RedisStore, and the
+// stores adapted from Store.
+type StoreOption func(*storeOptions)
+
+type storeOptions struct {
+	clock Clock
+}
+
+// StoreClock returns a store option that sets the clock the store tells
+// expiration by. By default, SystemClock is used.
+func StoreClock(c Clock) StoreOption {
+	return func(o *storeOptions) {
+		o.clock = c
+	}
+}
+
+func parseStoreOptions(opts []StoreOption) storeOptions {
+	options := storeOptions{clock: SystemClock}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
@@ -0,0 +1,80 @@
+package oas
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuotaLimiter_clock(t *testing.T) {
+	doc := loadDocBytes([]byte(`
+swagger: "2.0"
+info:
+  title: Test
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      operationId: findPets
+      x-oas-quota:
+        limit: 1
+        period: 1h
+      responses:
+        200:
+          description: OK
+`))
+
+	clock := NewFrozenClock(time.Date(2020, 1, 1, 10, 30, 0, 0, time.UTC))
+	basis := NewResolvingBasis(SpecAdapterName, doc, BasisStrict(false))
+	h := SpecMatcherMiddleware(doc)(
+		PrincipalContext(func(req *http.Request) (Principal, bool) {
+			return Principal{ID: "alice"}, true
+		})(
+			basis.QuotaLimiter(NewMemoryQuotaStore(StoreClock(clock)), WithClock(clock))(
+				http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}),
+			),
+		),
+	)
+
+	serve := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/pets", nil))
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, serve().Code)
+
+	w := serve()
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "1800", w.Header().Get("Retry-After"))
+
+	clock.Advance(29 * time.Minute)
+	assert.Equal(t, http.StatusTooManyRequests, serve().Code)
+
+	clock.Advance(time.Minute)
+	assert.Equal(t, http.StatusOK, serve().Code)
+}
+
+func TestSessions_clock(t *testing.T) {
+	clock := NewFrozenClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	store := NewMemorySessionStore(StoreClock(clock))
+	sessions := NewSessions(store, SessionClock(clock), SessionMaxAge(time.Hour))
+
+	w := httptest.NewRecorder()
+	session, err := sessions.Login(w, httptest.NewRequest(http.MethodPost, "/login", nil), Principal{ID: "alice"})
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2020, 1, 1, 1, 0, 0, 0, time.UTC), session.ExpiresAt)
+
+	clock.Advance(59 * time.Minute)
+	_, ok, err := store.Load(session.ID)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	clock.Advance(time.Minute)
+	_, ok, err = store.Load(session.ID)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
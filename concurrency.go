@@ -0,0 +1,213 @@
+package oas
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ConcurrencyExtension is the operation extension that limits the number of
+// in-flight requests to the operation, enforced by ConcurrencyLimiter, e.g.
+// to protect expensive operations like report generation:
+//
+//  paths:
+//    /reports:
+//      post:
+//        operationId: generateReport
+//        x-oas-concurrency:
+//          limit: 4
+//          queue: 16
+//          wait: 10s
+//
+// Requests over the limit wait in the queue for a free slot for up to the
+// wait duration. Requests that do not fit in the queue, or that do not get
+// a slot in time, are rejected. The queue is empty by default, so requests
+// over the limit are rejected immediately. The extension may be simply the
+// limit, e.g. "x-oas-concurrency: 4".
+const ConcurrencyExtension = "x-oas-concurrency"
+
+// ConcurrencyLimit is the concurrency limit of an operation.
+type ConcurrencyLimit struct {
+	// Limit is the maximum number of in-flight requests.
+	Limit int
+
+	// Queue is the maximum number of requests waiting for a slot.
+	Queue int
+
+	// Wait is the maximum time a request waits for a slot.
+	Wait time.Duration
+}
+
+// parseConcurrencyLimit parses the value of ConcurrencyExtension.
+func parseConcurrencyLimit(ext interface{}) (ConcurrencyLimit, error) {
+	positiveInt := func(v interface{}) (int, bool) {
+		f, ok := v.(float64)
+		return int(f), ok && f >= 1 && f == float64(int(f))
+	}
+
+	if limit, ok := positiveInt(ext); ok {
+		return ConcurrencyLimit{Limit: limit}, nil
+	}
+
+	m, ok := ext.(map[string]interface{})
+	if !ok {
+		return ConcurrencyLimit{}, fmt.Errorf("value must be a positive integer or an object")
+	}
+
+	var l ConcurrencyLimit
+	if l.Limit, ok = positiveInt(m["limit"]); !ok {
+		return ConcurrencyLimit{}, fmt.Errorf("limit must be a positive integer")
+	}
+	if v, found := m["queue"]; found {
+		if l.Queue, ok = positiveInt(v); !ok {
+			return ConcurrencyLimit{}, fmt.Errorf("queue must be a positive integer")
+		}
+	}
+	if v, found := m["wait"]; found {
+		s, _ := v.(string)
+		d, err := time.ParseDuration(s)
+		if err != nil || d <= 0 {
+			return ConcurrencyLimit{}, fmt.Errorf("wait %v is not a positive duration", v)
+		}
+		l.Wait = d
+	}
+	return l, nil
+}
+
+// concurrencySlots are the slots of in-flight requests to an operation.
+type concurrencySlots struct {
+	limit ConcurrencyLimit
+	slots chan struct{}
+
+	mx      sync.Mutex
+	waiting int
+}
+
+// acquire acquires a slot, waiting in the queue if there is room. It
+// returns false if the slot is not acquired.
+func (s *concurrencySlots) acquire(req *http.Request) bool {
+	select {
+	case s.slots <- struct{}{}:
+		return true
+	default:
+	}
+
+	s.mx.Lock()
+	if s.waiting >= s.limit.Queue {
+		s.mx.Unlock()
+		return false
+	}
+	s.waiting++
+	s.mx.Unlock()
+
+	defer func() {
+		s.mx.Lock()
+		s.waiting--
+		s.mx.Unlock()
+	}()
+
+	timer := time.NewTimer(s.limit.Wait)
+	defer timer.Stop()
+
+	select {
+	case s.slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-req.Context().Done():
+		return false
+	}
+}
+
+// release releases the acquired slot.
+func (s *concurrencySlots) release() {
+	<-s.slots
+}
+
+// defaultConcurrencyRetryAfter is the Retry-After value of requests
+// rejected by ConcurrencyLimiter.
+const defaultConcurrencyRetryAfter = time.Second
+
+// ConcurrencyLimiter returns a middleware that limits the number of
+// in-flight requests to operations with ConcurrencyExtension. The limits
+// map sets the limits of operations by id programmatically; they take
+// precedence over the extension.
+//
+// In case of rejection, this middleware sets the Retry-After header to one
+// second and responds with 503 by default. It panics if any limit is
+// invalid.
+func (b *ResolvingBasis) ConcurrencyLimiter(limits map[string]ConcurrencyLimit, opts ...MiddlewareOption) Middleware {
+	options := parseMiddlewareOptions(opts...)
+	if options.problemHandler == nil {
+		options.problemHandler = newProblemHandlerStatusResponder(http.StatusServiceUnavailable)
+	}
+
+	operations := make(map[string]*concurrencySlots)
+	for id, oi := range b.cache {
+		l, ok := limits[id]
+		if !ok {
+			ext, found := oi.operation.Extensions[ConcurrencyExtension]
+			if !found {
+				continue
+			}
+			var err error
+			if l, err = parseConcurrencyLimit(ext); err != nil {
+				panic(fmt.Sprintf("oas: operation %s: invalid %s: %s", id, ConcurrencyExtension, err))
+			}
+		}
+		if l.Limit < 1 || l.Queue < 0 || (l.Queue > 0 && l.Wait <= 0) {
+			panic(fmt.Sprintf("oas: operation %s: invalid concurrency limit %+v", id, l))
+		}
+		operations[id] = &concurrencySlots{limit: l, slots: make(chan struct{}, l.Limit)}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return &concurrencyLimiter{
+			next:           next,
+			operations:     operations,
+			problemHandler: options.problemHandler,
+			strict:         b.strict,
+		}
+	}
+}
+
+// concurrencyLimiter is a middleware that resolves operation context from
+// the request and limits in-flight requests.
+type concurrencyLimiter struct {
+	next       http.Handler
+	operations map[string]*concurrencySlots
+
+	problemHandler ProblemHandler
+
+	// strict enforces limits. If false, then requests without operation
+	// context are passed.
+	strict bool
+}
+
+func (mw *concurrencyLimiter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	oi, ok := getOperationInfo(req)
+	if !ok {
+		if mw.strict {
+			panic("concurrency limiter middleware: cannot find operation info in the request context")
+		}
+		mw.next.ServeHTTP(w, req)
+		return
+	}
+
+	s, ok := mw.operations[oi.operation.ID]
+	if !ok {
+		mw.next.ServeHTTP(w, req)
+		return
+	}
+
+	if !s.acquire(req) {
+		w.Header().Set("Retry-After", strconv.FormatInt(ceilSeconds(defaultConcurrencyRetryAfter), 10))
+		mw.problemHandler.HandleProblem(NewProblem(w, req, fmt.Errorf("concurrency limit of operation %s is reached", oi.operation.ID)))
+		return
+	}
+	defer s.release()
+
+	mw.next.ServeHTTP(w, req)
+}
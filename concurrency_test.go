@@ -0,0 +1,86 @@
+package oas
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcurrencyLimiter(t *testing.T) {
+	doc := loadDocBytes([]byte(`
+swagger: "2.0"
+info:
+  title: Test
+  version: 1.0.0
+paths:
+  /reports:
+    post:
+      operationId: generateReport
+      x-oas-concurrency: 1
+      responses:
+        200:
+          description: OK
+  /exports:
+    post:
+      operationId: exportData
+      responses:
+        200:
+          description: OK
+`))
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+
+	basis := NewResolvingBasis(SpecAdapterName, doc, BasisStrict(false))
+	limits := map[string]ConcurrencyLimit{
+		"exportData": {Limit: 1, Queue: 1, Wait: 5 * time.Second},
+	}
+	h := SpecMatcherMiddleware(doc)(
+		basis.ConcurrencyLimiter(limits)(
+			http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				entered <- struct{}{}
+				<-release
+			}),
+		),
+	)
+
+	serveAsync := func(path string) <-chan *httptest.ResponseRecorder {
+		done := make(chan *httptest.ResponseRecorder, 1)
+		go func() {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, nil))
+			done <- w
+		}()
+		return done
+	}
+
+	t.Run("rejected", func(t *testing.T) {
+		first := serveAsync("/reports")
+		<-entered
+
+		w := <-serveAsync("/reports")
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Equal(t, "1", w.Header().Get("Retry-After"))
+		assert.Equal(t, "concurrency limit of operation generateReport is reached", strings.TrimSpace(w.Body.String()))
+
+		release <- struct{}{}
+		assert.Equal(t, http.StatusOK, (<-first).Code)
+	})
+
+	t.Run("queued", func(t *testing.T) {
+		first := serveAsync("/exports")
+		<-entered
+
+		second := serveAsync("/exports")
+		release <- struct{}{}
+		assert.Equal(t, http.StatusOK, (<-first).Code)
+
+		<-entered
+		release <- struct{}{}
+		assert.Equal(t, http.StatusOK, (<-second).Code)
+	})
+}
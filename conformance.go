@@ -0,0 +1,396 @@
+package oas
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hypnoglow/oas2/validate"
+)
+
+// ConformanceReport describes conformance of recorded traffic to the spec.
+type ConformanceReport struct {
+	// Exchanges is the total number of analyzed request-response exchanges.
+	Exchanges int
+
+	// Unmatched is the number of exchanges whose requests do not match any
+	// operation.
+	Unmatched int
+
+	// Operations describe conformance of matched exchanges per operation,
+	// ordered by operation id.
+	Operations []*OperationConformance
+}
+
+// OperationConformance describes conformance of recorded traffic to the
+// operation.
+type OperationConformance struct {
+	// OperationID is the operation id.
+	OperationID string
+
+	// Exchanges is the number of exchanges matched to the operation.
+	Exchanges int
+
+	// Violations is the number of exchanges that violate the operation.
+	Violations int
+
+	// Errors are the violation errors with counts of their occurrences.
+	Errors map[string]int
+}
+
+// ConformanceAnalyzer validates recorded request-response exchanges against
+// the document and builds a ConformanceReport, grouping violations per
+// operation. This allows to assess conformance of the existing traffic
+// before enabling validation.
+//
+// ConformanceAnalyzer is not safe for concurrent use.
+type ConformanceAnalyzer struct {
+	doc *Document
+
+	jsonSelectors []*regexp.Regexp
+
+	exchanges  int
+	unmatched  int
+	operations map[string]*OperationConformance
+}
+
+// NewConformanceAnalyzer returns a new ConformanceAnalyzer for the document.
+func NewConformanceAnalyzer(doc *Document) *ConformanceAnalyzer {
+	return &ConformanceAnalyzer{
+		doc:           doc,
+		jsonSelectors: parseMiddlewareOptions().jsonSelectors,
+		operations:    make(map[string]*OperationConformance),
+	}
+}
+
+// Add validates the exchange and adds it to the report. Response may be nil
+// if the response was not recorded.
+func (a *ConformanceAnalyzer) Add(req *http.Request, resp *http.Response) {
+	a.exchanges++
+
+	route, _, ok := a.doc.specMatcher().match(req.Method, req.URL.EscapedPath())
+	if !ok {
+		a.unmatched++
+		return
+	}
+
+	_, path, op, _ := a.doc.Analyzer.OperationForName(route.id)
+	oi := newOperationInfo(a.doc, path, op)
+
+	oc, ok := a.operations[op.ID]
+	if !ok {
+		oc = &OperationConformance{OperationID: op.ID, Errors: make(map[string]int)}
+		a.operations[op.ID] = oc
+	}
+	oc.Exchanges++
+
+	errs := a.requestErrors(req, oi)
+	if resp != nil {
+		errs = append(errs, a.responseErrors(resp, oi)...)
+	}
+	if len(errs) == 0 {
+		return
+	}
+
+	oc.Violations++
+	for _, err := range errs {
+		oc.Errors[err.Error()]++
+	}
+}
+
+// Report returns the report on the exchanges added so far.
+func (a *ConformanceAnalyzer) Report() *ConformanceReport {
+	r := &ConformanceReport{
+		Exchanges: a.exchanges,
+		Unmatched: a.unmatched,
+	}
+	for _, oc := range a.operations {
+		c := *oc
+		c.Errors = make(map[string]int, len(oc.Errors))
+		for k, v := range oc.Errors {
+			c.Errors[k] = v
+		}
+		r.Operations = append(r.Operations, &c)
+	}
+	sort.Slice(r.Operations, func(i, j int) bool {
+		return r.Operations[i].OperationID < r.Operations[j].OperationID
+	})
+	return r
+}
+
+func (a *ConformanceAnalyzer) requestErrors(req *http.Request, oi operationInfo) []error {
+	errs := validate.Query(oi.params, req.URL.Query())
+
+	var body []byte
+	if req.Body != nil {
+		body, _ = ioutil.ReadAll(req.Body)
+	}
+
+	if len(body) == 0 {
+		for _, p := range oi.params {
+			if p.In == "body" && p.Required {
+				errs = append(errs, fmt.Errorf("request body is empty, but the operation requires non-empty body"))
+			}
+		}
+		return errs
+	}
+
+	contentType := req.Header.Get("Content-Type")
+	if !matchMediaType(contentType, oi.consumes) {
+		errs = append(errs, fmt.Errorf("Content-Type header of the request does not match any of the media types the operation can consume"))
+	}
+	if !a.matchJSON(contentType) {
+		return errs
+	}
+
+	var payload interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return append(errs, newJSONError("request body contains invalid json", err, body))
+	}
+	return append(errs, validate.Body(oi.params, payload)...)
+}
+
+func (a *ConformanceAnalyzer) responseErrors(resp *http.Response, oi operationInfo) []error {
+	var body []byte
+	if resp.Body != nil {
+		body, _ = ioutil.ReadAll(resp.Body)
+	}
+
+	if oi.operation.Responses == nil {
+		return nil
+	}
+	responseSpec, ok := oi.operation.Responses.StatusCodeResponses[resp.StatusCode]
+	if !ok {
+		// Responses are not expected to cover all status codes,
+		// see responseBodyValidator.
+		return nil
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+
+	var errs []error
+	if !matchMediaType(contentType, oi.produces) {
+		errs = append(errs, fmt.Errorf("Content-Type header of the response does not match any of the media types the operation can produce"))
+	}
+
+	if responseSpec.Schema == nil {
+		if len(body) > 0 {
+			errs = append(errs, fmt.Errorf("response has non-empty body, but the operation does not define response schema for code %d", resp.StatusCode))
+		}
+		return errs
+	}
+
+	if !a.matchJSON(contentType) {
+		return errs
+	}
+
+	var payload interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return append(errs, newJSONError("response body contains invalid json", err, body))
+	}
+	for _, err := range validate.BySchema(responseSpec.Schema, payload) {
+		errs = append(errs, fmt.Errorf("response body does not match the schema: %s", err))
+	}
+	return errs
+}
+
+// matchJSON checks if the content type matches any JSON selector.
+func (a *ConformanceAnalyzer) matchJSON(contentType string) bool {
+	for _, selector := range a.jsonSelectors {
+		if selector.MatchString(contentType) {
+			return true
+		}
+	}
+	return false
+}
+
+// AnalyzeHAR reads exchanges recorded in HAR format from r and reports
+// their conformance to the document.
+//
+// See http://www.softwareishard.com/blog/har-12-spec/ for HAR format.
+func AnalyzeHAR(doc *Document, r io.Reader) (*ConformanceReport, error) {
+	var har harFile
+	if err := json.NewDecoder(r).Decode(&har); err != nil {
+		return nil, fmt.Errorf("decode HAR: %s", err)
+	}
+
+	a := NewConformanceAnalyzer(doc)
+	for i, e := range har.Log.Entries {
+		req, err := e.Request.httpRequest()
+		if err != nil {
+			return nil, fmt.Errorf("HAR entry %d: %s", i, err)
+		}
+		resp, err := e.Response.httpResponse()
+		if err != nil {
+			return nil, fmt.Errorf("HAR entry %d: %s", i, err)
+		}
+		a.Add(req, resp)
+	}
+	return a.Report(), nil
+}
+
+type (
+	harFile struct {
+		Log struct {
+			Entries []harEntry `json:"entries"`
+		} `json:"log"`
+	}
+
+	harEntry struct {
+		Request  harRequest  `json:"request"`
+		Response harResponse `json:"response"`
+	}
+
+	harRequest struct {
+		Method   string      `json:"method"`
+		URL      string      `json:"url"`
+		Headers  []harHeader `json:"headers"`
+		PostData *struct {
+			MimeType string `json:"mimeType"`
+			Text     string `json:"text"`
+		} `json:"postData"`
+	}
+
+	harResponse struct {
+		Status  int         `json:"status"`
+		Headers []harHeader `json:"headers"`
+		Content struct {
+			MimeType string `json:"mimeType"`
+			Text     string `json:"text"`
+			Encoding string `json:"encoding"`
+		} `json:"content"`
+	}
+
+	harHeader struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+)
+
+func (r harRequest) httpRequest() (*http.Request, error) {
+	u, err := url.Parse(r.URL)
+	if err != nil {
+		return nil, fmt.Errorf("parse request url: %s", err)
+	}
+
+	req := &http.Request{
+		Method: r.Method,
+		URL:    u,
+		Header: harHeaders(r.Headers),
+		Body:   http.NoBody,
+	}
+	if r.PostData != nil {
+		req.Body = ioutil.NopCloser(strings.NewReader(r.PostData.Text))
+		if req.Header.Get("Content-Type") == "" {
+			req.Header.Set("Content-Type", r.PostData.MimeType)
+		}
+	}
+	return req, nil
+}
+
+func (r harResponse) httpResponse() (*http.Response, error) {
+	if r.Status == 0 {
+		// The response was not received.
+		return nil, nil
+	}
+
+	body := []byte(r.Content.Text)
+	if r.Content.Encoding == "base64" {
+		b, err := base64.StdEncoding.DecodeString(r.Content.Text)
+		if err != nil {
+			return nil, fmt.Errorf("decode response content: %s", err)
+		}
+		body = b
+	}
+
+	resp := &http.Response{
+		StatusCode: r.Status,
+		Header:     harHeaders(r.Headers),
+		Body:       ioutil.NopCloser(bytes.NewReader(body)),
+	}
+	if resp.Header.Get("Content-Type") == "" && r.Content.MimeType != "" {
+		resp.Header.Set("Content-Type", r.Content.MimeType)
+	}
+	return resp, nil
+}
+
+func harHeaders(hh []harHeader) http.Header {
+	h := make(http.Header)
+	for _, hdr := range hh {
+		h.Add(hdr.Name, hdr.Value)
+	}
+	return h
+}
+
+// goReplaySeparator separates payloads in GoReplay output files.
+const goReplaySeparator = "\n\U0001F435\U0001F648\U0001F649\n"
+
+// AnalyzeGoReplay reads exchanges recorded by GoReplay (gor) from r and
+// reports their conformance to the document. Requests are paired with
+// original responses by their ids; replayed responses are ignored.
+// Responses are recorded by GoReplay only with --output-http-track-response
+// or --input-raw-track-response flags.
+func AnalyzeGoReplay(doc *Document, r io.Reader) (*ConformanceReport, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("read GoReplay output: %s", err)
+	}
+
+	var (
+		ids       []string
+		requests  = make(map[string]*http.Request)
+		responses = make(map[string]*http.Response)
+	)
+
+	for _, payload := range strings.Split(string(data), goReplaySeparator) {
+		if strings.TrimSpace(payload) == "" {
+			continue
+		}
+
+		nl := strings.Index(payload, "\n")
+		if nl < 0 {
+			return nil, fmt.Errorf("GoReplay payload has no meta line")
+		}
+		meta := strings.Fields(payload[:nl])
+		if len(meta) < 2 {
+			return nil, fmt.Errorf("GoReplay payload has invalid meta line %q", payload[:nl])
+		}
+		kind, id := meta[0], meta[1]
+		msg := bufio.NewReader(strings.NewReader(payload[nl+1:]))
+
+		switch kind {
+		case "1":
+			req, err := http.ReadRequest(msg)
+			if err != nil {
+				return nil, fmt.Errorf("GoReplay request %s: %s", id, err)
+			}
+			body, _ := ioutil.ReadAll(req.Body)
+			req.Body = ioutil.NopCloser(bytes.NewReader(body))
+			requests[id] = req
+			ids = append(ids, id)
+		case "2":
+			resp, err := http.ReadResponse(msg, nil)
+			if err != nil {
+				return nil, fmt.Errorf("GoReplay response %s: %s", id, err)
+			}
+			body, _ := ioutil.ReadAll(resp.Body)
+			resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+			responses[id] = resp
+		}
+	}
+
+	a := NewConformanceAnalyzer(doc)
+	for _, id := range ids {
+		a.Add(requests[id], responses[id])
+	}
+	return a.Report(), nil
+}
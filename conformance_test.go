@@ -0,0 +1,88 @@
+package oas
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnalyzeHAR(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+
+	har := `{"log":{"entries":[
+		{
+			"request":{"method":"GET","url":"http://petstore.swagger.io/v2/pet/1","headers":[]},
+			"response":{"status":200,"headers":[{"name":"Content-Type","value":"application/json"}],"content":{"mimeType":"application/json","text":"{\"name\":\"Rex\",\"age\":3}"}}
+		},
+		{
+			"request":{"method":"GET","url":"http://petstore.swagger.io/v2/pet/2","headers":[]},
+			"response":{"status":200,"headers":[],"content":{"mimeType":"application/json","text":"eyJuYW1lIjoiUmV4In0=","encoding":"base64"}}
+		},
+		{
+			"request":{"method":"GET","url":"http://petstore.swagger.io/v2/pet/3?foo=bar","headers":[]},
+			"response":{"status":200,"headers":[],"content":{"mimeType":"application/json","text":"{\"name\":\"Rex\"}"}}
+		},
+		{
+			"request":{"method":"POST","url":"http://petstore.swagger.io/v2/pet","headers":[],"postData":{"mimeType":"application/json","text":"{\"name\":\"Rex\",\"age\":3}"}},
+			"response":{"status":0}
+		},
+		{
+			"request":{"method":"GET","url":"http://petstore.swagger.io/health","headers":[]},
+			"response":{"status":200,"headers":[],"content":{"mimeType":"text/plain","text":"ok"}}
+		}
+	]}}`
+
+	report, err := AnalyzeHAR(doc, strings.NewReader(har))
+	assert.NoError(t, err)
+
+	assert.Equal(t, 5, report.Exchanges)
+	assert.Equal(t, 1, report.Unmatched)
+	assert.Equal(t, []*OperationConformance{
+		{
+			OperationID: "addPet",
+			Exchanges:   1,
+			Errors:      map[string]int{},
+		},
+		{
+			OperationID: "getPetById",
+			Exchanges:   3,
+			Violations:  2,
+			Errors: map[string]int{
+				"response body does not match the schema: age in body is required": 2,
+				"parameter foo is unknown": 1,
+			},
+		},
+	}, report.Operations)
+
+	_, err = AnalyzeHAR(doc, strings.NewReader(`{`))
+	assert.EqualError(t, err, "decode HAR: unexpected EOF")
+}
+
+func TestAnalyzeGoReplay(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+
+	gor := strings.Join([]string{
+		"1 a1 1532000000000000000 0\nGET /v2/user/login?username=john HTTP/1.1\r\nHost: petstore.swagger.io\r\n\r\n",
+		"2 a1 1532000000000000001 100\nHTTP/1.1 400 Bad Request\r\nContent-Length: 0\r\n\r\n",
+		"3 a1 1532000000000000002 100\nHTTP/1.1 500 Internal Server Error\r\nContent-Length: 0\r\n\r\n",
+		"1 a2 1532000000000000003 0\nGET /v2/user/login?username=john&password=secret HTTP/1.1\r\nHost: petstore.swagger.io\r\n\r\n",
+		"2 a2 1532000000000000004 100\nHTTP/1.1 200 OK\r\nContent-Type: application/json\r\nContent-Length: 7\r\n\r\n\"token\"",
+	}, goReplaySeparator)
+
+	report, err := AnalyzeGoReplay(doc, strings.NewReader(gor))
+	assert.NoError(t, err)
+
+	assert.Equal(t, 2, report.Exchanges)
+	assert.Equal(t, 0, report.Unmatched)
+	assert.Equal(t, []*OperationConformance{
+		{
+			OperationID: "loginUser",
+			Exchanges:   2,
+			Violations:  1,
+			Errors: map[string]int{
+				"param password is required": 1,
+			},
+		},
+	}, report.Operations)
+}
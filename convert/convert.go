@@ -2,6 +2,7 @@ package convert
 
 import (
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -122,7 +123,7 @@ func convertInteger(val, format string) (interface{}, error) {
 	case "int32":
 		i, err := strconv.ParseInt(val, 10, 32)
 		if err != nil {
-			return nil, fmt.Errorf("cannot convert %v to int32", val)
+			return nil, newNumberError(val, "int32")
 		}
 		return int32(i), nil
 	case "int64":
@@ -130,7 +131,7 @@ func convertInteger(val, format string) (interface{}, error) {
 	case "":
 		i, err := strconv.ParseInt(val, 10, 64)
 		if err != nil {
-			return nil, fmt.Errorf("cannot convert %v to int64", val)
+			return nil, newNumberError(val, "int64")
 		}
 		return i, nil
 	default:
@@ -146,7 +147,7 @@ func convertNumber(val, format string) (interface{}, error) {
 	case "float":
 		f, err := strconv.ParseFloat(val, 32)
 		if err != nil {
-			return nil, fmt.Errorf("cannot convert %v to float", val)
+			return nil, newNumberError(val, "float")
 		}
 		return float32(f), nil
 	case "double":
@@ -154,7 +155,7 @@ func convertNumber(val, format string) (interface{}, error) {
 	case "":
 		f, err := strconv.ParseFloat(val, 64)
 		if err != nil {
-			return nil, fmt.Errorf("cannot convert %v to double", val)
+			return nil, newNumberError(val, "double")
 		}
 		return f, nil
 	default:
@@ -175,3 +176,58 @@ func convertBoolean(val string) (interface{}, error) {
 	}
 	return false, fmt.Errorf("unknown format %s for type boolean", val)
 }
+
+// NumberError is the error of conversion of a value that is not a number
+// of the format.
+type NumberError struct {
+	// Value is the value that cannot be converted.
+	Value string
+
+	// Format is the format of the number, e.g. int32 or double.
+	Format string
+
+	// Hint tells how to fix the value if it looks like a number in a locale
+	// format, e.g. "185,5", or is empty otherwise. See LocaleHint.
+	Hint string
+}
+
+func newNumberError(val, format string) *NumberError {
+	return &NumberError{Value: val, Format: format, Hint: LocaleHint(val)}
+}
+
+// Error implements error.
+func (e *NumberError) Error() string {
+	return fmt.Sprintf("cannot convert %v to %s", e.Value, e.Format)
+}
+
+var (
+	// decimalCommaRegex matches numbers with a comma as decimal separator,
+	// e.g. "185,5".
+	decimalCommaRegex = regexp.MustCompile(`^[+-]?[0-9]+,[0-9]+$`)
+
+	// groupedNumberRegex matches numbers with thousands separators used
+	// in common locales, e.g. "1,234.5", "1.234,5" or "1 234".
+	groupedNumberRegex = regexp.MustCompile(`^[+-]?[0-9]{1,3}([,. '\x{a0}][0-9]{3})+([.,][0-9]+)?$`)
+)
+
+// LocaleHint returns the hint on how to fix the value that looks like
+// a number in a common locale format, or an empty string otherwise.
+func LocaleHint(val string) string {
+	switch {
+	case decimalCommaRegex.MatchString(val):
+		return "use '.' as decimal separator"
+	case groupedNumberRegex.MatchString(val):
+		return "do not use thousands separators, and use '.' as decimal separator"
+	}
+	return ""
+}
+
+// DecimalComma returns the number with a comma as decimal separator, e.g.
+// "185,5", with the comma replaced by a dot. It returns false if the value
+// is not such number.
+func DecimalComma(val string) (string, bool) {
+	if !decimalCommaRegex.MatchString(val) {
+		return val, false
+	}
+	return strings.Replace(val, ",", ".", 1), true
+}
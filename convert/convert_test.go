@@ -270,3 +270,47 @@ func TestPrimitive(t *testing.T) {
 		}
 	}
 }
+
+func TestLocaleHint(t *testing.T) {
+	cases := []struct {
+		value        string
+		expectedHint string
+	}{
+		{value: "185,5", expectedHint: "use '.' as decimal separator"},
+		{value: "-0,25", expectedHint: "use '.' as decimal separator"},
+		{value: "1.234,5", expectedHint: "do not use thousands separators, and use '.' as decimal separator"},
+		{value: "1,234,567", expectedHint: "do not use thousands separators, and use '.' as decimal separator"},
+		{value: "1 234", expectedHint: "do not use thousands separators, and use '.' as decimal separator"},
+		{value: "abc", expectedHint: ""},
+		{value: "1,2,3", expectedHint: ""},
+	}
+
+	for _, c := range cases {
+		if hint := LocaleHint(c.value); hint != c.expectedHint {
+			t.Errorf("Expected hint for %q to be %q but got %q", c.value, c.expectedHint, hint)
+		}
+	}
+}
+
+func TestPrimitive_numberError(t *testing.T) {
+	_, err := Primitive("185,5", "number", "double")
+	ne, ok := err.(*NumberError)
+	if !ok {
+		t.Fatalf("Expected *NumberError, got %#v", err)
+	}
+	if ne.Error() != "cannot convert 185,5 to double" {
+		t.Errorf("Unexpected error message: %s", ne.Error())
+	}
+	if ne.Hint != "use '.' as decimal separator" {
+		t.Errorf("Unexpected hint: %s", ne.Hint)
+	}
+}
+
+func TestDecimalComma(t *testing.T) {
+	if v, ok := DecimalComma("185,5"); !ok || v != "185.5" {
+		t.Errorf("Expected 185.5, got %s (%v)", v, ok)
+	}
+	if v, ok := DecimalComma("1,234,5"); ok || v != "1,234,5" {
+		t.Errorf("Expected value to be kept, got %s (%v)", v, ok)
+	}
+}
@@ -0,0 +1,83 @@
+package oas
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-openapi/spec"
+
+	"github.com/hypnoglow/oas2/validate"
+)
+
+// LocationExtension is the operation extension that declares the id of the
+// operation that retrieves the resource created by the operation, used by
+// LinkBuilder.WriteCreated to build the Location header:
+//
+//  paths:
+//    /pet:
+//      post:
+//        operationId: addPet
+//        x-oas-location: getPetById
+const LocationExtension = "x-oas-location"
+
+// WriteCreated writes the value as the 201 Created response to the create
+// operation of the request, with the Location header set to the absolute URL
+// of the retrieve operation, with path parameters filled by args:
+//
+//  pet, err := store.AddPet(newPet)
+//  // ...
+//  err = links.WriteCreated(w, req, pet, pet.ID)
+//
+// The retrieve operation is configured by LinkLocations or LocationExtension.
+// The value is validated against the schema of the 201 response, and is
+// written by WriteResponse. If the value is invalid or the location cannot
+// be built, an error is returned and nothing is written.
+func (b *LinkBuilder) WriteCreated(w http.ResponseWriter, req *http.Request, v interface{}, args ...interface{}) error {
+	oi, ok := getOperationInfo(req)
+	if !ok {
+		return errors.New("write created: cannot find OpenAPI operation info in the request context")
+	}
+	id := oi.operation.ID
+
+	retrieveID, ok := b.locations[id]
+	if !ok {
+		retrieveID, ok = oi.operation.Extensions.GetString(LocationExtension)
+	}
+	if !ok || retrieveID == "" {
+		return fmt.Errorf("write created: operation %s has no retrieve operation configured", id)
+	}
+
+	location, err := b.AbsoluteURL(req, retrieveID, args...)
+	if err != nil {
+		return fmt.Errorf("write created: %s", err)
+	}
+
+	var created spec.Response
+	if oi.operation.Responses != nil {
+		created, ok = oi.operation.Responses.StatusCodeResponses[http.StatusCreated]
+	} else {
+		ok = false
+	}
+	if !ok {
+		return fmt.Errorf("write created: operation %s does not declare 201 response", id)
+	}
+
+	if schema := created.Schema; schema != nil {
+		data, err := currentJSONAPI().Marshal(v)
+		if err != nil {
+			return fmt.Errorf("write created: %s", err)
+		}
+		var value interface{}
+		if err := decodeJSON(bytes.NewReader(data), &value, true); err != nil {
+			return fmt.Errorf("write created: %s", err)
+		}
+		if errs := validate.BySchema(schema, value); len(errs) > 0 {
+			return fmt.Errorf("write created: %s", newMultiError("response body does not match the schema", errs...))
+		}
+	}
+
+	w.Header().Set("Location", location)
+	return WriteResponse(w, req, http.StatusCreated, v)
+}
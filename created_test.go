@@ -0,0 +1,85 @@
+package oas
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLinkBuilder_WriteCreated(t *testing.T) {
+	doc := loadDocBytes([]byte(`
+swagger: "2.0"
+info:
+  title: Test
+  version: 1.0.0
+host: api.example.com
+schemes: [https]
+basePath: /v2
+paths:
+  /pets:
+    post:
+      operationId: addPet
+      x-oas-location: getPet
+      responses:
+        201:
+          description: Created
+          schema:
+            type: object
+            required: [id]
+            properties:
+              id:
+                type: integer
+    put:
+      operationId: putPet
+      responses:
+        200:
+          description: OK
+  /pets/{id}:
+    get:
+      operationId: getPet
+      parameters:
+      - name: id
+        in: path
+        type: integer
+        required: true
+      responses:
+        200:
+          description: OK
+`))
+
+	write := func(links *LinkBuilder, operationID string, v interface{}) (*httptest.ResponseRecorder, error) {
+		req := httptest.NewRequest(http.MethodPost, "/v2/pets", nil)
+		ctx, _ := WithOperation(req.Context(), doc, operationID)
+		w := httptest.NewRecorder()
+		err := links.WriteCreated(w, req.WithContext(ctx), v, 12)
+		return w, err
+	}
+
+	t.Run("extension", func(t *testing.T) {
+		w, err := write(NewLinkBuilder(doc), "addPet", map[string]int{"id": 12})
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, "https://api.example.com/v2/pets/12", w.Header().Get("Location"))
+		assert.Equal(t, `{"id":12}`, w.Body.String())
+	})
+
+	t.Run("invalid value", func(t *testing.T) {
+		w, err := write(NewLinkBuilder(doc), "addPet", map[string]string{"name": "Rex"})
+		assert.EqualError(t, err, "write created: response body does not match the schema: id in body is required")
+		assert.Empty(t, w.Header().Get("Location"))
+		assert.Empty(t, w.Body.String())
+	})
+
+	t.Run("mapping", func(t *testing.T) {
+		links := NewLinkBuilder(doc, LinkLocations(map[string]string{"putPet": "getPet"}))
+		_, err := write(links, "putPet", map[string]int{"id": 12})
+		assert.EqualError(t, err, "write created: operation putPet does not declare 201 response")
+	})
+
+	t.Run("no retrieve operation", func(t *testing.T) {
+		_, err := write(NewLinkBuilder(doc), "putPet", map[string]int{"id": 12})
+		assert.EqualError(t, err, "write created: operation putPet has no retrieve operation configured")
+	})
+}
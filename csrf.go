@@ -0,0 +1,238 @@
+package oas
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+)
+
+// CSRFExtension is the security scheme extension that enables CSRF
+// protection of the cookie-authenticated scheme, enforced by CSRFProtector.
+// As Swagger 2.0 has no cookie schemes, the scheme is declared as an API
+// key, and the extension names the session cookie:
+//
+//  securityDefinitions:
+//    session:
+//      type: apiKey
+//      in: header
+//      name: X-Session
+//      x-oas-csrf:
+//        cookie: session_id
+//        mode: double-submit
+//        tokenCookie: csrf_token
+//        header: X-CSRF-Token
+//
+// In the double-submit mode (default), the token in the header must match
+// the token in the token cookie, "csrf_token" by default. In the
+// synchronizer mode, the token in the header must match the token stored
+// in the session, as returned by CSRFTokenFunc. The header is
+// "X-CSRF-Token" by default.
+const CSRFExtension = "x-oas-csrf"
+
+// CSRF protection modes of CSRFExtension.
+const (
+	CSRFDoubleSubmit = "double-submit"
+	CSRFSynchronizer = "synchronizer"
+)
+
+// CSRFTokenFunc returns the CSRF token stored in the session of the request
+// for the synchronizer mode. It returns false if there is no token.
+type CSRFTokenFunc func(req *http.Request) (token string, ok bool)
+
+// NewCSRFToken returns a new random CSRF token, to be set to the token
+// cookie in the double-submit mode, or stored in the session in the
+// synchronizer mode.
+func NewCSRFToken() (string, error) {
+	token, err := randomToken()
+	if err != nil {
+		return "", fmt.Errorf("new csrf token: %s", err)
+	}
+	return token, nil
+}
+
+// randomToken returns a new random URL-safe token of 256 bits.
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// csrfScheme is a security scheme with CSRFExtension.
+type csrfScheme struct {
+	cookie      string
+	mode        string
+	tokenCookie string
+	header      string
+}
+
+// parseCSRFScheme parses the value of CSRFExtension.
+func parseCSRFScheme(ext interface{}) (csrfScheme, error) {
+	m, ok := ext.(map[string]interface{})
+	if !ok {
+		return csrfScheme{}, fmt.Errorf("value is not an object")
+	}
+
+	s := csrfScheme{
+		mode:        CSRFDoubleSubmit,
+		tokenCookie: "csrf_token",
+		header:      "X-CSRF-Token",
+	}
+
+	fields := map[string]*string{
+		"cookie":      &s.cookie,
+		"mode":        &s.mode,
+		"tokenCookie": &s.tokenCookie,
+		"header":      &s.header,
+	}
+	for name, dst := range fields {
+		v, ok := m[name]
+		if !ok {
+			continue
+		}
+		str, ok := v.(string)
+		if !ok || str == "" {
+			return csrfScheme{}, fmt.Errorf("%s must be a non-empty string", name)
+		}
+		*dst = str
+	}
+
+	if s.cookie == "" {
+		return csrfScheme{}, fmt.Errorf("cookie is required")
+	}
+	if s.mode != CSRFDoubleSubmit && s.mode != CSRFSynchronizer {
+		return csrfScheme{}, fmt.Errorf("unknown mode %s", s.mode)
+	}
+	return s, nil
+}
+
+// verify verifies the CSRF token of the request.
+func (s csrfScheme) verify(req *http.Request, tokens CSRFTokenFunc) error {
+	token := req.Header.Get(s.header)
+	if token == "" {
+		return fmt.Errorf("header %s is missing", s.header)
+	}
+
+	var expected string
+	switch s.mode {
+	case CSRFSynchronizer:
+		expected, _ = tokens(req)
+	default:
+		if c, err := req.Cookie(s.tokenCookie); err == nil {
+			expected = c.Value
+		}
+	}
+
+	if expected == "" || subtle.ConstantTimeCompare([]byte(token), []byte(expected)) != 1 {
+		return fmt.Errorf("token does not match")
+	}
+	return nil
+}
+
+// isSafeMethod checks if the method is safe, i.e. it does not change state.
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	default:
+		return false
+	}
+}
+
+// CSRFProtector returns a middleware that validates CSRF tokens of requests
+// with state-changing methods to operations whose security requirements
+// include schemes with CSRFExtension. Only requests that carry the session
+// cookie of the scheme are validated, as CSRF is possible only when
+// browsers send the cookie automatically. The tokens function is required
+// if any scheme uses the synchronizer mode.
+//
+// In case of invalid token, this middleware responds with 403 by default.
+// It panics if any CSRFExtension in the spec is invalid.
+func (b *ResolvingBasis) CSRFProtector(tokens CSRFTokenFunc, opts ...MiddlewareOption) Middleware {
+	options := parseMiddlewareOptions(opts...)
+	if options.problemHandler == nil {
+		options.problemHandler = newProblemHandlerStatusResponder(http.StatusForbidden)
+	}
+
+	schemes := make(map[string]csrfScheme)
+	for name, ss := range b.doc.Spec().SecurityDefinitions {
+		ext, ok := ss.Extensions[CSRFExtension]
+		if !ok {
+			continue
+		}
+		s, err := parseCSRFScheme(ext)
+		if err != nil {
+			panic(fmt.Sprintf("oas: security scheme %s: invalid %s: %s", name, CSRFExtension, err))
+		}
+		if s.mode == CSRFSynchronizer && tokens == nil {
+			panic(fmt.Sprintf("oas: security scheme %s: CSRFProtector tokens function is required for %s mode", name, CSRFSynchronizer))
+		}
+		schemes[name] = s
+	}
+
+	operations := make(map[string][]csrfScheme)
+	for id, oi := range b.cache {
+		names, _ := securityUsage(b.doc, oi.operation, func(name string) bool {
+			_, ok := schemes[name]
+			return ok
+		})
+		for _, name := range names {
+			operations[id] = append(operations[id], schemes[name])
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return &csrfProtector{
+			next:           next,
+			tokens:         tokens,
+			operations:     operations,
+			problemHandler: options.problemHandler,
+			strict:         b.strict,
+		}
+	}
+}
+
+// csrfProtector is a middleware that resolves operation context from the
+// request and validates CSRF tokens.
+type csrfProtector struct {
+	next       http.Handler
+	tokens     CSRFTokenFunc
+	operations map[string][]csrfScheme
+
+	problemHandler ProblemHandler
+
+	// strict enforces validation. If false, then requests without
+	// operation context are passed.
+	strict bool
+}
+
+func (mw *csrfProtector) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if isSafeMethod(req.Method) {
+		mw.next.ServeHTTP(w, req)
+		return
+	}
+
+	oi, ok := getOperationInfo(req)
+	if !ok {
+		if mw.strict {
+			panic("csrf protector middleware: cannot find operation info in the request context")
+		}
+		mw.next.ServeHTTP(w, req)
+		return
+	}
+
+	for _, s := range mw.operations[oi.operation.ID] {
+		if _, err := req.Cookie(s.cookie); err != nil {
+			continue
+		}
+		if err := s.verify(req, mw.tokens); err != nil {
+			mw.problemHandler.HandleProblem(NewProblem(w, req, fmt.Errorf("invalid csrf token: %s", err)))
+			return
+		}
+	}
+
+	mw.next.ServeHTTP(w, req)
+}
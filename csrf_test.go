@@ -0,0 +1,144 @@
+package oas
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCSRFProtector(t *testing.T) {
+	doc := loadDocBytes([]byte(`
+swagger: "2.0"
+info:
+  title: Test
+  version: 1.0.0
+securityDefinitions:
+  session:
+    type: apiKey
+    in: header
+    name: X-Session
+    x-oas-csrf:
+      cookie: session_id
+  adminSession:
+    type: apiKey
+    in: header
+    name: X-Admin-Session
+    x-oas-csrf:
+      cookie: admin_session_id
+      mode: synchronizer
+paths:
+  /profile:
+    get:
+      operationId: getProfile
+      security:
+      - session: []
+      responses:
+        200:
+          description: OK
+    put:
+      operationId: updateProfile
+      security:
+      - session: []
+      responses:
+        200:
+          description: OK
+  /users:
+    post:
+      operationId: createUser
+      security:
+      - adminSession: []
+      responses:
+        200:
+          description: OK
+`))
+
+	tokens := func(req *http.Request) (string, bool) {
+		return "synchronized", true
+	}
+
+	basis := NewResolvingBasis(SpecAdapterName, doc, BasisStrict(false))
+	h := SpecMatcherMiddleware(doc)(
+		basis.CSRFProtector(tokens)(
+			http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}),
+		),
+	)
+
+	testCases := map[string]struct {
+		method       string
+		path         string
+		cookies      map[string]string
+		token        string
+		expectedCode int
+		expectedBody string
+	}{
+		"safe method": {
+			method:       http.MethodGet,
+			path:         "/profile",
+			cookies:      map[string]string{"session_id": "s"},
+			expectedCode: http.StatusOK,
+		},
+		"double submit": {
+			method:       http.MethodPut,
+			path:         "/profile",
+			cookies:      map[string]string{"session_id": "s", "csrf_token": "t1"},
+			token:        "t1",
+			expectedCode: http.StatusOK,
+		},
+		"double submit mismatch": {
+			method:       http.MethodPut,
+			path:         "/profile",
+			cookies:      map[string]string{"session_id": "s", "csrf_token": "t1"},
+			token:        "t2",
+			expectedCode: http.StatusForbidden,
+			expectedBody: "invalid csrf token: token does not match",
+		},
+		"missing token": {
+			method:       http.MethodPut,
+			path:         "/profile",
+			cookies:      map[string]string{"session_id": "s"},
+			expectedCode: http.StatusForbidden,
+			expectedBody: "invalid csrf token: header X-CSRF-Token is missing",
+		},
+		"no session cookie": {
+			method:       http.MethodPut,
+			path:         "/profile",
+			expectedCode: http.StatusOK,
+		},
+		"synchronizer": {
+			method:       http.MethodPost,
+			path:         "/users",
+			cookies:      map[string]string{"admin_session_id": "s"},
+			token:        "synchronized",
+			expectedCode: http.StatusOK,
+		},
+		"synchronizer mismatch": {
+			method:       http.MethodPost,
+			path:         "/users",
+			cookies:      map[string]string{"admin_session_id": "s", "csrf_token": "t1"},
+			token:        "t1",
+			expectedCode: http.StatusForbidden,
+			expectedBody: "invalid csrf token: token does not match",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			for name, value := range tc.cookies {
+				req.AddCookie(&http.Cookie{Name: name, Value: value})
+			}
+			if tc.token != "" {
+				req.Header.Set("X-CSRF-Token", tc.token)
+			}
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedCode, w.Code)
+			assert.Equal(t, tc.expectedBody, strings.TrimSpace(w.Body.String()))
+		})
+	}
+}
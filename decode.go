@@ -6,28 +6,161 @@ import (
 	"net/http"
 	"net/url"
 	"reflect"
+	"sort"
+	"strings"
+	"unicode"
 
 	"github.com/go-openapi/spec"
 
 	"github.com/hypnoglow/oas2/convert"
+	"github.com/hypnoglow/oas2/validate"
 )
 
 const (
 	tag = "oas"
 )
 
+// DecodeOption is an option for decoding.
+type DecodeOption func(*decodeOptions)
+
+type decodeOptions struct {
+	tagFallback bool
+	trace       *DecodeTrace
+	useNumber   bool
+	duplicates  validate.DuplicatePolicy
+	normalize   bool
+}
+
+// DecodeTagFallback returns a decode option that defines if fields without
+// `oas` tag should be matched to parameters by their `json` tag names or,
+// for fields without `json` tag, by their names converted to snake_case,
+// e.g. "UserID" to "user_id". This allows to decode to existing DTO structs
+// without re-tagging them. By default, only fields with `oas` tag are
+// decoded.
+//
+// DecodeBody decodes JSON, so it always uses `json` tags.
+func DecodeTagFallback(fallback bool) DecodeOption {
+	return func(o *decodeOptions) {
+		o.tagFallback = fallback
+	}
+}
+
+// DecodeWithTrace returns a decode option that records every coercion step
+// to the trace, so it can be inspected after decoding, e.g. to troubleshoot
+// why a request was decoded the way it was. Tracing also checks the values
+// against the parameter constraints, so it should only be used for
+// debugging.
+func DecodeWithTrace(t *DecodeTrace) DecodeOption {
+	return func(o *decodeOptions) {
+		o.trace = t
+	}
+}
+
+// DecodeJSONNumbers returns a decode option that defines if DecodeBody
+// should decode JSON numbers to interface{} values as json.Number instead
+// of float64, so that large integers, e.g. int64 ids, survive round trips
+// exactly. Numbers decoded to typed fields are not affected.
+func DecodeJSONNumbers(enabled bool) DecodeOption {
+	return func(o *decodeOptions) {
+		o.useNumber = enabled
+	}
+}
+
+// DecodeDuplicateParams returns a decode option that sets the policy for
+// non-array parameters that appear more than once, the same way
+// WithDuplicateParams does for the query validator. By default, decoding
+// such parameters fails with validate.DuplicateParamError.
+func DecodeDuplicateParams(policy validate.DuplicatePolicy) DecodeOption {
+	return func(o *decodeOptions) {
+		o.duplicates = policy
+	}
+}
+
+// DecodeNormalizeUnicode returns a decode option that defines if string
+// values of parameters should be normalized to NFC before decoding, the
+// same way WithUnicodeNormalization does for the validators. Parameters
+// override this option with UnicodeNormalizationExtension. DecodeBody is
+// not affected.
+func DecodeNormalizeUnicode(enabled bool) DecodeOption {
+	return func(o *decodeOptions) {
+		o.normalize = enabled
+	}
+}
+
+// Sources of values in coercion steps.
+const (
+	CoercionSourceRequest     = "request"
+	CoercionSourceTagDefault  = "tag default"
+	CoercionSourceSpecDefault = "spec default"
+	CoercionSourceAbsent      = "absent"
+)
+
+// CoercionStep describes how a parameter was decoded to a struct field.
+type CoercionStep struct {
+	// Param is the parameter name.
+	Param string
+
+	// Field is the struct field name.
+	Field string
+
+	// Source is the source of raw values, e.g. CoercionSourceRequest.
+	// When the source is CoercionSourceAbsent, the field is not set.
+	Source string
+
+	// Raw are the raw values the parameter was decoded from.
+	Raw []string
+
+	// Value is the parsed value, if parsing succeeded.
+	Value interface{}
+
+	// Err is the parsing or assignment error, if any.
+	Err error
+
+	// Violations are the errors of checking the raw values against the
+	// parameter constraints, e.g. maximum or enum.
+	Violations []error
+}
+
+// DecodeTrace records coercion steps of decoding.
+type DecodeTrace struct {
+	Steps []CoercionStep
+}
+
+func (t *DecodeTrace) add(s CoercionStep) {
+	if t != nil {
+		t.Steps = append(t.Steps, s)
+	}
+}
+
 // DecodeQuery decodes all query params by request operation spec to the dst.
-func DecodeQuery(req *http.Request, dst interface{}) error {
-	oi, ok := getOperationInfo(req)
+func DecodeQuery(req *http.Request, dst interface{}, opts ...DecodeOption) error {
+	params, ok := ParamsFromContext(req.Context())
 	if ok {
-		return DecodeQueryParams(oi.params, req.URL.Query(), dst)
+		return DecodeQueryParams(params, req.URL.Query(), dst, opts...)
 	}
 
 	return errors.New("decode query: cannot find OpenAPI operation info in the request context")
 }
 
 // DecodeQueryParams decodes query parameters by their spec to the dst.
-func DecodeQueryParams(ps []spec.Parameter, q url.Values, dst interface{}) error {
+//
+// Struct fields are tagged with the parameter name, optionally followed by
+// options that augment or override the spec:
+//
+//  Limit int64 `oas:"limit,default=10"`
+//  Query string `oas:"q,required"`
+//
+// Option "default" sets the value to use when the parameter is missing; it
+// takes precedence over the spec default and cannot contain commas. Option
+// "required" makes decoding fail when the parameter is missing and has no
+// default. If the name is omitted, e.g. `oas:",required"`, the field is
+// named as with DecodeTagFallback.
+func DecodeQueryParams(ps []spec.Parameter, q url.Values, dst interface{}, opts ...DecodeOption) error {
+	options := decodeOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
 	dv := reflect.ValueOf(dst)
 	if dv.Kind() != reflect.Ptr {
 		return fmt.Errorf("dst is not a pointer to struct (cannot modify)")
@@ -38,7 +171,7 @@ func DecodeQueryParams(ps []spec.Parameter, q url.Values, dst interface{}) error
 		return fmt.Errorf("dst is not a pointer to struct (cannot modify)")
 	}
 
-	fields := fieldMap(dv)
+	fields := fieldMap(dv, options.tagFallback)
 
 	for _, p := range ps {
 		// No such tag in struct - no need to populate.
@@ -47,24 +180,56 @@ func DecodeQueryParams(ps []spec.Parameter, q url.Values, dst interface{}) error
 			continue
 		}
 
+		step := CoercionStep{Param: p.Name, Field: f.Name, Source: CoercionSourceRequest}
+
 		vals, ok := q[p.Name]
 		if !ok {
 			// No such value in query.
 			// If there is default - use it, otherwise skip this value.
-			if p.Default == nil {
+			switch {
+			case f.tag.hasDefault:
+				vals = []string{f.tag.def}
+				step.Source = CoercionSourceTagDefault
+			case p.Default != nil:
+				// Default value can be in a weird format internally, e.g.
+				// when spec gets parsed default value for integer can be of
+				// type float64. So we cannot assign it directly. We need to
+				// proceed with conversion procedure.
+				vals = []string{fmt.Sprintf("%v", p.Default)}
+				step.Source = CoercionSourceSpecDefault
+			case f.tag.required:
+				step.Source = CoercionSourceAbsent
+				step.Err = fmt.Errorf("parameter %s is required by field %s", p.Name, f.Name)
+				options.trace.add(step)
+				return step.Err
+			default:
+				step.Source = CoercionSourceAbsent
+				options.trace.add(step)
 				continue
 			}
+		}
+		vals = validate.Deduplicate(&p, vals, options.duplicates)
+		if normalizesUnicode(&p, options.normalize) {
+			vals = append([]string(nil), vals...)
+			normalizeValues(vals)
+		}
+		step.Raw = vals
+
+		if err := validate.DuplicateError(&p, vals); err != nil {
+			step.Err = err
+			options.trace.add(step)
+			return err
+		}
 
-			// Default value can be in a weird format internally, e.g.
-			// when spec gets parsed default value for integer can be of
-			// type float64. So we cannot assign it directly. We need to
-			// proceed with conversion procedure.
-			vals = []string{fmt.Sprintf("%v", p.Default)}
+		if options.trace != nil {
+			step.Violations = checkParamConstraints(p, vals)
 		}
 
 		// Convert value by type+format in parameter.
 		v, err := convert.Parameter(vals, &p)
 		if err != nil {
+			step.Err = err
+			options.trace.add(step)
 			if p.Format != "" {
 				return fmt.Errorf(
 					"cannot use values %v as parameter %s with type %s and format %s",
@@ -82,14 +247,135 @@ func DecodeQueryParams(ps []spec.Parameter, q url.Values, dst interface{}) error
 			)
 		}
 
-		if err := set(v, f, dv); err != nil {
+		step.Value = v
+
+		if err := set(v, f.StructField, dv); err != nil {
+			step.Err = err
+			options.trace.add(step)
 			return err
 		}
+		options.trace.add(step)
 	}
 
 	return nil
 }
 
+// checkParamConstraints checks the raw values against the parameter
+// constraints.
+func checkParamConstraints(p spec.Parameter, vals []string) []error {
+	// Check the values as a query parameter regardless of the parameter
+	// location, since the constraints are the same.
+	p.In = "query"
+	return validate.Query([]spec.Parameter{p}, url.Values{p.Name: vals})
+}
+
+// DecodeFormBody decodes application/x-www-form-urlencoded request body by
+// request operation spec to the dst. The request body is consumed, but the
+// form values remain available in req.PostForm.
+func DecodeFormBody(req *http.Request, dst interface{}, opts ...DecodeOption) error {
+	params, ok := ParamsFromContext(req.Context())
+	if !ok {
+		return errors.New("decode form body: cannot find OpenAPI operation info in the request context")
+	}
+
+	if err := req.ParseForm(); err != nil {
+		return fmt.Errorf("decode form body: %s", err)
+	}
+	return DecodeFormParams(params, req.PostForm, dst, opts...)
+}
+
+// DecodeFormParams decodes form values by the formData parameters spec to
+// the dst, the same way DecodeQueryParams does.
+func DecodeFormParams(ps []spec.Parameter, form url.Values, dst interface{}, opts ...DecodeOption) error {
+	var formParams []spec.Parameter
+	for _, p := range ps {
+		if p.In == "formData" {
+			formParams = append(formParams, p)
+		}
+	}
+	return DecodeQueryParams(formParams, form, dst, opts...)
+}
+
+// DecodeQueryValues decodes query values to the dst without the spec. It
+// infers parameter names from `oas` tags, see DecodeQueryParams, and
+// parameter types from the field types: string, bool, int32, int64, float32,
+// float64, pointers to them, and slices of them except bool, which are
+// decoded from comma-separated values. This is useful for endpoints that are
+// not yet covered by the spec.
+func DecodeQueryValues(q url.Values, dst interface{}, opts ...DecodeOption) error {
+	options := decodeOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	dv := reflect.ValueOf(dst)
+	if dv.Kind() != reflect.Ptr || dv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("dst is not a pointer to struct (cannot modify)")
+	}
+
+	fields := fieldMap(dv.Elem(), options.tagFallback)
+
+	ps := make([]spec.Parameter, 0, len(fields))
+	for name, f := range fields {
+		p, err := inferParameter(name, f.Type)
+		if err != nil {
+			return fmt.Errorf("field %s: %s", f.Name, err)
+		}
+		ps = append(ps, p)
+	}
+
+	// Decode parameters in the fields order, so errors are stable.
+	sort.Slice(ps, func(i, j int) bool {
+		return fields[ps[i].Name].Index[0] < fields[ps[j].Name].Index[0]
+	})
+
+	return DecodeQueryParams(ps, q, dst, opts...)
+}
+
+// inferParameter returns the query parameter for the field of the type.
+func inferParameter(name string, t reflect.Type) (spec.Parameter, error) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	p := spec.QueryParam(name)
+
+	if t.Kind() == reflect.Slice {
+		typ, format, ok := parameterType(t.Elem())
+		if !ok || typ == "boolean" {
+			return spec.Parameter{}, fmt.Errorf("unsupported type %s", t)
+		}
+		return *p.CollectionOf(spec.NewItems().Typed(typ, format), "csv"), nil
+	}
+
+	typ, format, ok := parameterType(t)
+	if !ok {
+		return spec.Parameter{}, fmt.Errorf("unsupported type %s", t)
+	}
+	return *p.Typed(typ, format), nil
+}
+
+// parameterType returns the parameter type and format, whose values are
+// converted to the Go type. See parameterGoType for the reverse.
+func parameterType(t reflect.Type) (typ, format string, ok bool) {
+	switch t {
+	case reflect.TypeOf(""):
+		return "string", "", true
+	case reflect.TypeOf(false):
+		return "boolean", "", true
+	case reflect.TypeOf(int32(0)):
+		return "integer", "int32", true
+	case reflect.TypeOf(int64(0)):
+		return "integer", "int64", true
+	case reflect.TypeOf(float32(0)):
+		return "number", "float", true
+	case reflect.TypeOf(float64(0)):
+		return "number", "double", true
+	default:
+		return "", "", false
+	}
+}
+
 func set(v interface{}, f reflect.StructField, dst reflect.Value) error {
 	// Check if tag in struct can accept value of type v.
 	if !isAssignable(f, v) {
@@ -118,21 +404,103 @@ func isAssignable(field reflect.StructField, value interface{}) bool {
 	return reflect.TypeOf(value).AssignableTo(field.Type)
 }
 
-// fieldMap returns v fields mapped by their tags.
-func fieldMap(rv reflect.Value) map[string]reflect.StructField {
+// taggedField is a struct field with its parsed `oas` tag.
+type taggedField struct {
+	reflect.StructField
+	tag fieldTag
+}
+
+// fieldTag represents `oas` struct tag.
+type fieldTag struct {
+	name       string
+	required   bool
+	def        string
+	hasDefault bool
+}
+
+// parseFieldTag parses `oas` struct tag value, e.g. "limit,default=10".
+func parseFieldTag(s string) fieldTag {
+	parts := strings.Split(s, ",")
+	t := fieldTag{name: parts[0]}
+	for _, opt := range parts[1:] {
+		switch {
+		case opt == "required":
+			t.required = true
+		case strings.HasPrefix(opt, "default="):
+			t.def = strings.TrimPrefix(opt, "default=")
+			t.hasDefault = true
+		}
+	}
+	return t
+}
+
+// fieldMap returns v fields mapped by their tags. With fallback, fields
+// without tags are mapped by their json names.
+func fieldMap(rv reflect.Value, fallback bool) map[string]taggedField {
 	rt := rv.Type()
 
-	m := make(map[string]reflect.StructField)
+	m := make(map[string]taggedField)
 	n := rt.NumField()
 	for i := 0; i < n; i++ {
 		f := rt.Field(i)
-		tag, ok := f.Tag.Lookup(tag)
-		if !ok {
+
+		var t fieldTag
+		if s, ok := f.Tag.Lookup(tag); ok {
+			t = parseFieldTag(s)
+		} else if !fallback {
 			continue
 		}
 
-		m[tag] = f
+		if t.name == "" {
+			name, ok := jsonFieldName(f)
+			if !ok {
+				continue
+			}
+			t.name = name
+		}
+
+		m[t.name] = taggedField{StructField: f, tag: t}
 	}
 
 	return m
 }
+
+// jsonFieldName returns the name of the field in JSON: either the name from
+// `json` tag, or the field name converted to snake_case. Unexported fields
+// and fields ignored by `json` tag have no name.
+func jsonFieldName(f reflect.StructField) (string, bool) {
+	if f.PkgPath != "" {
+		return "", false
+	}
+
+	name := f.Tag.Get("json")
+	if i := strings.Index(name, ","); i >= 0 {
+		name = name[:i]
+	}
+	switch name {
+	case "-":
+		return "", false
+	case "":
+		return snakeCase(f.Name), true
+	default:
+		return name, true
+	}
+}
+
+// snakeCase converts the Go name to snake_case, keeping acronyms together,
+// e.g. "UserID" to "user_id" and "HTTPServer" to "http_server".
+func snakeCase(name string) string {
+	rs := []rune(name)
+	out := make([]rune, 0, len(rs)+4)
+	for i, r := range rs {
+		if unicode.IsUpper(r) && i > 0 {
+			prevLower := unicode.IsLower(rs[i-1]) || unicode.IsDigit(rs[i-1])
+			nextLower := i+1 < len(rs) && unicode.IsLower(rs[i+1])
+			if prevLower || (nextLower && unicode.IsUpper(rs[i-1])) {
+				out = append(out, '_')
+			}
+		}
+		out = append(out, unicode.ToLower(r))
+	}
+	return string(out)
+}
@@ -7,6 +7,8 @@ import (
 	"testing"
 
 	"github.com/go-openapi/spec"
+
+	"github.com/hypnoglow/oas2/validate"
 )
 
 func ExampleDecodeQueryParams() {
@@ -365,3 +367,228 @@ func TestDecodeQueryIntegerParameterWithDefault(t *testing.T) {
 		t.Fatalf("Expected limit to be 10 but got %v", input.Limit)
 	}
 }
+
+func TestDecodeTagFallback(t *testing.T) {
+	params := []spec.Parameter{
+		*spec.QueryParam("name").Typed("string", ""),
+		*spec.QueryParam("user_id").Typed("integer", "int64"),
+		*spec.QueryParam("loves_apples").Typed("boolean", ""),
+		*spec.QueryParam("secret").Typed("string", ""),
+	}
+
+	query := url.Values{
+		"name":         []string{"John"},
+		"user_id":      []string{"27"},
+		"loves_apples": []string{"true"},
+		"secret":       []string{"foo"},
+	}
+
+	type member struct {
+		Name        string `oas:"name"`
+		UserID      int64
+		LovesApples bool   `json:"loves_apples,omitempty"`
+		Secret      string `json:"-"`
+	}
+
+	var m member
+	if err := DecodeQueryParams(params, query, &m); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(member{Name: "John"}, m) {
+		t.Errorf("Expected only tagged fields to be decoded but got %#v", m)
+	}
+
+	m = member{}
+	if err := DecodeQueryParams(params, query, &m, DecodeTagFallback(true)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(member{Name: "John", UserID: 27, LovesApples: true}, m) {
+		t.Errorf("Expected fields to be decoded by fallback names but got %#v", m)
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	cases := map[string]string{
+		"Name":        "name",
+		"UserID":      "user_id",
+		"HTTPServer":  "http_server",
+		"LovesApples": "loves_apples",
+		"Address2":    "address2",
+	}
+
+	for in, expected := range cases {
+		if actual := snakeCase(in); actual != expected {
+			t.Errorf("Expected %s to be converted to %s but got %s", in, expected, actual)
+		}
+	}
+}
+
+func TestDecodeQueryParamsTagOptions(t *testing.T) {
+	params := []spec.Parameter{
+		*spec.QueryParam("limit").Typed("integer", "int64").WithDefault(float64(20)),
+		*spec.QueryParam("offset").Typed("integer", "int64"),
+		*spec.QueryParam("q").Typed("string", ""),
+	}
+
+	type input struct {
+		Limit  int64  `oas:"limit,default=10"`
+		Offset int64  `oas:",default=5"`
+		Query  string `oas:"q,required"`
+	}
+
+	var in input
+	if err := DecodeQueryParams(params, url.Values{"q": []string{"foo"}}, &in); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(input{Limit: 10, Offset: 5, Query: "foo"}, in) {
+		t.Errorf("Expected tag defaults to be used but got %#v", in)
+	}
+
+	in = input{}
+	err := DecodeQueryParams(params, url.Values{}, &in)
+	expectedErr := fmt.Errorf("parameter q is required by field Query")
+	if !reflect.DeepEqual(expectedErr, err) {
+		t.Errorf("Expected error to be %v but got %v", expectedErr, err)
+	}
+}
+
+func TestDecodeQueryValues(t *testing.T) {
+	type input struct {
+		Name    string    `oas:"name,required"`
+		Age     *int32    `oas:"age"`
+		Limit   int64     `oas:"limit,default=10"`
+		Tags    []string  `oas:"tags"`
+		Weights []float64 `oas:"weights"`
+		Adult   bool      `oas:"adult"`
+		Ignored string
+	}
+
+	q := url.Values{
+		"name":    []string{"John"},
+		"age":     []string{"27"},
+		"tags":    []string{"foo,bar"},
+		"weights": []string{"1.5,2"},
+		"adult":   []string{"true"},
+		"Ignored": []string{"baz"},
+	}
+
+	var in input
+	if err := DecodeQueryValues(q, &in); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	age := int32(27)
+	expected := input{
+		Name:    "John",
+		Age:     &age,
+		Limit:   10,
+		Tags:    []string{"foo", "bar"},
+		Weights: []float64{1.5, 2},
+		Adult:   true,
+	}
+	if !reflect.DeepEqual(expected, in) {
+		t.Errorf("Expected input to be %#v but got %#v", expected, in)
+	}
+
+	var unsupported struct {
+		Count int `oas:"count"`
+	}
+	err := DecodeQueryValues(q, &unsupported)
+	expectedErr := fmt.Errorf("field Count: unsupported type int")
+	if !reflect.DeepEqual(expectedErr, err) {
+		t.Errorf("Expected error to be %v but got %v", expectedErr, err)
+	}
+}
+
+func TestDecodeWithTrace(t *testing.T) {
+	params := []spec.Parameter{
+		*spec.QueryParam("limit").Typed("integer", "int64").WithMaximum(100, false),
+		*spec.QueryParam("offset").Typed("integer", "int64").WithDefault(float64(0)),
+		*spec.QueryParam("q").Typed("string", ""),
+		*spec.QueryParam("sort").Typed("string", ""),
+	}
+
+	type input struct {
+		Limit  int64  `oas:"limit"`
+		Offset int64  `oas:"offset"`
+		Query  string `oas:"q"`
+		Sort   string `oas:"sort,default=name"`
+	}
+
+	var (
+		in    input
+		trace DecodeTrace
+	)
+	err := DecodeQueryParams(params, url.Values{"limit": []string{"200"}}, &in, DecodeWithTrace(&trace))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(trace.Steps) != 4 {
+		t.Fatalf("Expected 4 steps but got %v", trace.Steps)
+	}
+
+	limit := trace.Steps[0]
+	if limit.Source != CoercionSourceRequest || limit.Value != int64(200) || len(limit.Violations) != 1 {
+		t.Errorf("Unexpected limit step: %#v", limit)
+	}
+
+	offset := trace.Steps[1]
+	if offset.Source != CoercionSourceSpecDefault || !reflect.DeepEqual([]string{"0"}, offset.Raw) || offset.Value != int64(0) {
+		t.Errorf("Unexpected offset step: %#v", offset)
+	}
+
+	q := trace.Steps[2]
+	if q.Source != CoercionSourceAbsent || q.Value != nil {
+		t.Errorf("Unexpected q step: %#v", q)
+	}
+
+	sort := trace.Steps[3]
+	if sort.Source != CoercionSourceTagDefault || sort.Value != "name" || sort.Field != "Sort" {
+		t.Errorf("Unexpected sort step: %#v", sort)
+	}
+}
+
+func TestDecodeDuplicateParams(t *testing.T) {
+	ps := []spec.Parameter{
+		{
+			ParamProps:   spec.ParamProps{Name: "age", In: "query"},
+			SimpleSchema: spec.SimpleSchema{Type: "integer", Format: "int32"},
+		},
+	}
+	q := url.Values{"age": {"1", "2"}}
+
+	testCases := map[string]struct {
+		policy      validate.DuplicatePolicy
+		expectedAge int32
+		expectedErr error
+	}{
+		"reject": {
+			policy:      validate.DuplicateReject,
+			expectedErr: validate.DuplicateParamError{Param: "age", Values: []string{"1", "2"}},
+		},
+		"first wins": {
+			policy:      validate.DuplicateFirstWins,
+			expectedAge: 1,
+		},
+		"last wins": {
+			policy:      validate.DuplicateLastWins,
+			expectedAge: 2,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var in struct {
+				Age int32 `oas:"age"`
+			}
+			err := DecodeQueryParams(ps, q, &in, DecodeDuplicateParams(tc.policy))
+			if !reflect.DeepEqual(tc.expectedErr, err) {
+				t.Errorf("Expected error to be %v but got %v", tc.expectedErr, err)
+			}
+			if in.Age != tc.expectedAge {
+				t.Errorf("Expected age to be %d but got %d", tc.expectedAge, in.Age)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package oas
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hypnoglow/oas2/validate"
+)
+
+// ValidateDefinition validates the JSON payload against the named spec
+// definition, e.g. "Pet". This allows to validate messages of other
+// transports, e.g. Kafka or queue messages, against the schemas shared with
+// the HTTP API. Validation errors are returned as MultiError.
+func (doc *Document) ValidateDefinition(name string, data []byte) error {
+	mv, err := doc.MessageValidator(name)
+	if err != nil {
+		return err
+	}
+	return mv.Validate(data)
+}
+
+// Definition returns the named spec definition, e.g. "Pet".
+func (doc *Document) Definition(name string) (Schema, bool) {
+	schema, ok := doc.Spec().Definitions[name]
+	if !ok {
+		return Schema{}, false
+	}
+	return Schema{Schema: &schema, name: name}, true
+}
+
+// Validate validates the data against the schema. This allows to validate
+// arbitrary data, e.g. config or database rows during migrations, without
+// HTTP machinery. The data can be any value that marshals to JSON, such as
+// a struct, a map or a json.RawMessage. Validation errors are returned as
+// MultiError.
+func (s Schema) Validate(data interface{}) error {
+	payload, err := jsonValue(data)
+	if err != nil {
+		return err
+	}
+
+	if errs := validate.BySchema(s.Schema, payload); len(errs) > 0 {
+		msg := "data does not match the schema"
+		if s.name != "" {
+			msg = fmt.Sprintf("data does not match the definition %s", s.name)
+		}
+		return newMultiError(msg, errs...)
+	}
+	return nil
+}
+
+// jsonValue converts the data to the generic JSON value, i.e. maps, slices,
+// strings, float64 numbers, booleans and nils.
+func jsonValue(data interface{}) (interface{}, error) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("data cannot be marshaled to json: %s", err)
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, fmt.Errorf("data cannot be unmarshaled from json: %s", err)
+	}
+	return v, nil
+}
+
+// MessageValidator validates JSON messages against a spec definition.
+//
+// MessageValidator is safe for concurrent use.
+type MessageValidator struct {
+	schema Schema
+}
+
+// MessageValidator returns a new MessageValidator for the named spec
+// definition, so the definition is looked up only once for a stream of
+// messages.
+func (doc *Document) MessageValidator(name string) (*MessageValidator, error) {
+	schema, ok := doc.Definition(name)
+	if !ok {
+		return nil, fmt.Errorf("definition %s is not found in the spec", name)
+	}
+	return &MessageValidator{schema: schema}, nil
+}
+
+// Validate validates the JSON message. Validation errors are returned
+// as MultiError.
+func (mv *MessageValidator) Validate(data []byte) error {
+	var payload interface{}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return fmt.Errorf("message contains invalid json: %s", err)
+	}
+
+	if errs := validate.BySchema(mv.schema.Schema, payload); len(errs) > 0 {
+		return newMultiError(fmt.Sprintf("message does not match the definition %s", mv.schema.name), errs...)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package oas
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDocument_ValidateDefinition(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+
+	testCases := map[string]struct {
+		definition    string
+		data          string
+		expectedError string
+	}{
+		"valid message": {
+			definition: "Pet",
+			data:       `{"name":"Kitty","age":3}`,
+		},
+		"invalid message": {
+			definition:    "Pet",
+			data:          `{"name":"Kitty","age":"3"}`,
+			expectedError: "message does not match the definition Pet: age in body must be of type integer: \"string\"",
+		},
+		"invalid json": {
+			definition:    "Pet",
+			data:          `{"name":`,
+			expectedError: "message contains invalid json: unexpected end of JSON input",
+		},
+		"unknown definition": {
+			definition:    "Cat",
+			data:          `{}`,
+			expectedError: "definition Cat is not found in the spec",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := doc.ValidateDefinition(tc.definition, []byte(tc.data))
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tc.expectedError)
+		})
+	}
+}
+
+func TestSchema_Validate(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+
+	_, ok := doc.Definition("Cat")
+	assert.False(t, ok)
+
+	schema, ok := doc.Definition("Pet")
+	if !assert.True(t, ok) {
+		return
+	}
+
+	type pet struct {
+		Name string `json:"name"`
+		Age  int    `json:"age"`
+	}
+
+	assert.NoError(t, schema.Validate(pet{Name: "Kitty", Age: 3}))
+	assert.NoError(t, schema.Validate(map[string]interface{}{"name": "Kitty", "age": 3}))
+	assert.EqualError(t,
+		schema.Validate(map[string]interface{}{"name": "Kitty"}),
+		"data does not match the definition Pet: age in body is required",
+	)
+	assert.EqualError(t,
+		schema.Validate(func() {}),
+		"data cannot be marshaled to json: json: unsupported type: func()",
+	)
+}
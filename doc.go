@@ -5,4 +5,8 @@
 // leveraging the OpenAPI specification in Go idiomatic way on top of `net/http`.
 // The package can handle request validation, request parameters decoding
 // and other routines.
+//
+// Only OpenAPI 2.0 documents are supported. The location of the API is
+// described by host, basePath and schemes of the document, so there are no
+// OpenAPI 3 servers with URL template variables to resolve.
 package oas
@@ -0,0 +1,203 @@
+package oas
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-openapi/spec"
+
+	"github.com/hypnoglow/oas2/convert"
+	"github.com/hypnoglow/oas2/validate"
+)
+
+// DebugEchoExtension is the operation extension that enables the debug echo
+// of the operation, served by DebugEcho:
+//
+//  paths:
+//    /pet:
+//      post:
+//        operationId: addPet
+//        x-oas-debug-echo: true
+const DebugEchoExtension = "x-oas-debug-echo"
+
+// DebugEchoHeader is the request header that asks DebugEcho for the echo
+// instead of the response of the operation.
+const DebugEchoHeader = "X-Oas-Debug-Echo"
+
+// DebugEchoView is the view of the request responded by DebugEcho. Params
+// are typed values coerced by their spec, with defaults applied to missing
+// params, so the view shows the request as the service sees it.
+type DebugEchoView struct {
+	OperationID string                 `json:"operationId"`
+	Path        map[string]interface{} `json:"path,omitempty"`
+	Query       map[string]interface{} `json:"query,omitempty"`
+	Header      map[string]interface{} `json:"header,omitempty"`
+	Body        interface{}            `json:"body,omitempty"`
+
+	// Errors are the validation errors of the query and the body.
+	Errors []string `json:"errors,omitempty"`
+}
+
+// DebugEchoOption is an option for DebugEcho.
+type DebugEchoOption func(*debugEcho)
+
+// DebugEchoRedactFields returns a debug echo option that sets the names of
+// params and JSON object fields whose values are redacted, at any depth of
+// the body, e.g. "password" or "cardNumber".
+func DebugEchoRedactFields(names ...string) DebugEchoOption {
+	return func(e *debugEcho) {
+		e.redactFields = make(map[string]bool, len(names))
+		for _, name := range names {
+			e.redactFields[name] = true
+		}
+	}
+}
+
+// DebugEcho returns a middleware that responds to requests to operations
+// with DebugEchoExtension that carry DebugEchoHeader with DebugEchoView of
+// the request, instead of passing them to the handler. This helps client
+// developers to diagnose validation failures, so mount it before the
+// validators. Properties marked with PIIExtension are filtered for
+// principals lacking ScopePIIRead.
+//
+// The echo exposes the internals of the service, so it is gated by enabled,
+// which should be set by the configuration, e.g. only in staging. If it is
+// false, the middleware passes all requests through.
+//
+// It panics if any DebugEchoExtension in the spec is not a boolean.
+func (b *ResolvingBasis) DebugEcho(enabled bool, opts ...DebugEchoOption) Middleware {
+	if !enabled {
+		return passThrough
+	}
+
+	operations := make(map[string]bool)
+	for id, oi := range b.cache {
+		v, ok := oi.operation.Extensions[DebugEchoExtension]
+		if !ok {
+			continue
+		}
+		echo, ok := v.(bool)
+		if !ok {
+			panic(fmt.Sprintf("oas: operation %s: invalid %s: value is not a boolean", id, DebugEchoExtension))
+		}
+		operations[id] = echo
+	}
+
+	return func(next http.Handler) http.Handler {
+		e := &debugEcho{
+			next:       next,
+			operations: operations,
+			strict:     b.strict,
+		}
+		for _, opt := range opts {
+			opt(e)
+		}
+		return e
+	}
+}
+
+// debugEcho is a middleware that resolves operation context from the
+// request and responds with the echo of the request.
+type debugEcho struct {
+	next         http.Handler
+	operations   map[string]bool
+	redactFields map[string]bool
+
+	// strict enforces operation context. If false, then requests without
+	// operation context are passed.
+	strict bool
+}
+
+func (mw *debugEcho) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	oi, ok := getOperationInfo(req)
+	if !ok {
+		if mw.strict {
+			panic("debug echo middleware: cannot find operation info in the request context")
+		}
+		mw.next.ServeHTTP(w, req)
+		return
+	}
+
+	if !mw.operations[oi.operation.ID] || req.Header.Get(DebugEchoHeader) == "" {
+		mw.next.ServeHTTP(w, req)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, mw.view(req, oi))
+}
+
+// view returns the echo of the request to the operation.
+func (mw *debugEcho) view(req *http.Request, oi operationInfo) DebugEchoView {
+	v := DebugEchoView{OperationID: oi.operation.ID}
+	query := req.URL.Query()
+
+	var errs []error
+	for _, p := range oi.params {
+		switch p.In {
+		case "path":
+			if pv := GetPathParam(req, p.Name); pv != nil {
+				v.Path = mw.set(v.Path, p.Name, pv)
+			}
+		case "query":
+			// Conversion errors are reported by the query validation.
+			if pv, err := echoParam(p, query[p.Name]); err == nil && pv != nil {
+				v.Query = mw.set(v.Query, p.Name, pv)
+			}
+		case "header":
+			pv, err := echoParam(p, req.Header[http.CanonicalHeaderKey(p.Name)])
+			if err != nil {
+				errs = append(errs, err)
+			} else if pv != nil {
+				v.Header = mw.set(v.Header, p.Name, pv)
+			}
+		case "body":
+			withContextBody(req)
+			if isEmptyBody(req) {
+				continue
+			}
+			body, err := bodyPayload(req, true)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			errs = append(errs, validate.Body(oi.params, body)...)
+			if !canReadPII(req) {
+				filterPII(p.Schema, body)
+			}
+			redactFields(body, mw.redactFields)
+			v.Body = body
+		}
+	}
+	errs = append(errs, validate.Query(oi.params, query)...)
+
+	for _, err := range errs {
+		v.Errors = append(v.Errors, err.Error())
+	}
+	return v
+}
+
+// set sets the param value to the map, redacting it if needed, and returns
+// the map.
+func (mw *debugEcho) set(m map[string]interface{}, name string, v interface{}) map[string]interface{} {
+	if m == nil {
+		m = make(map[string]interface{})
+	}
+	if mw.redactFields[name] {
+		v = Redacted
+	}
+	m[name] = v
+	return m
+}
+
+// echoParam returns the value of the param coerced by its spec, or its
+// default if the param is missing.
+func echoParam(p spec.Parameter, vals []string) (interface{}, error) {
+	if len(vals) == 0 {
+		return p.Default, nil
+	}
+	v, err := convert.Parameter(vals, &p)
+	if err != nil {
+		return nil, fmt.Errorf("param %s: %s", p.Name, err)
+	}
+	return v, nil
+}
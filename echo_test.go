@@ -0,0 +1,152 @@
+package oas
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolvingBasis_DebugEcho(t *testing.T) {
+	doc := loadDocBytes([]byte(`
+swagger: "2.0"
+info:
+  title: Test
+  version: 1.0.0
+paths:
+  /users:
+    post:
+      operationId: addUser
+      x-oas-debug-echo: true
+      consumes:
+      - application/json
+      parameters:
+      - name: limit
+        in: query
+        type: integer
+        default: 10
+      - name: dryRun
+        in: query
+        type: boolean
+      - name: X-Tenant
+        in: header
+        type: integer
+      - name: body
+        in: body
+        schema:
+          type: object
+          required: [name]
+          properties:
+            name:
+              type: string
+            email:
+              type: string
+              x-oas-pii: mask
+            password:
+              type: string
+      responses:
+        201:
+          description: Created
+    get:
+      operationId: listUsers
+      responses:
+        200:
+          description: OK
+`))
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("handler")) // nolint
+	})
+
+	testCases := map[string]struct {
+		enabled      bool
+		method       string
+		query        string
+		header       http.Header
+		body         string
+		expectedBody string
+	}{
+		"echo": {
+			enabled: true,
+			method:  http.MethodPost,
+			query:   "dryRun=true",
+			header:  http.Header{DebugEchoHeader: {"1"}, "X-Tenant": {"42"}},
+			body:    `{"name":"john","email":"john@example.com","password":"secret"}`,
+			expectedBody: `{"operationId":"addUser","query":{"dryRun":true,"limit":10},"header":{"X-Tenant":42},` +
+				`"body":{"email":"***","name":"john","password":"REDACTED"}}`,
+		},
+		"echo of invalid request": {
+			enabled: true,
+			method:  http.MethodPost,
+			query:   "dryRun=maybe",
+			header:  http.Header{DebugEchoHeader: {"1"}, "X-Tenant": {"acme"}},
+			body:    `{"email":"john@example.com"}`,
+			expectedBody: `{"operationId":"addUser","query":{"limit":10},"body":{"email":"***"},"errors":[` +
+				`"param X-Tenant: cannot convert acme to int64",` +
+				`"name in body is required",` +
+				`"param dryRun: unknown format maybe for type boolean"]}`,
+		},
+		"no header": {
+			enabled:      true,
+			method:       http.MethodPost,
+			body:         `{"name":"john"}`,
+			expectedBody: "handler",
+		},
+		"operation without extension": {
+			enabled:      true,
+			method:       http.MethodGet,
+			header:       http.Header{DebugEchoHeader: {"1"}},
+			expectedBody: "handler",
+		},
+		"disabled": {
+			method:       http.MethodPost,
+			header:       http.Header{DebugEchoHeader: {"1"}},
+			body:         `{"name":"john"}`,
+			expectedBody: "handler",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			basis := NewResolvingBasis(SpecAdapterName, doc)
+			h := SpecMatcherMiddleware(doc)(
+				basis.DebugEcho(tc.enabled, DebugEchoRedactFields("password"))(handler),
+			)
+
+			req := httptest.NewRequest(tc.method, "/users?"+tc.query, bytes.NewBufferString(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			for k, v := range tc.header {
+				req.Header.Set(k, v[0])
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tc.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestResolvingBasis_DebugEcho_invalidExtension(t *testing.T) {
+	doc := loadDocBytes([]byte(`
+swagger: "2.0"
+info:
+  title: Test
+  version: 1.0.0
+paths:
+  /users:
+    get:
+      operationId: listUsers
+      x-oas-debug-echo: "yes"
+      responses:
+        200:
+          description: OK
+`))
+
+	basis := NewResolvingBasis(SpecAdapterName, doc)
+	assert.Panics(t, func() {
+		basis.DebugEcho(true)
+	})
+}
@@ -0,0 +1,78 @@
+package oas
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+
+	"github.com/go-openapi/spec"
+)
+
+// EncodeQueryParams encodes the src to query values by the parameters spec.
+// This is the reverse of DecodeQueryParams: fields are mapped to parameters
+// by `oas` tags, and arrays are serialized by the parameter collectionFormat.
+// Nil pointers and nil slices are omitted.
+//
+// src is a struct or a pointer to struct.
+func EncodeQueryParams(params []spec.Parameter, src interface{}) (url.Values, error) {
+	return encodeParams(params, "query", src)
+}
+
+// encodeParams encodes the src to values of the parameters in the location.
+func encodeParams(params []spec.Parameter, in string, src interface{}) (url.Values, error) {
+	sv := reflect.ValueOf(src)
+	if sv.Kind() == reflect.Ptr {
+		sv = sv.Elem()
+	}
+	if sv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("src is not a struct or a pointer to struct")
+	}
+
+	fields := fieldMap(sv, false)
+
+	vals := make(url.Values)
+	for _, p := range params {
+		if p.In != in {
+			continue
+		}
+
+		f, ok := fields[p.Name]
+		if !ok {
+			continue
+		}
+
+		fv := sv.FieldByIndex(f.Index)
+		if (fv.Kind() == reflect.Ptr || fv.Kind() == reflect.Slice) && fv.IsNil() {
+			continue
+		}
+		if fv.Kind() == reflect.Ptr {
+			fv = fv.Elem()
+		}
+		if !fv.CanInterface() {
+			return nil, fmt.Errorf("field %s of type %s is not exported", f.Name, sv.Type().Name())
+		}
+
+		v := fv.Interface()
+		if fv.Kind() == reflect.Slice {
+			arr := make([]interface{}, fv.Len())
+			for i := range arr {
+				arr[i] = fv.Index(i).Interface()
+			}
+			v = arr
+		}
+
+		vals[p.Name] = formatParamValue(p, v)
+	}
+
+	return vals, nil
+}
+
+// EncodeFormBody encodes the src to application/x-www-form-urlencoded body
+// by the formData parameters spec, the same way EncodeQueryParams does.
+func EncodeFormBody(params []spec.Parameter, src interface{}) ([]byte, error) {
+	vals, err := encodeParams(params, "formData", src)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(vals.Encode()), nil
+}
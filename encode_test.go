@@ -0,0 +1,97 @@
+package oas
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/go-openapi/spec"
+)
+
+func TestEncodeQueryParams(t *testing.T) {
+	params := []spec.Parameter{
+		*spec.QueryParam("name").Typed("string", ""),
+		*spec.QueryParam("age").Typed("integer", "int32"),
+		*spec.QueryParam("nickname").Typed("string", ""),
+		*spec.QueryParam("tags").CollectionOf(spec.NewItems().Typed("string", ""), "pipes"),
+		*spec.QueryParam("ids").CollectionOf(spec.NewItems().Typed("integer", "int64"), "multi"),
+		*spec.HeaderParam("X-Request-ID").Typed("string", ""),
+	}
+
+	type member struct {
+		Name      string   `oas:"name"`
+		Age       int32    `oas:"age"`
+		Nickname  *string  `oas:"nickname"`
+		Tags      []string `oas:"tags"`
+		IDs       []int64  `oas:"ids"`
+		RequestID string   `oas:"X-Request-ID"`
+	}
+
+	m := member{
+		Name:      "John",
+		Age:       27,
+		Tags:      []string{"foo", "bar"},
+		IDs:       []int64{1, 2},
+		RequestID: "123",
+	}
+
+	vals, err := EncodeQueryParams(params, &m)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := url.Values{
+		"name": []string{"John"},
+		"age":  []string{"27"},
+		"tags": []string{"foo|bar"},
+		"ids":  []string{"1", "2"},
+	}
+	if !reflect.DeepEqual(expected, vals) {
+		t.Errorf("Expected values to be %v but got %v", expected, vals)
+	}
+
+	// Check the round trip.
+	var decoded member
+	if err := DecodeQueryParams(params, vals, &decoded); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	m.RequestID = ""
+	if !reflect.DeepEqual(m, decoded) {
+		t.Errorf("Expected decoded value to be %#v but got %#v", m, decoded)
+	}
+}
+
+func TestEncodeFormBody(t *testing.T) {
+	params := []spec.Parameter{
+		*spec.FormDataParam("name").Typed("string", ""),
+		*spec.FormDataParam("status").Typed("string", ""),
+		*spec.QueryParam("debug").Typed("boolean", ""),
+	}
+
+	type pet struct {
+		Name   string `oas:"name"`
+		Status string `oas:"status"`
+		Debug  bool   `oas:"debug"`
+	}
+
+	body, err := EncodeFormBody(params, pet{Name: "Kitty Cat", Status: "sold", Debug: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(body) != "name=Kitty+Cat&status=sold" {
+		t.Errorf("Expected body to be encoded form but got %s", body)
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var decoded pet
+	if err := DecodeFormParams(params, form, &decoded); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(pet{Name: "Kitty Cat", Status: "sold"}, decoded) {
+		t.Errorf("Expected decoded value to be symmetric but got %#v", decoded)
+	}
+}
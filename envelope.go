@@ -0,0 +1,120 @@
+package oas
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// EnvelopeExtension is the extension that makes successful responses of
+// the operation wrapped in an envelope by ResponseEnvelope, for consumers
+// that expect the legacy format:
+//
+//  {"data": <response>, "meta": <meta>}
+//
+// It is declared on the spec root for all operations, or on operations,
+// where false disables the envelope. The value is true, or an object that
+// renames the envelope fields:
+//
+//  x-oas-envelope:
+//    data: result
+//    meta: info
+//
+// The response schema declared in the spec describes the inner payload,
+// which is validated before it is wrapped.
+const EnvelopeExtension = "x-oas-envelope"
+
+// EnvelopeMetaFunc returns the meta of the envelope of the response, e.g.
+// the request id or pagination. If it returns nil, the meta field is
+// omitted.
+type EnvelopeMetaFunc func(req *http.Request, op *Operation, code int) interface{}
+
+// envelope are the field names of the envelope.
+type envelope struct {
+	data string
+	meta string
+}
+
+// parseEnvelope parses the value of EnvelopeExtension. It returns false if
+// the envelope is disabled.
+func parseEnvelope(ext interface{}) (envelope, bool, error) {
+	e := envelope{data: "data", meta: "meta"}
+
+	switch v := ext.(type) {
+	case bool:
+		return e, v, nil
+	case map[string]interface{}:
+		for name, dst := range map[string]*string{"data": &e.data, "meta": &e.meta} {
+			f, ok := v[name]
+			if !ok {
+				continue
+			}
+			s, _ := f.(string)
+			if s == "" {
+				return envelope{}, false, fmt.Errorf("%s must be a non-empty string", name)
+			}
+			*dst = s
+		}
+		if e.data == e.meta {
+			return envelope{}, false, fmt.Errorf("data and meta must be different fields")
+		}
+		return e, true, nil
+	default:
+		return envelope{}, false, fmt.Errorf("value must be a boolean or an object")
+	}
+}
+
+// ResponseEnvelope returns a response hook that wraps successful responses
+// of operations with EnvelopeExtension in the envelope, with the meta
+// returned by the meta function, which may be nil. Register it on the basis:
+//
+//  basis := oas.NewResolvingBasis("gorilla", doc,
+//      oas.BasisResponseHook(oas.ResponseEnvelope(doc, nil)),
+//  )
+//
+// so ResponseBodyValidator validates responses before they are wrapped.
+// It panics if any EnvelopeExtension in the spec is invalid.
+func ResponseEnvelope(doc *Document, meta EnvelopeMetaFunc) ResponseHook {
+	global, enabled := envelope{}, false
+	if ext, ok := doc.Spec().Extensions[EnvelopeExtension]; ok {
+		var err error
+		global, enabled, err = parseEnvelope(ext)
+		if err != nil {
+			panic(fmt.Sprintf("oas: spec: invalid %s: %s", EnvelopeExtension, err))
+		}
+	}
+
+	operations := make(map[string]envelope)
+	for _, ops := range doc.Analyzer.Operations() {
+		for _, op := range ops {
+			e, ok := global, enabled
+			if ext, found := op.Extensions[EnvelopeExtension]; found {
+				var err error
+				e, ok, err = parseEnvelope(ext)
+				if err != nil {
+					panic(fmt.Sprintf("oas: operation %s: invalid %s: %s", op.ID, EnvelopeExtension, err))
+				}
+			}
+			if ok {
+				operations[op.ID] = e
+			}
+		}
+	}
+
+	return func(req *http.Request, op *Operation, code int, header http.Header, body interface{}) (interface{}, error) {
+		if op == nil || code < 200 || code > 299 {
+			return body, nil
+		}
+		e, ok := operations[op.ID]
+		if !ok {
+			return body, nil
+		}
+
+		wrapped := map[string]interface{}{e.data: body}
+		if meta != nil {
+			if m := meta(req, op, code); m != nil {
+				wrapped[e.meta] = m
+			}
+		}
+		return wrapped, nil
+	}
+}
@@ -0,0 +1,98 @@
+package oas
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseEnvelope(t *testing.T) {
+	doc := loadDocBytes([]byte(`
+swagger: "2.0"
+info:
+  title: Test
+  version: 1.0.0
+x-oas-envelope: true
+paths:
+  /greetings:
+    get:
+      operationId: getGreeting
+      produces:
+      - application/json
+      responses:
+        200:
+          description: OK
+          schema:
+            type: object
+            properties:
+              message:
+                type: string
+  /legacy/greetings:
+    get:
+      operationId: getLegacyGreeting
+      produces:
+      - application/json
+      x-oas-envelope:
+        data: result
+        meta: info
+      responses:
+        200:
+          description: OK
+          schema:
+            type: object
+            properties:
+              message:
+                type: string
+  /raw/greetings:
+    get:
+      operationId: getRawGreeting
+      produces:
+      - application/json
+      x-oas-envelope: false
+      responses:
+        200:
+          description: OK
+          schema:
+            type: object
+            properties:
+              message:
+                type: string
+`))
+
+	meta := func(req *http.Request, op *Operation, code int) interface{} {
+		return map[string]interface{}{"requestId": req.Header.Get("X-Request-Id")}
+	}
+
+	basis := NewResolvingBasis(SpecAdapterName, doc,
+		BasisStrict(false),
+		BasisResponseHook(ResponseEnvelope(doc, meta)),
+	)
+	h := SpecMatcherMiddleware(doc)(
+		basis.ResponseBodyValidator()(
+			http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{"message":"hello"}`)) // nolint
+			}),
+		),
+	)
+
+	testCases := map[string]string{
+		"/greetings":        `{"data":{"message":"hello"},"meta":{"requestId":"r-1"}}`,
+		"/legacy/greetings": `{"result":{"message":"hello"},"info":{"requestId":"r-1"}}`,
+		"/raw/greetings":    `{"message":"hello"}`,
+	}
+
+	for path, expected := range testCases {
+		t.Run(path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			req.Header.Set("X-Request-Id", "r-1")
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.JSONEq(t, expected, w.Body.String())
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package oas
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"strings"
 )
 
@@ -44,3 +47,53 @@ func (me multiError) Message() string {
 func (me multiError) Errors() []error {
 	return me.errs
 }
+
+// jsonPositionMaxBody is the maximum size of a body for which JSONError
+// reports the line and the column.
+const jsonPositionMaxBody = 64 << 10
+
+// JSONError describes malformed JSON in a request or a response body.
+type JSONError struct {
+	msg string
+
+	// Offset is the number of bytes of the body read successfully before
+	// the error occurred.
+	Offset int64
+
+	// Line and Column point to the byte at which the error occurred,
+	// both starting at 1. They are zero for bodies larger than 64 KiB.
+	Line   int
+	Column int
+
+	// Err is the underlying decoding error.
+	Err error
+}
+
+// newJSONError returns a new JSONError for the error occurred while decoding
+// the data.
+func newJSONError(msg string, err error, data []byte) *JSONError {
+	e := &JSONError{msg: msg, Err: err, Offset: int64(len(data))}
+	if se, ok := err.(*json.SyntaxError); ok {
+		e.Offset = se.Offset
+	}
+
+	if len(data) <= jsonPositionMaxBody && e.Offset > 0 {
+		line := data[:e.Offset-1]
+		e.Line = bytes.Count(line, []byte("\n")) + 1
+		e.Column = len(line) - bytes.LastIndexByte(line, '\n')
+	}
+	return e
+}
+
+// Error implements error.
+func (e *JSONError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("%s: %s at offset %d", e.msg, e.Err, e.Offset)
+	}
+	return fmt.Sprintf("%s: %s at line %d, column %d (offset %d)", e.msg, e.Err, e.Line, e.Column, e.Offset)
+}
+
+// Unwrap returns the underlying decoding error.
+func (e *JSONError) Unwrap() error {
+	return e.Err
+}
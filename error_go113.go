@@ -0,0 +1,38 @@
+//go:build go1.13
+// +build go1.13
+
+package oas
+
+import "errors"
+
+// Is reports whether any of the wrapped errors matches the target, so on
+// Go 1.13+ errors.Is looks into them, e.g. into the cause of a validation
+// problem:
+//
+//  if errors.Is(problem.Cause(), validate.ErrRequired) {
+//      // ...
+//  }
+func (me multiError) Is(target error) bool {
+	for _, err := range me.errs {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first of the wrapped errors that matches the target, so on
+// Go 1.13+ errors.As looks into them:
+//
+//  var re validate.RangeError
+//  if errors.As(problem.Cause(), &re) {
+//      // ...
+//  }
+func (me multiError) As(target interface{}) bool {
+	for _, err := range me.errs {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
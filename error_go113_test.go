@@ -0,0 +1,48 @@
+//go:build go1.13
+// +build go1.13
+
+package oas
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hypnoglow/oas2/validate"
+)
+
+func TestProblemCause_errorsAs(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+
+	var problems []Problem
+	basis := NewResolvingBasis(SpecAdapterName, doc, BasisStrict(false))
+	h := SpecMatcherMiddleware(doc)(
+		basis.QueryValidator(WithProblemHandlerFunc(func(p Problem) {
+			problems = append(problems, p)
+		}))(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {})),
+	)
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v2/user/login?username=john", nil))
+	require.Len(t, problems, 1)
+
+	cause := problems[0].Cause()
+	assert.True(t, errors.Is(cause, validate.ErrRequired))
+	assert.False(t, errors.Is(cause, validate.ErrType))
+
+	var re validate.RequiredError
+	require.True(t, errors.As(cause, &re))
+	assert.Equal(t, "password", re.Field())
+}
+
+func TestJSONError_unwrap(t *testing.T) {
+	inner := errors.New("unexpected EOF")
+	err := &OperationError{OperationID: "addPet", Err: newJSONError("body contains invalid json", inner, nil)}
+
+	assert.True(t, errors.Is(err, inner))
+
+	var je *JSONError
+	assert.True(t, errors.As(err, &je))
+}
@@ -0,0 +1,53 @@
+package oas
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewJSONError(t *testing.T) {
+	testCases := map[string]struct {
+		data           string
+		expectedOffset int64
+		expectedLine   int
+		expectedColumn int
+		expectedError  string
+	}{
+		"invalid character": {
+			data:           "{\n  \"name\": x}",
+			expectedOffset: 13,
+			expectedLine:   2,
+			expectedColumn: 11,
+			expectedError:  "body contains invalid json: invalid character 'x' looking for beginning of value at line 2, column 11 (offset 13)",
+		},
+		"truncated": {
+			data:           `{"name":"johndoe`,
+			expectedOffset: 16,
+			expectedLine:   1,
+			expectedColumn: 16,
+			expectedError:  "body contains invalid json: unexpected EOF at line 1, column 16 (offset 16)",
+		},
+		"large body": {
+			data:           `["` + strings.Repeat("x", jsonPositionMaxBody),
+			expectedOffset: jsonPositionMaxBody + 2,
+			expectedError:  "body contains invalid json: unexpected EOF at offset 65538",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var v interface{}
+			err := json.NewDecoder(strings.NewReader(tc.data)).Decode(&v)
+			assert.Error(t, err)
+
+			e := newJSONError("body contains invalid json", err, []byte(tc.data))
+			assert.Equal(t, tc.expectedOffset, e.Offset)
+			assert.Equal(t, tc.expectedLine, e.Line)
+			assert.Equal(t, tc.expectedColumn, e.Column)
+			assert.Equal(t, tc.expectedError, e.Error())
+		})
+	}
+}
@@ -0,0 +1,312 @@
+package oas
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Event is a package lifecycle event. Use a type switch to handle events
+// of specific types:
+//
+//  oas.Subscribe(oas.SubscriberFunc(func(e oas.Event) {
+//      switch e := e.(type) {
+//      case oas.ValidationFailed:
+//          validationFailures.WithLabelValues(e.OperationID, e.Check).Inc()
+//      case oas.HandlerPanicked:
+//          alert(e)
+//      }
+//  }))
+type Event interface {
+	// EventName returns the event name, e.g. "SpecLoaded".
+	EventName() string
+}
+
+// SpecLoaded is published when a spec is loaded by LoadFile.
+type SpecLoaded struct {
+	Path     string
+	Document *Document
+}
+
+// SpecReloaded is published when the handler of ReloadableHandler is
+// replaced by Reload.
+type SpecReloaded struct{}
+
+// RouteRegistered is published for every operation handler when routing is
+// built by the OperationRouter returned from ResolvingBasis.OperationRouter.
+type RouteRegistered struct {
+	OperationID string
+	Method      string
+	Path        string
+}
+
+// RouteConflictDetected is published for every conflict of the document
+// routes when routing is built by the OperationRouter returned from
+// ResolvingBasis.OperationRouter. See Document.RouteConflicts.
+type RouteConflictDetected struct {
+	Conflict RouteConflict
+}
+
+// ValidationFailed is published when a validator check fails.
+type ValidationFailed struct {
+	// OperationID is the id of the operation of the request.
+	OperationID string
+
+	// Check is the name of the failed check, e.g. CheckQuery.
+	Check string
+
+	// Err is the check error.
+	Err error
+}
+
+// HandlerPanicked is published by PanicEvents middleware when the
+// downstream handler panics.
+type HandlerPanicked struct {
+	// OperationID is the id of the operation of the request, if the
+	// request has operation context.
+	OperationID string
+
+	// Value is the value passed to panic.
+	Value interface{}
+}
+
+// RequestShed is published by LoadShedder when a request is shed.
+type RequestShed struct {
+	// OperationID is the id of the operation of the request.
+	OperationID string
+
+	// Priority is the priority of the operation, e.g. PriorityLow.
+	Priority string
+
+	// Depth is the depth of the validation queue at the moment.
+	Depth int
+}
+
+// BytesTransferred is published by ByteAccounting middleware when a
+// request is served.
+type BytesTransferred struct {
+	// OperationID is the id of the operation of the request.
+	OperationID string
+
+	// Principal is the id of the principal of the request, if any.
+	Principal string
+
+	// Status is the status of the response.
+	Status int
+
+	// RequestBytes is the number of bytes read from the request body.
+	RequestBytes int64
+
+	// ResponseBytes is the number of bytes written to the response body.
+	ResponseBytes int64
+}
+
+// EventName implements Event.
+func (SpecLoaded) EventName() string { return "SpecLoaded" }
+
+// EventName implements Event.
+func (SpecReloaded) EventName() string { return "SpecReloaded" }
+
+// EventName implements Event.
+func (RouteRegistered) EventName() string { return "RouteRegistered" }
+
+// EventName implements Event.
+func (RouteConflictDetected) EventName() string { return "RouteConflictDetected" }
+
+// EventName implements Event.
+func (ValidationFailed) EventName() string { return "ValidationFailed" }
+
+// EventName implements Event.
+func (HandlerPanicked) EventName() string { return "HandlerPanicked" }
+
+// EventName implements Event.
+func (RequestShed) EventName() string { return "RequestShed" }
+
+// EventName implements Event.
+func (BytesTransferred) EventName() string { return "BytesTransferred" }
+
+// Subscriber handles published events. Events are delivered synchronously,
+// so subscribers should not block.
+type Subscriber interface {
+	HandleEvent(e Event)
+}
+
+// SubscriberFunc is a function that implements Subscriber.
+type SubscriberFunc func(e Event)
+
+// HandleEvent implements Subscriber.
+func (f SubscriberFunc) HandleEvent(e Event) {
+	f(e)
+}
+
+// ChanSubscriber returns a Subscriber that sends events to the channel.
+// Events are dropped while the channel is full, so publishers never block.
+func ChanSubscriber(ch chan<- Event) Subscriber {
+	return SubscriberFunc(func(e Event) {
+		select {
+		case ch <- e:
+		default:
+		}
+	})
+}
+
+var (
+	subscribersMx sync.RWMutex
+	subscribers   = make(map[int]Subscriber)
+	subscriberSeq int
+)
+
+// Subscribe subscribes s to the events published by the package, so that
+// integrations, e.g. metrics, audit or alerting, do not need to wrap every
+// component. It returns the function that unsubscribes s. If s is nil,
+// it panics.
+func Subscribe(s Subscriber) (unsubscribe func()) {
+	if s == nil {
+		panic("oas: Subscribe subscriber is nil")
+	}
+
+	subscribersMx.Lock()
+	defer subscribersMx.Unlock()
+
+	subscriberSeq++
+	id := subscriberSeq
+	subscribers[id] = s
+
+	return func() {
+		subscribersMx.Lock()
+		defer subscribersMx.Unlock()
+		delete(subscribers, id)
+	}
+}
+
+// hasSubscribers checks if there are any subscribers, so that publishers
+// can skip building events.
+func hasSubscribers() bool {
+	subscribersMx.RLock()
+	defer subscribersMx.RUnlock()
+	return len(subscribers) > 0
+}
+
+// publish delivers the event to the subscribers in order of subscription.
+func publish(e Event) {
+	subscribersMx.RLock()
+	ids := make([]int, 0, len(subscribers))
+	for id := range subscribers {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	subs := make([]Subscriber, len(ids))
+	for i, id := range ids {
+		subs[i] = subscribers[id]
+	}
+	subscribersMx.RUnlock()
+
+	for _, s := range subs {
+		s.HandleEvent(e)
+	}
+}
+
+// PanicEvents returns a middleware that publishes HandlerPanicked when the
+// downstream handler panics. The panic is propagated, so it is recovered as
+// before, e.g. by http.Server.
+func PanicEvents() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			defer func() {
+				if v := recover(); v != nil {
+					e := HandlerPanicked{Value: v}
+					if oi, ok := getOperationInfo(req); ok {
+						e.OperationID = oi.operation.ID
+					}
+					publish(e)
+					panic(v)
+				}
+			}()
+
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// eventsRouter is an OperationRouter that publishes RouteRegistered events
+// when routing is built.
+type eventsRouter struct {
+	router   OperationRouter
+	doc      *Document
+	handlers map[string]http.Handler
+}
+
+// WithDocument implements OperationRouter.
+func (r *eventsRouter) WithDocument(doc *Document) OperationRouter {
+	r.doc = doc
+	r.router.WithDocument(doc)
+	return r
+}
+
+// WithMiddleware implements OperationRouter.
+func (r *eventsRouter) WithMiddleware(mws ...Middleware) OperationRouter {
+	r.router.WithMiddleware(mws...)
+	return r
+}
+
+// WithOperationHandlers implements OperationRouter.
+func (r *eventsRouter) WithOperationHandlers(handlers map[string]http.Handler) OperationRouter {
+	r.handlers = handlers
+	r.router.WithOperationHandlers(handlers)
+	return r
+}
+
+// WithMissingOperationHandlerFunc implements OperationRouter.
+func (r *eventsRouter) WithMissingOperationHandlerFunc(fn func(string)) OperationRouter {
+	r.router.WithMissingOperationHandlerFunc(fn)
+	return r
+}
+
+// Build implements OperationRouter.
+func (r *eventsRouter) Build() error {
+	if err := r.router.Build(); err != nil {
+		return err
+	}
+	if r.doc == nil {
+		return nil
+	}
+
+	var events []RouteRegistered
+	for method, paths := range r.doc.Analyzer.Operations() {
+		for path, op := range paths {
+			if _, ok := r.handlers[op.ID]; !ok {
+				continue
+			}
+			events = append(events, RouteRegistered{
+				OperationID: op.ID,
+				Method:      strings.ToUpper(method),
+				Path:        joinBasePath(r.doc.BasePath(), path),
+			})
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].OperationID < events[j].OperationID
+	})
+	for _, e := range events {
+		publish(e)
+	}
+	for _, c := range r.doc.RouteConflicts() {
+		publish(RouteConflictDetected{Conflict: c})
+	}
+	return nil
+}
+
+// publishValidationFailed publishes ValidationFailed for the failed check
+// of the request.
+func publishValidationFailed(req *http.Request, check string, err error) {
+	if !hasSubscribers() {
+		return
+	}
+
+	e := ValidationFailed{Check: check, Err: err}
+	if oi, ok := getOperationInfo(req); ok {
+		e.OperationID = oi.operation.ID
+	}
+	publish(e)
+}
@@ -0,0 +1,78 @@
+package oas
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscribe(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+
+	ch := make(chan Event, 10)
+	unsubscribe := Subscribe(ChanSubscriber(ch))
+	defer unsubscribe()
+
+	t.Run("spec loaded", func(t *testing.T) {
+		loaded, err := LoadFile("testdata/petstore_1.yml")
+		assert.NoError(t, err)
+		assert.Equal(t, SpecLoaded{Path: "testdata/petstore_1.yml", Document: loaded}, <-ch)
+	})
+
+	t.Run("spec reloaded", func(t *testing.T) {
+		NewReloadableHandler(http.NotFoundHandler()).Reload(http.NotFoundHandler(), nil)
+		assert.Equal(t, SpecReloaded{}, <-ch)
+	})
+
+	t.Run("validation failed", func(t *testing.T) {
+		b := NewResolvingBasis(SpecAdapterName, doc)
+		h := b.OperationContext()(b.QueryValidator()(http.HandlerFunc(handleUserLogin)))
+
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v2/user/login?username=johndoe", nil))
+
+		e, ok := (<-ch).(ValidationFailed)
+		assert.True(t, ok)
+		assert.Equal(t, "loginUser", e.OperationID)
+		assert.Equal(t, CheckQuery, e.Check)
+		assert.Error(t, e.Err)
+	})
+
+	t.Run("handler panicked", func(t *testing.T) {
+		h := PanicEvents()(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			panic("boom")
+		}))
+
+		assert.PanicsWithValue(t, "boom", func() {
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		})
+		assert.Equal(t, HandlerPanicked{Value: "boom"}, <-ch)
+	})
+
+	t.Run("route registered", func(t *testing.T) {
+		r := &eventsRouter{router: &fakeOperationRouter{}}
+		err := r.WithDocument(doc).
+			WithOperationHandlers(map[string]http.Handler{"loginUser": http.HandlerFunc(handleUserLogin)}).
+			Build()
+
+		assert.NoError(t, err)
+		assert.Equal(t, RouteRegistered{OperationID: "loginUser", Method: http.MethodGet, Path: "/v2/user/login"}, <-ch)
+	})
+
+	t.Run("unsubscribed", func(t *testing.T) {
+		unsubscribe()
+		NewReloadableHandler(http.NotFoundHandler()).Reload(http.NotFoundHandler(), nil)
+		assert.Len(t, ch, 0)
+	})
+}
+
+type fakeOperationRouter struct{}
+
+func (r *fakeOperationRouter) WithDocument(doc *Document) OperationRouter                   { return r }
+func (r *fakeOperationRouter) WithMiddleware(mws ...Middleware) OperationRouter             { return r }
+func (r *fakeOperationRouter) WithMissingOperationHandlerFunc(func(string)) OperationRouter { return r }
+func (r *fakeOperationRouter) WithOperationHandlers(map[string]http.Handler) OperationRouter {
+	return r
+}
+func (r *fakeOperationRouter) Build() error { return nil }
@@ -0,0 +1,144 @@
+package oas
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/hypnoglow/oas2/validate"
+)
+
+// ExplainHeader is the request header that asks ValidationExplainer for
+// the explanation of the failed validation instead of the response.
+const ExplainHeader = "X-OAS-Explain"
+
+// ValidationExplanation is the machine-readable explanation of the failed
+// validation of a request, responded by ValidationExplainer.
+type ValidationExplanation struct {
+	// OperationID is the id of the operation of the request, if known.
+	OperationID string `json:"operationId,omitempty"`
+
+	// Status is the status code the request was responded with.
+	Status int `json:"status"`
+
+	// Checks are the checks that ran, in order.
+	Checks []ExplainedCheck `json:"checks"`
+}
+
+// ExplainedCheck describes a check that ran on the request.
+type ExplainedCheck struct {
+	// Name is the check name, e.g. CheckQuery.
+	Name string `json:"name"`
+
+	// Passed reports whether the check passed.
+	Passed bool `json:"passed"`
+
+	// Errors are the errors of the check, if it did not pass.
+	Errors []ExplainedError `json:"errors,omitempty"`
+}
+
+// ExplainedError describes a single error of a check.
+type ExplainedError struct {
+	Message string `json:"message"`
+
+	// Kind is the kind of the error: "required", "type", "range" or
+	// "duplicate" for the errors of the corresponding validate types, or
+	// empty.
+	Kind string `json:"kind,omitempty"`
+
+	// Field is the name of the parameter or property, if known.
+	Field string `json:"field,omitempty"`
+
+	// Pointer is the JSON Pointer to the value in the body, if known.
+	Pointer string `json:"pointer,omitempty"`
+
+	// Hint tells how to fix the value, if known.
+	Hint string `json:"hint,omitempty"`
+}
+
+// ValidationExplainer returns a middleware that responds to requests that
+// carry ExplainHeader and fail validation with ValidationExplanation, so
+// client developers can see which checks ran and why they failed without
+// reading server logs. Mount it before the validators. The response is
+// held until the request is processed, and is sent as is if all the checks
+// passed.
+//
+// The explanation exposes the internals of the service, so it is gated by
+// enabled, which should be set by the configuration. If it is false, the
+// middleware passes all requests through.
+func ValidationExplainer(enabled bool) Middleware {
+	if !enabled {
+		return passThrough
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.Header.Get(ExplainHeader) == "" {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			report, ok := GetValidationReport(req)
+			if !ok {
+				report = &ValidationReport{}
+				req = req.WithContext(context.WithValue(req.Context(), contextKeyValidationReport{}, report))
+			}
+
+			hw := &heldResponseWriter{header: w.Header()}
+			next.ServeHTTP(hw, req)
+
+			if report.Passed() {
+				w.WriteHeader(hw.status())
+				w.Write(hw.buf.Bytes()) // nolint: errcheck
+				return
+			}
+
+			e := ValidationExplanation{Status: hw.status()}
+			if oi, ok := getOperationInfo(req); ok {
+				e.OperationID = oi.operation.ID
+			}
+			for _, c := range report.Checks() {
+				e.Checks = append(e.Checks, explainCheck(c))
+			}
+
+			w.Header().Del("Content-Length")
+			writeJSON(w, e.Status, e)
+		})
+	}
+}
+
+// explainCheck returns the explanation of the check.
+func explainCheck(c ValidationCheck) ExplainedCheck {
+	ec := ExplainedCheck{Name: c.Name, Passed: c.Passed}
+	if c.Err == nil {
+		return ec
+	}
+
+	errs := []error{c.Err}
+	if me, ok := c.Err.(MultiError); ok {
+		errs = me.Errors()
+	}
+	for _, err := range errs {
+		ee := ExplainedError{Message: err.Error()}
+		switch err.(type) {
+		case validate.RequiredError:
+			ee.Kind = "required"
+		case validate.TypeError:
+			ee.Kind = "type"
+		case validate.RangeError:
+			ee.Kind = "range"
+		case validate.DuplicateParamError:
+			ee.Kind = "duplicate"
+		}
+		if ve, ok := err.(validate.ValidationError); ok {
+			ee.Field = ve.Field()
+		}
+		if pe, ok := err.(validate.PointerError); ok {
+			ee.Pointer = pe.Pointer()
+		}
+		if he, ok := err.(validate.HintError); ok {
+			ee.Hint = he.Hint()
+		}
+		ec.Errors = append(ec.Errors, ee)
+	}
+	return ec
+}
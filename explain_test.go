@@ -0,0 +1,76 @@
+package oas
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidationExplainer(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+	basis := NewResolvingBasis(SpecAdapterName, doc)
+
+	testCases := map[string]struct {
+		enabled        bool
+		explain        bool
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		"explained": {
+			enabled:        true,
+			explain:        true,
+			body:           `{"age":"old"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody: `{"operationId":"addPet","status":400,"checks":[` +
+				`{"name":"query","passed":true},` +
+				`{"name":"request content type","passed":true},` +
+				`{"name":"request body","passed":false,"errors":[` +
+				`{"message":"age in body must be of type integer: \"string\"","kind":"type","field":"age","pointer":"/age"},` +
+				`{"message":"name in body is required","kind":"required","field":"name","pointer":"/name"}]}]}`,
+		},
+		"valid request": {
+			enabled:        true,
+			explain:        true,
+			body:           `{"name":"johndoe","age":7}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   "pet name: johndoe",
+		},
+		"no header": {
+			enabled:        true,
+			body:           `{"age":"old"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `request body does not match the schema: age in body must be of type integer: "string", name in body is required`,
+		},
+		"disabled": {
+			explain:        true,
+			body:           `{"age":"old"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `request body does not match the schema: age in body must be of type integer: "string", name in body is required`,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			h := SpecMatcherMiddleware(doc)(
+				ValidationExplainer(tc.enabled)(
+					basis.RequestValidator()(http.HandlerFunc(handleAddPet)),
+				),
+			)
+
+			req := httptest.NewRequest(http.MethodPost, "/v2/pet", bytes.NewBufferString(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			if tc.explain {
+				req.Header.Set(ExplainHeader, "1")
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, tc.expectedBody, w.Body.String())
+		})
+	}
+}
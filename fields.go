@@ -0,0 +1,268 @@
+package oas
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/go-openapi/spec"
+
+	"github.com/hypnoglow/oas2/validate"
+)
+
+// FieldsParam is the name of the query parameter that selects fields of
+// partial responses. If the operation declares this parameter, WriteResponse
+// filters successful responses to the requested fields:
+//
+//  GET /pets?fields=id,name,owner(name,email),tags/name
+//
+// Fields are separated by commas, "a/b" selects the field b of the object
+// a, and "a(b,c)" selects the fields b and c of the object a. Selections
+// apply to every item of arrays. Fields are validated against the response
+// schema, so clients cannot request undeclared fields. QueryValidator
+// rejects invalid selections as invalid query params, before the handler
+// runs.
+const FieldsParam = "fields"
+
+// fieldSelection is a selection of object fields. A field mapped to nil is
+// selected as a whole.
+type fieldSelection map[string]fieldSelection
+
+// child returns the selection of the field sub-fields, adding the field to
+// the selection. If the field is already selected as a whole, the returned
+// selection is not added.
+func (sel fieldSelection) child(name string) fieldSelection {
+	c, ok := sel[name]
+	if ok && c == nil {
+		return fieldSelection{}
+	}
+	if !ok {
+		c = fieldSelection{}
+		sel[name] = c
+	}
+	return c
+}
+
+// parseFields parses the value of FieldsParam.
+func parseFields(s string) (fieldSelection, error) {
+	p := &fieldsParser{s: s}
+	sel := fieldSelection{}
+	if err := p.list(sel); err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.s) {
+		return nil, fmt.Errorf("unexpected %q at offset %d", p.s[p.pos], p.pos)
+	}
+	return sel, nil
+}
+
+type fieldsParser struct {
+	s   string
+	pos int
+}
+
+// list parses comma separated selections.
+func (p *fieldsParser) list(sel fieldSelection) error {
+	for {
+		if err := p.selection(sel); err != nil {
+			return err
+		}
+		if p.pos == len(p.s) || p.s[p.pos] != ',' {
+			return nil
+		}
+		p.pos++
+	}
+}
+
+// selection parses a field with an optional sub-selection.
+func (p *fieldsParser) selection(sel fieldSelection) error {
+	start := p.pos
+	for p.pos < len(p.s) && !strings.ContainsRune(",/()", rune(p.s[p.pos])) {
+		p.pos++
+	}
+	name := strings.TrimSpace(p.s[start:p.pos])
+	if name == "" {
+		return fmt.Errorf("field name expected at offset %d", start)
+	}
+
+	if p.pos == len(p.s) {
+		sel[name] = nil
+		return nil
+	}
+
+	switch p.s[p.pos] {
+	case '/':
+		p.pos++
+		return p.selection(sel.child(name))
+	case '(':
+		p.pos++
+		if err := p.list(sel.child(name)); err != nil {
+			return err
+		}
+		if p.pos == len(p.s) || p.s[p.pos] != ')' {
+			return fmt.Errorf("closing parenthesis expected at offset %d", p.pos)
+		}
+		p.pos++
+	default:
+		sel[name] = nil
+	}
+	return nil
+}
+
+// validate checks that the selected fields are declared in the schema.
+// Fields of objects without declared properties are not checked.
+func (sel fieldSelection) validate(s *spec.Schema, prefix string) error {
+	s = itemsSchema(s)
+	if s == nil || (len(s.Properties) == 0 && len(s.AllOf) == 0) {
+		return nil
+	}
+
+	for name, sub := range sel {
+		prop, ok := schemaProperty(s, name)
+		if !ok {
+			return fmt.Errorf("unknown field %s%s", prefix, name)
+		}
+		if sub != nil {
+			if err := sub.validate(prop, prefix+name+"/"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// apply filters the decoded JSON value to the selected fields.
+func (sel fieldSelection) apply(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		filtered := make(map[string]interface{}, len(sel))
+		for name, sub := range sel {
+			fv, ok := v[name]
+			if !ok {
+				continue
+			}
+			if sub != nil {
+				fv = sub.apply(fv)
+			}
+			filtered[name] = fv
+		}
+		return filtered
+	case []interface{}:
+		for i, item := range v {
+			v[i] = sel.apply(item)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// itemsSchema returns the schema of array items for array schemas, possibly
+// nested, and the schema itself otherwise.
+func itemsSchema(s *spec.Schema) *spec.Schema {
+	for s != nil && s.Items != nil && s.Items.Schema != nil {
+		s = s.Items.Schema
+	}
+	return s
+}
+
+// schemaProperty returns the schema of the object property, looking into
+// allOf schemas too.
+func schemaProperty(s *spec.Schema, name string) (*spec.Schema, bool) {
+	if prop, ok := s.Properties[name]; ok {
+		return &prop, true
+	}
+	for i := range s.AllOf {
+		if prop, ok := schemaProperty(&s.AllOf[i], name); ok {
+			return prop, true
+		}
+	}
+	return nil, false
+}
+
+// requestedFields returns the fields selected by FieldsParam of the request
+// and validated against the schema, or nil if the params do not declare
+// the parameter or the parameter is empty.
+func requestedFields(req *http.Request, params []spec.Parameter, s *spec.Schema) (fieldSelection, error) {
+	declared := false
+	for _, p := range params {
+		if p.In == "query" && p.Name == FieldsParam {
+			declared = true
+			break
+		}
+	}
+	if !declared {
+		return nil, nil
+	}
+
+	value := req.URL.Query().Get(FieldsParam)
+	if value == "" {
+		return nil, nil
+	}
+
+	sel, err := parseFields(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s parameter: %s", FieldsParam, err)
+	}
+	if err := sel.validate(s, ""); err != nil {
+		return nil, fmt.Errorf("invalid %s parameter: %s", FieldsParam, err)
+	}
+	return sel, nil
+}
+
+// fieldsErrors checks FieldsParam of the request against the schemas of
+// the successful responses of the operation, so that QueryValidator rejects
+// invalid selections before the handler runs. Without the operation in the
+// request context, only the syntax of the selection is checked.
+func fieldsErrors(req *http.Request, params []spec.Parameter) []error {
+	schemas := []*spec.Schema{nil}
+	if oi, ok := getOperationInfo(req); ok && oi.operation.Responses != nil {
+		schemas = successSchemas(oi.operation.Responses)
+	}
+
+	for _, s := range schemas {
+		if _, err := requestedFields(req, params, s); err != nil {
+			return []error{validate.ValidationErrorf(FieldsParam, req.URL.Query().Get(FieldsParam), "%s", err)}
+		}
+	}
+	return nil
+}
+
+// successSchemas returns the schemas of the successful responses, or the
+// schema of the default response if no successful response is declared.
+func successSchemas(responses *spec.Responses) []*spec.Schema {
+	var codes []int
+	for code := range responses.StatusCodeResponses {
+		if code >= 200 && code < 300 {
+			codes = append(codes, code)
+		}
+	}
+	if len(codes) == 0 {
+		return []*spec.Schema{responseSchema(responses, 0)}
+	}
+
+	sort.Ints(codes)
+	schemas := make([]*spec.Schema, len(codes))
+	for i, code := range codes {
+		schemas[i] = responses.StatusCodeResponses[code].Schema
+	}
+	return schemas
+}
+
+// filterResponse filters PII, if pii is true, and the selected fields, if
+// fields is not nil, out of the JSON response by the schema.
+func filterResponse(data []byte, s *spec.Schema, pii bool, fields fieldSelection) ([]byte, error) {
+	var v interface{}
+	if err := decodeJSON(bytes.NewReader(data), &v, true); err != nil {
+		return nil, err
+	}
+	if pii {
+		filterPII(s, v)
+	}
+	if fields != nil {
+		v = fields.apply(v)
+	}
+	return currentJSONAPI().Marshal(v)
+}
@@ -0,0 +1,199 @@
+package oas
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFields(t *testing.T) {
+	testCases := map[string]struct {
+		value       string
+		expected    fieldSelection
+		expectedErr string
+	}{
+		"flat": {
+			value:    "id,name",
+			expected: fieldSelection{"id": nil, "name": nil},
+		},
+		"nested": {
+			value: "id,owner(name,address/city),tags/name",
+			expected: fieldSelection{
+				"id":    nil,
+				"owner": {"name": nil, "address": {"city": nil}},
+				"tags":  {"name": nil},
+			},
+		},
+		"whole field wins": {
+			value:    "owner,owner/name",
+			expected: fieldSelection{"owner": nil},
+		},
+		"empty name": {
+			value:       "id,,name",
+			expectedErr: "field name expected at offset 3",
+		},
+		"unclosed parenthesis": {
+			value:       "owner(name",
+			expectedErr: "closing parenthesis expected at offset 10",
+		},
+		"unexpected parenthesis": {
+			value:       "id)",
+			expectedErr: `unexpected ')' at offset 2`,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			sel, err := parseFields(tc.value)
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, sel)
+		})
+	}
+}
+
+func TestWriteResponse_fields(t *testing.T) {
+	doc := loadDocBytes([]byte(`
+swagger: "2.0"
+info:
+  title: Test
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      produces:
+      - application/json
+      parameters:
+      - name: fields
+        in: query
+        type: string
+      responses:
+        200:
+          description: OK
+          schema:
+            type: array
+            items:
+              $ref: "#/definitions/Animal"
+        default:
+          description: Error
+          schema:
+            type: object
+            properties:
+              message:
+                type: string
+              code:
+                type: integer
+definitions:
+  Animal:
+    type: object
+    properties:
+      id:
+        type: integer
+      name:
+        type: string
+      owner:
+        type: object
+        properties:
+          name:
+            type: string
+          email:
+            type: string
+`))
+
+	pets := []map[string]interface{}{
+		{"id": 1, "name": "Rex", "owner": map[string]string{"name": "John", "email": "john@example.com"}},
+		{"id": 2, "name": "Kitty"},
+	}
+
+	serve := func(fields string, code int, v interface{}) (*httptest.ResponseRecorder, error) {
+		req := httptest.NewRequest(http.MethodGet, "/pets?fields="+url.QueryEscape(fields), nil)
+		ctx, _ := WithOperation(req.Context(), doc, "listPets")
+		w := httptest.NewRecorder()
+		err := WriteResponse(w, req.WithContext(ctx), code, v)
+		return w, err
+	}
+
+	t.Run("selects fields", func(t *testing.T) {
+		w, err := serve("id,owner/name", http.StatusOK, pets)
+		assert.NoError(t, err)
+		assert.Equal(t, `[{"id":1,"owner":{"name":"John"}},{"id":2}]`, w.Body.String())
+	})
+
+	t.Run("no fields", func(t *testing.T) {
+		w, err := serve("", http.StatusOK, pets[1:])
+		assert.NoError(t, err)
+		assert.Equal(t, `[{"id":2,"name":"Kitty"}]`, w.Body.String())
+	})
+
+	t.Run("undeclared field", func(t *testing.T) {
+		w, err := serve("id,owner(phone)", http.StatusOK, pets)
+		assert.EqualError(t, err, "write response: invalid fields parameter: unknown field owner/phone")
+		assert.Empty(t, w.Body.String())
+	})
+
+	t.Run("error responses are not filtered", func(t *testing.T) {
+		w, err := serve("id", http.StatusInternalServerError, map[string]interface{}{"code": 1, "message": "oops"})
+		assert.NoError(t, err)
+		assert.Equal(t, `{"code":1,"message":"oops"}`, w.Body.String())
+	})
+}
+
+func TestQueryValidator_fields(t *testing.T) {
+	doc := loadDocBytes([]byte(`
+swagger: "2.0"
+info:
+  title: Test
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      parameters:
+      - name: fields
+        in: query
+        type: string
+      responses:
+        200:
+          description: OK
+          schema:
+            type: array
+            items:
+              type: object
+              properties:
+                id:
+                  type: integer
+                name:
+                  type: string
+`))
+
+	basis := NewResolvingBasis(SpecAdapterName, doc, BasisStrict(false))
+	h := SpecMatcherMiddleware(doc)(
+		basis.QueryValidator()(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {})),
+	)
+
+	testCases := map[string]struct {
+		fields       string
+		expectedCode int
+	}{
+		"valid":      {fields: "id,name", expectedCode: http.StatusOK},
+		"malformed":  {fields: "id,(", expectedCode: http.StatusBadRequest},
+		"undeclared": {fields: "id,owner", expectedCode: http.StatusBadRequest},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/pets?fields="+url.QueryEscape(tc.fields), nil)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedCode, w.Code)
+		})
+	}
+}
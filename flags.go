@@ -0,0 +1,58 @@
+package oas
+
+import (
+	"net/http"
+)
+
+// ValidatorMode defines how a validator treats a request.
+type ValidatorMode int
+
+const (
+	// ValidatorEnforce makes the validator handle problems as configured,
+	// e.g. reject invalid requests. This is the default mode.
+	ValidatorEnforce ValidatorMode = iota
+
+	// ValidatorObserve makes the validator handle problems, but always
+	// continue to the next handler, as with WithContinueOnProblem. Response
+	// validators never stop the response, so for them this mode is the same
+	// as ValidatorEnforce.
+	ValidatorObserve
+
+	// ValidatorOff makes the validator skip validation.
+	ValidatorOff
+)
+
+// FlagProvider decides per request how validators treat the request, e.g.
+// based on feature flags. This allows percentage rollouts of stricter
+// validation: enable new validators in observe mode for a fraction of
+// requests, then enforce them.
+//
+// The check is the name of the check the validator performs, e.g. CheckQuery.
+type FlagProvider interface {
+	ValidatorMode(req *http.Request, check string) ValidatorMode
+}
+
+// FlagProviderFunc is a function that implements FlagProvider.
+type FlagProviderFunc func(req *http.Request, check string) ValidatorMode
+
+// ValidatorMode implements FlagProvider.
+func (f FlagProviderFunc) ValidatorMode(req *http.Request, check string) ValidatorMode {
+	return f(req, check)
+}
+
+// WithFlagProvider returns a middleware option that sets the provider that
+// is consulted on every request to decide the validator mode. Without the
+// provider, validators are always in ValidatorEnforce mode.
+func WithFlagProvider(p FlagProvider) MiddlewareOption {
+	return func(opts *MiddlewareOptions) {
+		opts.flagProvider = p
+	}
+}
+
+// validatorMode returns the validator mode for the request.
+func validatorMode(p FlagProvider, req *http.Request, check string) ValidatorMode {
+	if p == nil {
+		return ValidatorEnforce
+	}
+	return p.ValidatorMode(req, check)
+}
@@ -0,0 +1,66 @@
+package oas
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithFlagProvider(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+
+	flags := FlagProviderFunc(func(req *http.Request, check string) ValidatorMode {
+		switch req.Header.Get("X-Flag") {
+		case "observe":
+			return ValidatorObserve
+		case "off":
+			return ValidatorOff
+		default:
+			return ValidatorEnforce
+		}
+	})
+
+	testCases := map[string]struct {
+		flag            string
+		expectedServed  bool
+		expectedProblem bool
+	}{
+		"enforce": {
+			flag:            "",
+			expectedServed:  false,
+			expectedProblem: true,
+		},
+		"observe": {
+			flag:            "observe",
+			expectedServed:  true,
+			expectedProblem: true,
+		},
+		"off": {
+			flag:            "off",
+			expectedServed:  true,
+			expectedProblem: false,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var problem, served bool
+			ph := ProblemHandlerFunc(func(p Problem) { problem = true })
+			next := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) { served = true })
+
+			basis := NewResolvingBasis(SpecAdapterName, doc, BasisStrict(false))
+			h := SpecMatcherMiddleware(doc)(
+				basis.QueryValidator(WithProblemHandler(ph), WithFlagProvider(flags))(next),
+			)
+
+			req := httptest.NewRequest(http.MethodGet, "/v2/user/login?username=johndoe", nil)
+			req.Header.Set("X-Flag", tc.flag)
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, tc.expectedServed, served)
+			assert.Equal(t, tc.expectedProblem, problem)
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package oas
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+
+	"github.com/hypnoglow/oas2/convert"
+)
+
+// FuzzInput is a single input for Fuzzer.
+type FuzzInput struct {
+	// Operation selects the operation by index in the list of document
+	// operations ordered by id. The index wraps around.
+	Operation uint
+
+	// Query is the raw request query string.
+	Query string
+
+	// Header is the request header in form of "Key: value" lines.
+	Header string
+
+	// Body is the request body.
+	Body []byte
+}
+
+// Fuzzer feeds arbitrary inputs to the validators for the document
+// operations. It helps to find panics in the validators and inputs that
+// are accepted by the validators while they must not be.
+//
+// Fuzzer does not depend on any fuzzing engine. With Go 1.18+ use Fuzzer.Run
+// in a fuzz test; with other engines call Fuzzer.Fuzz directly.
+type Fuzzer struct {
+	ops     []operationInfo
+	handler http.Handler
+}
+
+type contextKeyFuzzResult struct{}
+
+// fuzzResult is the outcome of a single fuzz input.
+type fuzzResult struct {
+	accepted bool
+	err      error
+}
+
+// NewFuzzer returns a new Fuzzer for the document.
+func NewFuzzer(doc *Document) *Fuzzer {
+	f := &Fuzzer{}
+	for _, paths := range doc.Analyzer.Operations() {
+		for path, op := range paths {
+			f.ops = append(f.ops, newOperationInfo(doc, path, op))
+		}
+	}
+	sort.Slice(f.ops, func(i, j int) bool {
+		return f.ops[i].operation.ID < f.ops[j].operation.ID
+	})
+
+	ph := WithProblemHandlerFunc(func(p Problem) {
+		p.ResponseWriter().WriteHeader(http.StatusBadRequest)
+	})
+
+	b := NewResolvingBasis(SpecAdapterName, doc)
+	f.handler = b.QueryValidator(ph)(
+		b.RequestContentTypeValidator()(
+			b.RequestBodyValidator(ph)(
+				http.HandlerFunc(f.accept),
+			),
+		),
+	)
+
+	return f
+}
+
+// Seeds returns the seed inputs for the fuzzing corpus, built from the valid
+// requests generated by GenerateOperationTests.
+func (f *Fuzzer) Seeds() []FuzzInput {
+	var seeds []FuzzInput
+	for i, oi := range f.ops {
+		g := opTestGenerator{oi: oi, consumes: oi.consumes}
+		for _, tc := range g.generate() {
+			if !tc.Valid {
+				continue
+			}
+
+			var query string
+			if idx := strings.Index(tc.URL, "?"); idx >= 0 {
+				query = tc.URL[idx+1:]
+			}
+
+			var header bytes.Buffer
+			if err := tc.Header.Write(&header); err != nil {
+				panic(err)
+			}
+
+			seeds = append(seeds, FuzzInput{
+				Operation: uint(i),
+				Query:     query,
+				Header:    header.String(),
+				Body:      tc.Body,
+			})
+		}
+	}
+	return seeds
+}
+
+// Fuzz runs the validators on the input. It returns an error if the input
+// is accepted by the validators, but violates the spec: a required query
+// parameter or body is missing, an unknown query parameter is passed or
+// a query parameter value cannot be converted to its type.
+//
+// Panics in the validators are not recovered.
+func (f *Fuzzer) Fuzz(in FuzzInput) error {
+	if len(f.ops) == 0 {
+		return nil
+	}
+	oi := f.ops[in.Operation%uint(len(f.ops))]
+
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(in.Body))
+	if len(in.Body) == 0 {
+		req.Body = http.NoBody
+	}
+	req.URL.RawQuery = in.Query
+	for _, line := range strings.Split(in.Header, "\n") {
+		kv := strings.SplitN(line, ":", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		req.Header.Add(strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1]))
+	}
+
+	res := &fuzzResult{}
+	ctx := context.WithValue(contextWithOperationInfo(req.Context(), oi), contextKeyFuzzResult{}, res)
+	f.handler.ServeHTTP(httptest.NewRecorder(), req.WithContext(ctx))
+
+	if res.err != nil {
+		return fmt.Errorf("operation %s: input is accepted by validators: %s", oi.operation.ID, res.err)
+	}
+	return nil
+}
+
+// accept is the handler reached by the inputs accepted by the validators.
+func (f *Fuzzer) accept(w http.ResponseWriter, req *http.Request) {
+	res := req.Context().Value(contextKeyFuzzResult{}).(*fuzzResult)
+	res.accepted = true
+
+	oi, _ := getOperationInfo(req)
+	q := req.URL.Query()
+	for _, p := range oi.params {
+		switch p.In {
+		case "query":
+			vals, ok := q[p.Name]
+			if !ok {
+				if p.Required {
+					res.err = fmt.Errorf("required query parameter %s is missing", p.Name)
+					return
+				}
+				continue
+			}
+			if _, err := convert.Parameter(vals, &p); err != nil {
+				res.err = fmt.Errorf("query parameter %s has invalid value: %s", p.Name, err)
+				return
+			}
+			delete(q, p.Name)
+		case "body":
+			if p.Required && req.Body == http.NoBody {
+				res.err = fmt.Errorf("required body is missing")
+				return
+			}
+		}
+	}
+
+	for name := range q {
+		res.err = fmt.Errorf("unknown query parameter %s is passed", name)
+		return
+	}
+}
@@ -0,0 +1,30 @@
+//go:build go1.18
+// +build go1.18
+
+package oas
+
+import (
+	"testing"
+)
+
+// Run runs the fuzz test using Go native fuzzing. The corpus is seeded with
+// Seeds. Call it from a fuzz test:
+//
+//  func FuzzValidators(f *testing.F) {
+//      doc, _ := oas.LoadFile("spec.yaml")
+//      oas.NewFuzzer(doc).Run(f)
+//  }
+//
+// Then run "go test -fuzz FuzzValidators".
+func (f *Fuzzer) Run(tf *testing.F) {
+	for _, s := range f.Seeds() {
+		tf.Add(s.Operation, s.Query, s.Header, s.Body)
+	}
+
+	tf.Fuzz(func(t *testing.T, op uint, query, header string, body []byte) {
+		in := FuzzInput{Operation: op, Query: query, Header: header, Body: body}
+		if err := f.Fuzz(in); err != nil {
+			t.Error(err)
+		}
+	})
+}
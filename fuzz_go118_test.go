@@ -0,0 +1,17 @@
+//go:build go1.18
+// +build go1.18
+
+package oas
+
+import (
+	"testing"
+)
+
+func FuzzValidators(f *testing.F) {
+	doc, err := LoadFile("testdata/petstore_1.yml")
+	if err != nil {
+		f.Fatalf("Unexpected error: %v", err)
+	}
+
+	NewFuzzer(doc).Run(f)
+}
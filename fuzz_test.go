@@ -0,0 +1,34 @@
+package oas
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFuzzer(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+	f := NewFuzzer(doc)
+
+	seeds := f.Seeds()
+	assert.NotEmpty(t, seeds)
+	for _, s := range seeds {
+		assert.NoError(t, f.Fuzz(s))
+	}
+
+	testCases := map[string]FuzzInput{
+		"rejected query":     {Operation: 2, Query: "username=john"},
+		"malformed query":    {Operation: 2, Query: "username=%zz&password"},
+		"rejected body":      {Operation: 0, Header: "Content-Type: application/json", Body: []byte(`{"name":1}`)},
+		"malformed body":     {Operation: 0, Header: "Content-Type: application/json", Body: []byte(`{`)},
+		"unknown operation":  {Operation: 42},
+		"missing body":       {Operation: 0},
+		"wrong content type": {Operation: 0, Header: "Content-Type: text/plain", Body: []byte(`x`)},
+	}
+
+	for name, in := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.NoError(t, f.Fuzz(in))
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package oas
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// Kinds of spec gaps.
+const (
+	// GapUnknownRoute is a request that does not match any operation.
+	GapUnknownRoute = "unknown route"
+
+	// GapUndeclaredParam is a query parameter that is not declared for
+	// the operation.
+	GapUndeclaredParam = "undeclared param"
+
+	// GapUndocumentedStatus is a response status code that is not
+	// documented for the operation.
+	GapUndocumentedStatus = "undocumented status"
+)
+
+// maxSpecGaps limits the number of distinct gaps recorded, so that arbitrary
+// client input cannot exhaust the memory.
+const maxSpecGaps = 1000
+
+// SpecGap describes behavior of the service that is not described in the
+// spec.
+type SpecGap struct {
+	// Kind is the gap kind, e.g. GapUnknownRoute.
+	Kind string `json:"kind"`
+
+	// OperationID is the operation the gap belongs to, if any.
+	OperationID string `json:"operationId,omitempty"`
+
+	// Detail describes the gap: the method and path of the unknown route,
+	// the name of the undeclared param or the undocumented status code.
+	Detail string `json:"detail"`
+
+	// Count is the number of times the gap was observed.
+	Count int `json:"count"`
+}
+
+// SpecGapRecorder records traffic that is not described in the spec:
+// requests to unknown routes, undeclared query parameters and undocumented
+// response status codes. This helps to discover undocumented behavior of
+// legacy services before enabling validation.
+//
+// SpecGapRecorder is safe for concurrent use.
+type SpecGapRecorder struct {
+	mx   sync.Mutex
+	gaps map[SpecGap]int
+}
+
+// NewSpecGapRecorder returns a new SpecGapRecorder.
+func NewSpecGapRecorder() *SpecGapRecorder {
+	return &SpecGapRecorder{gaps: make(map[SpecGap]int)}
+}
+
+// Middleware returns a middleware that records the spec gaps. It must come
+// after the middleware that adds the operation context, e.g.
+// OperationContext or SpecMatcherMiddleware. Requests without operation
+// context are recorded as unknown routes.
+func (r *SpecGapRecorder) Middleware() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			oi, ok := getOperationInfo(req)
+			if !ok {
+				r.record(SpecGap{Kind: GapUnknownRoute, Detail: req.Method + " " + req.URL.Path})
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			declared := make(map[string]bool, len(oi.params))
+			for _, p := range oi.params {
+				if p.In == "query" {
+					declared[p.Name] = true
+				}
+			}
+			for name := range req.URL.Query() {
+				if !declared[name] {
+					r.record(SpecGap{Kind: GapUndeclaredParam, OperationID: oi.operation.ID, Detail: name})
+				}
+			}
+
+			rr := newWrapResponseWriter(w, req.ProtoMajor)
+			next.ServeHTTP(rr, req)
+
+			status := rr.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			if !documentedStatus(oi, status) {
+				r.record(SpecGap{Kind: GapUndocumentedStatus, OperationID: oi.operation.ID, Detail: strconv.Itoa(status)})
+			}
+		})
+	}
+}
+
+// documentedStatus checks if the response status code is documented for
+// the operation, either explicitly or by the default response.
+func documentedStatus(oi operationInfo, status int) bool {
+	responses := oi.operation.Responses
+	if responses == nil {
+		return false
+	}
+	if responses.Default != nil {
+		return true
+	}
+	_, ok := responses.StatusCodeResponses[status]
+	return ok
+}
+
+func (r *SpecGapRecorder) record(gap SpecGap) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	if _, ok := r.gaps[gap]; !ok && len(r.gaps) >= maxSpecGaps {
+		return
+	}
+	r.gaps[gap]++
+}
+
+// Gaps returns the recorded gaps ordered by kind, operation id and detail.
+func (r *SpecGapRecorder) Gaps() []SpecGap {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	gaps := make([]SpecGap, 0, len(r.gaps))
+	for gap, count := range r.gaps {
+		gap.Count = count
+		gaps = append(gaps, gap)
+	}
+	sort.Slice(gaps, func(i, j int) bool {
+		a, b := gaps[i], gaps[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.OperationID != b.OperationID {
+			return a.OperationID < b.OperationID
+		}
+		return a.Detail < b.Detail
+	})
+	return gaps
+}
+
+// Reset forgets all the recorded gaps.
+func (r *SpecGapRecorder) Reset() {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	r.gaps = make(map[SpecGap]int)
+}
+
+// ServeHTTP implements http.Handler, so the recorder can be mounted as
+// an endpoint that serves the recorded gaps as JSON.
+func (r *SpecGapRecorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	b, err := json.Marshal(r.Gaps())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(b) // nolint: errcheck
+}
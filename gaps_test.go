@@ -0,0 +1,52 @@
+package oas
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpecGapRecorder(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+	rec := NewSpecGapRecorder()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/pet/", handleGetPetByID)
+	mux.HandleFunc("/v2/user/login", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	mux.HandleFunc("/health", func(w http.ResponseWriter, req *http.Request) {})
+
+	h := SpecMatcherMiddleware(doc)(rec.Middleware()(mux))
+
+	for _, u := range []string{
+		"/v2/pet/12",
+		"/v2/pet/12?debug=true&verbose=1",
+		"/v2/pet/13?verbose=1",
+		"/v2/user/login?username=john&password=secret",
+		"/health",
+	} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, u, nil))
+	}
+
+	expected := []SpecGap{
+		{Kind: GapUndeclaredParam, OperationID: "getPetById", Detail: "verbose", Count: 2},
+		{Kind: GapUndocumentedStatus, OperationID: "loginUser", Detail: "418", Count: 1},
+		{Kind: GapUnknownRoute, Detail: "GET /health", Count: 1},
+	}
+	assert.Equal(t, expected, rec.Gaps())
+
+	w := httptest.NewRecorder()
+	rec.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/gaps", nil))
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `[
+		{"kind":"undeclared param","operationId":"getPetById","detail":"verbose","count":2},
+		{"kind":"undocumented status","operationId":"loginUser","detail":"418","count":1},
+		{"kind":"unknown route","detail":"GET /health","count":1}
+	]`, w.Body.String())
+
+	rec.Reset()
+	assert.Empty(t, rec.Gaps())
+}
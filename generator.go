@@ -0,0 +1,382 @@
+package oas
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-openapi/spec"
+)
+
+// Generator produces random requests that conform to the spec, e.g. for load
+// tests and mock clients. Generated values respect types, formats, enums,
+// ranges, lengths and required properties. Values for strings with pattern
+// are taken from spec examples and defaults, if any, so they may not match
+// the pattern.
+//
+// Generator is safe for concurrent use.
+type Generator struct {
+	doc     *Document
+	baseURL string
+
+	mx  sync.Mutex
+	rnd *rand.Rand
+}
+
+// GeneratorOption is an option for Generator.
+type GeneratorOption func(*Generator)
+
+// GeneratorSeed returns an option that seeds the generator random source,
+// so the generated requests are reproducible.
+func GeneratorSeed(seed int64) GeneratorOption {
+	return func(g *Generator) {
+		g.rnd = rand.New(rand.NewSource(seed))
+	}
+}
+
+// GeneratorBaseURL returns an option that sets the base URL of generated
+// requests, e.g. "http://localhost:8080". The spec base path is appended to
+// it. By default, the URL is built from the spec schemes and host.
+func GeneratorBaseURL(u string) GeneratorOption {
+	return func(g *Generator) {
+		g.baseURL = strings.TrimSuffix(u, "/")
+	}
+}
+
+// NewGenerator returns a new Generator for the document.
+func NewGenerator(doc *Document, opts ...GeneratorOption) *Generator {
+	g := &Generator{doc: doc}
+	for _, opt := range opts {
+		opt(g)
+	}
+
+	if g.rnd == nil {
+		g.rnd = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+
+	if g.baseURL == "" {
+		scheme := "http"
+		if schemes := doc.Spec().Schemes; len(schemes) > 0 {
+			scheme = schemes[0]
+		}
+		host := doc.Spec().Host
+		if host == "" {
+			host = "localhost"
+		}
+		g.baseURL = scheme + "://" + host
+	}
+
+	return g
+}
+
+// NewRequest returns a new random client request for the operation.
+// Required parameters are always set, optional ones are set at random.
+func (g *Generator) NewRequest(operationID string) (*http.Request, error) {
+	method, path, op, ok := g.doc.Analyzer.OperationForName(operationID)
+	if !ok {
+		return nil, fmt.Errorf("operation %s is not found in the spec", operationID)
+	}
+
+	og := opTestGenerator{
+		method: strings.ToUpper(method),
+		oi:     newOperationInfo(g.doc, path, op),
+	}
+	og.consumes = og.oi.consumes
+
+	// Parameters order is not stable, so sort them to make the generated
+	// values reproducible.
+	params := append([]spec.Parameter(nil), og.oi.params...)
+	sort.Slice(params, func(i, j int) bool {
+		if params[i].In != params[j].In {
+			return params[i].In < params[j].In
+		}
+		return params[i].Name < params[j].Name
+	})
+
+	g.mx.Lock()
+	r := g.request(params)
+	g.mx.Unlock()
+
+	tc := og.newCase(operationID, true, r)
+
+	req, err := http.NewRequest(tc.Method, g.baseURL+tc.URL, bytes.NewReader(tc.Body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header = tc.Header
+	return req, nil
+}
+
+// request generates a random request for the params.
+func (g *Generator) request(params []spec.Parameter) testRequest {
+	r := testRequest{
+		path:   make(map[string]string),
+		query:  make(url.Values),
+		header: make(http.Header),
+		form:   make(url.Values),
+	}
+
+	for _, p := range params {
+		if !p.Required && g.rnd.Intn(2) == 0 {
+			continue
+		}
+
+		if p.In == "body" {
+			r.body = g.schemaValue(p.Schema, 0)
+			r.hasBody = true
+			continue
+		}
+
+		vals := formatParamValue(p, g.paramValue(p))
+		switch p.In {
+		case "path":
+			r.path[p.Name] = vals[0]
+		case "query":
+			r.query[p.Name] = vals
+		case "header":
+			r.header[http.CanonicalHeaderKey(p.Name)] = vals
+		case "formData":
+			r.form[p.Name] = vals
+		}
+	}
+
+	return r
+}
+
+// paramValue generates a random value for the non-body parameter.
+func (g *Generator) paramValue(p spec.Parameter) interface{} {
+	if len(p.Enum) > 0 {
+		return p.Enum[g.rnd.Intn(len(p.Enum))]
+	}
+	if p.Pattern != "" {
+		return sampleParam(p)
+	}
+
+	if p.Type == "array" {
+		if p.Items == nil {
+			return nil
+		}
+		n := g.length(p.MinItems, p.MaxItems, 1)
+		arr := make([]interface{}, n)
+		for i := range arr {
+			arr[i] = g.itemsValue(p.Items)
+		}
+		return arr
+	}
+
+	return g.primitive(p.Type, p.Format, numberBounds{
+		min: p.Minimum, exclMin: p.ExclusiveMinimum,
+		max: p.Maximum, exclMax: p.ExclusiveMaximum,
+	}, p.MultipleOf, p.MinLength, p.MaxLength)
+}
+
+// itemsValue generates a random value for the array parameter items.
+func (g *Generator) itemsValue(items *spec.Items) interface{} {
+	if len(items.Enum) > 0 {
+		return items.Enum[g.rnd.Intn(len(items.Enum))]
+	}
+	if items.Pattern != "" {
+		return sampleItems(items)
+	}
+
+	return g.primitive(items.Type, items.Format, numberBounds{
+		min: items.Minimum, exclMin: items.ExclusiveMinimum,
+		max: items.Maximum, exclMax: items.ExclusiveMaximum,
+	}, items.MultipleOf, items.MinLength, items.MaxLength)
+}
+
+// schemaValue generates a random value that conforms to the schema.
+// Required properties are always set, optional ones are set at random.
+func (g *Generator) schemaValue(schema *spec.Schema, depth int) interface{} {
+	if schema == nil || depth > maxSampleDepth {
+		return nil
+	}
+
+	if len(schema.Enum) > 0 {
+		return schema.Enum[g.rnd.Intn(len(schema.Enum))]
+	}
+	if schema.Pattern != "" {
+		return sampleSchema(schema, depth)
+	}
+
+	if len(schema.AllOf) > 0 {
+		obj := make(map[string]interface{})
+		for i := range schema.AllOf {
+			if m, ok := g.schemaValue(&schema.AllOf[i], depth+1).(map[string]interface{}); ok {
+				for k, v := range m {
+					obj[k] = v
+				}
+			}
+		}
+		return obj
+	}
+
+	switch schemaType(schema) {
+	case "object":
+		required := make(map[string]bool, len(schema.Required))
+		for _, name := range schema.Required {
+			required[name] = true
+		}
+
+		obj := make(map[string]interface{})
+		for _, name := range sortedPropertyNames(schema) {
+			if !required[name] && g.rnd.Intn(2) == 0 {
+				continue
+			}
+			prop := schema.Properties[name]
+			if v := g.schemaValue(&prop, depth+1); v != nil {
+				obj[name] = v
+			}
+		}
+		return obj
+	case "array":
+		n := g.length(schema.MinItems, schema.MaxItems, 1)
+		arr := make([]interface{}, 0, n)
+		if schema.Items != nil && schema.Items.Schema != nil {
+			for i := 0; i < n; i++ {
+				arr = append(arr, g.schemaValue(schema.Items.Schema, depth+1))
+			}
+		}
+		return arr
+	default:
+		return g.primitive(schemaType(schema), schema.Format, numberBounds{
+			min: schema.Minimum, exclMin: schema.ExclusiveMinimum,
+			max: schema.Maximum, exclMax: schema.ExclusiveMaximum,
+		}, schema.MultipleOf, schema.MinLength, schema.MaxLength)
+	}
+}
+
+// generatorRange is the default width of the generated numbers range when
+// the spec does not define both bounds.
+const generatorRange = 1000
+
+// primitive generates a random value of the primitive type and format.
+func (g *Generator) primitive(typ, format string, bounds numberBounds, multipleOf *float64, minLength, maxLength *int64) interface{} {
+	switch typ {
+	case "integer":
+		low, high := g.numberRange(bounds, true)
+		if format == "int32" {
+			low, high = math.Max(low, math.MinInt32), math.Min(high, math.MaxInt32)
+		}
+		v := low + float64(g.rnd.Int63n(int64(high-low)+1))
+		if multipleOf != nil && *multipleOf > 0 {
+			v = g.multiple(low, high, *multipleOf, v)
+		}
+		if format == "int32" {
+			return int32(v)
+		}
+		return int64(v)
+	case "number":
+		low, high := g.numberRange(bounds, false)
+		v := low + g.rnd.Float64()*(high-low)
+		if multipleOf != nil && *multipleOf > 0 {
+			v = g.multiple(low, high, *multipleOf, v)
+		}
+		if format == "float" {
+			return float32(v)
+		}
+		return v
+	case "boolean":
+		return g.rnd.Intn(2) == 0
+	case "string":
+		return g.str(format, minLength, maxLength)
+	default:
+		return nil
+	}
+}
+
+// numberRange returns the range of numbers to generate.
+func (g *Generator) numberRange(bounds numberBounds, integer bool) (low, high float64) {
+	low, hasLow := bounds.lowest(integer)
+	high, hasHigh := bounds.highest(integer)
+	switch {
+	case !hasLow && !hasHigh:
+		low, high = 0, generatorRange
+	case !hasLow:
+		low = high - generatorRange
+	case !hasHigh:
+		high = low + generatorRange
+	}
+	if high < low {
+		high = low
+	}
+	return low, high
+}
+
+// multiple returns a multiple of m in the range, closest to v. If there is
+// no such multiple, v is returned as is.
+func (g *Generator) multiple(low, high, m, v float64) float64 {
+	first, last := math.Ceil(low/m), math.Floor(high/m)
+	if first > last {
+		return v
+	}
+	return (first + float64(g.rnd.Int63n(int64(last-first)+1))) * m
+}
+
+// length returns a random length within the bounds.
+func (g *Generator) length(min, max *int64, defaultMin int) int {
+	low := defaultMin
+	if min != nil {
+		low = int(*min)
+	}
+	high := low + 3
+	if max != nil && int(*max) < high {
+		high = int(*max)
+	}
+	if high < low {
+		high = low
+	}
+	return low + g.rnd.Intn(high-low+1)
+}
+
+const generatorLetters = "abcdefghijklmnopqrstuvwxyz"
+
+// str generates a random string of the format within the length bounds.
+func (g *Generator) str(format string, minLength, maxLength *int64) string {
+	switch format {
+	case "date-time":
+		return g.time().Format(time.RFC3339)
+	case "date":
+		return g.time().Format("2006-01-02")
+	case "uuid":
+		b := make([]byte, 16)
+		g.rnd.Read(b)
+		b[6] = (b[6] & 0x0f) | 0x40
+		b[8] = (b[8] & 0x3f) | 0x80
+		return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+	case "email":
+		return g.word(8) + "@example.com"
+	}
+	if s, ok := sampleFormats[format]; ok {
+		return s
+	}
+
+	n := g.length(minLength, maxLength, 1)
+	if maxLength == nil {
+		n += g.rnd.Intn(12)
+	}
+	return g.word(n)
+}
+
+// time returns a random time within years 2000 and 2030.
+func (g *Generator) time() time.Time {
+	start := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	return start.Add(time.Duration(g.rnd.Int63n(int64(end.Sub(start)/time.Second))) * time.Second)
+}
+
+// word returns a random lowercase word of length n.
+func (g *Generator) word(n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = generatorLetters[g.rnd.Intn(len(generatorLetters))]
+	}
+	return string(b)
+}
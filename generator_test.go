@@ -0,0 +1,67 @@
+package oas
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerator(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+
+	t.Run("generates valid requests", func(t *testing.T) {
+		basis := NewResolvingBasis(SpecAdapterName, doc, BasisStrict(false))
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("/v2/pet", handleAddPet)
+		mux.HandleFunc("/v2/pet/", handleGetPetByID)
+		mux.HandleFunc("/v2/user/login", handleUserLogin)
+
+		h := SpecMatcherMiddleware(doc)(
+			basis.QueryValidator(WithProblemHandler(problemHandlerResponseWriter()))(
+				basis.RequestBodyValidator(WithProblemHandler(problemHandlerResponseWriter()))(mux),
+			),
+		)
+
+		g := NewGenerator(doc, GeneratorSeed(1))
+		for i := 0; i < 50; i++ {
+			for _, id := range []string{"addPet", "getPetById", "loginUser"} {
+				req, err := g.NewRequest(id)
+				assert.NoError(t, err)
+				assert.Equal(t, "petstore.swagger.io", req.URL.Host)
+
+				w := httptest.NewRecorder()
+				h.ServeHTTP(w, httptest.NewRequest(req.Method, req.URL.RequestURI(), req.Body).WithContext(req.Context()))
+				assert.Equal(t, http.StatusOK, w.Code, "%s %s: %s", req.Method, req.URL, w.Body)
+			}
+		}
+	})
+
+	t.Run("seed makes requests reproducible", func(t *testing.T) {
+		g1 := NewGenerator(doc, GeneratorSeed(42), GeneratorBaseURL("http://localhost:8080/"))
+		g2 := NewGenerator(doc, GeneratorSeed(42), GeneratorBaseURL("http://localhost:8080/"))
+
+		for i := 0; i < 10; i++ {
+			req1, err := g1.NewRequest("addPet")
+			assert.NoError(t, err)
+			req2, err := g2.NewRequest("addPet")
+			assert.NoError(t, err)
+
+			assert.Equal(t, "http://localhost:8080/v2/pet", req1.URL.Scheme+"://"+req1.URL.Host+req1.URL.Path)
+			assert.Equal(t, req1.URL.String(), req2.URL.String())
+			assert.Equal(t, "application/json", req1.Header.Get("Content-Type"))
+
+			b1, _ := ioutil.ReadAll(req1.Body)
+			b2, _ := ioutil.ReadAll(req2.Body)
+			assert.Equal(t, string(b1), string(b2))
+		}
+	})
+
+	t.Run("unknown operation", func(t *testing.T) {
+		_, err := NewGenerator(doc).NewRequest("foo")
+		assert.EqualError(t, err, "operation foo is not found in the spec")
+	})
+}
@@ -1,6 +1,9 @@
 package oas
 
 import (
+	"sync"
+
+	"github.com/go-openapi/analysis"
 	"github.com/go-openapi/loads"
 	"github.com/go-openapi/spec"
 )
@@ -11,6 +14,12 @@ import (
 // Document represents a swagger spec document.
 type Document struct {
 	*loads.Document
+
+	matcherOnce sync.Once
+	matcher     *specMatcher
+
+	origOnce     sync.Once
+	origAnalyzer *analysis.Spec
 }
 
 func wrapDocument(doc *loads.Document) *Document {
@@ -25,3 +34,11 @@ type Operation struct {
 func wrapOperation(op *spec.Operation) *Operation {
 	return &Operation{Operation: op}
 }
+
+// Schema describes a spec schema, e.g. a definition.
+type Schema struct {
+	*spec.Schema
+
+	// name is the definition name, if the schema is a definition.
+	name string
+}
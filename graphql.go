@@ -0,0 +1,126 @@
+package oas
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// OperationError is an error of a request to an operation of the document,
+// annotated with the operation id.
+type OperationError struct {
+	// OperationID is the id of the operation the request is matched to,
+	// or empty if the request does not match any operation.
+	OperationID string
+
+	// Err is the original error.
+	Err error
+}
+
+// Error implements error.
+func (e *OperationError) Error() string {
+	if e.OperationID == "" {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("operation %s: %s", e.OperationID, e.Err)
+}
+
+// Unwrap returns the original error.
+func (e *OperationError) Unwrap() error {
+	return e.Err
+}
+
+// Extensions returns the extensions of GraphQL errors, so GraphQL servers
+// that support them, e.g. graph-gophers/graphql-go, report the operation id
+// to the client.
+func (e *OperationError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"operationId": e.OperationID}
+}
+
+// AsOperationError returns the OperationError, if err is one or is an
+// *url.Error returned by http.Client wrapping one.
+func AsOperationError(err error) (*OperationError, bool) {
+	if ue, ok := err.(*url.Error); ok {
+		err = ue.Err
+	}
+	oe, ok := err.(*OperationError)
+	return oe, ok
+}
+
+// GraphQLBridge validates REST requests issued by GraphQL resolvers layered
+// over the document, and annotates their errors with the originating
+// operation id, so GraphQL errors point to the failed REST operation:
+//
+//  bridge := oas.NewGraphQLBridge(doc, nil)
+//  client := bridge.Client()
+//
+//  func (r *petResolver) Pet(ctx context.Context, args struct{ ID int32 }) (*pet, error) {
+//      resp, err := client.Do(newGetPetRequest(ctx, args.ID))
+//      if err != nil {
+//          oe, _ := oas.AsOperationError(err)
+//          return nil, oe
+//      }
+//      if err := bridge.ResponseError(resp); err != nil {
+//          return nil, err
+//      }
+//      // ...
+//  }
+//
+// Requests are validated by ValidatingTransport before sending.
+type GraphQLBridge struct {
+	doc *Document
+	vt  *ValidatingTransport
+}
+
+// NewGraphQLBridge returns a new GraphQLBridge that sends valid requests
+// using the base transport. If base is nil, http.DefaultTransport is used.
+func NewGraphQLBridge(doc *Document, base http.RoundTripper) *GraphQLBridge {
+	return &GraphQLBridge{
+		doc: doc,
+		vt:  NewValidatingTransport(doc, base),
+	}
+}
+
+// Client returns a new HTTP client that uses the bridge as the transport.
+func (b *GraphQLBridge) Client() *http.Client {
+	return &http.Client{Transport: b}
+}
+
+// RoundTrip implements http.RoundTripper. Errors are returned as
+// *OperationError.
+func (b *GraphQLBridge) RoundTrip(req *http.Request) (*http.Response, error) {
+	id := b.operationID(req)
+
+	resp, err := b.vt.RoundTrip(req)
+	if err != nil {
+		return nil, &OperationError{OperationID: id, Err: err}
+	}
+	return resp, nil
+}
+
+// ResponseError returns *OperationError if the response status is 4xx or
+// 5xx, and nil otherwise.
+func (b *GraphQLBridge) ResponseError(resp *http.Response) error {
+	if resp.StatusCode < 400 {
+		return nil
+	}
+
+	var id string
+	if resp.Request != nil {
+		id = b.operationID(resp.Request)
+	}
+	return &OperationError{
+		OperationID: id,
+		Err:         fmt.Errorf("responded with status %s", resp.Status),
+	}
+}
+
+// operationID returns the id of the operation the request matches, or empty
+// string if the request does not match any operation.
+func (b *GraphQLBridge) operationID(req *http.Request) string {
+	route, _, ok := b.doc.specMatcher().match(req.Method, req.URL.EscapedPath())
+	if !ok {
+		return ""
+	}
+	return route.id
+}
@@ -0,0 +1,67 @@
+package oas
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGraphQLBridge(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/v2/pet/404" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	bridge := NewGraphQLBridge(doc, nil)
+	client := bridge.Client()
+
+	t.Run("invalid request", func(t *testing.T) {
+		_, err := client.Get(srv.URL + "/v2/user/login?username=john")
+
+		oe, ok := AsOperationError(err)
+		if !assert.True(t, ok) {
+			return
+		}
+		assert.Equal(t, "loginUser", oe.OperationID)
+		assert.Equal(t, map[string]interface{}{"operationId": "loginUser"}, oe.Extensions())
+		assert.EqualError(t, oe, "operation loginUser: oas: outgoing request does not match operation loginUser: param password is required")
+	})
+
+	t.Run("unknown operation", func(t *testing.T) {
+		_, err := client.Get(srv.URL + "/v2/store")
+
+		oe, ok := AsOperationError(err)
+		if !assert.True(t, ok) {
+			return
+		}
+		assert.Empty(t, oe.OperationID)
+		assert.EqualError(t, oe, "oas: outgoing request GET /v2/store does not match any operation")
+	})
+
+	t.Run("error response", func(t *testing.T) {
+		resp, err := client.Get(srv.URL + "/v2/pet/404")
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer resp.Body.Close()
+
+		err = bridge.ResponseError(resp)
+		assert.EqualError(t, err, "operation getPetById: responded with status 404 Not Found")
+	})
+
+	t.Run("successful response", func(t *testing.T) {
+		resp, err := client.Get(srv.URL + "/v2/pet/12")
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer resp.Body.Close()
+
+		assert.NoError(t, bridge.ResponseError(resp))
+	})
+}
@@ -0,0 +1,215 @@
+package oas
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// SpecHealth tracks the state of the spec the service is built on: whether
+// the spec is loaded, when it was (re)loaded last time, whether validators
+// are ready, and which operations have handlers. It provides health and
+// readiness handlers, so orchestration can gate rollouts on spec readiness:
+//
+//  health := oas.NewSpecHealth()
+//  doc, err := oas.LoadFile("spec.yaml")
+//  if err != nil {
+//      health.SetLoadError(err)
+//  } else {
+//      health.SetDocument(doc)
+//      health.SetHandlers(handlers)
+//      health.SetCheckErrors(basis.Warmup(ctx, mws...), oas.SelfCheck(doc, handlers))
+//  }
+//  adminMux.Handle("/healthz", health.HealthzHandler())
+//  adminMux.Handle("/readyz", health.ReadyzHandler())
+//
+// SpecHealth is safe for concurrent use.
+type SpecHealth struct {
+	mx sync.RWMutex
+
+	doc      *Document
+	loadErr  error
+	loadedAt time.Time
+	reloads  int
+
+	checkErrs []error
+	handlers  map[string]bool
+}
+
+// NewSpecHealth returns a new SpecHealth. Until the spec is loaded, it is
+// not ready.
+func NewSpecHealth() *SpecHealth {
+	return &SpecHealth{}
+}
+
+// SetDocument records the successfully loaded (or reloaded) document and
+// resets the load error. Handlers and check errors recorded for the
+// previous document are reset too.
+func (h *SpecHealth) SetDocument(doc *Document) {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+
+	if h.doc != nil {
+		h.reloads++
+	}
+	h.doc = doc
+	h.loadErr = nil
+	h.loadedAt = time.Now()
+	h.checkErrs = nil
+	h.handlers = nil
+}
+
+// SetLoadError records the error occurred while loading (or reloading) the
+// spec. If the previous document is still served, it keeps serving, but
+// the service is not ready until the next successful load.
+func (h *SpecHealth) SetLoadError(err error) {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+
+	h.loadErr = err
+}
+
+// SetCheckErrors records the errors of validators compilation and checks,
+// e.g. of Warmup and SelfCheck. Nil errors are skipped, so results of the
+// checks can be passed as is. The service is not ready while there are
+// check errors.
+func (h *SpecHealth) SetCheckErrors(errs ...error) {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+
+	h.checkErrs = nil
+	for _, err := range errs {
+		if err != nil {
+			h.checkErrs = append(h.checkErrs, err)
+		}
+	}
+}
+
+// SetHandlers records the operation handlers, so the health reports the
+// coverage of spec operations by handlers.
+func (h *SpecHealth) SetHandlers(handlers map[string]http.Handler) {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+
+	h.handlers = make(map[string]bool, len(handlers))
+	for id := range handlers {
+		h.handlers[id] = true
+	}
+}
+
+// SpecHealthStatus describes the spec state.
+type SpecHealthStatus struct {
+	// Ready reports whether the spec is loaded and there are no load
+	// and check errors.
+	Ready bool `json:"ready"`
+
+	// Title and Version are the title and the version of the loaded spec.
+	Title   string `json:"title,omitempty"`
+	Version string `json:"version,omitempty"`
+
+	// LoadedAt is the time of the last successful load.
+	LoadedAt *time.Time `json:"loadedAt,omitempty"`
+
+	// Reloads is the number of successful reloads after the first load.
+	Reloads int `json:"reloads"`
+
+	// Errors are the load and check errors.
+	Errors []string `json:"errors,omitempty"`
+
+	// Coverage describes the coverage of spec operations by handlers.
+	// It is nil until handlers are set.
+	Coverage *SpecCoverage `json:"coverage,omitempty"`
+}
+
+// SpecCoverage describes the coverage of spec operations by handlers.
+type SpecCoverage struct {
+	// Operations is the number of operations in the spec.
+	Operations int `json:"operations"`
+
+	// Handled is the number of operations that have handlers.
+	Handled int `json:"handled"`
+
+	// Missing are the ids of operations without handlers, ordered.
+	Missing []string `json:"missing,omitempty"`
+}
+
+// Status returns the current spec state.
+func (h *SpecHealth) Status() SpecHealthStatus {
+	h.mx.RLock()
+	defer h.mx.RUnlock()
+
+	s := SpecHealthStatus{Reloads: h.reloads}
+	if h.loadErr != nil {
+		s.Errors = append(s.Errors, "load spec: "+h.loadErr.Error())
+	}
+	for _, err := range h.checkErrs {
+		s.Errors = append(s.Errors, err.Error())
+	}
+	s.Ready = h.doc != nil && len(s.Errors) == 0
+
+	if h.doc == nil {
+		return s
+	}
+
+	if info := h.doc.Spec().Info; info != nil {
+		s.Title, s.Version = info.Title, info.Version
+	}
+	loadedAt := h.loadedAt
+	s.LoadedAt = &loadedAt
+
+	if h.handlers != nil {
+		c := &SpecCoverage{}
+		for _, paths := range h.doc.Analyzer.Operations() {
+			for _, op := range paths {
+				c.Operations++
+				if h.handlers[op.ID] {
+					c.Handled++
+				} else {
+					c.Missing = append(c.Missing, op.ID)
+				}
+			}
+		}
+		sort.Strings(c.Missing)
+		s.Coverage = c
+	}
+
+	return s
+}
+
+// HealthzHandler returns a liveness handler. It always responds with 200 OK
+// and the spec state as JSON, because the service is alive even if the spec
+// cannot be loaded.
+func (h *SpecHealth) HealthzHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		writeJSON(w, http.StatusOK, h.Status())
+	})
+}
+
+// ReadyzHandler returns a readiness handler. It responds with 200 OK if the
+// spec is ready, and with 503 Service Unavailable otherwise, along with
+// the spec state as JSON.
+func (h *SpecHealth) ReadyzHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		s := h.Status()
+		code := http.StatusOK
+		if !s.Ready {
+			code = http.StatusServiceUnavailable
+		}
+		writeJSON(w, code, s)
+	})
+}
+
+// writeJSON writes the value as the JSON response with the status code.
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(b) // nolint: errcheck
+}
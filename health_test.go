@@ -0,0 +1,70 @@
+package oas
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpecHealth(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+	h := NewSpecHealth()
+
+	serve := func(handler http.Handler) (int, SpecHealthStatus) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+		var s SpecHealthStatus
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &s))
+		return w.Code, s
+	}
+
+	t.Run("not loaded", func(t *testing.T) {
+		code, s := serve(h.ReadyzHandler())
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.False(t, s.Ready)
+
+		code, _ = serve(h.HealthzHandler())
+		assert.Equal(t, http.StatusOK, code)
+	})
+
+	t.Run("loaded", func(t *testing.T) {
+		h.SetDocument(doc)
+		h.SetHandlers(map[string]http.Handler{
+			"getPetById": http.HandlerFunc(handleGetPetByID),
+			"loginUser":  http.HandlerFunc(handleUserLogin),
+		})
+
+		code, s := serve(h.ReadyzHandler())
+		assert.Equal(t, http.StatusOK, code)
+		assert.True(t, s.Ready)
+		assert.Equal(t, "Swagger Petstore", s.Title)
+		assert.NotNil(t, s.LoadedAt)
+		assert.Equal(t, &SpecCoverage{Operations: 3, Handled: 2, Missing: []string{"addPet"}}, s.Coverage)
+	})
+
+	t.Run("check errors", func(t *testing.T) {
+		h.SetCheckErrors(nil, errors.New("warmup requests are rejected"))
+
+		code, s := serve(h.ReadyzHandler())
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, []string{"warmup requests are rejected"}, s.Errors)
+	})
+
+	t.Run("reload", func(t *testing.T) {
+		h.SetLoadError(errors.New("file not found"))
+		code, s := serve(h.ReadyzHandler())
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Contains(t, s.Errors, "load spec: file not found")
+
+		h.SetDocument(doc)
+		code, s = serve(h.ReadyzHandler())
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, 1, s.Reloads)
+		assert.Nil(t, s.Coverage)
+	})
+}
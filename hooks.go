@@ -0,0 +1,239 @@
+package oas
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+
+	"github.com/go-openapi/spec"
+)
+
+// RequestHook mutates the request to the operation, e.g. to rename legacy
+// parameters before validation, or to stamp headers for downstream services
+// after validation. It returns the request to pass on, which may be the
+// same request. If it returns an error, the request is rejected and the
+// error is handled by the problem handler.
+type RequestHook func(req *http.Request, op *Operation) (*http.Request, error)
+
+// HookStage is the stage of the request validation pipeline a hook runs at.
+type HookStage int
+
+const (
+	// HookPreValidation hooks run before all request validators, so
+	// validators see the mutated request.
+	HookPreValidation HookStage = iota
+
+	// HookPostValidation hooks run after all request validators, only for
+	// requests that passed validation or that validators continued on.
+	HookPostValidation
+)
+
+// BasisRequestHook returns a basis option that registers the hook at the
+// stage of the request validation pipeline built by RequestValidator.
+// Hooks of the same stage run in order of registration. It panics if the
+// hook is nil.
+func BasisRequestHook(stage HookStage, h RequestHook) BasisOption {
+	if h == nil {
+		panic("oas: BasisRequestHook hook is nil")
+	}
+	return func(b *ResolvingBasis) {
+		b.hooks[stage] = append(b.hooks[stage], h)
+	}
+}
+
+// RequestValidator returns a middleware that runs the whole request
+// validation pipeline derived from the basis, in this order:
+//
+//  1. HookPreValidation hooks
+//  2. ParamTransformer
+//  3. QueryValidator
+//  4. RequestContentTypeValidator
+//  5. RequestBodyValidator
+//  6. CustomValidator
+//  7. HookPostValidation hooks
+//
+// The options apply to all the validators. Hooks failures are handled by
+// the problem handler, which responds with 400 by default.
+//
+// The request body is read only by RequestBodyValidator. The server sends
+// 100 Continue to a client that sent Expect: 100-continue on the first read
+// of the body, so such client is asked for the body only after the request
+// passed the validators that precede RequestBodyValidator, as well as the
+// middlewares mounted before RequestValidator, e.g. security ones. Requests
+// rejected earlier are responded without waiting for the body.
+func (b *ResolvingBasis) RequestValidator(opts ...MiddlewareOption) Middleware {
+	options := b.middlewareOptions(opts)
+	if options.problemHandler == nil {
+		options.problemHandler = newProblemHandlerErrorResponder()
+		if options.hideProblemDetails {
+			options.problemHandler = newProblemHandlerDetailsHider(options.problemHandler)
+		}
+	}
+
+	pipeline := []Middleware{
+		b.requestHooks(b.hooks[HookPreValidation], options.problemHandler),
+		b.ParamTransformer(),
+		b.QueryValidator(opts...),
+		b.RequestContentTypeValidator(opts...),
+		b.RequestBodyValidator(opts...),
+		b.CustomValidator(opts...),
+		b.requestHooks(b.hooks[HookPostValidation], options.problemHandler),
+	}
+
+	return func(next http.Handler) http.Handler {
+		for i := len(pipeline) - 1; i >= 0; i-- {
+			next = pipeline[i](next)
+		}
+		return next
+	}
+}
+
+// requestHooks returns a middleware that runs the hooks.
+func (b *ResolvingBasis) requestHooks(hooks []RequestHook, problemHandler ProblemHandler) Middleware {
+	return func(next http.Handler) http.Handler {
+		if len(hooks) == 0 {
+			return next
+		}
+		return &requestHooks{
+			next:           next,
+			hooks:          hooks,
+			problemHandler: problemHandler,
+			strict:         b.strict,
+		}
+	}
+}
+
+// requestHooks is a middleware that resolves operation context from the
+// request and runs request hooks.
+type requestHooks struct {
+	next  http.Handler
+	hooks []RequestHook
+
+	problemHandler ProblemHandler
+
+	// strict enforces hooks. If false, then hooks are not applied to
+	// requests without operation context.
+	strict bool
+}
+
+func (mw *requestHooks) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	oi, ok := getOperationInfo(req)
+	if !ok {
+		if mw.strict {
+			panic("request hooks middleware: cannot find operation info in the request context")
+		}
+		mw.next.ServeHTTP(w, req)
+		return
+	}
+
+	op := wrapOperation(oi.operation)
+	for _, h := range mw.hooks {
+		r, err := h(req, op)
+		if err != nil {
+			mw.problemHandler.HandleProblem(NewProblem(w, req, err))
+			return
+		}
+		req = r
+	}
+
+	mw.next.ServeHTTP(w, req)
+}
+
+// ResponseHook mutates the validated response of the operation, e.g. to
+// inject a standard envelope or a trace id. It gets the decoded response
+// body that matches the schema and returns the body to send, which is
+// encoded as JSON. The header may be modified in place. If it returns an
+// error, the original response is sent and the error is handled by the
+// problem handler of the response validator.
+type ResponseHook func(req *http.Request, op *Operation, code int, header http.Header, body interface{}) (interface{}, error)
+
+// BasisResponseHook returns a basis option that registers the hook to be
+// run by ResponseBodyValidator on JSON responses that match the schema.
+// Hooks run in order of registration. The response validator holds the
+// response until it is validated, instead of streaming it through, so the
+// hooks work on the buffer of the validator and responses are not buffered
+// twice. It panics if the hook is nil.
+func BasisResponseHook(h ResponseHook) BasisOption {
+	if h == nil {
+		panic("oas: BasisResponseHook hook is nil")
+	}
+	return func(b *ResolvingBasis) {
+		b.responseHooks = append(b.responseHooks, h)
+	}
+}
+
+// serveHeld serves the request holding the response, validates it, runs
+// the response hooks on it if it is valid, and sends it.
+func (mw *responseBodyValidator) serveHeld(w http.ResponseWriter, req *http.Request, responses *spec.Responses) {
+	hw := &heldResponseWriter{header: w.Header()}
+	mw.next.ServeHTTP(hw, req)
+
+	data := hw.buf.Bytes()
+	body, valid := mw.validate(w, req, responses, hw.status(), hw.header, bytes.NewBuffer(data))
+	if valid {
+		if mutated, err := mw.runHooks(req, hw.status(), hw.header, body); err != nil {
+			mw.problemHandler.HandleProblem(newProblemOfKind(w, req, err, ProblemKindUnspecified))
+		} else {
+			data = mutated
+			hw.header.Del("Content-Length")
+		}
+	}
+
+	w.WriteHeader(hw.status())
+	w.Write(data) // nolint: errcheck
+}
+
+// runHooks runs the response hooks on the body and returns the encoded
+// mutated body.
+func (mw *responseBodyValidator) runHooks(req *http.Request, code int, header http.Header, body interface{}) ([]byte, error) {
+	var op *Operation
+	if oi, ok := getOperationInfo(req); ok {
+		op = wrapOperation(oi.operation)
+	}
+
+	var err error
+	for _, h := range mw.hooks {
+		body, err = h(req, op, code, header, body)
+		if err != nil {
+			return nil, fmt.Errorf("response hook: %s", err)
+		}
+	}
+
+	data, err := currentJSONAPI().Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("response hook: encode body: %s", err)
+	}
+	return data, nil
+}
+
+// heldResponseWriter is a response writer that holds the response instead
+// of sending it. The header is the header of the underlying writer, so
+// headers set by the handler are sent as is.
+type heldResponseWriter struct {
+	header http.Header
+	code   int
+	buf    bytes.Buffer
+}
+
+func (w *heldResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *heldResponseWriter) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+}
+
+func (w *heldResponseWriter) Write(b []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.buf.Write(b)
+}
+
+// status returns the status of the held response.
+func (w *heldResponseWriter) status() int {
+	if w.code == 0 {
+		return http.StatusOK
+	}
+	return w.code
+}
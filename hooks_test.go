@@ -0,0 +1,203 @@
+package oas
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolvingBasis_RequestValidator(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+
+	renameLegacyParams := func(req *http.Request, op *Operation) (*http.Request, error) {
+		q := req.URL.Query()
+		if user, ok := q["user"]; ok {
+			q["username"] = user
+			delete(q, "user")
+			req.URL.RawQuery = q.Encode()
+		}
+		return req, nil
+	}
+	rejectBanned := func(req *http.Request, op *Operation) (*http.Request, error) {
+		if req.URL.Query().Get("username") == "banned" {
+			return nil, errors.New("user is banned")
+		}
+		return req, nil
+	}
+	stampValidated := func(req *http.Request, op *Operation) (*http.Request, error) {
+		req.Header.Set("X-Validated", op.ID)
+		return req, nil
+	}
+
+	basis := NewResolvingBasis(SpecAdapterName, doc,
+		BasisStrict(false),
+		BasisRequestHook(HookPostValidation, stampValidated),
+		BasisRequestHook(HookPreValidation, renameLegacyParams),
+		BasisRequestHook(HookPreValidation, rejectBanned),
+	)
+
+	h := SpecMatcherMiddleware(doc)(
+		basis.RequestValidator()(
+			http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.Write([]byte(req.Header.Get("X-Validated"))) // nolint
+			}),
+		),
+	)
+
+	testCases := map[string]struct {
+		query        string
+		expectedCode int
+		expectedBody string
+	}{
+		"legacy param": {
+			query:        "user=alice&password=secret",
+			expectedCode: http.StatusOK,
+			expectedBody: "loginUser",
+		},
+		"invalid": {
+			query:        "password=secret",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "query params do not match the schema: param username is required",
+		},
+		"rejected by hook": {
+			query:        "user=banned&password=secret",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "user is banned",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v2/user/login?"+tc.query, nil))
+
+			assert.Equal(t, tc.expectedCode, w.Code)
+			assert.Equal(t, tc.expectedBody, strings.TrimSpace(w.Body.String()))
+		})
+	}
+}
+
+func TestResolvingBasis_RequestValidator_expectContinue(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+	basis := NewResolvingBasis(SpecAdapterName, doc, BasisStrict(false))
+
+	srv := httptest.NewServer(SpecMatcherMiddleware(doc)(
+		basis.RequestValidator()(http.HandlerFunc(handleAddPet)),
+	))
+	defer srv.Close()
+
+	testCases := map[string]struct {
+		query          string
+		expectedStatus string
+	}{
+		"valid query": {
+			query:          "debug=true",
+			expectedStatus: "HTTP/1.1 100 Continue",
+		},
+		"invalid query": {
+			query:          "debug=maybe",
+			expectedStatus: "HTTP/1.1 400 Bad Request",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			defer conn.Close()
+
+			// Send the headers only, as the client does before it gets
+			// 100 Continue.
+			fmt.Fprintf(conn, "POST /v2/pet?%s HTTP/1.1\r\n"+
+				"Host: petstore.swagger.io\r\n"+
+				"Content-Type: application/json\r\n"+
+				"Transfer-Encoding: chunked\r\n"+
+				"Expect: 100-continue\r\n\r\n", tc.query)
+
+			conn.SetReadDeadline(time.Now().Add(5 * time.Second)) // nolint: errcheck
+			status, err := bufio.NewReader(conn).ReadString('\n')
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			assert.Equal(t, tc.expectedStatus, strings.TrimSpace(status))
+		})
+	}
+}
+
+func TestBasisResponseHook(t *testing.T) {
+	doc := loadDocBytes([]byte(`
+swagger: "2.0"
+info:
+  title: Test
+  version: 1.0.0
+paths:
+  /greetings:
+    get:
+      operationId: getGreeting
+      produces:
+      - application/json
+      responses:
+        200:
+          description: OK
+          schema:
+            type: object
+            required: [message]
+            properties:
+              message:
+                type: string
+`))
+
+	traceID := func(req *http.Request, op *Operation, code int, header http.Header, body interface{}) (interface{}, error) {
+		header.Set("X-Trace-Id", "t-1")
+		return body, nil
+	}
+	envelope := func(req *http.Request, op *Operation, code int, header http.Header, body interface{}) (interface{}, error) {
+		return map[string]interface{}{"data": body, "meta": map[string]interface{}{"operation": op.ID}}, nil
+	}
+
+	var problems []error
+	basis := NewResolvingBasis(SpecAdapterName, doc,
+		BasisStrict(false),
+		BasisResponseHook(traceID),
+		BasisResponseHook(envelope),
+	)
+	serve := func(body string) *httptest.ResponseRecorder {
+		problems = nil
+		h := SpecMatcherMiddleware(doc)(
+			basis.ResponseBodyValidator(WithProblemHandler(ProblemHandlerFunc(func(p Problem) {
+				problems = append(problems, p.Cause())
+			})))(
+				http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusOK)
+					w.Write([]byte(body)) // nolint
+				}),
+			),
+		)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/greetings", nil))
+		return w
+	}
+
+	w := serve(`{"message":"hello"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "t-1", w.Header().Get("X-Trace-Id"))
+	assert.JSONEq(t, `{"data":{"message":"hello"},"meta":{"operation":"getGreeting"}}`, w.Body.String())
+	assert.Empty(t, problems)
+
+	w = serve(`{"msg":"hello"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "", w.Header().Get("X-Trace-Id"), "invalid responses are not mutated")
+	assert.Equal(t, `{"msg":"hello"}`, w.Body.String())
+	assert.Len(t, problems, 1)
+}
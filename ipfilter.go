@@ -0,0 +1,223 @@
+package oas
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// IP filter extensions, evaluated by IPFilter. They are lists of IP
+// addresses and CIDR ranges, declared on operations or on the spec root
+// for all operations:
+//
+//  x-oas-ip-deny:
+//  - 203.0.113.7
+//  paths:
+//    /admin/reindex:
+//      post:
+//        operationId: reindex
+//        x-oas-ip-allow:
+//        - 10.0.0.0/8
+//        - ::1
+//
+// Requests from denied addresses are rejected. If an allow list applies to
+// the operation, requests from addresses not in the list are rejected too.
+// An operation allow list replaces the global one, while both deny lists
+// apply.
+const (
+	IPAllowExtension = "x-oas-ip-allow"
+	IPDenyExtension  = "x-oas-ip-deny"
+)
+
+// WithTrustedProxies returns a middleware option that sets the addresses of
+// trusted proxies, as IP addresses or CIDR ranges, to resolve client
+// addresses with, as ClientIPResolver does. It panics if any address is
+// invalid.
+func WithTrustedProxies(proxies ...string) MiddlewareOption {
+	r, err := NewClientIPResolver(proxies...)
+	if err != nil {
+		panic(fmt.Sprintf("oas: WithTrustedProxies: %s", err))
+	}
+	return func(opts *MiddlewareOptions) {
+		opts.clientIPResolver = r
+	}
+}
+
+// ipRules are the IP allow and deny lists of an operation.
+type ipRules struct {
+	allow []*net.IPNet
+	deny  []*net.IPNet
+}
+
+// permits checks if the address is permitted by the rules.
+func (r ipRules) permits(ip net.IP) bool {
+	if containsIP(r.deny, ip) {
+		return false
+	}
+	return len(r.allow) == 0 || containsIP(r.allow, ip)
+}
+
+// compileIPRules compiles the IP lists of the operation, merged with the
+// global lists.
+func compileIPRules(global ipRules, ext map[string]interface{}) (ipRules, error) {
+	rules := ipRules{allow: global.allow, deny: global.deny}
+
+	allow, err := ipListExtension(ext, IPAllowExtension)
+	if err != nil {
+		return ipRules{}, err
+	}
+	if allow != nil {
+		rules.allow = allow
+	}
+
+	deny, err := ipListExtension(ext, IPDenyExtension)
+	if err != nil {
+		return ipRules{}, err
+	}
+	rules.deny = append(append([]*net.IPNet(nil), rules.deny...), deny...)
+
+	return rules, nil
+}
+
+// ipListExtension parses the IP list extension. It returns nil if there is
+// no such extension.
+func ipListExtension(ext map[string]interface{}, name string) ([]*net.IPNet, error) {
+	v, ok := ext[name]
+	if !ok {
+		return nil, nil
+	}
+
+	items, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid %s: value is not a list", name)
+	}
+	addrs := make([]string, 0, len(items))
+	for _, item := range items {
+		s, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid %s: %v is not a string", name, item)
+		}
+		addrs = append(addrs, s)
+	}
+
+	nets, err := parseIPNets(addrs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %s", name, err)
+	}
+	return nets, nil
+}
+
+// parseIPNets parses IP addresses and CIDR ranges. Addresses are parsed as
+// single address ranges.
+func parseIPNets(addrs []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(addrs))
+	for _, addr := range addrs {
+		if !strings.Contains(addr, "/") {
+			ip := net.ParseIP(addr)
+			if ip == nil {
+				return nil, fmt.Errorf("%s is not an IP address", addr)
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, n, err := net.ParseCIDR(addr)
+		if err != nil {
+			return nil, fmt.Errorf("%s is not a CIDR range", addr)
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+// containsIP checks if any of the ranges contains the address.
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// IPFilter returns a middleware that rejects requests from client addresses
+// denied by IPAllowExtension and IPDenyExtension lists of the operation and
+// the spec. Mount it early in the pipeline, right after the operation
+// context middleware. Client addresses are resolved with respect to
+// WithTrustedProxies, or taken from ClientIPContext middleware if the option
+// is not set.
+//
+// In case of rejection, this middleware responds with 403 by default.
+// It panics if any IP list in the spec is invalid.
+func (b *ResolvingBasis) IPFilter(opts ...MiddlewareOption) Middleware {
+	options := parseMiddlewareOptions(opts...)
+	if options.problemHandler == nil {
+		options.problemHandler = newProblemHandlerStatusResponder(http.StatusForbidden)
+	}
+
+	global, err := compileIPRules(ipRules{}, b.doc.Spec().Extensions)
+	if err != nil {
+		panic(fmt.Sprintf("oas: spec: %s", err))
+	}
+
+	operations := make(map[string]ipRules, len(b.cache))
+	for id, oi := range b.cache {
+		rules, err := compileIPRules(global, oi.operation.Extensions)
+		if err != nil {
+			panic(fmt.Sprintf("oas: operation %s: %s", id, err))
+		}
+		operations[id] = rules
+	}
+
+	return func(next http.Handler) http.Handler {
+		return &ipFilter{
+			next:           next,
+			global:         global,
+			operations:     operations,
+			resolver:       options.clientIPResolver,
+			problemHandler: options.problemHandler,
+		}
+	}
+}
+
+// ipFilter is a middleware that resolves operation context from the request
+// and rejects requests from denied client addresses. Requests without
+// operation context are filtered by the global lists.
+type ipFilter struct {
+	next http.Handler
+
+	global     ipRules
+	operations map[string]ipRules
+
+	resolver       *ClientIPResolver
+	problemHandler ProblemHandler
+}
+
+func (mw *ipFilter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	// Operations unknown to the basis, e.g. from WithOperation with another
+	// document, are filtered by the global lists.
+	rules := mw.global
+	if oi, ok := getOperationInfo(req); ok {
+		if r, ok := mw.operations[oi.operation.ID]; ok {
+			rules = r
+		}
+	}
+
+	if len(rules.allow) > 0 || len(rules.deny) > 0 {
+		ip := GetClientIP(req)
+		if mw.resolver != nil {
+			ip = mw.resolver.ClientIP(req)
+		}
+		if ip == nil || !rules.permits(ip) {
+			mw.problemHandler.HandleProblem(NewProblem(w, req, fmt.Errorf("client address %s is not allowed", ip)))
+			return
+		}
+	}
+
+	mw.next.ServeHTTP(w, req)
+}
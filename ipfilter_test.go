@@ -0,0 +1,182 @@
+package oas
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIPFilter(t *testing.T) {
+	doc := loadDocBytes([]byte(`
+swagger: "2.0"
+info:
+  title: Test
+  version: 1.0.0
+x-oas-ip-deny:
+- 203.0.113.7
+paths:
+  /pets:
+    get:
+      operationId: findPets
+      responses:
+        200:
+          description: OK
+  /reindex:
+    post:
+      operationId: reindex
+      x-oas-ip-allow:
+      - 10.0.0.0/8
+      - ::1
+      responses:
+        200:
+          description: OK
+`))
+
+	basis := NewResolvingBasis(SpecAdapterName, doc, BasisStrict(false))
+	h := SpecMatcherMiddleware(doc)(
+		basis.IPFilter(WithTrustedProxies("192.168.0.0/16"))(
+			http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}),
+		),
+	)
+
+	testCases := map[string]struct {
+		method       string
+		path         string
+		remoteAddr   string
+		forwarded    string
+		expectedCode int
+		expectedBody string
+	}{
+		"allowed by global lists": {
+			method:       http.MethodGet,
+			path:         "/pets",
+			remoteAddr:   "198.51.100.1:5000",
+			expectedCode: http.StatusOK,
+		},
+		"denied by global list": {
+			method:       http.MethodGet,
+			path:         "/pets",
+			remoteAddr:   "203.0.113.7:5000",
+			expectedCode: http.StatusForbidden,
+			expectedBody: "client address 203.0.113.7 is not allowed",
+		},
+		"allowed by operation list": {
+			method:       http.MethodPost,
+			path:         "/reindex",
+			remoteAddr:   "10.1.2.3:5000",
+			expectedCode: http.StatusOK,
+		},
+		"allowed IPv6": {
+			method:       http.MethodPost,
+			path:         "/reindex",
+			remoteAddr:   "[::1]:5000",
+			expectedCode: http.StatusOK,
+		},
+		"not in operation list": {
+			method:       http.MethodPost,
+			path:         "/reindex",
+			remoteAddr:   "198.51.100.1:5000",
+			expectedCode: http.StatusForbidden,
+			expectedBody: "client address 198.51.100.1 is not allowed",
+		},
+		"forwarded by trusted proxy": {
+			method:       http.MethodPost,
+			path:         "/reindex",
+			remoteAddr:   "192.168.1.1:5000",
+			forwarded:    "203.0.113.7, 10.1.2.3, 192.168.1.2",
+			expectedCode: http.StatusOK,
+		},
+		"forwarded by untrusted proxy": {
+			method:       http.MethodPost,
+			path:         "/reindex",
+			remoteAddr:   "198.51.100.1:5000",
+			forwarded:    "10.1.2.3",
+			expectedCode: http.StatusForbidden,
+			expectedBody: "client address 198.51.100.1 is not allowed",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			req.RemoteAddr = tc.remoteAddr
+			if tc.forwarded != "" {
+				req.Header.Set("X-Forwarded-For", tc.forwarded)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedCode, w.Code)
+			assert.Equal(t, tc.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestIPFilter_unknownOperation(t *testing.T) {
+	doc := loadDocBytes([]byte(`
+swagger: "2.0"
+info:
+  title: Test
+  version: 1.0.0
+x-oas-ip-deny:
+- 203.0.113.7
+paths:
+  /pets:
+    get:
+      operationId: findPets
+      responses:
+        200:
+          description: OK
+`))
+	other := loadDocBytes([]byte(`
+swagger: "2.0"
+info:
+  title: Other
+  version: 1.0.0
+paths:
+  /files:
+    get:
+      operationId: listFiles
+      responses:
+        200:
+          description: OK
+`))
+
+	basis := NewResolvingBasis(SpecAdapterName, doc, BasisStrict(false))
+	h := basis.IPFilter()(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+
+	req := httptest.NewRequest(http.MethodGet, "/files", nil)
+	req.RemoteAddr = "203.0.113.7:5000"
+	ctx, ok := WithOperation(req.Context(), other, "listFiles")
+	assert.True(t, ok)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req.WithContext(ctx))
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, "client address 203.0.113.7 is not allowed", w.Body.String())
+}
+
+func TestIPFilter_invalid(t *testing.T) {
+	doc := loadDocBytes([]byte(`
+swagger: "2.0"
+info:
+  title: Test
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      operationId: findPets
+      x-oas-ip-allow:
+      - 10.0.0.0/33
+      responses:
+        200:
+          description: OK
+`))
+
+	basis := NewResolvingBasis(SpecAdapterName, doc, BasisStrict(false))
+	assert.PanicsWithValue(t, "oas: operation findPets: invalid x-oas-ip-allow: 10.0.0.0/33 is not a CIDR range", func() {
+		basis.IPFilter()
+	})
+}
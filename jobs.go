@@ -0,0 +1,315 @@
+package oas
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-openapi/spec"
+)
+
+// JobStatusOperationID is the id of the job status operation added to the
+// served spec by SpecWithJobStatus.
+const JobStatusOperationID = "getJobStatus"
+
+// JobStatus is the status of an asynchronous job.
+type JobStatus string
+
+// Job statuses.
+const (
+	JobRunning   JobStatus = "running"
+	JobSucceeded JobStatus = "succeeded"
+	JobFailed    JobStatus = "failed"
+)
+
+// Job is an asynchronous job started by an operation that responds with
+// 202 Accepted.
+type Job struct {
+	ID          string    `json:"id"`
+	OperationID string    `json:"operationId,omitempty"`
+	Status      JobStatus `json:"status"`
+
+	// Result is the result of the succeeded job.
+	Result interface{} `json:"result,omitempty"`
+
+	// Error is the error message of the failed job.
+	Error string `json:"error,omitempty"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// JobStore stores jobs. Jobs may be shared between service instances, e.g.
+// by a store backed by a database, so any instance can serve the status.
+type JobStore interface {
+	// Save creates or updates the job.
+	Save(job Job) error
+
+	// Load returns the job by id. It returns false if there is no such job.
+	Load(id string) (Job, bool, error)
+}
+
+// MemoryJobStore is an in-memory JobStore for a single service instance.
+// Finished jobs are kept for the retention period after they are saved, so
+// clients have time to poll their status; running jobs are kept until they
+// finish. It is safe for concurrent use.
+type MemoryJobStore struct {
+	mx        sync.Mutex
+	jobs      map[string]memoryJobStoreItem
+	retention time.Duration
+	sweepAt   time.Time
+	clock     Clock
+}
+
+type memoryJobStoreItem struct {
+	job     Job
+	expires time.Time
+}
+
+// expired checks if the item is expired at the time.
+func (it memoryJobStoreItem) expired(now time.Time) bool {
+	return !it.expires.IsZero() && !it.expires.After(now)
+}
+
+// jobStoreSweepInterval is the interval between removals of expired jobs
+// from MemoryJobStore.
+const jobStoreSweepInterval = time.Minute
+
+// NewMemoryJobStore returns a new MemoryJobStore that keeps finished jobs
+// for the retention period. Zero retention keeps finished jobs forever.
+func NewMemoryJobStore(retention time.Duration, opts ...StoreOption) *MemoryJobStore {
+	return &MemoryJobStore{
+		jobs:      make(map[string]memoryJobStoreItem),
+		retention: retention,
+		clock:     parseStoreOptions(opts).clock,
+	}
+}
+
+// sweep removes expired jobs from time to time. It must be called with the
+// lock held.
+func (s *MemoryJobStore) sweep(now time.Time) {
+	if !now.After(s.sweepAt) {
+		return
+	}
+	for id, it := range s.jobs {
+		if it.expired(now) {
+			delete(s.jobs, id)
+		}
+	}
+	s.sweepAt = now.Add(jobStoreSweepInterval)
+}
+
+// Save implements JobStore.
+func (s *MemoryJobStore) Save(job Job) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	now := s.clock.Now()
+	s.sweep(now)
+
+	it := memoryJobStoreItem{job: job}
+	if job.Status != JobRunning && s.retention > 0 {
+		it.expires = now.Add(s.retention)
+	}
+	s.jobs[job.ID] = it
+	return nil
+}
+
+// Load implements JobStore.
+func (s *MemoryJobStore) Load(id string) (Job, bool, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	now := s.clock.Now()
+	s.sweep(now)
+
+	it, ok := s.jobs[id]
+	if !ok || it.expired(now) {
+		return Job{}, false, nil
+	}
+	return it.job, true, nil
+}
+
+// JobFunc is the work of an asynchronous job. It returns the job result.
+type JobFunc func(ctx context.Context) (interface{}, error)
+
+// Jobs runs long-running operations asynchronously: handlers accept requests
+// with 202 Accepted and the URL of the job status, which clients poll until
+// the job is done:
+//
+//  jobs := oas.NewJobs(doc, oas.NewMemoryJobStore(24*time.Hour), "/jobs")
+//  mux.Handle("/v2/jobs/", jobs.StatusHandler())
+//  specHandler := oas.NewStaticSpecHandler(doc, oas.SpecWithJobStatus("/jobs"))
+//
+//  func exportPets(w http.ResponseWriter, req *http.Request) {
+//      jobs.Accept(w, req, func(ctx context.Context) (interface{}, error) {
+//          return store.ExportPets(ctx)
+//      })
+//  }
+//
+// The status path is a spec path, i.e. relative to the spec base path, and
+// SpecWithJobStatus documents the status operation in the served spec.
+type Jobs struct {
+	store    JobStore
+	basePath string
+	path     string
+
+	wg sync.WaitGroup
+}
+
+// NewJobs returns a new Jobs that stores jobs in the store and serves job
+// statuses on the path.
+func NewJobs(doc *Document, store JobStore, path string) *Jobs {
+	return &Jobs{
+		store:    store,
+		basePath: doc.BasePath(),
+		path:     strings.TrimSuffix(path, "/"),
+	}
+}
+
+// Accept starts the job and responds with 202 Accepted, the Location header
+// set to the job status URL, and the job as JSON. The job is run with
+// a context that is not canceled when the request is done.
+func (j *Jobs) Accept(w http.ResponseWriter, req *http.Request, fn JobFunc) (Job, error) {
+	id, err := newJobID()
+	if err != nil {
+		return Job{}, fmt.Errorf("accept job: %s", err)
+	}
+
+	now := time.Now()
+	job := Job{ID: id, Status: JobRunning, CreatedAt: now, UpdatedAt: now}
+	if oi, ok := getOperationInfo(req); ok {
+		job.OperationID = oi.operation.ID
+	}
+	if err := j.store.Save(job); err != nil {
+		return Job{}, fmt.Errorf("accept job: %s", err)
+	}
+
+	j.wg.Add(1)
+	go j.run(job, fn)
+
+	w.Header().Set("Location", j.StatusURL(id))
+	writeJSON(w, http.StatusAccepted, job)
+	return job, nil
+}
+
+// run runs the job and saves its result.
+func (j *Jobs) run(job Job, fn JobFunc) {
+	defer j.wg.Done()
+
+	result, err := runJob(fn)
+	if err != nil {
+		job.Status, job.Error = JobFailed, err.Error()
+	} else {
+		job.Status, job.Result = JobSucceeded, result
+	}
+	job.UpdatedAt = time.Now()
+	j.store.Save(job) // nolint: errcheck
+}
+
+// runJob runs the job, recovering from panics.
+func runJob(fn JobFunc) (result interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+
+	return fn(context.Background())
+}
+
+// Wait waits for the running jobs to finish, e.g. on graceful shutdown.
+func (j *Jobs) Wait() {
+	j.wg.Wait()
+}
+
+// StatusURL returns the URL of the job status.
+func (j *Jobs) StatusURL(id string) string {
+	return joinBasePath(j.basePath, j.path+"/"+id)
+}
+
+// StatusHandler returns the handler of the job status operation. It serves
+// GET requests to the job status URLs, and responds with the job as JSON,
+// or 404 Not Found if there is no such job.
+func (j *Jobs) StatusHandler() http.Handler {
+	prefix := joinBasePath(j.basePath, j.path) + "/"
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet || !strings.HasPrefix(req.URL.Path, prefix) {
+			http.NotFound(w, req)
+			return
+		}
+
+		job, ok, err := j.store.Load(strings.TrimPrefix(req.URL.Path, prefix))
+		switch {
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		case !ok:
+			http.NotFound(w, req)
+		default:
+			writeJSON(w, http.StatusOK, job)
+		}
+	})
+}
+
+// newJobID returns a new random job id.
+func newJobID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// SpecWithJobStatus returns a spec handler option that augments the served
+// spec with the job status operation on the path, e.g. "/jobs", and the Job
+// definition, as served by Jobs.StatusHandler.
+func SpecWithJobStatus(path string) SpecHandlerOption {
+	return func(o *specHandlerOptions) {
+		o.jobStatusPath = strings.TrimSuffix(path, "/")
+	}
+}
+
+// addJobStatusOperation adds the job status operation on the path and the
+// Job definition to the spec.
+func addJobStatusOperation(s *spec.Swagger, path string) {
+	job := spec.Schema{}
+	job.Typed("object", "")
+	job.Required = []string{"id", "status", "createdAt", "updatedAt"}
+	job.SetProperty("id", *spec.StringProperty())
+	job.SetProperty("operationId", *spec.StringProperty())
+	job.SetProperty("status", *spec.StringProperty().WithEnum(string(JobRunning), string(JobSucceeded), string(JobFailed)))
+	job.SetProperty("result", spec.Schema{})
+	job.SetProperty("error", *spec.StringProperty())
+	job.SetProperty("createdAt", *spec.DateTimeProperty())
+	job.SetProperty("updatedAt", *spec.DateTimeProperty())
+
+	if s.Definitions == nil {
+		s.Definitions = make(spec.Definitions)
+	}
+	s.Definitions["Job"] = job
+
+	op := spec.NewOperation(JobStatusOperationID).
+		WithSummary("Get the status of an asynchronous job").
+		WithProduces("application/json").
+		AddParam(spec.PathParam("jobId").Typed("string", "")).
+		RespondsWith(http.StatusOK, spec.NewResponse().
+			WithDescription("The job").
+			WithSchema(spec.RefProperty("#/definitions/Job"))).
+		RespondsWith(http.StatusNotFound, spec.NewResponse().
+			WithDescription("The job is not found"))
+
+	if s.Paths == nil {
+		s.Paths = &spec.Paths{}
+	}
+	if s.Paths.Paths == nil {
+		s.Paths.Paths = make(map[string]spec.PathItem)
+	}
+	item := s.Paths.Paths[path+"/{jobId}"]
+	item.Get = op
+	s.Paths.Paths[path+"/{jobId}"] = item
+}
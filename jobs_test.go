@@ -0,0 +1,109 @@
+package oas
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/go-openapi/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobs(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+	jobs := NewJobs(doc, NewMemoryJobStore(time.Hour), "/jobs")
+
+	accept := func(fn JobFunc) Job {
+		req := httptest.NewRequest(http.MethodPost, "/v2/pet", nil)
+		ctx, _ := WithOperation(req.Context(), doc, "addPet")
+		w := httptest.NewRecorder()
+
+		job, err := jobs.Accept(w, req.WithContext(ctx), fn)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		assert.Equal(t, "/v2/jobs/"+job.ID, w.Header().Get("Location"))
+		assert.Equal(t, JobRunning, job.Status)
+		assert.Equal(t, "addPet", job.OperationID)
+		return job
+	}
+
+	status := func(url string) (int, Job) {
+		w := httptest.NewRecorder()
+		jobs.StatusHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+
+		var job Job
+		if w.Code == http.StatusOK {
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &job))
+		}
+		return w.Code, job
+	}
+
+	succeeded := accept(func(ctx context.Context) (interface{}, error) {
+		return map[string]int{"id": 12}, nil
+	})
+	failed := accept(func(ctx context.Context) (interface{}, error) {
+		return nil, errors.New("store is unavailable")
+	})
+	jobs.Wait()
+
+	code, job := status(jobs.StatusURL(succeeded.ID))
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, JobSucceeded, job.Status)
+	assert.Equal(t, map[string]interface{}{"id": float64(12)}, job.Result)
+
+	code, job = status(jobs.StatusURL(failed.ID))
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, JobFailed, job.Status)
+	assert.Equal(t, "store is unavailable", job.Error)
+
+	code, _ = status("/v2/jobs/unknown")
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
+func TestMemoryJobStore_retention(t *testing.T) {
+	clock := NewFrozenClock(time.Date(2019, 3, 1, 12, 0, 0, 0, time.UTC))
+	store := NewMemoryJobStore(time.Hour, StoreClock(clock))
+
+	assert.NoError(t, store.Save(Job{ID: "running", Status: JobRunning}))
+	assert.NoError(t, store.Save(Job{ID: "done", Status: JobSucceeded}))
+
+	clock.Advance(59 * time.Minute)
+	_, ok, err := store.Load("done")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	clock.Advance(2 * time.Minute)
+	_, ok, err = store.Load("done")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.NotContains(t, store.jobs, "done")
+
+	_, ok, err = store.Load("running")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestSpecWithJobStatus(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+
+	w := httptest.NewRecorder()
+	NewStaticSpecHandler(doc, SpecWithJobStatus("/jobs")).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	var s spec.Swagger
+	if !assert.NoError(t, yaml.Unmarshal(w.Body.Bytes(), &s)) {
+		return
+	}
+
+	op := s.Paths.Paths["/jobs/{jobId}"].Get
+	if assert.NotNil(t, op) {
+		assert.Equal(t, JobStatusOperationID, op.ID)
+		assert.Equal(t, "#/definitions/Job", op.Responses.StatusCodeResponses[http.StatusOK].Schema.Ref.String())
+	}
+	assert.Contains(t, s.Definitions, "Job")
+	assert.Nil(t, doc.Spec().Paths.Paths["/jobs/{jobId}"].Get, "document spec must not be modified")
+}
@@ -0,0 +1,127 @@
+package oas
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"sync"
+)
+
+// JSONAPI is a JSON engine. It is used by body validators, DecodeBody,
+// WriteResponse, RequestMessage and problem handlers that respond with
+// JSON problems, so high-throughput services can swap the engine.
+//
+// Engines compatible with encoding/json implement JSONAPI as is, e.g.:
+//
+//  oas.UseJSONAPI(jsoniter.ConfigCompatibleWithStandardLibrary)
+//
+// Engines that provide package-level functions can be adapted with
+// JSONAPIFuncs, e.g. for github.com/goccy/go-json:
+//
+//  oas.UseJSONAPI(oas.JSONAPIFuncs{
+//      MarshalFunc:   gojson.Marshal,
+//      UnmarshalFunc: gojson.Unmarshal,
+//      ValidFunc:     gojson.Valid,
+//  })
+type JSONAPI interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+	Valid(data []byte) bool
+}
+
+// StdJSON is the JSONAPI of encoding/json. It is used by default.
+var StdJSON JSONAPI = stdJSON{}
+
+type stdJSON struct{}
+
+func (stdJSON) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (stdJSON) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (stdJSON) Valid(data []byte) bool {
+	return json.Valid(data)
+}
+
+// JSONAPIFuncs adapts JSON engine functions to JSONAPI.
+type JSONAPIFuncs struct {
+	MarshalFunc   func(v interface{}) ([]byte, error)
+	UnmarshalFunc func(data []byte, v interface{}) error
+	ValidFunc     func(data []byte) bool
+}
+
+// Marshal implements JSONAPI.
+func (f JSONAPIFuncs) Marshal(v interface{}) ([]byte, error) {
+	return f.MarshalFunc(v)
+}
+
+// Unmarshal implements JSONAPI.
+func (f JSONAPIFuncs) Unmarshal(data []byte, v interface{}) error {
+	return f.UnmarshalFunc(data, v)
+}
+
+// Valid implements JSONAPI.
+func (f JSONAPIFuncs) Valid(data []byte) bool {
+	return f.ValidFunc(data)
+}
+
+var (
+	jsonAPIMx sync.RWMutex
+	jsonAPI   = StdJSON
+)
+
+// UseJSONAPI makes the package use the JSON engine. It should be called
+// before serving requests, e.g. in main. If api is nil, it panics.
+//
+// JSON numbers are still decoded by encoding/json when WithJSONNumbers or
+// DecodeJSONNumbers options are used, because json.Number is specific to it.
+func UseJSONAPI(api JSONAPI) {
+	if api == nil {
+		panic("oas: UseJSONAPI api is nil")
+	}
+
+	jsonAPIMx.Lock()
+	defer jsonAPIMx.Unlock()
+	jsonAPI = api
+}
+
+// currentJSONAPI returns the JSON engine in use.
+func currentJSONAPI() JSONAPI {
+	jsonAPIMx.RLock()
+	defer jsonAPIMx.RUnlock()
+	return jsonAPI
+}
+
+// decodeJSON decodes JSON from r to v with the JSON engine in use. If
+// useNumber is true, encoding/json is used to decode numbers as json.Number.
+func decodeJSON(r io.Reader, v interface{}, useNumber bool) error {
+	api := currentJSONAPI()
+	if useNumber || api == StdJSON {
+		return newJSONDecoder(r, useNumber).Decode(v)
+	}
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if !api.Valid(data) {
+		// Engines report errors differently, so locate the error with
+		// encoding/json to report it consistently.
+		return json.NewDecoder(bytes.NewReader(data)).Decode(v)
+	}
+	return api.Unmarshal(data, v)
+}
+
+// newJSONDecoder returns a new JSON decoder that reads from r. If useNumber
+// is true, the decoder decodes numbers as json.Number.
+func newJSONDecoder(r io.Reader, useNumber bool) *json.Decoder {
+	dec := json.NewDecoder(r)
+	if useNumber {
+		dec.UseNumber()
+	}
+	return dec
+}
@@ -0,0 +1,69 @@
+package oas
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUseJSONAPI(t *testing.T) {
+	var calls []string
+	UseJSONAPI(JSONAPIFuncs{
+		MarshalFunc: func(v interface{}) ([]byte, error) {
+			calls = append(calls, "marshal")
+			return json.Marshal(v)
+		},
+		UnmarshalFunc: func(data []byte, v interface{}) error {
+			calls = append(calls, "unmarshal")
+			return json.Unmarshal(data, v)
+		},
+		ValidFunc: func(data []byte) bool {
+			calls = append(calls, "valid")
+			return json.Valid(data)
+		},
+	})
+	defer UseJSONAPI(StdJSON)
+
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+	params := doc.Analyzer.ParametersFor("addPet")
+
+	v := &requestBodyValidator{
+		next:           http.HandlerFunc(handleAddPet),
+		jsonSelectors:  []*regexp.Regexp{contentTypeSelectorRegexJSON},
+		problemHandler: newProblemHandlerErrorResponder(),
+	}
+
+	t.Run("valid json body", func(t *testing.T) {
+		calls = nil
+
+		req := httptest.NewRequest(http.MethodPost, "/v2/pet", bytes.NewBufferString(`{"name":"johndoe","age":7}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		v.ServeHTTP(w, req, params, true)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, []string{"valid", "unmarshal"}, calls)
+	})
+
+	t.Run("invalid json body", func(t *testing.T) {
+		calls = nil
+
+		req := httptest.NewRequest(http.MethodPost, "/v2/pet", bytes.NewBufferString(`{"name":"johndoe`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		v.ServeHTTP(w, req, params, true)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "request body contains invalid json: unexpected EOF at line 1, column 16 (offset 16)", w.Body.String())
+		assert.Equal(t, []string{"valid"}, calls)
+	})
+
+	t.Run("nil api", func(t *testing.T) {
+		assert.Panics(t, func() { UseJSONAPI(nil) })
+	})
+}
@@ -0,0 +1,211 @@
+package oas
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/go-openapi/spec"
+
+	"github.com/hypnoglow/oas2/validate"
+)
+
+// pathParamTemplate matches path parameters in path templates.
+var pathParamTemplate = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// LinkBuilder builds URLs of the document operations, e.g. for links in
+// responses, so handlers never format paths by hand:
+//
+//  links := oas.NewLinkBuilder(doc)
+//  self, err := links.URL("getPetById", pet.ID)
+//
+// Parameter values are validated against the operation parameters, so the
+// links always conform to the spec.
+type LinkBuilder struct {
+	doc *Document
+
+	// baseURL is the external base URL of the API. If nil, absolute URLs
+	// are built with the spec host and schemes.
+	baseURL *url.URL
+
+	// trustForwarded defines if X-Forwarded-* headers should be used
+	// to determine the host and the scheme of absolute URLs.
+	trustForwarded bool
+
+	// locations map create operations to retrieve operations.
+	locations map[string]string
+}
+
+// LinkOption is an option for NewLinkBuilder.
+type LinkOption func(*LinkBuilder)
+
+// LinkBaseURL returns an option that sets the external base URL of the API,
+// e.g. "https://api.example.com/petstore" when the service is exposed by
+// a gateway. Absolute URLs are built with its scheme and host instead of
+// the spec ones, and its path is prepended to the operation URLs.
+// NewLinkBuilder panics if the URL is not absolute.
+func LinkBaseURL(base string) LinkOption {
+	return func(b *LinkBuilder) {
+		u, err := url.Parse(base)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			panic(fmt.Sprintf("oas: LinkBaseURL %s is not an absolute URL", base))
+		}
+		b.baseURL = u
+	}
+}
+
+// LinkTrustForwardedHeaders returns an option that defines if X-Forwarded-Host
+// and X-Forwarded-Proto headers of the request should be used to determine
+// the host and the scheme of absolute URLs. Enable it only when the service
+// is behind a proxy that sets these headers.
+func LinkTrustForwardedHeaders(trust bool) LinkOption {
+	return func(b *LinkBuilder) {
+		b.trustForwarded = trust
+	}
+}
+
+// LinkLocations returns an option that maps the ids of create operations to
+// the ids of the operations that retrieve the created resources, used by
+// WriteCreated. The mapping takes precedence over LocationExtension.
+func LinkLocations(locations map[string]string) LinkOption {
+	return func(b *LinkBuilder) {
+		b.locations = locations
+	}
+}
+
+// NewLinkBuilder returns a new LinkBuilder for the document.
+func NewLinkBuilder(doc *Document, opts ...LinkOption) *LinkBuilder {
+	b := &LinkBuilder{doc: doc}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// URL returns the URL of the operation, i.e. its path prefixed with the spec
+// base path, with path parameters filled by args in order of appearance in
+// the path template. Values are formatted with fmt.Sprint.
+func (b *LinkBuilder) URL(operationID string, args ...interface{}) (string, error) {
+	return b.URLWithQuery(operationID, nil, args...)
+}
+
+// URLWithQuery is like URL, but also adds the query to the URL. The query is
+// validated against the operation query parameters.
+func (b *LinkBuilder) URLWithQuery(operationID string, query url.Values, args ...interface{}) (string, error) {
+	_, path, op, ok := b.doc.Analyzer.OperationForName(operationID)
+	if !ok {
+		return "", fmt.Errorf("link: operation %s is not found in the spec", operationID)
+	}
+	params := b.doc.parametersFor(path, op)
+
+	names := pathParamTemplate.FindAllStringSubmatch(path, -1)
+	if len(names) != len(args) {
+		return "", fmt.Errorf("link: operation %s has %d path parameters, but %d values are given", operationID, len(names), len(args))
+	}
+
+	var errs []error
+	values := make(map[string]string, len(args))
+	for i, m := range names {
+		name := m[1]
+		values[name] = fmt.Sprint(args[i])
+		if p, ok := findParam(params, "path", name); ok {
+			errs = append(errs, validatePathParam(p, values[name])...)
+		}
+	}
+
+	if query == nil {
+		query = url.Values{}
+	}
+	q := make(url.Values, len(query))
+	for k, v := range query {
+		q[k] = v
+	}
+	errs = append(errs, validate.Query(params, q)...)
+
+	if len(errs) > 0 {
+		return "", newMultiError(fmt.Sprintf("link: values do not match operation %s", operationID), errs...)
+	}
+
+	u := pathParamTemplate.ReplaceAllStringFunc(path, func(s string) string {
+		return url.PathEscape(values[s[1:len(s)-1]])
+	})
+	u = joinBasePath(b.doc.BasePath(), u)
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	return u, nil
+}
+
+// AbsoluteURL is like URL, but returns the absolute URL, e.g. for Location
+// headers. The URL is built with the base URL set by LinkBaseURL. Otherwise,
+// the spec host is used, or the host of the request, if the spec defines no
+// host. The scheme of the request is used if the spec allows it, otherwise
+// https is preferred among the spec schemes. The request may be nil if the
+// base URL or the spec host is set.
+func (b *LinkBuilder) AbsoluteURL(req *http.Request, operationID string, args ...interface{}) (string, error) {
+	return b.AbsoluteURLWithQuery(req, operationID, nil, args...)
+}
+
+// AbsoluteURLWithQuery is like AbsoluteURL, but also adds the query to the
+// URL, like URLWithQuery does.
+func (b *LinkBuilder) AbsoluteURLWithQuery(req *http.Request, operationID string, query url.Values, args ...interface{}) (string, error) {
+	u, err := b.URLWithQuery(operationID, query, args...)
+	if err != nil {
+		return "", err
+	}
+
+	if b.baseURL != nil {
+		return b.baseURL.Scheme + "://" + b.baseURL.Host + strings.TrimSuffix(b.baseURL.Path, "/") + u, nil
+	}
+
+	host := b.doc.Spec().Host
+	if host == "" {
+		if req == nil {
+			return "", fmt.Errorf("link: cannot build absolute URL of operation %s, as neither the spec nor the request defines the host", operationID)
+		}
+		host = requestHost(req, b.trustForwarded)
+	}
+
+	return b.scheme(req) + "://" + host + u, nil
+}
+
+// scheme returns the scheme of absolute URLs for the request.
+func (b *LinkBuilder) scheme(req *http.Request) string {
+	schemes := b.doc.Spec().Schemes
+
+	if req != nil {
+		scheme := requestScheme(req, b.trustForwarded)
+		if matchScheme(scheme, schemes) {
+			return scheme
+		}
+	}
+
+	for _, s := range schemes {
+		if strings.EqualFold(s, "https") {
+			return "https"
+		}
+	}
+	if len(schemes) > 0 {
+		return strings.ToLower(schemes[0])
+	}
+	return "https"
+}
+
+// findParam returns the parameter by location and name.
+func findParam(params []spec.Parameter, in, name string) (spec.Parameter, bool) {
+	for _, p := range params {
+		if p.In == in && p.Name == name {
+			return p, true
+		}
+	}
+	return spec.Parameter{}, false
+}
+
+// validatePathParam validates the path parameter value. Path parameters are
+// validated the same way as query parameters are.
+func validatePathParam(p spec.Parameter, value string) []error {
+	p.In = "query"
+	return validate.Query([]spec.Parameter{p}, url.Values{p.Name: {value}})
+}
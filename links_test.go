@@ -0,0 +1,141 @@
+package oas
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLinkBuilder(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+	links := NewLinkBuilder(doc)
+
+	testCases := map[string]struct {
+		operationID string
+		query       url.Values
+		args        []interface{}
+		expected    string
+		expectedErr string
+	}{
+		"path params": {
+			operationID: "getPetById",
+			args:        []interface{}{int64(12)},
+			expected:    "/v2/pet/12",
+		},
+		"query params": {
+			operationID: "loginUser",
+			query:       url.Values{"username": {"john doe"}, "password": {"123"}},
+			expected:    "/v2/user/login?password=123&username=john+doe",
+		},
+		"invalid path param": {
+			operationID: "getPetById",
+			args:        []interface{}{"rex"},
+			expectedErr: "link: values do not match operation getPetById: param petId: cannot convert rex to int64",
+		},
+		"missing query param": {
+			operationID: "loginUser",
+			query:       url.Values{"username": {"john"}},
+			expectedErr: "link: values do not match operation loginUser: param password is required",
+		},
+		"wrong number of args": {
+			operationID: "getPetById",
+			expectedErr: "link: operation getPetById has 1 path parameters, but 0 values are given",
+		},
+		"unknown operation": {
+			operationID: "deletePet",
+			expectedErr: "link: operation deletePet is not found in the spec",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			u, err := links.URLWithQuery(tc.operationID, tc.query, tc.args...)
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, u)
+		})
+	}
+}
+
+func TestLinkBuilder_AbsoluteURL(t *testing.T) {
+	petstore := loadDocFile(t, "testdata/petstore_1.yml")
+	hostless := loadDocBytes([]byte(`
+swagger: "2.0"
+info:
+  title: Test
+  version: 1.0.0
+basePath: /v2
+schemes: [http, https]
+paths:
+  /pets/{id}:
+    get:
+      operationId: getPet
+      parameters:
+      - name: id
+        in: path
+        type: integer
+        required: true
+      responses:
+        200:
+          description: OK
+`))
+
+	forwarded := httptest.NewRequest(http.MethodPost, "/v2/pets", nil)
+	forwarded.Header.Set("X-Forwarded-Host", "api.example.com")
+	forwarded.Header.Set("X-Forwarded-Proto", "https")
+
+	testCases := map[string]struct {
+		links       *LinkBuilder
+		req         *http.Request
+		operationID string
+		expected    string
+		expectedErr string
+	}{
+		"spec host and scheme": {
+			links:       NewLinkBuilder(petstore),
+			operationID: "getPetById",
+			expected:    "http://petstore.swagger.io/v2/pet/12",
+		},
+		"base url": {
+			links:       NewLinkBuilder(petstore, LinkBaseURL("https://gw.example.com/petstore/")),
+			req:         forwarded,
+			operationID: "getPetById",
+			expected:    "https://gw.example.com/petstore/v2/pet/12",
+		},
+		"request host": {
+			links:       NewLinkBuilder(hostless),
+			req:         forwarded,
+			operationID: "getPet",
+			expected:    "http://example.com/v2/pets/12",
+		},
+		"forwarded host": {
+			links:       NewLinkBuilder(hostless, LinkTrustForwardedHeaders(true)),
+			req:         forwarded,
+			operationID: "getPet",
+			expected:    "https://api.example.com/v2/pets/12",
+		},
+		"no host": {
+			links:       NewLinkBuilder(hostless),
+			operationID: "getPet",
+			expectedErr: "link: cannot build absolute URL of operation getPet, as neither the spec nor the request defines the host",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			u, err := tc.links.AbsoluteURL(tc.req, tc.operationID, 12)
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, u)
+		})
+	}
+}
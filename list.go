@@ -0,0 +1,147 @@
+package oas
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/go-openapi/spec"
+)
+
+// List query parameters, parsed by ParseListQuery.
+const (
+	// SortParam is the query parameter with comma separated fields to sort
+	// the list by, e.g. "sort=-createdAt,name". Fields prefixed with "-"
+	// are sorted in descending order.
+	SortParam = "sort"
+
+	// FilterParamPrefix is the prefix of query parameters that filter the
+	// list by fields, e.g. "filter[status]=sold".
+	FilterParamPrefix = "filter"
+)
+
+// SortField is a field to sort a list by.
+type SortField struct {
+	Name string
+	Desc bool
+}
+
+// ListFilter is a filter of a list by field value.
+type ListFilter struct {
+	Name  string
+	Value string
+}
+
+// ListQuery is the sorting and filtering of a list requested by the client.
+type ListQuery struct {
+	Sort    []SortField
+	Filters []ListFilter
+}
+
+// ParseListQuery parses SortParam and FilterParamPrefix parameters of the
+// request to the list operation, and validates the fields against the
+// properties of the list items in the successful response schema, so the
+// query semantics are tied to the contract:
+//
+//  q, err := oas.ParseListQuery(req)
+//  if err != nil {
+//      http.Error(w, err.Error(), http.StatusBadRequest)
+//      return
+//  }
+//  pets, err := store.ListPets(q.Sort, q.Filters)
+//
+// Fields of nested objects are separated by dots, e.g. "owner.name". Only
+// fields of primitive types can be used, and filters are ordered by names.
+// The request must have operation context.
+func ParseListQuery(req *http.Request) (ListQuery, error) {
+	oi, ok := getOperationInfo(req)
+	if !ok {
+		return ListQuery{}, errors.New("parse list query: cannot find OpenAPI operation info in the request context")
+	}
+
+	items, err := listItemsSchema(oi.operation)
+	if err != nil {
+		return ListQuery{}, fmt.Errorf("parse list query: %s", err)
+	}
+
+	var q ListQuery
+	query := req.URL.Query()
+
+	if s := query.Get(SortParam); s != "" {
+		for _, name := range strings.Split(s, ",") {
+			f := SortField{Name: strings.TrimSpace(name)}
+			if strings.HasPrefix(f.Name, "-") {
+				f.Name, f.Desc = f.Name[1:], true
+			}
+			if err := checkListField(items, f.Name); err != nil {
+				return ListQuery{}, fmt.Errorf("sort field %s: %s", f.Name, err)
+			}
+			q.Sort = append(q.Sort, f)
+		}
+	}
+
+	for key, values := range query {
+		if !strings.HasPrefix(key, FilterParamPrefix+"[") || !strings.HasSuffix(key, "]") {
+			continue
+		}
+		name := key[len(FilterParamPrefix)+1 : len(key)-1]
+		if err := checkListField(items, name); err != nil {
+			return ListQuery{}, fmt.Errorf("filter field %s: %s", name, err)
+		}
+		for _, v := range values {
+			q.Filters = append(q.Filters, ListFilter{Name: name, Value: v})
+		}
+	}
+	sort.SliceStable(q.Filters, func(i, j int) bool {
+		return q.Filters[i].Name < q.Filters[j].Name
+	})
+
+	return q, nil
+}
+
+// listItemsSchema returns the schema of the list items in the successful
+// response of the operation.
+func listItemsSchema(op *spec.Operation) (*spec.Schema, error) {
+	if op.Responses != nil {
+		codes := make([]int, 0, len(op.Responses.StatusCodeResponses))
+		for code := range op.Responses.StatusCodeResponses {
+			codes = append(codes, code)
+		}
+		sort.Ints(codes)
+
+		for _, code := range codes {
+			if code < 200 || code >= 300 {
+				continue
+			}
+			s := op.Responses.StatusCodeResponses[code].Schema
+			if s != nil && schemaType(s) == "array" && s.Items != nil && s.Items.Schema != nil {
+				return s.Items.Schema, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("operation %s does not respond with a list", op.ID)
+}
+
+// checkListField checks that the dot separated field is a declared property
+// of primitive type.
+func checkListField(s *spec.Schema, field string) error {
+	if field == "" {
+		return errors.New("field name is empty")
+	}
+
+	for _, name := range strings.Split(field, ".") {
+		prop, ok := schemaProperty(s, name)
+		if !ok {
+			return errors.New("field is not declared in the list items")
+		}
+		s = prop
+	}
+
+	switch schemaType(s) {
+	case "object", "array":
+		return fmt.Errorf("field of type %s cannot be used", schemaType(s))
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package oas
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseListQuery(t *testing.T) {
+	doc := loadDocBytes([]byte(`
+swagger: "2.0"
+info:
+  title: Test
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      responses:
+        200:
+          description: OK
+          schema:
+            type: array
+            items:
+              type: object
+              properties:
+                name:
+                  type: string
+                status:
+                  type: string
+                tags:
+                  type: array
+                  items:
+                    type: string
+                owner:
+                  type: object
+                  properties:
+                    name:
+                      type: string
+  /pets/{id}:
+    get:
+      operationId: getPet
+      parameters:
+      - name: id
+        in: path
+        type: integer
+        required: true
+      responses:
+        200:
+          description: OK
+          schema:
+            type: object
+`))
+
+	testCases := map[string]struct {
+		operationID string
+		query       string
+		expected    ListQuery
+		expectedErr string
+	}{
+		"sort and filter": {
+			operationID: "listPets",
+			query:       "sort=-status,owner.name&filter[status]=sold&filter[name]=Rex",
+			expected: ListQuery{
+				Sort:    []SortField{{Name: "status", Desc: true}, {Name: "owner.name"}},
+				Filters: []ListFilter{{Name: "name", Value: "Rex"}, {Name: "status", Value: "sold"}},
+			},
+		},
+		"empty": {
+			operationID: "listPets",
+		},
+		"undeclared sort field": {
+			operationID: "listPets",
+			query:       "sort=age",
+			expectedErr: "sort field age: field is not declared in the list items",
+		},
+		"undeclared filter field": {
+			operationID: "listPets",
+			query:       "filter[owner.email]=john@example.com",
+			expectedErr: "filter field owner.email: field is not declared in the list items",
+		},
+		"non-primitive field": {
+			operationID: "listPets",
+			query:       "sort=tags",
+			expectedErr: "sort field tags: field of type array cannot be used",
+		},
+		"not a list": {
+			operationID: "getPet",
+			query:       "sort=name",
+			expectedErr: "parse list query: operation getPet does not respond with a list",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/pets?"+tc.query, nil)
+			ctx, _ := WithOperation(req.Context(), doc, tc.operationID)
+
+			q, err := ParseListQuery(req.WithContext(ctx))
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, q)
+		})
+	}
+}
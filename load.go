@@ -83,7 +83,9 @@ func LoadFile(fpath string, opts ...LoadOption) (*Document, error) {
 		document.OrigSpec().Info.Version = options.appVersion
 	}
 
-	return wrapDocument(document), nil
+	doc := wrapDocument(document)
+	publish(SpecLoaded{Path: fpath, Document: doc})
+	return doc, nil
 }
 
 func loadDocument(fpath, cacheDir string) (*loads.Document, error) {
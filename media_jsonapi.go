@@ -0,0 +1,277 @@
+package oas
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/go-openapi/spec"
+
+	"github.com/hypnoglow/oas2/validate"
+)
+
+// JSONAPIMediaType is the media type of JSON:API documents.
+const JSONAPIMediaType = "application/vnd.api+json"
+
+// ValidateJSONAPIDocument validates the structure of the top-level JSON:API
+// document: it must be an object with data, errors or meta members, where
+// data and errors do not coexist, data is null, a resource object or an
+// array of them, and every resource object has a type. The errors are
+// returned as MultiError.
+func ValidateJSONAPIDocument(data []byte) error {
+	var doc interface{}
+	if err := decodeJSON(bytes.NewReader(data), &doc, true); err != nil {
+		return newJSONError("json:api document contains invalid json", err, data)
+	}
+
+	top, ok := doc.(map[string]interface{})
+	if !ok {
+		return errors.New("json:api document must be an object")
+	}
+
+	var errs []error
+	_, hasData := top["data"]
+	_, hasErrors := top["errors"]
+	_, hasMeta := top["meta"]
+	if !hasData && !hasErrors && !hasMeta {
+		errs = append(errs, errors.New("document must contain at least one of data, errors or meta"))
+	}
+	if hasData && hasErrors {
+		errs = append(errs, errors.New("document must not contain both data and errors"))
+	}
+
+	switch d := top["data"].(type) {
+	case nil:
+	case map[string]interface{}:
+		errs = append(errs, validateJSONAPIResource("data", d)...)
+	case []interface{}:
+		for i, item := range d {
+			errs = append(errs, validateJSONAPIResource(fmt.Sprintf("data[%d]", i), item)...)
+		}
+	default:
+		errs = append(errs, errors.New("data must be null, a resource object or an array of resource objects"))
+	}
+
+	if hasErrors {
+		items, ok := top["errors"].([]interface{})
+		if !ok {
+			errs = append(errs, errors.New("errors must be an array"))
+		}
+		for i, item := range items {
+			if _, ok := item.(map[string]interface{}); !ok {
+				errs = append(errs, fmt.Errorf("errors[%d] must be an object", i))
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return newMultiError("json:api document is invalid", errs...)
+	}
+	return nil
+}
+
+// validateJSONAPIResource validates the resource object at the path.
+func validateJSONAPIResource(path string, v interface{}) []error {
+	res, ok := v.(map[string]interface{})
+	if !ok {
+		return []error{fmt.Errorf("%s must be a resource object", path)}
+	}
+
+	var errs []error
+	if t, ok := res["type"].(string); !ok || t == "" {
+		errs = append(errs, fmt.Errorf("%s.type must be a non-empty string", path))
+	}
+	if id, ok := res["id"]; ok {
+		if _, ok := id.(string); !ok {
+			errs = append(errs, fmt.Errorf("%s.id must be a string", path))
+		}
+	}
+	for _, member := range []string{"attributes", "relationships", "links", "meta"} {
+		if m, ok := res[member]; ok {
+			if _, ok := m.(map[string]interface{}); !ok {
+				errs = append(errs, fmt.Errorf("%s.%s must be an object", path, member))
+			}
+		}
+	}
+	if attrs, ok := res["attributes"].(map[string]interface{}); ok {
+		for _, member := range []string{"id", "type"} {
+			if _, ok := attrs[member]; ok {
+				errs = append(errs, fmt.Errorf("%s.attributes must not contain %s", path, member))
+			}
+		}
+	}
+	return errs
+}
+
+// DecodeResource decodes the JSON:API request body to v. The resource
+// object in data is mapped to the definition the operation body refers to:
+// its type must be the definition name, and its id and attributes become
+// the properties of the definition, which are validated against the schema.
+// E.g. the body
+//
+//  {"data": {"type": "Pet", "id": "12", "attributes": {"name": "Rex"}}}
+//
+// is decoded to v as {"id": 12, "name": "Rex"} for the Pet definition with
+// the integer id. As the spec describes the definition rather than the
+// envelope, request body validators should not select JSONAPIMediaType
+// for such operations, see WithJSONSelectors. The request must have
+// operation context.
+func DecodeResource(req *http.Request, v interface{}) error {
+	oi, ok := getOperationInfo(req)
+	if !ok {
+		return errors.New("decode resource: cannot find OpenAPI operation info in the request context")
+	}
+
+	var schema *spec.Schema
+	for _, p := range oi.params {
+		if p.In == "body" {
+			schema = p.Schema
+		}
+	}
+	name := oi.models.body.name
+	if schema == nil || name == "" || oi.models.body.array {
+		return errors.New("decode resource: operation body does not refer to a definition")
+	}
+
+	data, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return fmt.Errorf("decode resource: %s", err)
+	}
+	if err := ValidateJSONAPIDocument(data); err != nil {
+		return fmt.Errorf("decode resource: %s", err)
+	}
+
+	var doc struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("decode resource: %s", err)
+	}
+	var res struct {
+		Type       string                 `json:"type"`
+		ID         *string                `json:"id"`
+		Attributes map[string]interface{} `json:"attributes"`
+	}
+	if err := decodeJSON(bytes.NewReader(doc.Data), &res, true); err != nil || res.Type == "" {
+		return errors.New("decode resource: data must be a single resource object")
+	}
+	if res.Type != name {
+		return fmt.Errorf("decode resource: resource type %s does not match %s", res.Type, name)
+	}
+
+	obj := make(map[string]interface{}, len(res.Attributes)+1)
+	for k, attr := range res.Attributes {
+		obj[k] = attr
+	}
+	if res.ID != nil {
+		obj["id"] = resourceIDValue(schema, *res.ID)
+	}
+
+	if errs := validate.Body(oi.params, obj); len(errs) > 0 {
+		return fmt.Errorf("decode resource: %s", newMultiError("resource does not match the schema", errs...))
+	}
+
+	b, err := json.Marshal(obj)
+	if err != nil {
+		return fmt.Errorf("decode resource: %s", err)
+	}
+	if err := currentJSONAPI().Unmarshal(b, v); err != nil {
+		return fmt.Errorf("decode resource: %s", err)
+	}
+	return nil
+}
+
+// resourceIDValue returns the value of the resource id for the id property
+// of the schema: numeric ids are converted to numbers.
+func resourceIDValue(s *spec.Schema, id string) interface{} {
+	prop, ok := schemaProperty(s, "id")
+	if !ok {
+		return id
+	}
+	switch schemaType(prop) {
+	case "integer", "number":
+		n := json.Number(id)
+		if _, err := n.Float64(); err == nil {
+			return n
+		}
+	}
+	return id
+}
+
+// WriteResource writes the value as the JSON:API response with the status
+// code. The value is mapped to the resource object of the definition the
+// response refers to, the same way DecodeResource maps resource objects to
+// definitions: the "id" property becomes the resource id, and the other
+// properties become the attributes. Values of arrays of definitions are
+// mapped to arrays of resource objects.
+func WriteResource(w http.ResponseWriter, req *http.Request, code int, v interface{}) error {
+	oi, ok := getOperationInfo(req)
+	if !ok {
+		return errors.New("write resource: cannot find OpenAPI operation info in the request context")
+	}
+
+	ref := oi.models.response(code)
+	if ref.name == "" {
+		return fmt.Errorf("write resource: response for code %d does not refer to a definition", code)
+	}
+
+	b, err := currentJSONAPI().Marshal(v)
+	if err != nil {
+		return fmt.Errorf("write resource: %s", err)
+	}
+	var value interface{}
+	if err := decodeJSON(bytes.NewReader(b), &value, true); err != nil {
+		return fmt.Errorf("write resource: %s", err)
+	}
+
+	var data interface{}
+	switch value := value.(type) {
+	case nil:
+	case map[string]interface{}:
+		data = newResourceObject(ref.name, value)
+	case []interface{}:
+		resources := make([]interface{}, 0, len(value))
+		for _, item := range value {
+			obj, ok := item.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("write resource: value of type %T is not an object or an array of objects", v)
+			}
+			resources = append(resources, newResourceObject(ref.name, obj))
+		}
+		data = resources
+	default:
+		return fmt.Errorf("write resource: value of type %T is not an object or an array of objects", v)
+	}
+
+	b, err = currentJSONAPI().Marshal(map[string]interface{}{"data": data})
+	if err != nil {
+		return fmt.Errorf("write resource: %s", err)
+	}
+
+	w.Header().Set("Content-Type", JSONAPIMediaType)
+	w.WriteHeader(code)
+	_, err = w.Write(b)
+	return err
+}
+
+// newResourceObject maps the object of the definition to the resource.
+func newResourceObject(typ string, obj map[string]interface{}) map[string]interface{} {
+	res := map[string]interface{}{"type": typ}
+	if id, ok := obj["id"]; ok && id != nil {
+		res["id"] = fmt.Sprint(id)
+	}
+
+	attrs := make(map[string]interface{}, len(obj))
+	for name, v := range obj {
+		if name != "id" {
+			attrs[name] = v
+		}
+	}
+	if len(attrs) > 0 {
+		res["attributes"] = attrs
+	}
+	return res
+}
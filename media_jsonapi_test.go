@@ -0,0 +1,109 @@
+package oas
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateJSONAPIDocument(t *testing.T) {
+	testCases := map[string]struct {
+		data        string
+		expectedErr string
+	}{
+		"resource": {
+			data: `{"data":{"type":"Pet","id":"1","attributes":{"name":"Rex"}}}`,
+		},
+		"collection": {
+			data: `{"data":[{"type":"Pet","id":"1"},{"type":"Pet","id":"2"}],"meta":{"total":2}}`,
+		},
+		"errors": {
+			data: `{"errors":[{"status":"404"}]}`,
+		},
+		"not an object": {
+			data:        `[]`,
+			expectedErr: "json:api document must be an object",
+		},
+		"empty": {
+			data:        `{}`,
+			expectedErr: "json:api document is invalid: document must contain at least one of data, errors or meta",
+		},
+		"invalid resources": {
+			data:        `{"data":[{"id":1},{"type":"Pet","attributes":{"id":"1"}}],"errors":[]}`,
+			expectedErr: "json:api document is invalid: document must not contain both data and errors, data[0].type must be a non-empty string, data[0].id must be a string, data[1].attributes must not contain id",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := ValidateJSONAPIDocument([]byte(tc.data))
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestDecodeResource(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+
+	decode := func(body string) (map[string]interface{}, error) {
+		req := httptest.NewRequest(http.MethodPost, "/v2/pet", strings.NewReader(body))
+		ctx, _ := WithOperation(req.Context(), doc, "addPet")
+
+		var v map[string]interface{}
+		err := DecodeResource(req.WithContext(ctx), &v)
+		return v, err
+	}
+
+	t.Run("maps resource to definition", func(t *testing.T) {
+		v, err := decode(`{"data":{"type":"Pet","id":"12","attributes":{"name":"Rex","age":3}}}`)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"id": float64(12), "name": "Rex", "age": float64(3)}, v)
+	})
+
+	t.Run("rejects other types", func(t *testing.T) {
+		_, err := decode(`{"data":{"type":"User","attributes":{"name":"Rex","age":3}}}`)
+		assert.EqualError(t, err, "decode resource: resource type User does not match Pet")
+	})
+
+	t.Run("validates attributes", func(t *testing.T) {
+		_, err := decode(`{"data":{"type":"Pet","attributes":{"name":"Rex"}}}`)
+		assert.EqualError(t, err, "decode resource: resource does not match the schema: age in body is required")
+	})
+
+	t.Run("rejects collections", func(t *testing.T) {
+		_, err := decode(`{"data":[{"type":"Pet"}]}`)
+		assert.EqualError(t, err, "decode resource: data must be a single resource object")
+	})
+}
+
+func TestWriteResource(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+
+	req := httptest.NewRequest(http.MethodGet, "/v2/pet/1", nil)
+	ctx, _ := WithOperation(req.Context(), doc, "getPetById")
+	req = req.WithContext(ctx)
+
+	t.Run("writes resource", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		err := WriteResource(w, req, http.StatusOK, &testPet{ID: 1, Name: "Rex", Age: 3})
+
+		assert.NoError(t, err)
+		assert.Equal(t, JSONAPIMediaType, w.Header().Get("Content-Type"))
+		assert.Equal(t, `{"data":{"attributes":{"age":3,"name":"Rex"},"id":"1","type":"Pet"}}`, w.Body.String())
+	})
+
+	t.Run("rejects responses without definition", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		err := WriteResource(w, req, http.StatusNotFound, nil)
+
+		assert.EqualError(t, err, "write resource: response for code 404 does not refer to a definition")
+		assert.Empty(t, w.Body.String())
+	})
+}
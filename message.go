@@ -0,0 +1,97 @@
+package oas
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/hypnoglow/oas2/convert"
+)
+
+// MessageOption is an option to use when building a request message.
+type MessageOption func(*messageOptions)
+
+type messageOptions struct {
+	fieldNames map[string]string
+}
+
+// MessageFieldNames returns an option that maps parameter names to message
+// field names. Parameters not present in the mapping keep their names.
+func MessageFieldNames(m map[string]string) MessageOption {
+	return func(o *messageOptions) {
+		o.fieldNames = m
+	}
+}
+
+// RequestMessage returns a JSON object that combines all request parameters:
+// path and query parameters as typed values, and fields of the body object.
+//
+// This is the mapping used by gRPC gateways with `body: "*"`, so the result
+// can be unmarshaled into a protobuf message with protojson, letting services
+// that front gRPC with an OpenAPI edge reuse the validation layer. The
+// reverse direction needs no special handling: a message marshaled with
+// protojson is a regular JSON response that can be validated by
+// ResponseBodyValidator.
+//
+// The request must have operation context, and path parameters are taken from
+// PathParamsContext middleware. Request body can be read again later.
+func RequestMessage(req *http.Request, opts ...MessageOption) ([]byte, error) {
+	params, ok := ParamsFromContext(req.Context())
+	if !ok {
+		return nil, errors.New("request message: cannot find OpenAPI operation info in the request context")
+	}
+
+	options := messageOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	fieldName := func(name string) string {
+		if f, ok := options.fieldNames[name]; ok {
+			return f
+		}
+		return name
+	}
+
+	msg := make(map[string]interface{})
+	query := req.URL.Query()
+
+	for _, p := range params {
+		switch p.In {
+		case "path":
+			if v := GetPathParam(req, p.Name); v != nil {
+				msg[fieldName(p.Name)] = v
+			}
+		case "query":
+			vals, ok := query[p.Name]
+			if !ok {
+				continue
+			}
+			v, err := convert.Parameter(vals, &p)
+			if err != nil {
+				return nil, fmt.Errorf("request message: param %s: %s", p.Name, err)
+			}
+			msg[fieldName(p.Name)] = v
+		case "body":
+			withContextBody(req)
+			if isEmptyBody(req) {
+				continue
+			}
+			body, err := bodyPayload(req, true)
+			if err != nil {
+				return nil, fmt.Errorf("request message: %s", err)
+			}
+			fields, ok := body.(map[string]interface{})
+			if !ok {
+				// Non-object body is mapped to the field named after
+				// the body parameter.
+				msg[fieldName(p.Name)] = body
+				continue
+			}
+			for k, v := range fields {
+				msg[fieldName(k)] = v
+			}
+		}
+	}
+
+	return currentJSONAPI().Marshal(msg)
+}
@@ -0,0 +1,47 @@
+package oas
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestMessage(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+
+	t.Run("path and query params", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/v2/pet/12?debug=true", nil)
+		req = withOperationInfo(req, operationInfo{params: doc.Analyzer.ParametersFor("getPetById")})
+		req = WithPathParam(req, "petId", int64(12))
+
+		b, err := RequestMessage(req, MessageFieldNames(map[string]string{"petId": "pet_id"}))
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"pet_id":12,"debug":true}`, string(b))
+	})
+
+	t.Run("body fields", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/v2/pet?debug=false", strings.NewReader(`{"name":"Kitty","age":3}`))
+		req = withOperationInfo(req, operationInfo{params: doc.Analyzer.ParametersFor("addPet")})
+
+		b, err := RequestMessage(req)
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"name":"Kitty","age":3,"debug":false}`, string(b))
+	})
+
+	t.Run("large integers", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/v2/pet", strings.NewReader(`{"name":"Kitty","age":9007199254740993}`))
+		req = withOperationInfo(req, operationInfo{params: doc.Analyzer.ParametersFor("addPet")})
+
+		b, err := RequestMessage(req)
+		assert.NoError(t, err)
+		assert.Contains(t, string(b), `"age":9007199254740993`)
+	})
+
+	t.Run("no operation context", func(t *testing.T) {
+		_, err := RequestMessage(httptest.NewRequest(http.MethodGet, "/v2/pet/12", nil))
+		assert.Error(t, err)
+	})
+}
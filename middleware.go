@@ -3,16 +3,51 @@ package oas
 import (
 	"net/http"
 	"regexp"
+	"time"
+
+	"github.com/hypnoglow/oas2/validate"
 )
 
 // Middleware describes a middleware that can be applied to a http.handler.
 type Middleware func(next http.Handler) http.Handler
 
+// passThrough is a middleware that does nothing.
+func passThrough(next http.Handler) http.Handler {
+	return next
+}
+
 // MiddlewareOptions represent options for middleware.
 type MiddlewareOptions struct {
 	jsonSelectors     []*regexp.Regexp
 	problemHandler    ProblemHandler
 	continueOnProblem bool
+	trustForwarded    bool
+
+	allowUnknownQuery      bool
+	hideProblemDetails     bool
+	skipResponseValidation bool
+
+	flagProvider    FlagProvider
+	responseSampler *ResponseSampler
+
+	headerSeverities map[string]Severity
+
+	transcodeLatin1  bool
+	useNumber        bool
+	maxBodySize      int64
+	decimalComma     bool
+	duplicateParams  validate.DuplicatePolicy
+	literalPlus      bool
+	doubleDecoding   bool
+	normalizeUnicode bool
+
+	retryAfter       RetryAfterFunc
+	clientIPResolver *ClientIPResolver
+
+	validationLoad *ValidationLoad
+	nonceTTL       time.Duration
+
+	clock Clock
 }
 
 // MiddlewareOption represent option for middleware.
@@ -47,6 +82,167 @@ func WithContinueOnProblem(contin bool) MiddlewareOption {
 	}
 }
 
+// WithTrustForwardedHeaders returns a middleware option that defines if
+// middleware should trust X-Forwarded-Host and X-Forwarded-Proto headers
+// of the request. Enable it only when the service is behind a proxy that
+// sets these headers.
+func WithTrustForwardedHeaders(trust bool) MiddlewareOption {
+	return func(opts *MiddlewareOptions) {
+		opts.trustForwarded = trust
+	}
+}
+
+// WithUnknownQueryParams returns a middleware option that defines if query
+// validator should allow query parameters that are not described in the spec.
+// By default, unknown parameters are rejected.
+func WithUnknownQueryParams(allow bool) MiddlewareOption {
+	return func(opts *MiddlewareOptions) {
+		opts.allowUnknownQuery = allow
+	}
+}
+
+// WithProblemDetails returns a middleware option that defines if the default
+// problem handler of request validators should respond with error details,
+// which echo the offending values. Without details, only a general message
+// is responded. By default, details are responded.
+func WithProblemDetails(show bool) MiddlewareOption {
+	return func(opts *MiddlewareOptions) {
+		opts.hideProblemDetails = !show
+	}
+}
+
+// WithResponseValidation returns a middleware option that defines if
+// response validators should validate responses at all. Disabled response
+// validators just pass responses through. By default, responses are validated.
+func WithResponseValidation(enabled bool) MiddlewareOption {
+	return func(opts *MiddlewareOptions) {
+		opts.skipResponseValidation = !enabled
+	}
+}
+
+// WithLatin1Transcoding returns a middleware option that defines if request
+// body validator should transcode JSON request bodies declared with
+// ISO-8859-1 charset to UTF-8, which allows legacy clients to send such
+// bodies. By default, JSON request bodies must be encoded in UTF-8.
+func WithLatin1Transcoding(enabled bool) MiddlewareOption {
+	return func(opts *MiddlewareOptions) {
+		opts.transcodeLatin1 = enabled
+	}
+}
+
+// WithJSONNumbers returns a middleware option that defines if body
+// validators should decode JSON numbers as json.Number instead of float64.
+// This allows to validate integers that cannot be represented as float64
+// exactly, e.g. large int64 ids, without loss of precision.
+func WithJSONNumbers(enabled bool) MiddlewareOption {
+	return func(opts *MiddlewareOptions) {
+		opts.useNumber = enabled
+	}
+}
+
+// WithDecimalComma returns a middleware option that defines if query
+// validator should accept numbers with a comma as decimal separator, e.g.
+// "185,5", sent by clients that format numbers by their locale. Such values
+// of number params are rewritten in the request query with a dot, so the
+// handler gets them as regular numbers. By default, such values are rejected
+// with a hint on the decimal separator.
+func WithDecimalComma(accept bool) MiddlewareOption {
+	return func(opts *MiddlewareOptions) {
+		opts.decimalComma = accept
+	}
+}
+
+// WithDuplicateParams returns a middleware option that sets the policy for
+// non-array query params that appear more than once, e.g. "?age=1&age=2".
+// With validate.DuplicateFirstWins or validate.DuplicateLastWins, such
+// params are rewritten in the request query with the winning value, so the
+// handler decodes the same value that was validated. By default, such
+// params are rejected with validate.DuplicateParamError. Decoding functions
+// accept the same policy with DecodeDuplicateParams.
+func WithDuplicateParams(policy validate.DuplicatePolicy) MiddlewareOption {
+	return func(opts *MiddlewareOptions) {
+		opts.duplicateParams = policy
+	}
+}
+
+// WithLiteralPlus returns a middleware option that defines if query
+// validator should treat '+' in the request query as a literal plus instead
+// of an encoded space. Clients built with frameworks that encode only by
+// RFC 3986 send "+1" for "+1", which is otherwise decoded as " 1" and fails
+// validation as a number. The request query is rewritten, so the handler
+// gets the same values that were validated. By default, '+' is a space, as
+// in HTML forms.
+func WithLiteralPlus(literal bool) MiddlewareOption {
+	return func(opts *MiddlewareOptions) {
+		opts.literalPlus = literal
+	}
+}
+
+// WithDoubleDecoding returns a middleware option that defines if query
+// validator should decode the values of the request query that remain
+// percent-encoded after decoding, e.g. "2020-01-01T00%3A00%3A00Z" sent as
+// "2020-01-01T00%253A00%253A00Z" by clients that encode values twice. The
+// request query is rewritten, so the handler gets the same values that were
+// validated. By default, values are decoded once.
+func WithDoubleDecoding(enabled bool) MiddlewareOption {
+	return func(opts *MiddlewareOptions) {
+		opts.doubleDecoding = enabled
+	}
+}
+
+// WithUnicodeNormalization returns a middleware option that defines if
+// query and request body validators should normalize string values of the
+// request to NFC before validation, so pattern and length checks, and
+// comparisons in handlers, behave the same for the text composed by
+// different input methods, e.g. "é" sent as "e" with a combining accent.
+// The request query and body are rewritten, so the handler gets the same
+// values that were validated. Parameters override this option with
+// UnicodeNormalizationExtension. By default, values are not normalized.
+func WithUnicodeNormalization(enabled bool) MiddlewareOption {
+	return func(opts *MiddlewareOptions) {
+		opts.normalizeUnicode = enabled
+	}
+}
+
+// WithMaxBodySize returns a middleware option that sets the maximum size of
+// a request body in bytes the body validator accepts. The size is checked
+// by the bytes actually read, so bodies without Content-Length, e.g. sent
+// with Transfer-Encoding: chunked, are limited as well, and at most n+1
+// bytes of a body are read. Larger bodies are reported as problems with
+// *BodyTooLargeError. By default, the size is not limited.
+func WithMaxBodySize(n int64) MiddlewareOption {
+	return func(opts *MiddlewareOptions) {
+		opts.maxBodySize = n
+	}
+}
+
+// Severity is the severity of a validation problem.
+type Severity int
+
+const (
+	// SeverityError problems are handled by the problem handler.
+	SeverityError Severity = iota
+
+	// SeverityWarning problems are logged as warnings to the standard logger.
+	SeverityWarning
+
+	// SeverityIgnore problems are not checked at all.
+	SeverityIgnore
+)
+
+// WithResponseHeaderSeverity returns a middleware option that sets the
+// severity of problems with the declared response header, e.g. when the
+// header is missing or its value does not match the declared type and format.
+// By default, the severity is SeverityError.
+func WithResponseHeaderSeverity(header string, s Severity) MiddlewareOption {
+	return func(opts *MiddlewareOptions) {
+		if opts.headerSeverities == nil {
+			opts.headerSeverities = make(map[string]Severity)
+		}
+		opts.headerSeverities[http.CanonicalHeaderKey(header)] = s
+	}
+}
+
 func parseMiddlewareOptions(opts ...MiddlewareOption) MiddlewareOptions {
 	options := MiddlewareOptions{
 		jsonSelectors:     nil,
@@ -59,6 +255,9 @@ func parseMiddlewareOptions(opts ...MiddlewareOption) MiddlewareOptions {
 	if options.jsonSelectors == nil {
 		defaultJSONSelectors()(&options)
 	}
+	if options.clock == nil {
+		options.clock = SystemClock
+	}
 
 	return options
 }
@@ -0,0 +1,117 @@
+package oas
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// statusMisdirectedRequest is HTTP 421 status code, which is not available
+// as a constant in net/http prior to Go 1.11.
+const statusMisdirectedRequest = 421
+
+// hostValidator is a middleware that validates request host and scheme
+// against the spec host and schemes.
+type hostValidator struct {
+	next http.Handler
+
+	// host is the spec host. If empty, any host is allowed.
+	host string
+
+	// schemes are the spec schemes. If empty, any scheme is allowed.
+	schemes []string
+
+	// trustForwarded defines if X-Forwarded-* headers should be used
+	// to determine request host and scheme.
+	trustForwarded bool
+
+	problemHandler    ProblemHandler
+	continueOnProblem bool
+
+	flags FlagProvider
+}
+
+func (mw *hostValidator) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	mode := validatorMode(mw.flags, req, CheckHost)
+	if mode == ValidatorOff {
+		mw.next.ServeHTTP(w, req)
+		return
+	}
+
+	start := time.Now()
+	host, scheme := requestHost(req, mw.trustForwarded), requestScheme(req, mw.trustForwarded)
+
+	var err error
+	switch {
+	case !matchHost(host, mw.host):
+		err = fmt.Errorf("request host %s does not match the spec host %s", host, mw.host)
+	case !matchScheme(scheme, mw.schemes):
+		err = fmt.Errorf("request scheme %s does not match any of the spec schemes %s", scheme, strings.Join(mw.schemes, ", "))
+	}
+	recordCheck(req, CheckHost, start, err, nil)
+
+	if err != nil {
+		mw.problemHandler.HandleProblem(NewProblem(w, req, err))
+		if !mw.continueOnProblem && mode != ValidatorObserve {
+			return
+		}
+	}
+
+	mw.next.ServeHTTP(w, req)
+}
+
+// requestHost returns the host the request was sent to.
+func requestHost(req *http.Request, trustForwarded bool) string {
+	if trustForwarded {
+		if host := req.Header.Get("X-Forwarded-Host"); host != "" {
+			return host
+		}
+	}
+	return req.Host
+}
+
+// requestScheme returns the scheme the request was sent with.
+func requestScheme(req *http.Request, trustForwarded bool) string {
+	if trustForwarded {
+		if scheme := req.Header.Get("X-Forwarded-Proto"); scheme != "" {
+			return strings.ToLower(scheme)
+		}
+	}
+	if req.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// matchHost checks if request host matches the spec host. When the spec host
+// does not define a port, any port is allowed.
+func matchHost(host, specHost string) bool {
+	if specHost == "" {
+		return true
+	}
+
+	if _, _, err := net.SplitHostPort(specHost); err != nil {
+		// Spec host has no port, so compare hostnames only.
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+	}
+
+	return strings.EqualFold(host, specHost)
+}
+
+// matchScheme checks if request scheme matches any of the spec schemes.
+func matchScheme(scheme string, schemes []string) bool {
+	if len(schemes) == 0 {
+		return true
+	}
+
+	for _, s := range schemes {
+		if strings.EqualFold(s, scheme) {
+			return true
+		}
+	}
+	return false
+}
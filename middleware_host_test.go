@@ -0,0 +1,102 @@
+package oas
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHostValidator(t *testing.T) {
+	testCases := map[string]struct {
+		host           string
+		schemes        []string
+		trustForwarded bool
+		requestHost    string
+		tls            bool
+		headers        map[string]string
+		expectedStatus int
+		expectedBody   string
+	}{
+		"valid host and scheme": {
+			host:           "petstore.swagger.io",
+			schemes:        []string{"http"},
+			requestHost:    "petstore.swagger.io:8080",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "ok",
+		},
+		"wrong host": {
+			host:           "petstore.swagger.io",
+			requestHost:    "example.com",
+			expectedStatus: statusMisdirectedRequest,
+			expectedBody:   "request host example.com does not match the spec host petstore.swagger.io",
+		},
+		"wrong port": {
+			host:           "petstore.swagger.io:8080",
+			requestHost:    "petstore.swagger.io:9090",
+			expectedStatus: statusMisdirectedRequest,
+			expectedBody:   "request host petstore.swagger.io:9090 does not match the spec host petstore.swagger.io:8080",
+		},
+		"wrong scheme": {
+			schemes:        []string{"http"},
+			requestHost:    "example.com",
+			tls:            true,
+			expectedStatus: statusMisdirectedRequest,
+			expectedBody:   "request scheme https does not match any of the spec schemes http",
+		},
+		"forwarded headers are ignored by default": {
+			host:           "petstore.swagger.io",
+			requestHost:    "internal",
+			headers:        map[string]string{"X-Forwarded-Host": "petstore.swagger.io"},
+			expectedStatus: statusMisdirectedRequest,
+			expectedBody:   "request host internal does not match the spec host petstore.swagger.io",
+		},
+		"forwarded headers are trusted": {
+			host:           "petstore.swagger.io",
+			schemes:        []string{"https"},
+			trustForwarded: true,
+			requestHost:    "internal",
+			headers: map[string]string{
+				"X-Forwarded-Host":  "petstore.swagger.io",
+				"X-Forwarded-Proto": "https",
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "ok",
+		},
+		"no host and schemes in spec": {
+			requestHost:    "example.com",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "ok",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			v := &hostValidator{
+				next: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+					w.Write([]byte("ok"))
+				}),
+				host:           tc.host,
+				schemes:        tc.schemes,
+				trustForwarded: tc.trustForwarded,
+				problemHandler: newProblemHandlerStatusResponder(statusMisdirectedRequest),
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/v2/pet/12", nil)
+			req.Host = tc.requestHost
+			if tc.tls {
+				req.TLS = &tls.ConnectionState{}
+			}
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			v.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, tc.expectedBody, w.Body.String())
+		})
+	}
+}
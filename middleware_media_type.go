@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 )
 
 // matchMediaType checks if media type matches any allowed media type.
@@ -21,11 +22,13 @@ func matchMediaType(mediaType string, allowed []string) bool {
 		return true
 	}
 
+	// Parameters, e.g. charset, do not affect matching.
+	mediaType = bareMediaType(mediaType)
 	for _, a := range allowed {
 		if a == mediaTypeWildcard {
 			return true
 		}
-		if strings.EqualFold(mediaType, a) {
+		if strings.EqualFold(mediaType, bareMediaType(a)) {
 			return true
 		}
 	}
@@ -67,6 +70,10 @@ func matchMediaTypes(mediaTypes []string, allowed []string) bool {
 // are defined by the corresponding operation.
 type requestContentTypeValidator struct {
 	next http.Handler
+
+	// continueOnProblem makes the validator only record the failed checks,
+	// without responding.
+	continueOnProblem bool
 }
 
 func (mw *requestContentTypeValidator) ServeHTTP(w http.ResponseWriter, req *http.Request, consumes []string, produces []string, ok bool) {
@@ -75,19 +82,33 @@ func (mw *requestContentTypeValidator) ServeHTTP(w http.ResponseWriter, req *htt
 		return
 	}
 
-	if req.ContentLength > 0 {
+	start := time.Now()
+
+	if mayHaveBody(req) {
 		ct := req.Header.Get("Content-Type")
 		if !matchMediaType(ct, consumes) {
-			w.WriteHeader(http.StatusUnsupportedMediaType)
+			recordCheck(req, CheckRequestContentType, start, fmt.Errorf("Content-Type header of the request does not match any of the media types the operation can consume"), nil)
+			if !mw.continueOnProblem {
+				w.WriteHeader(http.StatusUnsupportedMediaType)
+				return
+			}
+			mw.next.ServeHTTP(w, req)
 			return
 		}
 	}
 
 	if !matchMediaTypes(req.Header["Accept"], produces) {
-		w.WriteHeader(http.StatusNotAcceptable)
+		recordCheck(req, CheckRequestContentType, start, fmt.Errorf("Accept header of the request does not match any of the media types the operation can produce"), nil)
+		if !mw.continueOnProblem {
+			w.WriteHeader(http.StatusNotAcceptable)
+			return
+		}
+		mw.next.ServeHTTP(w, req)
 		return
 	}
 
+	recordCheck(req, CheckRequestContentType, start, nil, nil)
+
 	mw.next.ServeHTTP(w, req)
 }
 
@@ -105,15 +126,21 @@ func (mw *responseContentTypeValidator) ServeHTTP(w http.ResponseWriter, req *ht
 		return
 	}
 
+	start := time.Now()
 	ct := w.Header().Get("Content-Type")
 
+	var failed error
 	if !matchMediaType(ct, req.Header["Accept"]) {
 		err := fmt.Errorf("Content-Type header of the response does not match Accept header of the request")
-		mw.problemHandler.HandleProblem(NewProblem(w, req, err))
+		mw.problemHandler.HandleProblem(newProblemOfKind(w, req, err, ProblemKindSemantic))
+		failed = err
 	}
 
 	if !matchMediaType(ct, produces) {
 		err := fmt.Errorf("Content-Type header of the response does not match any of the media types the operation can produce")
-		mw.problemHandler.HandleProblem(NewProblem(w, req, err))
+		mw.problemHandler.HandleProblem(newProblemOfKind(w, req, err, ProblemKindSemantic))
+		failed = err
 	}
+
+	recordCheck(req, CheckResponseContentType, start, failed, nil)
 }
@@ -22,6 +22,13 @@ func TestRequestContentTypeValidator(t *testing.T) {
 			expectHandlerCalled: true,
 			expectedStatus:      http.StatusOK,
 		},
+		"consumes application/json with charset": {
+			consumes: []string{
+				"application/json; charset=utf-8",
+			},
+			expectHandlerCalled: true,
+			expectedStatus:      http.StatusOK,
+		},
 		"consumes application/xml": {
 			consumes: []string{
 				"application/xml",
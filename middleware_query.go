@@ -2,9 +2,13 @@ package oas
 
 import (
 	"net/http"
+	"net/url"
+	"strings"
+	"time"
 
 	"github.com/go-openapi/spec"
 
+	"github.com/hypnoglow/oas2/convert"
 	"github.com/hypnoglow/oas2/validate"
 )
 
@@ -15,6 +19,30 @@ type queryValidator struct {
 
 	problemHandler    ProblemHandler
 	continueOnProblem bool
+
+	// allowUnknown allows query params that are not described in the spec.
+	allowUnknown bool
+
+	// decimalComma accepts numbers with a comma as decimal separator.
+	decimalComma bool
+
+	// literalPlus treats '+' in the query as a literal plus.
+	literalPlus bool
+
+	// doubleDecoding decodes values that remain percent-encoded once more.
+	doubleDecoding bool
+
+	// normalizeUnicode normalizes values to NFC, unless overridden by the
+	// params.
+	normalizeUnicode bool
+
+	// duplicates is the policy for non-array params that appear more than
+	// once.
+	duplicates validate.DuplicatePolicy
+
+	// dependencies are the declared constraints between query params by
+	// operation id.
+	dependencies map[string][]QueryDependencyFunc
 }
 
 func (mw *queryValidator) ServeHTTP(w http.ResponseWriter, req *http.Request, params []spec.Parameter, ok bool) {
@@ -23,9 +51,35 @@ func (mw *queryValidator) ServeHTTP(w http.ResponseWriter, req *http.Request, pa
 		return
 	}
 
-	if errs := validate.Query(params, req.URL.Query()); len(errs) > 0 {
-		me := newMultiError("query params do not match the schema", errs...)
-		mw.problemHandler.HandleProblem(NewProblem(w, req, me))
+	start := time.Now()
+	if mw.literalPlus || mw.doubleDecoding {
+		req.URL.RawQuery = decodeQuery(req.URL.RawQuery, mw.literalPlus, mw.doubleDecoding).Encode()
+	}
+	if mw.decimalComma {
+		normalizeDecimalComma(params, req)
+	}
+	if mw.duplicates != validate.DuplicateReject {
+		deduplicateQuery(params, req, mw.duplicates)
+	}
+	normalizeUnicodeQuery(params, req, mw.normalizeUnicode)
+	query := req.URL.Query()
+	if mw.allowUnknown {
+		query = knownQueryValues(params, query)
+	}
+	errs := validate.Query(params, query)
+	errs = append(errs, mw.dependencyErrors(req)...)
+	errs = append(errs, fieldsErrors(req, params)...)
+
+	var err error
+	if len(errs) > 0 {
+		err = newMultiError("query params do not match the schema", errs...)
+	}
+	recordCheck(req, CheckQuery, start, err, func() map[string]interface{} {
+		return coercedQueryValues(params, req.URL.Query())
+	})
+
+	if err != nil {
+		mw.problemHandler.HandleProblem(newProblemOfKind(w, req, err, ProblemKindSemantic))
 		if !mw.continueOnProblem {
 			return
 		}
@@ -33,3 +87,128 @@ func (mw *queryValidator) ServeHTTP(w http.ResponseWriter, req *http.Request, pa
 
 	mw.next.ServeHTTP(w, req)
 }
+
+// dependencyErrors evaluates the constraints between query params of the
+// operation of the request.
+func (mw *queryValidator) dependencyErrors(req *http.Request) []error {
+	oi, ok := getOperationInfo(req)
+	if !ok {
+		return nil
+	}
+
+	var errs []error
+	query := req.URL.Query()
+	for _, fn := range mw.dependencies[oi.operation.ID] {
+		errs = append(errs, fn(query)...)
+	}
+	for _, fn := range registeredQueryDependencies(oi.operation.ID) {
+		errs = append(errs, fn(query)...)
+	}
+	return errs
+}
+
+// normalizeDecimalComma rewrites values of number query params with a comma
+// as decimal separator in the request query, so they are valid numbers.
+func normalizeDecimalComma(params []spec.Parameter, req *http.Request) {
+	query := req.URL.Query()
+	changed := false
+	for _, p := range params {
+		if p.In != "query" || p.Type != "number" {
+			continue
+		}
+		for i, v := range query[p.Name] {
+			if n, ok := convert.DecimalComma(v); ok {
+				query[p.Name][i] = n
+				changed = true
+			}
+		}
+	}
+	if changed {
+		req.URL.RawQuery = query.Encode()
+	}
+}
+
+// decodeQuery parses the raw query like url.ParseQuery, but treats '+' as
+// a literal plus if literalPlus is set, and decodes keys and values that
+// remain percent-encoded once more if doubleDecoding is set. Pairs that
+// cannot be decoded are dropped, as url.ParseQuery does.
+func decodeQuery(raw string, literalPlus, doubleDecoding bool) url.Values {
+	q := make(url.Values)
+	for raw != "" {
+		pair := raw
+		raw = ""
+		if i := strings.Index(pair, "&"); i >= 0 {
+			pair, raw = pair[:i], pair[i+1:]
+		}
+		if pair == "" {
+			continue
+		}
+
+		key, value := pair, ""
+		if i := strings.Index(pair, "="); i >= 0 {
+			key, value = pair[:i], pair[i+1:]
+		}
+		key, err := decodeQueryComponent(key, literalPlus, doubleDecoding)
+		if err != nil {
+			continue
+		}
+		value, err = decodeQueryComponent(value, literalPlus, doubleDecoding)
+		if err != nil {
+			continue
+		}
+		q[key] = append(q[key], value)
+	}
+	return q
+}
+
+// decodeQueryComponent decodes the key or value of the query.
+func decodeQueryComponent(s string, literalPlus, doubleDecoding bool) (string, error) {
+	if literalPlus {
+		s = strings.Replace(s, "+", "%2B", -1)
+	}
+	s, err := url.QueryUnescape(s)
+	if err != nil {
+		return "", err
+	}
+	if doubleDecoding && strings.Contains(s, "%") {
+		// Values that contain '%' but are not valid escapes, e.g. "50%",
+		// are kept as is.
+		if d, err := url.PathUnescape(s); err == nil {
+			s = d
+		}
+	}
+	return s, nil
+}
+
+// deduplicateQuery rewrites values of non-array query params that appear
+// more than once in the request query with the value that wins by the
+// policy.
+func deduplicateQuery(params []spec.Parameter, req *http.Request, policy validate.DuplicatePolicy) {
+	query := req.URL.Query()
+	changed := false
+	for i := range params {
+		p := &params[i]
+		if p.In != "query" {
+			continue
+		}
+		vals := query[p.Name]
+		if dedup := validate.Deduplicate(p, vals, policy); len(dedup) != len(vals) {
+			query[p.Name] = dedup
+			changed = true
+		}
+	}
+	if changed {
+		req.URL.RawQuery = query.Encode()
+	}
+}
+
+// knownQueryValues returns only the query values described by the params.
+func knownQueryValues(params []spec.Parameter, q url.Values) url.Values {
+	known := make(url.Values)
+	for _, p := range params {
+		if vals, ok := q[p.Name]; ok && p.In == "query" {
+			known[p.Name] = vals
+		}
+	}
+	return known
+}
@@ -7,6 +7,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/hypnoglow/oas2/validate"
 )
 
 func TestQueryValidator(t *testing.T) {
@@ -48,6 +50,187 @@ func TestQueryValidator(t *testing.T) {
 	}
 }
 
+func TestQueryValidator_decimalComma(t *testing.T) {
+	doc := loadDocBytes([]byte(`
+swagger: "2.0"
+info:
+  title: Test
+  version: 1.0.0
+paths:
+  /items:
+    get:
+      operationId: findItems
+      parameters:
+      - name: price
+        in: query
+        type: number
+      responses:
+        200:
+          description: OK
+`))
+	params := doc.Analyzer.ParametersFor("findItems")
+
+	testCases := map[string]struct {
+		decimalComma   bool
+		expectedStatus int
+		expectedBody   string
+	}{
+		"rejected with hint": {
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "use '.' as decimal separator",
+		},
+		"accepted": {
+			decimalComma:   true,
+			expectedStatus: http.StatusOK,
+			expectedBody:   "price: 185.5",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			v := &queryValidator{
+				next: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+					fmt.Fprintf(w, "price: %s", req.URL.Query().Get("price"))
+				}),
+				problemHandler: ProblemHandlerFunc(func(p Problem) {
+					p.ResponseWriter().WriteHeader(http.StatusBadRequest)
+					for _, err := range p.Cause().(MultiError).Errors() {
+						fmt.Fprint(p.ResponseWriter(), err.(validate.HintError).Hint())
+					}
+				}),
+				decimalComma: tc.decimalComma,
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/items?price=185,5", nil)
+			w := httptest.NewRecorder()
+			v.ServeHTTP(w, req, params, true)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, tc.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestQueryValidator_duplicateParams(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+	params := doc.Analyzer.ParametersFor("loginUser")
+
+	testCases := map[string]struct {
+		policy         validate.DuplicatePolicy
+		expectedStatus int
+		expectedBody   string
+	}{
+		"rejected": {
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"errors":[{"message":"param username must be passed once, got 2 values","field":"username","value":["johndoe","janedoe"]}]}`,
+		},
+		"first wins": {
+			policy:         validate.DuplicateFirstWins,
+			expectedStatus: http.StatusOK,
+			expectedBody:   "username: johndoe, password: 123",
+		},
+		"last wins": {
+			policy:         validate.DuplicateLastWins,
+			expectedStatus: http.StatusOK,
+			expectedBody:   "username: janedoe, password: 123",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			v := &queryValidator{
+				next:           http.HandlerFunc(handleUserLogin),
+				problemHandler: problemHandlerResponseWriter(),
+				duplicates:     tc.policy,
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/v2/user/login?username=johndoe&username=janedoe&password=123", nil)
+			w := httptest.NewRecorder()
+			v.ServeHTTP(w, req, params, true)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, tc.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestQueryValidator_encoding(t *testing.T) {
+	doc := loadDocBytes([]byte(`
+swagger: "2.0"
+info:
+  title: Test
+  version: 1.0.0
+paths:
+  /items:
+    get:
+      operationId: findItems
+      parameters:
+      - name: q
+        in: query
+        type: string
+      responses:
+        200:
+          description: OK
+`))
+	params := doc.Analyzer.ParametersFor("findItems")
+
+	testCases := map[string]struct {
+		query          string
+		literalPlus    bool
+		doubleDecoding bool
+		expectedBody   string
+	}{
+		"plus as space": {
+			query:        "q=a+b",
+			expectedBody: "q: a b",
+		},
+		"literal plus": {
+			query:        "q=a+b",
+			literalPlus:  true,
+			expectedBody: "q: a+b",
+		},
+		"encoded plus": {
+			query:        "q=a%2Bb",
+			literalPlus:  true,
+			expectedBody: "q: a+b",
+		},
+		"single decoding": {
+			query:        "q=a%2520b",
+			expectedBody: "q: a%20b",
+		},
+		"double decoding": {
+			query:          "q=a%2520b",
+			doubleDecoding: true,
+			expectedBody:   "q: a b",
+		},
+		"double decoding of percent sign": {
+			query:          "q=50%25",
+			doubleDecoding: true,
+			expectedBody:   "q: 50%",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			v := &queryValidator{
+				next: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+					fmt.Fprintf(w, "q: %s", req.URL.Query().Get("q"))
+				}),
+				problemHandler: problemHandlerResponseWriter(),
+				literalPlus:    tc.literalPlus,
+				doubleDecoding: tc.doubleDecoding,
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/items?"+tc.query, nil)
+			w := httptest.NewRecorder()
+			v.ServeHTTP(w, req, params, true)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tc.expectedBody, w.Body.String())
+		})
+	}
+}
+
 func handleUserLogin(w http.ResponseWriter, req *http.Request) {
 	username := req.URL.Query().Get("username")
 	password := req.URL.Query().Get("password")
@@ -55,3 +238,23 @@ func handleUserLogin(w http.ResponseWriter, req *http.Request) {
 	// Never do this! This is just for testing purposes.
 	fmt.Fprintf(w, "username: %s, password: %s", username, password)
 }
+
+func TestBasisCheckErrorResponses(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+
+	var missing []string
+	check := func(operationID string, code int) {
+		missing = append(missing, fmt.Sprintf("%s %d", operationID, code))
+	}
+
+	basis := NewResolvingBasis(SpecAdapterName, doc, BasisCheckErrorResponses(check, 400, 422))
+	basis.QueryValidator()
+	basis.RequestBodyValidator()
+
+	assert.Equal(t, []string{
+		"addPet 400",
+		"addPet 422",
+		"getPetById 422",
+		"loginUser 422",
+	}, missing)
+}
@@ -2,12 +2,14 @@ package oas
 
 import (
 	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"regexp"
+	"strings"
+	"time"
 
 	"github.com/go-openapi/spec"
 
@@ -24,6 +26,23 @@ type requestBodyValidator struct {
 	// Otherwise no validation is performed.
 	jsonSelectors []*regexp.Regexp
 
+	// transcodeLatin1 enables transcoding of bodies in ISO-8859-1 charset
+	// to UTF-8.
+	transcodeLatin1 bool
+
+	// useNumber makes JSON numbers decoded as json.Number.
+	useNumber bool
+
+	// maxBodySize is the maximum size of a body that is validated, if set.
+	maxBodySize int64
+
+	// normalizeUnicode normalizes string values to NFC, unless overridden
+	// by the body param.
+	normalizeUnicode bool
+
+	// load tracks the number of bodies being validated, if set.
+	load *ValidationLoad
+
 	problemHandler    ProblemHandler
 	continueOnProblem bool
 }
@@ -34,46 +53,169 @@ func (mw *requestBodyValidator) ServeHTTP(w http.ResponseWriter, req *http.Reque
 		return
 	}
 
-	if req.Body == http.NoBody {
+	// The body may block on a slow client as soon as it is probed for
+	// emptiness, so the probe reads through the request context too, and
+	// is tracked by the load along with the validation.
+	withContextBody(req)
+	mw.load.enter()
+	pass := mw.check(w, req, params)
+	mw.load.leave()
+	if !pass {
+		return
+	}
+
+	mw.next.ServeHTTP(w, req)
+}
+
+// check checks the request body. It returns false if the request should not
+// be passed to the next handler.
+func (mw *requestBodyValidator) check(w http.ResponseWriter, req *http.Request, params []spec.Parameter) bool {
+	start := time.Now()
+
+	if isEmptyBody(req) {
 		for _, param := range params {
 			if param.In == "body" && param.Required {
 				// No request body found, but operation actually requires body.
 				e := fmt.Errorf("request body is empty, but the operation requires non-empty body")
-				mw.problemHandler.HandleProblem(NewProblem(w, req, e))
+				recordCheck(req, CheckRequestBody, start, e, nil)
+				mw.problemHandler.HandleProblem(newProblemOfKind(w, req, e, ProblemKindSemantic))
 				if !mw.continueOnProblem {
-					return
+					return false
 				}
 			}
 		}
-		mw.next.ServeHTTP(w, req)
-		return
+		return true
+	}
+
+	// The emptiness probe is abandoned if the context is done.
+	if mw.handleCanceled(w, req, start) {
+		return false
 	}
 
 	if !mw.matchContentType(req) {
-		mw.next.ServeHTTP(w, req)
-		return
+		return true
+	}
+
+	if cs := mediaTypeCharset(req.Header.Get("Content-Type")); !supportedCharset(cs, mw.transcodeLatin1) {
+		e := fmt.Errorf("request body charset %s is not supported, JSON must be encoded in UTF-8", cs)
+		recordCheck(req, CheckRequestBody, start, e, nil)
+		mw.problemHandler.HandleProblem(NewProblem(w, req, e))
+		return mw.continueOnProblem
+	}
+
+	if !mw.validate(w, req, params, start) {
+		return mw.continueOnProblem && req.Context().Err() == nil
+	}
+	return true
+}
+
+// validate reads and validates the request body. It returns false if the
+// body is invalid.
+func (mw *requestBodyValidator) validate(w http.ResponseWriter, req *http.Request, params []spec.Parameter, start time.Time) bool {
+	// Every read of the body below stops as soon as the context of the
+	// request is done, and the failed read is reported as canceled.
+	withContextBody(req)
+
+	if mw.maxBodySize > 0 {
+		if err := limitBody(req, mw.maxBodySize); err != nil {
+			if mw.handleCanceled(w, req, start) {
+				return false
+			}
+			recordCheck(req, CheckRequestBody, start, err, nil)
+			mw.problemHandler.HandleProblem(NewProblem(w, req, err))
+			return false
+		}
+	}
+
+	if err := normalizeBodyCharset(req); err != nil {
+		if mw.handleCanceled(w, req, start) {
+			return false
+		}
+		recordCheck(req, CheckRequestBody, start, err, nil)
+		mw.problemHandler.HandleProblem(newProblemOfKind(w, req, err, ProblemKindSyntax))
+		return false
 	}
 
+	if mw.normalizesUnicode(params) {
+		if err := normalizeUnicodeBody(req); err != nil {
+			if mw.handleCanceled(w, req, start) {
+				return false
+			}
+			recordCheck(req, CheckRequestBody, start, err, nil)
+			mw.problemHandler.HandleProblem(NewProblem(w, req, err))
+			return false
+		}
+	}
+
+	valid := true
+
 	// Read req.Body using io.TeeReader, so it can be read again
 	// in the actual request handler.
-	body, err := bodyPayload(req)
+	body, err := bodyPayload(req, mw.useNumber)
+	if mw.handleCanceled(w, req, start) {
+		return false
+	}
 	if err != nil {
-		e := fmt.Errorf("request body contains invalid json: %s", err)
-		mw.problemHandler.HandleProblem(NewProblem(w, req, e))
+		recordCheck(req, CheckRequestBody, start, err, nil)
+		mw.problemHandler.HandleProblem(newProblemOfKind(w, req, err, ProblemKindSyntax))
 		if !mw.continueOnProblem {
-			return
+			return false
 		}
+		valid = false
 	}
 
 	if errs := validate.Body(params, body); len(errs) > 0 {
 		me := newMultiError("request body does not match the schema", errs...)
-		mw.problemHandler.HandleProblem(NewProblem(w, req, me))
-		if !mw.continueOnProblem {
-			return
+		recordCheck(req, CheckRequestBody, start, me, nil)
+		mw.problemHandler.HandleProblem(newProblemOfKind(w, req, me, ProblemKindSemantic))
+		return false
+	} else if mw.handleCanceled(w, req, start) {
+		return false
+	} else if errs := mw.ruleErrors(req, body); len(errs) > 0 {
+		me := newMultiError("request body violates the operation rules", errs...)
+		recordCheck(req, CheckRequestBody, start, me, nil)
+		mw.problemHandler.HandleProblem(newProblemOfKind(w, req, me, ProblemKindSemantic))
+		return false
+	}
+
+	if valid {
+		recordCheck(req, CheckRequestBody, start, nil, nil)
+	}
+	return valid
+}
+
+// handleCanceled handles the problem of kind ProblemKindCanceled if the
+// context of the request is done. It returns true if it is, so the
+// validation must be aborted.
+func (mw *requestBodyValidator) handleCanceled(w http.ResponseWriter, req *http.Request, start time.Time) bool {
+	err := req.Context().Err()
+	if err == nil {
+		return false
+	}
+	e := &CanceledError{Err: err}
+	recordCheck(req, CheckRequestBody, start, e, nil)
+	mw.problemHandler.HandleProblem(newProblemOfKind(w, req, e, ProblemKindCanceled))
+	return true
+}
+
+// normalizesUnicode checks if the string values of the body should be
+// normalized.
+func (mw *requestBodyValidator) normalizesUnicode(params []spec.Parameter) bool {
+	for i := range params {
+		if params[i].In == "body" {
+			return normalizesUnicode(&params[i], mw.normalizeUnicode)
 		}
 	}
+	return mw.normalizeUnicode
+}
 
-	mw.next.ServeHTTP(w, req)
+// ruleErrors executes the body rules of the operation of the request.
+func (mw *requestBodyValidator) ruleErrors(req *http.Request, body interface{}) []error {
+	oi, ok := getOperationInfo(req)
+	if !ok {
+		return nil
+	}
+	return bodyRuleErrors(oi.operation.ID, body)
 }
 
 // matchContentType checks if content type of the request matches any selector.
@@ -89,17 +231,163 @@ func (mw *requestBodyValidator) matchContentType(req *http.Request) bool {
 }
 
 // bodyPayload reads req.Body and returns it. Request body can be
-// read again later.
-func bodyPayload(req *http.Request) (interface{}, error) {
+// read again later. If the body contains invalid json, the error is
+// a *JSONError. If useNumber is true, JSON numbers are decoded as
+// json.Number. Reading stops as soon as the context of the request is done.
+func bodyPayload(req *http.Request, useNumber bool) (interface{}, error) {
+	withContextBody(req)
+
 	buf := &bytes.Buffer{}
 	tr := io.TeeReader(req.Body, buf)
 	defer req.Body.Close()
 
 	var payload interface{}
-	if err := json.NewDecoder(tr).Decode(&payload); err != nil {
-		return nil, err
+	if err := decodeJSON(tr, &payload, useNumber); err != nil {
+		// Read the rest of the body to locate the error and to allow
+		// the body to be read again.
+		ioutil.ReadAll(tr) // nolint: errcheck
+		req.Body = ioutil.NopCloser(bytes.NewReader(buf.Bytes()))
+		return nil, newJSONError("request body contains invalid json", err, buf.Bytes())
 	}
 
 	req.Body = ioutil.NopCloser(buf)
 	return payload, nil
 }
+
+// isEmptyBody checks if the request has no body. The body of unknown length,
+// e.g. a chunked body, is checked by reading the first byte, which is put
+// back, so the body can be read again. The read is abandoned as soon as the
+// context of the request is done, and the body is then reported as not
+// empty, while reading it fails with the error of the context.
+func isEmptyBody(req *http.Request) bool {
+	if req.Body == nil || req.Body == http.NoBody {
+		return true
+	}
+	if req.ContentLength >= 0 {
+		return req.ContentLength == 0
+	}
+
+	type probe struct {
+		b   [1]byte
+		n   int
+		err error
+	}
+
+	body := req.Body
+	done := make(chan probe, 1)
+	go func() {
+		var p probe
+		p.n, p.err = io.ReadFull(body, p.b[:])
+		done <- p
+	}()
+
+	var p probe
+	select {
+	case p = <-done:
+	case <-req.Context().Done():
+		// The abandoned read may still be in progress, so the body must
+		// not be read past the context.
+		req.Body = readCloser{
+			Reader: contextReader{ctx: req.Context(), r: body},
+			Closer: body,
+		}
+		return false
+	}
+
+	if p.n == 0 && p.err == io.EOF {
+		body.Close() // nolint: errcheck
+		req.Body = http.NoBody
+		return true
+	}
+	req.Body = readCloser{
+		Reader: io.MultiReader(bytes.NewReader(p.b[:p.n]), body),
+		Closer: body,
+	}
+	return false
+}
+
+// expectsContinue checks if the client waits for 100 Continue before
+// sending the request body.
+func expectsContinue(req *http.Request) bool {
+	return strings.EqualFold(req.Header.Get("Expect"), "100-continue")
+}
+
+// mayHaveBody checks if the request may have a body. Unlike isEmptyBody, it
+// never reads the body of a request that expects 100 Continue, because
+// the server sends 100 Continue on the first read of the body, and the
+// client must not be asked for the body before the request passes the
+// validation of everything but the body.
+func mayHaveBody(req *http.Request) bool {
+	if expectsContinue(req) {
+		return req.Body != nil && req.Body != http.NoBody && req.ContentLength != 0
+	}
+	return !isEmptyBody(req)
+}
+
+// BodyTooLargeError describes a request body that exceeds the maximum size
+// set by WithMaxBodySize. Problem handlers usually respond with 413 Request
+// Entity Too Large on such error.
+type BodyTooLargeError struct {
+	// Limit is the maximum size of the body in bytes.
+	Limit int64
+}
+
+// Error implements error.
+func (e *BodyTooLargeError) Error() string {
+	return fmt.Sprintf("request body is larger than %d bytes", e.Limit)
+}
+
+// limitBody checks that the request body is not larger than limit bytes,
+// both by Content-Length and by the bytes actually read, so bodies of
+// unknown length, e.g. chunked bodies, are limited too. At most limit+1
+// bytes are read, and they are put back, so the body can be read again.
+func limitBody(req *http.Request, limit int64) error {
+	if req.ContentLength > limit {
+		return &BodyTooLargeError{Limit: limit}
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(req.Body, limit+1))
+	req.Body = readCloser{
+		Reader: io.MultiReader(bytes.NewReader(data), req.Body),
+		Closer: req.Body,
+	}
+	if err != nil {
+		return fmt.Errorf("read request body: %s", err)
+	}
+	if int64(len(data)) > limit {
+		return &BodyTooLargeError{Limit: limit}
+	}
+	return nil
+}
+
+// contextReader is a reader that fails with the error of the context once
+// the context is done, so reading a long body from a client that
+// disconnected is aborted promptly.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (cr contextReader) Read(p []byte) (int, error) {
+	if err := cr.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cr.r.Read(p)
+}
+
+// withContextBody makes the request body read through contextReader, unless
+// it already is, so the body is wrapped once however many times it is read.
+func withContextBody(req *http.Request) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return
+	}
+	if rc, ok := req.Body.(readCloser); ok {
+		if _, ok := rc.Reader.(contextReader); ok {
+			return
+		}
+	}
+	req.Body = readCloser{
+		Reader: contextReader{ctx: req.Context(), r: req.Body},
+		Closer: req.Body,
+	}
+}
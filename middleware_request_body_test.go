@@ -2,13 +2,16 @@ package oas
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"regexp"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -48,7 +51,7 @@ func TestRequestBodyValidator(t *testing.T) {
 			contentType:    "application/json",
 			body:           `{"name":"johndoe`,
 			expectedStatus: http.StatusBadRequest,
-			expectedBody:   `{"errors":[{"message":"request body contains invalid json: unexpected EOF"}]}`,
+			expectedBody:   `{"errors":[{"message":"request body contains invalid json: unexpected EOF at line 1, column 16 (offset 16)"}]}`,
 		},
 		"skip body validation for not application/json content type": {
 			contentType:    "text/plain",
@@ -85,6 +88,378 @@ func TestRequestBodyValidator(t *testing.T) {
 	}
 }
 
+func TestRequestBodyValidator_problemKind(t *testing.T) {
+	testCases := map[string]struct {
+		body           string
+		expectedKind   ProblemKind
+		expectedStatus int
+	}{
+		"invalid json body": {
+			body:           `{"name":"johndoe`,
+			expectedKind:   ProblemKindSyntax,
+			expectedStatus: http.StatusBadRequest,
+		},
+		"schema violation": {
+			body:           `{"age":7}`,
+			expectedKind:   ProblemKindSemantic,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+	params := doc.Analyzer.ParametersFor("addPet")
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var kind ProblemKind
+			v := &requestBodyValidator{
+				next:          http.HandlerFunc(handleAddPet),
+				jsonSelectors: []*regexp.Regexp{contentTypeSelectorRegexJSON},
+				problemHandler: ProblemHandlerFunc(func(p Problem) {
+					kind = p.Kind()
+					NewProblemKindResponder().HandleProblem(p)
+				}),
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/v2/pet", bytes.NewBufferString(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			v.ServeHTTP(w, req, params, true)
+
+			assert.Equal(t, tc.expectedKind, kind)
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestRequestBodyValidator_charset(t *testing.T) {
+	testCases := map[string]struct {
+		contentType     string
+		body            string
+		transcodeLatin1 bool
+		expectedStatus  int
+		expectedBody    string
+	}{
+		"utf-8 charset": {
+			contentType:    "application/json; charset=UTF-8",
+			body:           `{"name":"caf\u00e9","age":7}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   "pet name: café",
+		},
+		"invalid utf-8": {
+			contentType:    "application/json",
+			body:           "{\"name\":\"caf\xe9\",\"age\":7}",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "request body contains invalid UTF-8 sequence at offset 12",
+		},
+		"latin1 charset is rejected": {
+			contentType:    "application/json; charset=ISO-8859-1",
+			body:           "{\"name\":\"caf\xe9\",\"age\":7}",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "request body charset iso-8859-1 is not supported, JSON must be encoded in UTF-8",
+		},
+		"latin1 charset is transcoded": {
+			contentType:     "application/json; charset=latin1",
+			body:            "{\"name\":\"caf\xe9\",\"age\":7}",
+			transcodeLatin1: true,
+			expectedStatus:  http.StatusOK,
+			expectedBody:    "pet name: café",
+		},
+	}
+
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+	params := doc.Analyzer.ParametersFor("addPet")
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			v := &requestBodyValidator{
+				next:            http.HandlerFunc(handleAddPet),
+				jsonSelectors:   []*regexp.Regexp{contentTypeSelectorRegexJSON},
+				transcodeLatin1: tc.transcodeLatin1,
+				problemHandler:  newProblemHandlerErrorResponder(),
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/v2/pet", bytes.NewBufferString(tc.body))
+			req.Header.Set("Content-Type", tc.contentType)
+			w := httptest.NewRecorder()
+			v.ServeHTTP(w, req, params, true)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, tc.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestRequestBodyValidator_jsonNumbers(t *testing.T) {
+	testCases := map[string]struct {
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		"large integer": {
+			body:           `{"name":"johndoe","age":9007199254740993}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   "pet name: johndoe",
+		},
+		"not an integer": {
+			body:           `{"name":"johndoe","age":7.5}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `request body does not match the schema: age in body must be of type integer: "number"`,
+		},
+	}
+
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+	params := doc.Analyzer.ParametersFor("addPet")
+
+	v := &requestBodyValidator{
+		next:           http.HandlerFunc(handleAddPet),
+		jsonSelectors:  []*regexp.Regexp{contentTypeSelectorRegexJSON},
+		useNumber:      true,
+		problemHandler: newProblemHandlerErrorResponder(),
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/v2/pet", bytes.NewBufferString(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			v.ServeHTTP(w, req, params, true)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, tc.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestRequestBodyValidator_unknownLength(t *testing.T) {
+	testCases := map[string]struct {
+		body           string
+		contentLength  int64
+		expectedStatus int
+		expectedBody   string
+	}{
+		"chunked body": {
+			body:           `{"name":"johndoe","age":7}`,
+			contentLength:  -1,
+			expectedStatus: http.StatusOK,
+			expectedBody:   "pet name: johndoe",
+		},
+		"empty chunked body": {
+			body:           "",
+			contentLength:  -1,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "request body is empty, but the operation requires non-empty body",
+		},
+		"chunked body over the limit": {
+			body:           `{"name":"johndoe","age":7,"photoUrls":["http://example.com/rex.png"]}`,
+			contentLength:  -1,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "request body is larger than 32 bytes",
+		},
+		"declared length over the limit": {
+			body:           `{"name":"johndoe","age":7}`,
+			contentLength:  64,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "request body is larger than 32 bytes",
+		},
+	}
+
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+	params := doc.Analyzer.ParametersFor("addPet")
+
+	v := &requestBodyValidator{
+		next:           http.HandlerFunc(handleAddPet),
+		jsonSelectors:  []*regexp.Regexp{contentTypeSelectorRegexJSON},
+		maxBodySize:    32,
+		problemHandler: newProblemHandlerErrorResponder(),
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			// Hide the type of the reader, so the length of the body is
+			// unknown, as with Transfer-Encoding: chunked.
+			body := struct{ io.Reader }{bytes.NewBufferString(tc.body)}
+			req := httptest.NewRequest(http.MethodPost, "/v2/pet", body)
+			req.ContentLength = tc.contentLength
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			v.ServeHTTP(w, req, params, true)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, tc.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestRequestBodyValidator_canceled(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+	params := doc.Analyzer.ParametersFor("addPet")
+
+	var problems []Problem
+	called := false
+	v := &requestBodyValidator{
+		next: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			called = true
+		}),
+		jsonSelectors: []*regexp.Regexp{contentTypeSelectorRegexJSON},
+		problemHandler: ProblemHandlerFunc(func(p Problem) {
+			problems = append(problems, p)
+		}),
+		continueOnProblem: true,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	body := &cancelingReader{r: bytes.NewBufferString(`{"name":"johndoe","age":7}`), cancel: cancel}
+	req := httptest.NewRequest(http.MethodPost, "/v2/pet", body).WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	v.ServeHTTP(httptest.NewRecorder(), req, params, true)
+
+	assert.False(t, called)
+	assert.Equal(t, 1, body.reads)
+	if assert.Len(t, problems, 1) {
+		assert.Equal(t, ProblemKindCanceled, problems[0].Kind())
+		ce, ok := problems[0].Cause().(*CanceledError)
+		if assert.True(t, ok) {
+			assert.Equal(t, context.Canceled, ce.Err)
+		}
+	}
+}
+
+func TestRequestBodyValidator_canceledNormalization(t *testing.T) {
+	testCases := map[string]struct {
+		contentType     string
+		transcodeLatin1 bool
+		maxBodySize     int64
+	}{
+		"latin1 body is transcoded": {
+			contentType:     "application/json; charset=latin1",
+			transcodeLatin1: true,
+		},
+		"body size is limited": {
+			contentType: "application/json",
+			maxBodySize: 1024,
+		},
+	}
+
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+	params := doc.Analyzer.ParametersFor("addPet")
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var problems []Problem
+			called := false
+			v := &requestBodyValidator{
+				next: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+					called = true
+				}),
+				jsonSelectors:   []*regexp.Regexp{contentTypeSelectorRegexJSON},
+				transcodeLatin1: tc.transcodeLatin1,
+				maxBodySize:     tc.maxBodySize,
+				problemHandler: ProblemHandlerFunc(func(p Problem) {
+					problems = append(problems, p)
+				}),
+				continueOnProblem: true,
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			body := &cancelingReader{r: bytes.NewBufferString("{\"name\":\"caf\xe9\",\"age\":7}"), cancel: cancel}
+			req := httptest.NewRequest(http.MethodPost, "/v2/pet", body).WithContext(ctx)
+			req.Header.Set("Content-Type", tc.contentType)
+			v.ServeHTTP(httptest.NewRecorder(), req, params, true)
+
+			assert.False(t, called)
+			assert.Equal(t, 1, body.reads)
+			if assert.Len(t, problems, 1) {
+				assert.Equal(t, ProblemKindCanceled, problems[0].Kind())
+				ce, ok := problems[0].Cause().(*CanceledError)
+				if assert.True(t, ok) {
+					assert.Equal(t, context.Canceled, ce.Err)
+				}
+			}
+		})
+	}
+}
+
+func TestRequestBodyValidator_slowBody(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+	params := doc.Analyzer.ParametersFor("addPet")
+
+	var problems []Problem
+	load := NewValidationLoad(1)
+	v := &requestBodyValidator{
+		next: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			t.Error("next handler is called")
+		}),
+		jsonSelectors: []*regexp.Regexp{contentTypeSelectorRegexJSON},
+		load:          load,
+		problemHandler: ProblemHandlerFunc(func(p Problem) {
+			problems = append(problems, p)
+		}),
+	}
+
+	// The client never sends the body, so probing it for emptiness blocks.
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	body := &signalingReader{r: pr, started: make(chan struct{})}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodPost, "/v2/pet", body).WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		v.ServeHTTP(httptest.NewRecorder(), req, params, true)
+	}()
+
+	<-body.started
+	select {
+	case <-done:
+		t.Fatal("validation is done before the body is sent")
+	case <-time.After(50 * time.Millisecond):
+	}
+	assert.Equal(t, 1, load.Depth())
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("validation is not done after the context is canceled")
+	}
+
+	assert.Equal(t, 0, load.Depth())
+	if assert.Len(t, problems, 1) {
+		assert.Equal(t, ProblemKindCanceled, problems[0].Kind())
+	}
+}
+
+// cancelingReader is a reader that reads a single byte at a time and
+// cancels the context after the first read, as if the client disconnected.
+type cancelingReader struct {
+	r      io.Reader
+	cancel context.CancelFunc
+	reads  int
+}
+
+func (cr *cancelingReader) Read(p []byte) (int, error) {
+	cr.reads++
+	defer cr.cancel()
+	return cr.r.Read(p[:1])
+}
+
+// signalingReader is a reader that closes the started channel on the first
+// read.
+type signalingReader struct {
+	r       io.Reader
+	started chan struct{}
+	once    sync.Once
+}
+
+func (sr *signalingReader) Read(p []byte) (int, error) {
+	sr.once.Do(func() { close(sr.started) })
+	return sr.r.Read(p)
+}
+
 func handleAddPet(w http.ResponseWriter, req *http.Request) {
 	type pet struct {
 		Name      string   `json:"name"`
@@ -2,10 +2,11 @@ package oas
 
 import (
 	"bytes"
-	"encoding/json"
 	"fmt"
 	"net/http"
 	"regexp"
+	"sort"
+	"time"
 
 	"github.com/go-openapi/spec"
 
@@ -23,6 +24,20 @@ type responseBodyValidator struct {
 	jsonSelectors []*regexp.Regexp
 
 	problemHandler ProblemHandler
+
+	// sampler, if set, observes the validation time.
+	sampler *ResponseSampler
+
+	// headerSeverities are the severities of response header problems
+	// by canonical header names.
+	headerSeverities map[string]Severity
+
+	// useNumber makes JSON numbers decoded as json.Number.
+	useNumber bool
+
+	// hooks mutate validated responses. If there are any, the response
+	// is held until it is validated and mutated.
+	hooks []ResponseHook
 }
 
 func (mw *responseBodyValidator) ServeHTTP(w http.ResponseWriter, req *http.Request, responses *spec.Responses, ok bool) {
@@ -31,14 +46,32 @@ func (mw *responseBodyValidator) ServeHTTP(w http.ResponseWriter, req *http.Requ
 		return
 	}
 
+	if len(mw.hooks) > 0 {
+		mw.serveHeld(w, req, responses)
+		return
+	}
+
 	respBuf := &bytes.Buffer{}
 	rr := newWrapResponseWriter(w, 1)
 	rr.Tee(respBuf)
 
 	mw.next.ServeHTTP(rr, req)
 
+	mw.validate(w, req, responses, rr.Status(), rr.Header(), respBuf)
+}
+
+// validate validates the response. It returns the decoded body if the
+// response is a JSON response that matches the schema.
+func (mw *responseBodyValidator) validate(w http.ResponseWriter, req *http.Request, responses *spec.Responses, status int, header http.Header, respBuf *bytes.Buffer) (interface{}, bool) {
+	start := time.Now()
+	if mw.sampler != nil {
+		if oi, ok := getOperationInfo(req); ok {
+			defer func() { mw.sampler.observe(oi.operation.ID, time.Since(start)) }()
+		}
+	}
+
 	// First of all, check if response is defined for the status code.
-	responseSpec, ok := responses.StatusCodeResponses[rr.Status()]
+	responseSpec, ok := responses.StatusCodeResponses[status]
 	if !ok {
 		// If no response is explicitly defined for the status code, consider it
 		// is ok.
@@ -46,9 +79,11 @@ func (mw *responseBodyValidator) ServeHTTP(w http.ResponseWriter, req *http.Requ
 		// Quote from OpenAPI 2.0 spec:
 		// > It is not expected from the documentation to necessarily cover all
 		// > possible HTTP response codes, since they may not be known in advance.
-		return
+		return nil, false
 	}
 
+	mw.validateHeaders(w, req, header, responseSpec.Headers)
+
 	if responseSpec.Schema == nil {
 		// This may be ok for example for HTTP 204 responses, but any response
 		// with a body should explicitly define a schema.
@@ -57,30 +92,37 @@ func (mw *responseBodyValidator) ServeHTTP(w http.ResponseWriter, req *http.Requ
 		// > If this field does not exist, it means no content is returned as
 		// > part of the response.
 		if respBuf.Len() > 0 {
-			e := fmt.Errorf("response has non-emtpy body, but the operation does not define response schema for code %d", rr.Status())
-			mw.problemHandler.HandleProblem(NewProblem(w, req, e))
+			e := fmt.Errorf("response has non-emtpy body, but the operation does not define response schema for code %d", status)
+			recordCheck(req, CheckResponseBody, start, e, nil)
+			mw.problemHandler.HandleProblem(newProblemOfKind(w, req, e, ProblemKindSemantic))
 		}
-		return
+		return nil, false
 	}
 
 	// Check the content type of the response. If it does not match any selector,
 	// don't validate the response.
-	if !mw.matchContentType(rr.Header()) {
-		return
+	if !mw.matchContentType(header) {
+		return nil, false
 	}
 
+	data := respBuf.Bytes()
 	var body interface{}
-	if err := json.NewDecoder(respBuf).Decode(&body); err != nil {
-		e := fmt.Errorf("response body contains invalid json: %s", err)
-		mw.problemHandler.HandleProblem(NewProblem(w, req, e))
-		return
+	if err := decodeJSON(respBuf, &body, mw.useNumber); err != nil {
+		e := newJSONError("response body contains invalid json", err, data)
+		recordCheck(req, CheckResponseBody, start, e, nil)
+		mw.problemHandler.HandleProblem(newProblemOfKind(w, req, e, ProblemKindSyntax))
+		return nil, false
 	}
 
 	if errs := validate.BySchema(responseSpec.Schema, body); len(errs) > 0 {
 		me := newMultiError("response body does not match the schema", errs...)
-		mw.problemHandler.HandleProblem(NewProblem(w, req, me))
-		return
+		recordCheck(req, CheckResponseBody, start, me, nil)
+		mw.problemHandler.HandleProblem(newProblemOfKind(w, req, me, ProblemKindSemantic))
+		return nil, false
 	}
+
+	recordCheck(req, CheckResponseBody, start, nil, nil)
+	return body, true
 }
 
 // matchContentType checks if content type of the request matches any selector.
@@ -94,3 +136,46 @@ func (mw *responseBodyValidator) matchContentType(hdr http.Header) bool {
 
 	return false
 }
+
+// validateHeaders validates the response headers declared in the spec.
+func (mw *responseBodyValidator) validateHeaders(w http.ResponseWriter, req *http.Request, hdr http.Header, headers map[string]spec.Header) {
+	if len(headers) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs, warns []error
+	for _, name := range names {
+		severity := mw.headerSeverities[http.CanonicalHeaderKey(name)]
+		if severity == SeverityIgnore {
+			continue
+		}
+
+		var herrs []error
+		if vals, ok := hdr[http.CanonicalHeaderKey(name)]; ok {
+			herrs = validate.Header(name, headers[name], vals)
+		} else {
+			herrs = []error{validate.ValidationErrorf(name, nil, "response header %s is missing", name)}
+		}
+
+		if severity == SeverityWarning {
+			warns = append(warns, herrs...)
+		} else {
+			errs = append(errs, herrs...)
+		}
+	}
+
+	if len(warns) > 0 {
+		me := newMultiError("response headers do not match the spec", warns...)
+		newProblemHandlerWarnLogger("response").HandleProblem(newProblemOfKind(w, req, me, ProblemKindSemantic))
+	}
+	if len(errs) > 0 {
+		me := newMultiError("response headers do not match the spec", errs...)
+		mw.problemHandler.HandleProblem(newProblemOfKind(w, req, me, ProblemKindSemantic))
+	}
+}
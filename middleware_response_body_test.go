@@ -41,7 +41,7 @@ func TestResponseBodyValidator(t *testing.T) {
 			url:               "/v2/pet/badjson",
 			expectedStatus:    http.StatusOK,
 			expectedBody:      `{"name":`,
-			expectedLogBuffer: "problem handler: response body contains invalid json: unexpected EOF",
+			expectedLogBuffer: "problem handler: response body contains invalid json: unexpected EOF at line 1, column 8 (offset 8)",
 		},
 	}
 
@@ -110,3 +110,78 @@ func handleGetPetByIDFaked(w http.ResponseWriter, req *http.Request) {
 	err := json.NewEncoder(w).Encode(p)
 	assertNoError(err)
 }
+
+func TestResponseBodyValidator_headers(t *testing.T) {
+	testCases := map[string]struct {
+		header            http.Header
+		severities        map[string]Severity
+		expectedLogBuffer string
+	}{
+		"valid headers": {
+			header: http.Header{
+				"X-Rate-Limit":    []string{"100"},
+				"X-Expires-After": []string{"2018-01-02T15:04:05Z"},
+			},
+			expectedLogBuffer: "",
+		},
+		"missing and invalid headers": {
+			header: http.Header{
+				"X-Expires-After": []string{"tomorrow"},
+			},
+			expectedLogBuffer: "problem handler: response headers do not match the spec: " +
+				"field=X-Expires-After value=tomorrow message=X-Expires-After in header must be of type date-time: \"tomorrow\"\n" +
+				"problem handler: response headers do not match the spec: " +
+				"field=X-Rate-Limit value=<nil> message=response header X-Rate-Limit is missing",
+		},
+		"ignored header": {
+			header: http.Header{
+				"X-Expires-After": []string{"2018-01-02T15:04:05Z"},
+			},
+			severities:        map[string]Severity{"x-rate-limit": SeverityIgnore},
+			expectedLogBuffer: "",
+		},
+		"warning header": {
+			header: http.Header{
+				"X-Expires-After": []string{"2018-01-02T15:04:05Z"},
+			},
+			severities:        map[string]Severity{"X-Rate-Limit": SeverityWarning},
+			expectedLogBuffer: "",
+		},
+	}
+
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+	_, _, op, ok := doc.Analyzer.OperationForName("loginUser")
+	assert.True(t, ok)
+
+	logBuffer := &bytes.Buffer{}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			logBuffer.Reset()
+			defer logBuffer.Reset()
+
+			var opts []MiddlewareOption
+			for h, s := range tc.severities {
+				opts = append(opts, WithResponseHeaderSeverity(h, s))
+			}
+
+			v := &responseBodyValidator{
+				next: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+					for k, v := range tc.header {
+						w.Header()[k] = v
+					}
+					w.Header().Set("Content-Type", "application/json")
+					w.Write([]byte(`"token"`)) // nolint: errcheck
+				}),
+				jsonSelectors:    []*regexp.Regexp{contentTypeSelectorRegexJSON},
+				problemHandler:   problemHandlerBufferLogger(logBuffer),
+				headerSeverities: parseMiddlewareOptions(opts...).headerSeverities,
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/v2/user/login", nil)
+			v.ServeHTTP(httptest.NewRecorder(), req, op.Responses, true)
+
+			assert.Equal(t, tc.expectedLogBuffer, strings.TrimSpace(logBuffer.String()))
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package oas
+
+import (
+	"net/http"
+)
+
+// UndeclaredStatusFunc is called when a handler responds with a status code
+// that is not declared for the operation.
+type UndeclaredStatusFunc func(req *http.Request, operationID string, status int)
+
+// undeclaredStatusDetector is a middleware that detects responses with status
+// codes that are not declared for the operation.
+type undeclaredStatusDetector struct {
+	next http.Handler
+	fn   UndeclaredStatusFunc
+}
+
+func (mw *undeclaredStatusDetector) ServeHTTP(w http.ResponseWriter, req *http.Request, oi operationInfo, ok bool) {
+	if !ok {
+		mw.next.ServeHTTP(w, req)
+		return
+	}
+
+	rr := newWrapResponseWriter(w, req.ProtoMajor)
+	mw.next.ServeHTTP(rr, req)
+
+	status := rr.Status()
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if !documentedStatus(oi, status) {
+		mw.fn(req, oi.operation.ID, status)
+	}
+}
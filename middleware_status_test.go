@@ -0,0 +1,43 @@
+package oas
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUndeclaredStatusDetector(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+
+	var detected []string
+	fn := func(req *http.Request, operationID string, status int) {
+		detected = append(detected, fmt.Sprintf("%s %d", operationID, status))
+	}
+
+	basis := NewResolvingBasis(SpecAdapterName, doc, BasisStrict(false))
+	h := SpecMatcherMiddleware(doc)(
+		basis.UndeclaredStatusDetector(fn)(
+			http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				switch req.URL.Path {
+				case "/v2/pet/1":
+					w.WriteHeader(http.StatusNotFound)
+				case "/v2/pet/2":
+					w.WriteHeader(http.StatusServiceUnavailable)
+				case "/v2/pet/3":
+					w.Write([]byte("{}")) // nolint: errcheck
+				default:
+					w.WriteHeader(http.StatusTeapot)
+				}
+			}),
+		),
+	)
+
+	for _, path := range []string{"/v2/pet/1", "/v2/pet/2", "/v2/pet/3", "/v2/unknown"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	assert.Equal(t, []string{"getPetById 503"}, detected)
+}
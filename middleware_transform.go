@@ -0,0 +1,159 @@
+package oas
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/go-openapi/spec"
+)
+
+// TransformExtension is the parameter extension that declares
+// transformations of query and header parameter values, applied by
+// ParamTransformer before validation. Transformations are applied in order
+// to each raw value:
+//
+//  parameters:
+//  - name: status
+//    in: query
+//    type: string
+//    enum: [available, sold]
+//    x-oas-transform:
+//    - trim
+//    - toLower
+//    - map:
+//        on-sale: available
+//
+// Supported transformations are "trim", "toLower", "toUpper", and "map",
+// which replaces values found in the mapping, e.g. old enum values with new
+// ones. This allows small client compatibility shims to live in the spec.
+const TransformExtension = "x-oas-transform"
+
+// valueTransform transforms a raw parameter value.
+type valueTransform func(string) string
+
+// paramTransforms are the transformations of a parameter.
+type paramTransforms struct {
+	name       string
+	in         string
+	transforms []valueTransform
+}
+
+// compileParamTransforms compiles transformations of the parameters.
+func compileParamTransforms(params []spec.Parameter) ([]paramTransforms, error) {
+	var pts []paramTransforms
+	for _, p := range params {
+		ext, ok := p.Extensions[TransformExtension]
+		if !ok {
+			continue
+		}
+		if p.In != "query" && p.In != "header" {
+			return nil, fmt.Errorf("param %s: %s is supported only for query and header params", p.Name, TransformExtension)
+		}
+
+		transforms, err := parseTransforms(ext)
+		if err != nil {
+			return nil, fmt.Errorf("param %s: invalid %s: %s", p.Name, TransformExtension, err)
+		}
+		pts = append(pts, paramTransforms{name: p.Name, in: p.In, transforms: transforms})
+	}
+
+	sort.Slice(pts, func(i, j int) bool { return pts[i].name < pts[j].name })
+	return pts, nil
+}
+
+// parseTransforms parses the value of TransformExtension.
+func parseTransforms(ext interface{}) ([]valueTransform, error) {
+	items, ok := ext.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("value is not a list")
+	}
+
+	transforms := make([]valueTransform, 0, len(items))
+	for _, item := range items {
+		switch t := item.(type) {
+		case string:
+			switch t {
+			case "trim":
+				transforms = append(transforms, strings.TrimSpace)
+			case "toLower":
+				transforms = append(transforms, strings.ToLower)
+			case "toUpper":
+				transforms = append(transforms, strings.ToUpper)
+			default:
+				return nil, fmt.Errorf("unknown transformation %s", t)
+			}
+		case map[string]interface{}:
+			m, ok := t["map"].(map[string]interface{})
+			if !ok || len(t) != 1 {
+				return nil, fmt.Errorf("unknown transformation %v", t)
+			}
+			mapping := make(map[string]string, len(m))
+			for k, v := range m {
+				mapping[k] = fmt.Sprint(v)
+			}
+			transforms = append(transforms, func(s string) string {
+				if v, ok := mapping[s]; ok {
+					return v
+				}
+				return s
+			})
+		default:
+			return nil, fmt.Errorf("unknown transformation %v", t)
+		}
+	}
+	return transforms, nil
+}
+
+// paramTransformer is a middleware that transforms request parameter values
+// by their TransformExtension.
+type paramTransformer struct {
+	next http.Handler
+
+	// operations are parameter transformations by operation id.
+	operations map[string][]paramTransforms
+}
+
+func (mw *paramTransformer) ServeHTTP(w http.ResponseWriter, req *http.Request, oi operationInfo, ok bool) {
+	if !ok || len(mw.operations[oi.operation.ID]) == 0 {
+		mw.next.ServeHTTP(w, req)
+		return
+	}
+
+	u := *req.URL
+	q := u.Query()
+	header := cloneHeader(req.Header)
+
+	for _, pt := range mw.operations[oi.operation.ID] {
+		var vals []string
+		switch pt.in {
+		case "query":
+			vals = q[pt.name]
+		case "header":
+			vals = header[http.CanonicalHeaderKey(pt.name)]
+		}
+		for i, v := range vals {
+			for _, t := range pt.transforms {
+				v = t(v)
+			}
+			vals[i] = v
+		}
+	}
+
+	u.RawQuery = q.Encode()
+
+	r := req.WithContext(req.Context())
+	r.URL = &u
+	r.Header = header
+	mw.next.ServeHTTP(w, r)
+}
+
+// cloneHeader returns a deep copy of the header.
+func cloneHeader(h http.Header) http.Header {
+	c := make(http.Header, len(h))
+	for k, vv := range h {
+		c[k] = append([]string(nil), vv...)
+	}
+	return c
+}
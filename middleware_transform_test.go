@@ -0,0 +1,89 @@
+package oas
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParamTransformer(t *testing.T) {
+	doc := loadDocBytes([]byte(`
+swagger: "2.0"
+info:
+  title: Test
+  version: 1.0.0
+basePath: /v2
+paths:
+  /pets:
+    get:
+      operationId: findPets
+      parameters:
+      - name: status
+        in: query
+        type: string
+        enum: [available, sold]
+        x-oas-transform:
+        - trim
+        - toLower
+        - map:
+            on-sale: available
+      - name: X-Region
+        in: header
+        type: string
+        x-oas-transform:
+        - toUpper
+      responses:
+        200:
+          description: OK
+`))
+
+	basis := NewResolvingBasis(SpecAdapterName, doc, BasisStrict(false))
+	h := SpecMatcherMiddleware(doc)(
+		basis.ParamTransformer()(
+			basis.QueryValidator()(
+				http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+					fmt.Fprintf(w, "%s %s", req.URL.Query().Get("status"), req.Header.Get("X-Region"))
+				}),
+			),
+		),
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "/v2/pets?status=+On-Sale+", nil)
+	req.Header.Set("X-Region", "eu")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "available EU", w.Body.String())
+	assert.Equal(t, "eu", req.Header.Get("X-Region"), "original request must not be modified")
+}
+
+func TestParamTransformer_invalid(t *testing.T) {
+	doc := loadDocBytes([]byte(`
+swagger: "2.0"
+info:
+  title: Test
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      operationId: findPets
+      parameters:
+      - name: status
+        in: query
+        type: string
+        x-oas-transform:
+        - reverse
+      responses:
+        200:
+          description: OK
+`))
+
+	basis := NewResolvingBasis(SpecAdapterName, doc, BasisStrict(false))
+	assert.PanicsWithValue(t, "oas: operation findPets: param status: invalid x-oas-transform: unknown transformation reverse", func() {
+		basis.ParamTransformer()
+	})
+}
@@ -0,0 +1,183 @@
+package oas
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/go-openapi/analysis"
+	"github.com/go-openapi/spec"
+)
+
+// MockDefinitionFunc returns a value for an instance of a spec definition
+// in a mock response, e.g. a Pet with a realistic name. The request is the
+// one the mock responds to. If the function returns nil, the value is
+// synthesized from the definition schema.
+type MockDefinitionFunc func(req *http.Request) interface{}
+
+// MockOptions represent options for mock handlers.
+type MockOptions struct {
+	definitions map[string]MockDefinitionFunc
+}
+
+// MockOption is an option for mock handlers.
+type MockOption func(*MockOptions)
+
+// MockDefinition returns an option that overrides generation of values
+// for the named spec definition, e.g. "Pet".
+func MockDefinition(name string, fn MockDefinitionFunc) MockOption {
+	return func(o *MockOptions) {
+		o.definitions[name] = fn
+	}
+}
+
+// MockHandlers returns mock handlers for all operations in the document,
+// keyed by operation id, so they can be passed to the operation router as is
+// or combined with real handlers using WithFallbackHandler.
+//
+// A mock handler responds with the lowest 2xx response defined for the
+// operation, or the default response, or the lowest response defined. Response body is the example defined
+// for the response media type; when there is no example, the body is
+// synthesized from the response schema using schema examples, defaults,
+// enums and formats. Values of definitions with a model registered with
+// RegisterModel are shaped by the model type. Response headers are
+// synthesized the same way.
+func MockHandlers(doc *Document, opts ...MockOption) map[string]http.Handler {
+	options := MockOptions{definitions: make(map[string]MockDefinitionFunc)}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	// Use the original spec, so the definitions can be recognized by
+	// references to them.
+	orig := analysis.New(doc.OrigSpec())
+
+	handlers := make(map[string]http.Handler)
+	for method, paths := range orig.Operations() {
+		for path, op := range paths {
+			handlers[op.ID] = &mockHandler{
+				definitions: doc.OrigSpec().Definitions,
+				overrides:   options.definitions,
+				produces:    orig.ProducesFor(op),
+				method:      method,
+				path:        path,
+				operation:   op,
+			}
+		}
+	}
+	return handlers
+}
+
+// mockHandler responds with a mock response for the operation.
+type mockHandler struct {
+	definitions spec.Definitions
+	overrides   map[string]MockDefinitionFunc
+	produces    []string
+
+	method    string
+	path      string
+	operation *spec.Operation
+}
+
+func (h *mockHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	code, resp := mockResponse(h.operation)
+
+	sampler := schemaSampler{
+		definitions: h.definitions,
+		overrides:   make(map[string]func() interface{}, len(h.overrides)),
+	}
+	for name, fn := range h.overrides {
+		fn := fn
+		sampler.overrides[name] = func() interface{} { return fn(req) }
+	}
+
+	if resp == nil {
+		w.WriteHeader(code)
+		return
+	}
+
+	for name, header := range resp.Headers {
+		w.Header().Set(name, fmt.Sprint(mockHeaderValue(header)))
+	}
+
+	contentType := jsonMediaType(h.produces)
+
+	body, ok := resp.Examples[contentType]
+	if !ok && resp.Schema != nil {
+		body = sampler.sample(resp.Schema, 0)
+		ok = body != nil
+	}
+	if !ok {
+		w.WriteHeader(code)
+		return
+	}
+
+	b, err := json.Marshal(body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("oas: mock %s %s: %s", h.method, h.path, err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(code)
+	w.Write(b) // nolint: errcheck
+}
+
+// mockResponse returns the status code and the response to mock for the
+// operation: the lowest 2xx response, or the default response, or the
+// lowest response defined.
+func mockResponse(op *spec.Operation) (int, *spec.Response) {
+	if op.Responses == nil {
+		return http.StatusOK, nil
+	}
+
+	codes := make([]int, 0, len(op.Responses.StatusCodeResponses))
+	for code := range op.Responses.StatusCodeResponses {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+
+	for _, code := range codes {
+		if code >= 200 && code < 300 {
+			resp := op.Responses.StatusCodeResponses[code]
+			return code, &resp
+		}
+	}
+	if op.Responses.Default != nil {
+		return http.StatusOK, op.Responses.Default
+	}
+	if len(codes) > 0 {
+		resp := op.Responses.StatusCodeResponses[codes[0]]
+		return codes[0], &resp
+	}
+	return http.StatusOK, nil
+}
+
+// jsonMediaType returns the JSON media type the operation produces.
+func jsonMediaType(produces []string) string {
+	for _, ct := range produces {
+		if strings.Contains(ct, "json") {
+			return ct
+		}
+	}
+	return "application/json"
+}
+
+// mockHeaderValue returns a value for the response header.
+func mockHeaderValue(h spec.Header) interface{} {
+	if h.Default != nil {
+		return h.Default
+	}
+	if len(h.Enum) > 0 {
+		return h.Enum[0]
+	}
+	if h.Type == "array" && h.Items != nil {
+		return sampleItems(h.Items)
+	}
+	return samplePrimitive(h.Type, h.Format, numberBounds{
+		min: h.Minimum, exclMin: h.ExclusiveMinimum,
+		max: h.Maximum, exclMax: h.ExclusiveMaximum,
+	}, h.MinLength, h.MaxLength)
+}
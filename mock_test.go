@@ -0,0 +1,70 @@
+package oas
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockHandlers(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+
+	t.Run("synthesizes responses", func(t *testing.T) {
+		handlers := MockHandlers(doc)
+		assert.Len(t, handlers, 3)
+
+		w := httptest.NewRecorder()
+		handlers["getPetById"].ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v2/pet/12", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{"id":1,"name":"doggie","age":7,"status":"available"}`, w.Body.String())
+
+		w = httptest.NewRecorder()
+		handlers["loginUser"].ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v2/user/login", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `"sample"`, w.Body.String())
+		assert.Equal(t, "1", w.Header().Get("X-Rate-Limit"))
+		assert.Equal(t, "2018-01-02T15:04:05Z", w.Header().Get("X-Expires-After"))
+
+		w = httptest.NewRecorder()
+		handlers["addPet"].ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v2/pet", nil))
+
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		assert.Empty(t, w.Body.String())
+	})
+
+	t.Run("overrides definitions", func(t *testing.T) {
+		handlers := MockHandlers(doc, MockDefinition("Pet", func(req *http.Request) interface{} {
+			return map[string]interface{}{"name": "Rex", "age": 3, "path": req.URL.Path}
+		}))
+
+		w := httptest.NewRecorder()
+		handlers["getPetById"].ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v2/pet/12", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"name":"Rex","age":3,"path":"/v2/pet/12"}`, w.Body.String())
+	})
+
+	t.Run("mock responses pass response validation", func(t *testing.T) {
+		basis := NewResolvingBasis(SpecAdapterName, doc, BasisStrict(false))
+		buf := &bytes.Buffer{}
+
+		mux := http.NewServeMux()
+		mux.Handle("/v2/pet/", MockHandlers(doc)["getPetById"])
+
+		h := SpecMatcherMiddleware(doc)(
+			basis.ResponseBodyValidator(WithProblemHandler(problemHandlerBufferLogger(buf)))(mux),
+		)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v2/pet/12", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, buf.String())
+	})
+}
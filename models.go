@@ -0,0 +1,249 @@
+package oas
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/go-openapi/analysis"
+	"github.com/go-openapi/spec"
+)
+
+var (
+	modelsMx sync.RWMutex
+	models   = make(map[string]reflect.Type)
+)
+
+// RegisterModel registers the Go type of the model as the type of the named
+// spec definition, e.g.:
+//
+//  oas.RegisterModel("Pet", Pet{})
+//
+// Registered types are used by DecodeBody and WriteResponse, and by mock
+// handlers to shape the generated values, which gives round-trip type safety
+// without code generation. If this function is called twice with the same
+// name or if model is nil, it panics.
+func RegisterModel(name string, model interface{}) {
+	modelsMx.Lock()
+	defer modelsMx.Unlock()
+
+	if model == nil {
+		panic("oas: RegisterModel model is nil")
+	}
+	if _, dup := models[name]; dup {
+		panic("oas: RegisterModel called twice for model " + name)
+	}
+
+	t := reflect.TypeOf(model)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	models[name] = t
+}
+
+// lookupModel returns the Go type registered for the definition.
+func lookupModel(name string) (reflect.Type, bool) {
+	modelsMx.RLock()
+	defer modelsMx.RUnlock()
+
+	t, ok := models[name]
+	return t, ok
+}
+
+// modelRef is a reference to a spec definition, or to an array of them.
+type modelRef struct {
+	name  string
+	array bool
+}
+
+// goType returns the Go type registered for the referenced definition.
+func (m modelRef) goType() (reflect.Type, bool) {
+	if m.name == "" {
+		return nil, false
+	}
+	t, ok := lookupModel(m.name)
+	if !ok {
+		return nil, false
+	}
+	if m.array {
+		t = reflect.SliceOf(t)
+	}
+	return t, true
+}
+
+func (m modelRef) String() string {
+	if m.array {
+		return "array of " + m.name
+	}
+	return m.name
+}
+
+// operationModels are the spec definitions the operation body and responses
+// refer to.
+type operationModels struct {
+	body      modelRef
+	responses map[int]modelRef
+	fallback  modelRef
+}
+
+// response returns the definition the response with the status code refers
+// to. If there is no such response, the default response is used.
+func (m operationModels) response(code int) modelRef {
+	if ref, ok := m.responses[code]; ok {
+		return ref
+	}
+	return m.fallback
+}
+
+// operationModels returns the spec definitions the operation refers to.
+// Definitions are looked up in the original spec, because references are
+// resolved in the expanded one.
+func (doc *Document) operationModels(operationID string) operationModels {
+	doc.origOnce.Do(func() {
+		doc.origAnalyzer = analysis.New(doc.OrigSpec())
+	})
+
+	var models operationModels
+
+	_, _, op, ok := doc.origAnalyzer.OperationForName(operationID)
+	if !ok {
+		return models
+	}
+
+	for _, p := range doc.origAnalyzer.ParametersFor(operationID) {
+		if p.In == "body" {
+			models.body = schemaModelRef(p.Schema)
+		}
+	}
+
+	if op.Responses != nil {
+		models.responses = make(map[int]modelRef)
+		for code, resp := range op.Responses.StatusCodeResponses {
+			models.responses[code] = schemaModelRef(resp.Schema)
+		}
+		if op.Responses.Default != nil {
+			models.fallback = schemaModelRef(op.Responses.Default.Schema)
+		}
+	}
+
+	return models
+}
+
+// schemaModelRef returns the definition the schema refers to, if any.
+func schemaModelRef(schema *spec.Schema) modelRef {
+	if schema == nil {
+		return modelRef{}
+	}
+
+	if schema.Items != nil && schema.Items.Schema != nil && schemaType(schema) == "array" {
+		return modelRef{name: definitionName(schema.Items.Schema.Ref), array: true}
+	}
+	return modelRef{name: definitionName(schema.Ref)}
+}
+
+// definitionName returns the name of the local definition the reference
+// points to.
+func definitionName(ref spec.Ref) string {
+	s := ref.String()
+	if !strings.HasPrefix(s, "#/definitions/") {
+		return ""
+	}
+	return strings.TrimPrefix(s, "#/definitions/")
+}
+
+// DecodeBody decodes the request body to a new value of the Go type
+// registered with RegisterModel for the definition the operation body refers
+// to, and returns a pointer to it. For body of array type, a pointer to
+// a slice is returned.
+//
+//  v, err := oas.DecodeBody(req)
+//  if err != nil {
+//      // ...
+//  }
+//  pet := v.(*Pet)
+func DecodeBody(req *http.Request, opts ...DecodeOption) (interface{}, error) {
+	options := decodeOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	oi, ok := getOperationInfo(req)
+	if !ok {
+		return nil, errors.New("decode body: cannot find OpenAPI operation info in the request context")
+	}
+
+	ref := oi.models.body
+	if ref.name == "" {
+		return nil, errors.New("decode body: operation body does not refer to a definition")
+	}
+	t, ok := ref.goType()
+	if !ok {
+		return nil, fmt.Errorf("decode body: no model registered for definition %s", ref.name)
+	}
+
+	v := reflect.New(t).Interface()
+	if err := decodeJSON(req.Body, v, options.useNumber); err != nil {
+		return nil, fmt.Errorf("decode body: %s", err)
+	}
+	return v, nil
+}
+
+// WriteResponse writes the value as the JSON response with the status code.
+// If the response for the code refers to a definition with a model
+// registered with RegisterModel, the value must be of that model type
+// (or a pointer to it), otherwise an error is returned and nothing is
+// written.
+//
+// Properties marked with PIIExtension in the response schema are stripped
+// or masked, unless the principal of the request has ScopePIIRead. If the
+// operation declares FieldsParam, successful responses are filtered to the
+// requested fields. Invalid fields are rejected by QueryValidator before the
+// handler runs; without it, an error is returned for them.
+func WriteResponse(w http.ResponseWriter, req *http.Request, code int, v interface{}) error {
+	oi, ok := getOperationInfo(req)
+	if !ok {
+		return errors.New("write response: cannot find OpenAPI operation info in the request context")
+	}
+
+	ref := oi.models.response(code)
+	if t, ok := ref.goType(); ok {
+		vt := reflect.TypeOf(v)
+		for vt != nil && vt.Kind() == reflect.Ptr {
+			vt = vt.Elem()
+		}
+		if vt != t {
+			return fmt.Errorf("write response: value of type %T does not match model %s of definition %s", v, t, ref)
+		}
+	}
+
+	b, err := currentJSONAPI().Marshal(v)
+	if err != nil {
+		return fmt.Errorf("write response: %s", err)
+	}
+
+	if oi.operation.Responses != nil {
+		schema := responseSchema(oi.operation.Responses, code)
+		pii := hasPII(schema) && !canReadPII(req)
+
+		var fields fieldSelection
+		if code >= 200 && code < 300 {
+			if fields, err = requestedFields(req, oi.params, schema); err != nil {
+				return fmt.Errorf("write response: %s", err)
+			}
+		}
+
+		if pii || fields != nil {
+			if b, err = filterResponse(b, schema, pii, fields); err != nil {
+				return fmt.Errorf("write response: %s", err)
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", jsonMediaType(oi.produces))
+	w.WriteHeader(code)
+	_, err = w.Write(b)
+	return err
+}
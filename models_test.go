@@ -0,0 +1,95 @@
+package oas
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testPet struct {
+	ID     int64  `json:"id,omitempty"`
+	Name   string `json:"name"`
+	Age    int32  `json:"age"`
+	Status string `json:"status,omitempty"`
+}
+
+func init() {
+	RegisterModel("Pet", &testPet{})
+}
+
+func TestRegisterModel(t *testing.T) {
+	assert.Panics(t, func() { RegisterModel("Pet", testPet{}) })
+	assert.Panics(t, func() { RegisterModel("Foo", nil) })
+}
+
+func TestDecodeBody(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+
+	t.Run("decodes to registered model", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/v2/pet", strings.NewReader(`{"name":"Rex","age":3}`))
+		ctx, _ := WithOperation(req.Context(), doc, "addPet")
+
+		v, err := DecodeBody(req.WithContext(ctx))
+		assert.NoError(t, err)
+		assert.Equal(t, &testPet{Name: "Rex", Age: 3}, v)
+	})
+
+	t.Run("operation without body", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/v2/pet/1", nil)
+		ctx, _ := WithOperation(req.Context(), doc, "getPetById")
+
+		_, err := DecodeBody(req.WithContext(ctx))
+		assert.EqualError(t, err, "decode body: operation body does not refer to a definition")
+	})
+
+	t.Run("malformed body", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/v2/pet", strings.NewReader(`{`))
+		ctx, _ := WithOperation(req.Context(), doc, "addPet")
+
+		_, err := DecodeBody(req.WithContext(ctx))
+		assert.EqualError(t, err, "decode body: unexpected EOF")
+	})
+
+	t.Run("no operation context", func(t *testing.T) {
+		_, err := DecodeBody(httptest.NewRequest(http.MethodPost, "/v2/pet", nil))
+		assert.EqualError(t, err, "decode body: cannot find OpenAPI operation info in the request context")
+	})
+}
+
+func TestWriteResponse(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+
+	req := httptest.NewRequest(http.MethodGet, "/v2/pet/1", nil)
+	ctx, _ := WithOperation(req.Context(), doc, "getPetById")
+	req = req.WithContext(ctx)
+
+	t.Run("writes registered model", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		err := WriteResponse(w, req, http.StatusOK, &testPet{ID: 1, Name: "Rex", Age: 3})
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		assert.Equal(t, `{"id":1,"name":"Rex","age":3}`, w.Body.String())
+	})
+
+	t.Run("rejects other types", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		err := WriteResponse(w, req, http.StatusOK, map[string]interface{}{"name": "Rex"})
+
+		assert.EqualError(t, err, "write response: value of type map[string]interface {} does not match model oas.testPet of definition Pet")
+		assert.Empty(t, w.Body.String())
+	})
+
+	t.Run("writes responses without model", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		err := WriteResponse(w, req, http.StatusNotFound, map[string]string{"error": "not found"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, `{"error":"not found"}`, w.Body.String())
+	})
+}
@@ -0,0 +1,144 @@
+package oas
+
+import (
+	"crypto/x509"
+	"fmt"
+	"net/http"
+)
+
+// MTLSExtension is the security scheme extension that marks the scheme as
+// TLS client certificate authentication, satisfied by MTLSAuthenticator.
+// As Swagger 2.0 has no such scheme type, the scheme is declared as an API
+// key, which is ignored:
+//
+//  securityDefinitions:
+//    clientCertificate:
+//      type: apiKey
+//      in: header
+//      name: X-Client-Certificate
+//      x-oas-mtls: true
+//
+// The server must request client certificates and verify them, e.g. with
+// tls.Config ClientAuth set to tls.VerifyClientCertIfGiven.
+const MTLSExtension = "x-oas-mtls"
+
+// CertPrincipalFunc maps the verified client certificate to the principal,
+// e.g. by the certificate subject. It returns false if the certificate is
+// not known.
+type CertPrincipalFunc func(cert *x509.Certificate) (Principal, bool)
+
+// MTLSAuthenticator returns a middleware that authenticates requests to
+// operations whose security requirements include schemes with MTLSExtension
+// by the verified client certificate, and adds the principal mapped by fn
+// to the request context. Certificates that are presented but not verified
+// by the TLS stack are ignored.
+//
+// Requests without a certificate are passed if the operation allows
+// alternative security requirements, so other security middleware can
+// authenticate them. Otherwise, this middleware responds with 401 by
+// default.
+//
+// It panics if fn is nil or any MTLSExtension in the spec is not a boolean.
+func (b *ResolvingBasis) MTLSAuthenticator(fn CertPrincipalFunc, opts ...MiddlewareOption) Middleware {
+	if fn == nil {
+		panic("oas: MTLSAuthenticator principal function is nil")
+	}
+
+	options := parseMiddlewareOptions(opts...)
+	if options.problemHandler == nil {
+		options.problemHandler = newProblemHandlerStatusResponder(http.StatusUnauthorized)
+	}
+
+	schemes := make(map[string]bool)
+	for name, ss := range b.doc.Spec().SecurityDefinitions {
+		v, ok := ss.Extensions[MTLSExtension]
+		if !ok {
+			continue
+		}
+		enabled, ok := v.(bool)
+		if !ok {
+			panic(fmt.Sprintf("oas: security scheme %s: invalid %s: value is not a boolean", name, MTLSExtension))
+		}
+		schemes[name] = enabled
+	}
+
+	// operations maps operation ids to whether alternative security
+	// requirements are allowed.
+	operations := make(map[string]bool)
+	for id, oi := range b.cache {
+		names, optional := securityUsage(b.doc, oi.operation, func(name string) bool {
+			return schemes[name]
+		})
+		if len(names) > 0 {
+			operations[id] = optional
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return &mtlsAuthenticator{
+			next:           next,
+			fn:             fn,
+			operations:     operations,
+			problemHandler: options.problemHandler,
+			strict:         b.strict,
+		}
+	}
+}
+
+// mtlsAuthenticator is a middleware that resolves operation context from
+// the request and authenticates the request by the client certificate.
+type mtlsAuthenticator struct {
+	next       http.Handler
+	fn         CertPrincipalFunc
+	operations map[string]bool
+
+	problemHandler ProblemHandler
+
+	// strict enforces authentication. If false, then requests without
+	// operation context are passed.
+	strict bool
+}
+
+func (mw *mtlsAuthenticator) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	oi, ok := getOperationInfo(req)
+	if !ok {
+		if mw.strict {
+			panic("mtls authenticator middleware: cannot find operation info in the request context")
+		}
+		mw.next.ServeHTTP(w, req)
+		return
+	}
+
+	optional, ok := mw.operations[oi.operation.ID]
+	if !ok {
+		mw.next.ServeHTTP(w, req)
+		return
+	}
+
+	cert := verifiedClientCert(req)
+	if cert == nil {
+		if optional {
+			mw.next.ServeHTTP(w, req)
+			return
+		}
+		mw.problemHandler.HandleProblem(NewProblem(w, req, fmt.Errorf("client certificate is required")))
+		return
+	}
+
+	p, ok := mw.fn(cert)
+	if !ok {
+		mw.problemHandler.HandleProblem(NewProblem(w, req, fmt.Errorf("client certificate %s is not allowed", cert.Subject.CommonName)))
+		return
+	}
+
+	mw.next.ServeHTTP(w, WithPrincipal(req, p))
+}
+
+// verifiedClientCert returns the leaf of the first verified client
+// certificate chain of the request, or nil if there is none.
+func verifiedClientCert(req *http.Request) *x509.Certificate {
+	if req.TLS == nil || len(req.TLS.VerifiedChains) == 0 || len(req.TLS.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return req.TLS.VerifiedChains[0][0]
+}
@@ -0,0 +1,110 @@
+package oas
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMTLSAuthenticator(t *testing.T) {
+	doc := loadDocBytes([]byte(`
+swagger: "2.0"
+info:
+  title: Test
+  version: 1.0.0
+securityDefinitions:
+  clientCertificate:
+    type: apiKey
+    in: header
+    name: X-Client-Certificate
+    x-oas-mtls: true
+  apiKey:
+    type: apiKey
+    in: header
+    name: X-API-Key
+security:
+- clientCertificate: []
+paths:
+  /settlements:
+    post:
+      operationId: settle
+      responses:
+        200:
+          description: OK
+  /rates:
+    get:
+      operationId: getRates
+      security:
+      - clientCertificate: []
+      - apiKey: []
+      responses:
+        200:
+          description: OK
+`))
+
+	fn := func(cert *x509.Certificate) (Principal, bool) {
+		if cert.Subject.CommonName == "bank" {
+			return Principal{ID: "bank"}, true
+		}
+		return Principal{}, false
+	}
+
+	basis := NewResolvingBasis(SpecAdapterName, doc, BasisStrict(false))
+	h := SpecMatcherMiddleware(doc)(
+		basis.MTLSAuthenticator(fn)(
+			http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				p, _ := GetPrincipal(req)
+				w.Write([]byte(p.ID)) // nolint
+			}),
+		),
+	)
+
+	withCert := func(req *http.Request, cn string) *http.Request {
+		cert := &x509.Certificate{Subject: pkix.Name{CommonName: cn}}
+		req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+		return req
+	}
+
+	testCases := map[string]struct {
+		req          *http.Request
+		expectedCode int
+		expectedBody string
+	}{
+		"verified certificate": {
+			req:          withCert(httptest.NewRequest(http.MethodPost, "/settlements", nil), "bank"),
+			expectedCode: http.StatusOK,
+			expectedBody: "bank",
+		},
+		"unknown certificate": {
+			req:          withCert(httptest.NewRequest(http.MethodPost, "/settlements", nil), "mallory"),
+			expectedCode: http.StatusUnauthorized,
+			expectedBody: "client certificate mallory is not allowed",
+		},
+		"no certificate": {
+			req:          httptest.NewRequest(http.MethodPost, "/settlements", nil),
+			expectedCode: http.StatusUnauthorized,
+			expectedBody: "client certificate is required",
+		},
+		"alternative requirement": {
+			req:          httptest.NewRequest(http.MethodGet, "/rates", nil),
+			expectedCode: http.StatusOK,
+			expectedBody: "",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, tc.req)
+
+			assert.Equal(t, tc.expectedCode, w.Code)
+			assert.Equal(t, tc.expectedBody, strings.TrimSpace(w.Body.String()))
+		})
+	}
+}
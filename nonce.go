@@ -0,0 +1,249 @@
+package oas
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// NonceExtension is the operation extension that requires requests to the
+// operation to carry a unique nonce, enforced by NonceValidator, e.g. for
+// payment-like operations that must not be replayed:
+//
+//  paths:
+//    /payments:
+//      post:
+//        operationId: createPayment
+//        x-oas-nonce:
+//          header: X-Nonce
+//          ttl: 24h
+//
+// Nonces are unique per operation and principal within the TTL. The header
+// is "X-Nonce" and the TTL is 10 minutes by default, so the extension may
+// be simply true.
+const NonceExtension = "x-oas-nonce"
+
+// Nonce defaults of NonceExtension.
+const (
+	defaultNonceHeader = "X-Nonce"
+	defaultNonceTTL    = 10 * time.Minute
+)
+
+// NonceStore stores used nonces. Nonces may be shared between service
+// instances, e.g. by a store backed by Redis with SET NX and expiring keys.
+type NonceStore interface {
+	// Use records the nonce key as used until the expiration time. It
+	// returns false if the key is already used and not yet expired.
+	Use(key string, expires time.Time) (bool, error)
+}
+
+// MemoryNonceStore is an in-memory NonceStore for a single service instance.
+// It is safe for concurrent use.
+type MemoryNonceStore struct {
+	mx      sync.Mutex
+	nonces  map[string]time.Time
+	sweepAt time.Time
+	clock   Clock
+}
+
+// nonceStoreSweepInterval is the interval between removals of expired
+// nonces from MemoryNonceStore.
+const nonceStoreSweepInterval = time.Minute
+
+// NewMemoryNonceStore returns a new MemoryNonceStore.
+func NewMemoryNonceStore(opts ...StoreOption) *MemoryNonceStore {
+	return &MemoryNonceStore{
+		nonces: make(map[string]time.Time),
+		clock:  parseStoreOptions(opts).clock,
+	}
+}
+
+// Use implements NonceStore.
+func (s *MemoryNonceStore) Use(key string, expires time.Time) (bool, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	now := s.clock.Now()
+	if now.After(s.sweepAt) {
+		for k, exp := range s.nonces {
+			if !exp.After(now) {
+				delete(s.nonces, k)
+			}
+		}
+		s.sweepAt = now.Add(nonceStoreSweepInterval)
+	}
+
+	if exp, ok := s.nonces[key]; ok && exp.After(now) {
+		return false, nil
+	}
+	s.nonces[key] = expires
+	return true, nil
+}
+
+// WithNonceTTL returns a middleware option that sets the TTL of nonces of
+// operations that do not set it in NonceExtension, i.e. the window requests
+// are protected from replay in. By default, the TTL is 10 minutes.
+func WithNonceTTL(ttl time.Duration) MiddlewareOption {
+	return func(opts *MiddlewareOptions) {
+		opts.nonceTTL = ttl
+	}
+}
+
+// nonceRule is the nonce requirement of an operation.
+type nonceRule struct {
+	header string
+	ttl    time.Duration
+}
+
+// parseNonceRule parses the value of NonceExtension, with the TTL used if
+// the extension does not set one.
+func parseNonceRule(ext interface{}, ttl time.Duration) (nonceRule, error) {
+	r := nonceRule{header: defaultNonceHeader, ttl: ttl}
+
+	switch v := ext.(type) {
+	case bool:
+		if !v {
+			return nonceRule{}, fmt.Errorf("value must be true or an object")
+		}
+		return r, nil
+	case map[string]interface{}:
+		if h, ok := v["header"]; ok {
+			s, _ := h.(string)
+			if s == "" {
+				return nonceRule{}, fmt.Errorf("header must be a non-empty string")
+			}
+			r.header = s
+		}
+		if t, ok := v["ttl"]; ok {
+			s, _ := t.(string)
+			d, err := time.ParseDuration(s)
+			if err != nil || d <= 0 {
+				return nonceRule{}, fmt.Errorf("ttl %v is not a positive duration", t)
+			}
+			r.ttl = d
+		}
+		return r, nil
+	default:
+		return nonceRule{}, fmt.Errorf("value must be true or an object")
+	}
+}
+
+// NonceValidator returns a middleware that rejects requests to operations
+// with NonceExtension that have no nonce, or reuse a nonce within its TTL.
+// The principal is taken from the request context if present, so mount the
+// middleware after the security middleware.
+//
+// By default, this middleware responds with 400 to requests without nonce,
+// with 409 to replayed requests, and with 503 if the store fails, as
+// requests cannot be checked for replay then. If a problem handler is set,
+// it handles all of these problems.
+//
+// The TTL of nonces of operations that do not set it in the extension is
+// 10 minutes by default, see WithNonceTTL. If the store implements
+// StoreInspector, register it on Admin to inspect and purge used nonces.
+//
+// It panics if the store is nil or any NonceExtension in the spec is
+// invalid.
+func (b *ResolvingBasis) NonceValidator(store NonceStore, opts ...MiddlewareOption) Middleware {
+	if store == nil {
+		panic("oas: NonceValidator store is nil")
+	}
+
+	options := parseMiddlewareOptions(opts...)
+	if options.nonceTTL <= 0 {
+		options.nonceTTL = defaultNonceTTL
+	}
+
+	rules := make(map[string]nonceRule)
+	for id, oi := range b.cache {
+		ext, ok := oi.operation.Extensions[NonceExtension]
+		if !ok {
+			continue
+		}
+		r, err := parseNonceRule(ext, options.nonceTTL)
+		if err != nil {
+			panic(fmt.Sprintf("oas: operation %s: invalid %s: %s", id, NonceExtension, err))
+		}
+		rules[id] = r
+	}
+
+	return func(next http.Handler) http.Handler {
+		return &nonceValidator{
+			next:           next,
+			store:          store,
+			rules:          rules,
+			problemHandler: options.problemHandler,
+			clock:          options.clock,
+			strict:         b.strict,
+		}
+	}
+}
+
+// nonceValidator is a middleware that resolves operation context from the
+// request and rejects replayed requests.
+type nonceValidator struct {
+	next  http.Handler
+	store NonceStore
+	rules map[string]nonceRule
+
+	// problemHandler handles all problems, if set.
+	problemHandler ProblemHandler
+
+	clock Clock
+
+	// strict enforces nonces. If false, then requests without operation
+	// context are passed.
+	strict bool
+}
+
+func (mw *nonceValidator) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	oi, ok := getOperationInfo(req)
+	if !ok {
+		if mw.strict {
+			panic("nonce validator middleware: cannot find operation info in the request context")
+		}
+		mw.next.ServeHTTP(w, req)
+		return
+	}
+
+	r, ok := mw.rules[oi.operation.ID]
+	if !ok {
+		mw.next.ServeHTTP(w, req)
+		return
+	}
+
+	nonce := req.Header.Get(r.header)
+	if nonce == "" {
+		mw.reject(w, req, http.StatusBadRequest, fmt.Errorf("header %s is required", r.header))
+		return
+	}
+
+	key := oi.operation.ID + " "
+	if p, ok := GetPrincipal(req); ok {
+		key += p.ID
+	}
+	key += " " + nonce
+
+	fresh, err := mw.store.Use(key, mw.clock.Now().Add(r.ttl))
+	if err != nil {
+		mw.reject(w, req, http.StatusServiceUnavailable, fmt.Errorf("nonce store error: %s", err))
+		return
+	}
+	if !fresh {
+		mw.reject(w, req, http.StatusConflict, fmt.Errorf("nonce %s is already used", nonce))
+		return
+	}
+
+	mw.next.ServeHTTP(w, req)
+}
+
+// reject passes the problem to the problem handler, or responds with the
+// code if there is no problem handler.
+func (mw *nonceValidator) reject(w http.ResponseWriter, req *http.Request, code int, err error) {
+	h := mw.problemHandler
+	if h == nil {
+		h = newProblemHandlerStatusResponder(code)
+	}
+	h.HandleProblem(NewProblem(w, req, err))
+}
@@ -0,0 +1,96 @@
+package oas
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type failingNonceStore struct{}
+
+func (failingNonceStore) Use(key string, expires time.Time) (bool, error) {
+	return false, errors.New("connection refused")
+}
+
+func TestNonceValidator(t *testing.T) {
+	doc := loadDocBytes([]byte(`
+swagger: "2.0"
+info:
+  title: Test
+  version: 1.0.0
+paths:
+  /payments:
+    post:
+      operationId: createPayment
+      x-oas-nonce:
+        header: X-Request-Nonce
+        ttl: 1h
+      responses:
+        200:
+          description: OK
+  /transfers:
+    post:
+      operationId: createTransfer
+      x-oas-nonce: true
+      responses:
+        200:
+          description: OK
+  /quotes:
+    post:
+      operationId: createQuote
+      responses:
+        200:
+          description: OK
+`))
+
+	basis := NewResolvingBasis(SpecAdapterName, doc, BasisStrict(false))
+	newHandler := func(store NonceStore) http.Handler {
+		return SpecMatcherMiddleware(doc)(
+			basis.NonceValidator(store)(
+				http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}),
+			),
+		)
+	}
+	h := newHandler(NewMemoryNonceStore())
+
+	serve := func(h http.Handler, path, header, nonce, principal string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		if nonce != "" {
+			req.Header.Set(header, nonce)
+		}
+		if principal != "" {
+			req = WithPrincipal(req, Principal{ID: principal})
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve(h, "/payments", "X-Request-Nonce", "n1", "alice")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = serve(h, "/payments", "X-Request-Nonce", "n1", "alice")
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, "nonce n1 is already used", strings.TrimSpace(w.Body.String()))
+
+	w = serve(h, "/payments", "X-Request-Nonce", "n1", "bob")
+	assert.Equal(t, http.StatusOK, w.Code, "nonces are unique per principal")
+
+	w = serve(h, "/transfers", "X-Nonce", "n1", "alice")
+	assert.Equal(t, http.StatusOK, w.Code, "nonces are unique per operation")
+
+	w = serve(h, "/transfers", "X-Nonce", "", "alice")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "header X-Nonce is required", strings.TrimSpace(w.Body.String()))
+
+	w = serve(h, "/quotes", "X-Nonce", "", "alice")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = serve(newHandler(failingNonceStore{}), "/transfers", "X-Nonce", "n2", "alice")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
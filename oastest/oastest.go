@@ -0,0 +1,125 @@
+// Package oastest provides assertions for unit tests of services built on
+// oas, so tests can assert routing and validation behavior declaratively,
+// without standing up servers:
+//
+//  func TestRoutes(t *testing.T) {
+//      doc := loadDoc(t)
+//      oastest.AssertSpecMatched(t, doc, "GET", "/v2/pet/12", "getPetById")
+//
+//      req := httptest.NewRequest("GET", "/v2/user/login?username=john&password=x", nil)
+//      oastest.AssertValidates(t, doc, "loginUser", req)
+//  }
+package oastest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/hypnoglow/oas2"
+)
+
+// TestingT is the subset of testing.TB used by the assertions.
+type TestingT interface {
+	Errorf(format string, args ...interface{})
+}
+
+// helper marks the caller as a test helper, if supported by t.
+func helper(t TestingT) {
+	if h, ok := t.(interface {
+		Helper()
+	}); ok {
+		h.Helper()
+	}
+}
+
+// Router matches requests to operations of the spec. It is implemented by
+// *oas.Document.
+type Router interface {
+	FindOperationByRequest(req *http.Request) (*oas.Operation, map[string]string, bool)
+}
+
+// AssertSpecMatched asserts that the request with the method and the path
+// is matched by the router to the operation. The path may contain a query.
+// It returns whether the assertion passed.
+func AssertSpecMatched(t TestingT, router Router, method, path, operationID string) bool {
+	helper(t)
+
+	req := httptest.NewRequest(method, path, nil)
+	op, _, ok := router.FindOperationByRequest(req)
+	if !ok {
+		t.Errorf("%s %s: expected to match operation %s, but no operation is matched", method, path, operationID)
+		return false
+	}
+	if op.ID != operationID {
+		t.Errorf("%s %s: expected to match operation %s, but matched %s", method, path, operationID, op.ID)
+		return false
+	}
+	return true
+}
+
+// AssertValidates asserts that the request is matched to the operation of
+// the spec and passes the request validation pipeline of
+// oas.ResolvingBasis.RequestValidator with default options. Failures list
+// all the validation problems of the request. It returns whether the
+// assertion passed.
+func AssertValidates(t TestingT, doc *oas.Document, operationID string, req *http.Request) bool {
+	helper(t)
+
+	if !AssertSpecMatched(t, doc, req.Method, req.URL.RequestURI(), operationID) {
+		return false
+	}
+
+	problems := validationProblems(doc, req)
+	if len(problems) > 0 {
+		t.Errorf("%s %s: expected to pass validation of operation %s, but got problems:\n\t%s",
+			req.Method, req.URL.RequestURI(), operationID, strings.Join(problems, "\n\t"))
+		return false
+	}
+	return true
+}
+
+// AssertRejects asserts that the request is matched to the operation of
+// the spec and fails the request validation pipeline, with a problem that
+// contains the message if it is not empty. It returns whether the assertion
+// passed.
+func AssertRejects(t TestingT, doc *oas.Document, operationID string, req *http.Request, message string) bool {
+	helper(t)
+
+	if !AssertSpecMatched(t, doc, req.Method, req.URL.RequestURI(), operationID) {
+		return false
+	}
+
+	problems := validationProblems(doc, req)
+	if len(problems) == 0 {
+		t.Errorf("%s %s: expected to fail validation of operation %s, but it passed",
+			req.Method, req.URL.RequestURI(), operationID)
+		return false
+	}
+	for _, p := range problems {
+		if strings.Contains(p, message) {
+			return true
+		}
+	}
+	t.Errorf("%s %s: expected a problem containing %q, but got problems:\n\t%s",
+		req.Method, req.URL.RequestURI(), message, strings.Join(problems, "\n\t"))
+	return false
+}
+
+// validationProblems runs the request through the validation pipeline and
+// returns the messages of all problems.
+func validationProblems(doc *oas.Document, req *http.Request) []string {
+	var problems []string
+	collect := oas.WithProblemHandlerFunc(func(p oas.Problem) {
+		problems = append(problems, p.Cause().Error())
+	})
+
+	basis := oas.NewResolvingBasis(oas.SpecAdapterName, doc, oas.BasisStrict(false))
+	h := basis.OperationContext()(
+		basis.RequestValidator(collect, oas.WithContinueOnProblem(true))(
+			http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}),
+		),
+	)
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	return problems
+}
@@ -0,0 +1,69 @@
+package oastest
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hypnoglow/oas2"
+)
+
+type recordingT struct {
+	errors []string
+}
+
+func (t *recordingT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func loadDoc(t *testing.T) *oas.Document {
+	doc, err := oas.LoadFile("../testdata/petstore_1.yml")
+	require.NoError(t, err)
+	return doc
+}
+
+func TestAssertSpecMatched(t *testing.T) {
+	doc := loadDoc(t)
+
+	assert.True(t, AssertSpecMatched(t, doc, http.MethodGet, "/v2/pet/12", "getPetById"))
+
+	rt := &recordingT{}
+	assert.False(t, AssertSpecMatched(rt, doc, http.MethodGet, "/v2/pet/12", "loginUser"))
+	assert.False(t, AssertSpecMatched(rt, doc, http.MethodDelete, "/v2/user/login", "loginUser"))
+	assert.Equal(t, []string{
+		"GET /v2/pet/12: expected to match operation loginUser, but matched getPetById",
+		"DELETE /v2/user/login: expected to match operation loginUser, but no operation is matched",
+	}, rt.errors)
+}
+
+func TestAssertValidates(t *testing.T) {
+	doc := loadDoc(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/v2/user/login?username=john&password=x", nil)
+	assert.True(t, AssertValidates(t, doc, "loginUser", req))
+
+	rt := &recordingT{}
+	req = httptest.NewRequest(http.MethodGet, "/v2/user/login?username=john", nil)
+	assert.False(t, AssertValidates(rt, doc, "loginUser", req))
+	require.Len(t, rt.errors, 1)
+	assert.Contains(t, rt.errors[0], "expected to pass validation of operation loginUser, but got problems")
+	assert.Contains(t, rt.errors[0], "password")
+}
+
+func TestAssertRejects(t *testing.T) {
+	doc := loadDoc(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/v2/user/login?username=john", nil)
+	assert.True(t, AssertRejects(t, doc, "loginUser", req, "password"))
+
+	rt := &recordingT{}
+	req = httptest.NewRequest(http.MethodGet, "/v2/user/login?username=john&password=x", nil)
+	assert.False(t, AssertRejects(rt, doc, "loginUser", req, ""))
+	assert.Equal(t, []string{
+		"GET /v2/user/login?username=john&password=x: expected to fail validation of operation loginUser, but it passed",
+	}, rt.errors)
+}
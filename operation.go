@@ -3,6 +3,7 @@ package oas
 import (
 	"context"
 	"net/http"
+	"sort"
 
 	"github.com/go-openapi/spec"
 )
@@ -10,6 +11,10 @@ import (
 type operationInfo struct {
 	operation *spec.Operation
 
+	// path is the operation path template, prefixed with the spec base path.
+	// Greedy path parameters are marked with "+", see GreedyExtension.
+	path string
+
 	// params include all applicable operation params, even those defined
 	// on the path operation belongs to.
 	params []spec.Parameter
@@ -21,6 +26,10 @@ type operationInfo struct {
 	// produces is either operation-defined "produces" property or spec-wide
 	// "produces" property.
 	produces []string
+
+	// models are the spec definitions the operation body and responses
+	// refer to.
+	models operationModels
 }
 
 // operationContext is a middleware that adds operation info to the request
@@ -37,20 +46,119 @@ func (mw *operationContext) ServeHTTP(w http.ResponseWriter, req *http.Request,
 	mw.next.ServeHTTP(w, req)
 }
 
+// newOperationInfo returns operation info for the operation from the document.
+func newOperationInfo(doc *Document, path string, operation *spec.Operation) operationInfo {
+	params := doc.parametersFor(path, operation)
+	return operationInfo{
+		operation: operation,
+		path:      greedyTemplate(joinBasePath(doc.BasePath(), path), params),
+		params:    params,
+		consumes:  doc.Analyzer.ConsumesFor(operation),
+		produces:  doc.Analyzer.ProducesFor(operation),
+		models:    doc.operationModels(operation.ID),
+	}
+}
+
+// parametersFor returns the parameters of the operation, including those
+// defined on its path, in order of declaration, so that errors of params
+// are reported in a stable order. Parameters of the path go first, unless
+// they are overridden by the operation.
+func (doc *Document) parametersFor(path string, operation *spec.Operation) []spec.Parameter {
+	params := doc.Analyzer.ParametersFor(operation.ID)
+
+	var declared []spec.Parameter
+	if paths := doc.Spec().Paths; paths != nil {
+		declared = append(declared, paths.Paths[path].Parameters...)
+	}
+	declared = append(declared, operation.Parameters...)
+
+	rank := make(map[string]int, len(declared))
+	for i, p := range declared {
+		rank[p.In+" "+p.Name] = i
+	}
+	sort.SliceStable(params, func(i, j int) bool {
+		return rank[params[i].In+" "+params[i].Name] < rank[params[j].In+" "+params[j].Name]
+	})
+	return params
+}
+
 type contextKeyOperationInfo struct{}
 
 // withOperationInfo returns request with context value defining *spec.Operation.
 func withOperationInfo(req *http.Request, info operationInfo) *http.Request {
-	return req.WithContext(
-		context.WithValue(req.Context(), contextKeyOperationInfo{}, info),
-	)
+	return req.WithContext(contextWithOperationInfo(req.Context(), info))
 }
 
 // getOperationInfo returns *spec.Operation from the request's context.
 // In case of operation not found GetOperation returns nil.
+//
+// The context that carries only parameters set by WithOperationParams has
+// no operation, so getOperationInfo returns false for it. Middleware that
+// needs only the parameters uses ParamsFromContext instead.
 func getOperationInfo(req *http.Request) (operationInfo, bool) {
-	op, ok := req.Context().Value(contextKeyOperationInfo{}).(operationInfo)
-	return op, ok
+	oi, ok := operationInfoFromContext(req.Context())
+	if !ok || oi.operation == nil {
+		return operationInfo{}, false
+	}
+	return oi, true
+}
+
+func contextWithOperationInfo(ctx context.Context, info operationInfo) context.Context {
+	return context.WithValue(ctx, contextKeyOperationInfo{}, info)
+}
+
+func operationInfoFromContext(ctx context.Context) (operationInfo, bool) {
+	oi, ok := ctx.Value(contextKeyOperationInfo{}).(operationInfo)
+	return oi, ok
+}
+
+// WithOperation returns a copy of ctx that carries the OpenAPI operation
+// context for the operation found in the document by id. The second return
+// value reports whether the operation was found.
+//
+// This allows custom routing stacks to feed oas middleware (validators,
+// path params context, etc.) without using any adapter: set the operation
+// context on the request before passing it to the middleware, and the
+// middleware will work just like with the operation router.
+func WithOperation(ctx context.Context, doc *Document, operationID string) (context.Context, bool) {
+	_, path, op, ok := doc.Analyzer.OperationForName(operationID)
+	if !ok {
+		return ctx, false
+	}
+
+	return contextWithOperationInfo(ctx, newOperationInfo(doc, path, op)), true
+}
+
+// OperationFromContext returns the OpenAPI operation from the context.
+func OperationFromContext(ctx context.Context) (*Operation, bool) {
+	oi, ok := operationInfoFromContext(ctx)
+	if !ok || oi.operation == nil {
+		return nil, false
+	}
+	return wrapOperation(oi.operation), true
+}
+
+// WithOperationParams returns a copy of ctx that carries the given operation
+// parameters. If ctx already carries an operation context, only its
+// parameters are replaced.
+//
+// Parameters must include all applicable operation parameters, even those
+// defined on the path the operation belongs to.
+//
+// The parameters alone are enough for the validators of path, query and body
+// params, DecodeQuery, DecodeFormBody and RequestMessage. Middleware that
+// needs the operation itself handles the request as one without operation
+// context, use WithOperation for it.
+func WithOperationParams(ctx context.Context, params []spec.Parameter) context.Context {
+	oi, _ := operationInfoFromContext(ctx)
+	oi.params = params
+	return contextWithOperationInfo(ctx, oi)
+}
+
+// ParamsFromContext returns the operation parameters from the context.
+func ParamsFromContext(ctx context.Context) ([]spec.Parameter, bool) {
+	oi, ok := operationInfoFromContext(ctx)
+	return oi.params, ok
 }
 
 // mustOperationInfo returns *spec.Operation from the request's context.
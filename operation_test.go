@@ -0,0 +1,114 @@
+package oas
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-openapi/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithOperation(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+
+	t.Run("feeds validators", func(t *testing.T) {
+		ctx, ok := WithOperation(context.Background(), doc, "loginUser")
+		assert.True(t, ok)
+
+		op, ok := OperationFromContext(ctx)
+		assert.True(t, ok)
+		assert.Equal(t, "loginUser", op.ID)
+
+		params, ok := ParamsFromContext(ctx)
+		assert.True(t, ok)
+		assert.Len(t, params, 2)
+
+		mw := &resolvingQueryValidator{
+			qv: &queryValidator{
+				next:           http.HandlerFunc(handleUserLogin),
+				problemHandler: problemHandlerResponseWriter(),
+			},
+			strict: true,
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/v2/user/login?username=johndoe", nil)
+		w := httptest.NewRecorder()
+		mw.ServeHTTP(w, req.WithContext(ctx))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, `{"errors":[{"message":"param password is required","field":"password"}]}`, w.Body.String())
+	})
+
+	t.Run("unknown operation", func(t *testing.T) {
+		ctx, ok := WithOperation(context.Background(), doc, "unknown")
+		assert.False(t, ok)
+
+		_, ok = OperationFromContext(ctx)
+		assert.False(t, ok)
+	})
+}
+
+func TestWithOperationParams(t *testing.T) {
+	params := []spec.Parameter{*spec.QueryParam("name").Typed("string", "")}
+
+	ctx := WithOperationParams(context.Background(), params)
+
+	got, ok := ParamsFromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, params, got)
+
+	// No operation is set, only params.
+	_, ok = OperationFromContext(ctx)
+	assert.False(t, ok)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	_, ok = getOperationInfo(req)
+	assert.False(t, ok)
+}
+
+func TestDocument_parametersFor(t *testing.T) {
+	doc := loadDocBytes([]byte(`
+swagger: "2.0"
+info:
+  title: Test
+  version: 1.0.0
+paths:
+  /items/{id}:
+    parameters:
+      - name: id
+        in: path
+        required: true
+        type: integer
+      - name: zeta
+        in: query
+        type: string
+    get:
+      operationId: getItem
+      parameters:
+        - name: mu
+          in: query
+          type: string
+        - name: alpha
+          in: query
+          type: string
+        - name: zeta
+          in: query
+          type: integer
+      responses:
+        200:
+          description: OK
+`))
+
+	_, path, op, ok := doc.Analyzer.OperationForName("getItem")
+	assert.True(t, ok)
+
+	for i := 0; i < 10; i++ {
+		var names []string
+		for _, p := range doc.parametersFor(path, op) {
+			names = append(names, p.Name)
+		}
+		assert.Equal(t, []string{"id", "mu", "alpha", "zeta"}, names)
+	}
+}
@@ -0,0 +1,128 @@
+package oas
+
+import (
+	"net/http"
+
+	"github.com/go-openapi/spec"
+)
+
+// PIIExtension is the property extension that classifies the property as
+// personally identifiable information. WriteResponse filters such
+// properties out of responses for principals lacking ScopePIIRead:
+//
+//  definitions:
+//    User:
+//      properties:
+//        username:
+//          type: string
+//        email:
+//          type: string
+//          x-oas-pii: mask
+//        phone:
+//          type: string
+//          x-oas-pii: true
+//
+// Properties marked with "mask" are replaced with PIIMask, and properties
+// marked with true or "strip" are removed from the response. Masking is
+// meant for string properties, and stripping for optional ones, so that
+// filtered responses still match the schema.
+const PIIExtension = "x-oas-pii"
+
+// ScopePIIRead is the principal scope that allows to read properties marked
+// with PIIExtension.
+const ScopePIIRead = "pii:read"
+
+// PIIMask is the value that masked properties are replaced with.
+const PIIMask = "***"
+
+// canReadPII checks if the principal of the request is allowed to read PII.
+// Requests without principal are not.
+func canReadPII(req *http.Request) bool {
+	p, ok := GetPrincipal(req)
+	return ok && p.HasScope(ScopePIIRead)
+}
+
+// piiAction returns the action for the property marked with PIIExtension:
+// "strip", "mask", or an empty string for properties that are not PII.
+func piiAction(s *spec.Schema) string {
+	switch v := s.Extensions[PIIExtension].(type) {
+	case bool:
+		if v {
+			return "strip"
+		}
+	case string:
+		if v == "mask" || v == "strip" {
+			return v
+		}
+	}
+	return ""
+}
+
+// hasPII checks if the schema has properties marked with PIIExtension.
+func hasPII(s *spec.Schema) bool {
+	if s == nil || s.Ref.String() != "" {
+		return false
+	}
+
+	for name := range s.Properties {
+		prop := s.Properties[name]
+		if piiAction(&prop) != "" || hasPII(&prop) {
+			return true
+		}
+	}
+	if s.Items != nil && hasPII(s.Items.Schema) {
+		return true
+	}
+	for i := range s.AllOf {
+		if hasPII(&s.AllOf[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterPII strips or masks the properties of the value marked with
+// PIIExtension in the schema, in place. The value is a decoded JSON value.
+func filterPII(s *spec.Schema, v interface{}) {
+	// References left in the expanded spec are circular, so the properties
+	// below them are not filtered.
+	if s == nil || s.Ref.String() != "" {
+		return
+	}
+
+	for i := range s.AllOf {
+		filterPII(&s.AllOf[i], v)
+	}
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for name := range s.Properties {
+			prop := s.Properties[name]
+			pv, ok := v[name]
+			if !ok {
+				continue
+			}
+			switch piiAction(&prop) {
+			case "strip":
+				delete(v, name)
+			case "mask":
+				if pv != nil {
+					v[name] = PIIMask
+				}
+			default:
+				filterPII(&prop, pv)
+			}
+		}
+	case []interface{}:
+		if s.Items == nil {
+			return
+		}
+		for i, item := range v {
+			is := s.Items.Schema
+			if len(s.Items.Schemas) > i {
+				is = &s.Items.Schemas[i]
+			}
+			filterPII(is, item)
+		}
+	}
+}
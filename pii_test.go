@@ -0,0 +1,96 @@
+package oas
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteResponse_pii(t *testing.T) {
+	doc := loadDocBytes([]byte(`
+swagger: "2.0"
+info:
+  title: Test
+  version: 1.0.0
+paths:
+  /users:
+    get:
+      operationId: listUsers
+      produces:
+      - application/json
+      responses:
+        200:
+          description: OK
+          schema:
+            type: array
+            items:
+              $ref: "#/definitions/User"
+definitions:
+  User:
+    type: object
+    properties:
+      id:
+        type: integer
+        format: int64
+      email:
+        type: string
+        x-oas-pii: mask
+      phone:
+        type: string
+        x-oas-pii: true
+      address:
+        type: object
+        properties:
+          city:
+            type: string
+          street:
+            type: string
+            x-oas-pii: strip
+`))
+
+	users := []map[string]interface{}{
+		{
+			"id":      int64(9007199254740993),
+			"email":   "john@example.com",
+			"phone":   "+100000000",
+			"address": map[string]string{"city": "Paris", "street": "Rue de Rivoli"},
+		},
+		{"id": 2},
+	}
+
+	testCases := map[string]struct {
+		principal    *Principal
+		expectedBody string
+	}{
+		"principal without scope": {
+			principal:    &Principal{ID: "alice"},
+			expectedBody: `[{"address":{"city":"Paris"},"email":"***","id":9007199254740993},{"id":2}]`,
+		},
+		"no principal": {
+			expectedBody: `[{"address":{"city":"Paris"},"email":"***","id":9007199254740993},{"id":2}]`,
+		},
+		"principal with scope": {
+			principal:    &Principal{ID: "bob", Scopes: []string{ScopePIIRead}},
+			expectedBody: `[{"address":{"city":"Paris","street":"Rue de Rivoli"},"email":"john@example.com","id":9007199254740993,"phone":"+100000000"},{"id":2}]`,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/users", nil)
+			ctx, _ := WithOperation(req.Context(), doc, "listUsers")
+			req = req.WithContext(ctx)
+			if tc.principal != nil {
+				req = WithPrincipal(req, *tc.principal)
+			}
+
+			w := httptest.NewRecorder()
+			err := WriteResponse(w, req, http.StatusOK, users)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package oas
+
+import (
+	"context"
+	"net/http"
+)
+
+type contextKeyPrincipal struct{}
+
+// Principal is the client identity established by the security middleware
+// of the service, e.g. the owner of an API key or a bearer token.
+type Principal struct {
+	// ID identifies the principal, e.g. the API key id or the user id.
+	ID string
+
+	// Scopes are the scopes granted to the principal, e.g. ScopePIIRead.
+	Scopes []string
+}
+
+// HasScope checks if the scope is granted to the principal.
+func (p Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// PrincipalFunc identifies the principal of the request. It returns false
+// if the request is not authenticated.
+type PrincipalFunc func(req *http.Request) (Principal, bool)
+
+// WithPrincipal returns the request with the principal in its context.
+// Security middleware should call it once the request is authenticated:
+//
+//  func auth(next http.Handler) http.Handler {
+//      return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+//          key, ok := apiKeys[req.Header.Get("X-API-Key")]
+//          if !ok {
+//              http.Error(w, "unauthorized", http.StatusUnauthorized)
+//              return
+//          }
+//          next.ServeHTTP(w, oas.WithPrincipal(req, oas.Principal{ID: key.ID}))
+//      })
+//  }
+func WithPrincipal(req *http.Request, p Principal) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), contextKeyPrincipal{}, p))
+}
+
+// GetPrincipal returns the principal of the request, if the request is
+// authenticated.
+func GetPrincipal(req *http.Request) (Principal, bool) {
+	p, ok := req.Context().Value(contextKeyPrincipal{}).(Principal)
+	return p, ok
+}
+
+// PrincipalContext returns a middleware that identifies the principal of
+// the request by fn and adds it to the request context. Requests that are
+// not authenticated are passed as is.
+func PrincipalContext(fn PrincipalFunc) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if p, ok := fn(req); ok {
+				req = WithPrincipal(req, p)
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package oas
 
 import (
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 )
@@ -14,12 +16,77 @@ func NewProblem(w http.ResponseWriter, req *http.Request, err error) Problem {
 	}
 }
 
+// ProblemKind is the kind of a problem.
+type ProblemKind int
+
+const (
+	// ProblemKindUnspecified is a problem of unspecified kind, e.g.
+	// a mismatched host.
+	ProblemKindUnspecified ProblemKind = iota
+
+	// ProblemKindSyntax is a syntactic problem, e.g. malformed JSON body.
+	ProblemKindSyntax
+
+	// ProblemKindSemantic is a semantic problem, e.g. a request body that is
+	// well-formed, but does not match the schema.
+	ProblemKindSemantic
+
+	// ProblemKindCanceled is a problem of a request whose context is done,
+	// e.g. the client disconnected while the request body was validated.
+	// The cause of such problem is a *CanceledError. Usually there is no one
+	// to respond to, so the handler should not bother writing a response.
+	ProblemKindCanceled
+)
+
+// CanceledError describes a validation aborted because the context of the
+// request is done.
+type CanceledError struct {
+	// Err is the error of the context, i.e. context.Canceled or
+	// context.DeadlineExceeded.
+	Err error
+}
+
+// Error implements error.
+func (e *CanceledError) Error() string {
+	return fmt.Sprintf("validation canceled: %s", e.Err)
+}
+
+// Unwrap returns the error of the context.
+func (e *CanceledError) Unwrap() error {
+	return e.Err
+}
+
 // Problem describes a problem occurred while processing the request (or the response).
 // In most cases, the problem represents a validation error.
 type Problem struct {
-	w   http.ResponseWriter
-	req *http.Request
-	err error
+	w    http.ResponseWriter
+	req  *http.Request
+	err  error
+	kind ProblemKind
+
+	throttle *Throttle
+}
+
+// newProblemOfKind returns a new problem of the kind.
+func newProblemOfKind(w http.ResponseWriter, req *http.Request, err error, kind ProblemKind) Problem {
+	p := NewProblem(w, req, err)
+	p.kind = kind
+	return p
+}
+
+// Kind returns the problem kind, which allows to respond differently to
+// syntactic and semantic problems.
+func (p Problem) Kind() ProblemKind {
+	return p.kind
+}
+
+// Throttle returns the limit the request exceeded, if the problem is
+// a throttled request, e.g. a request over the quota.
+func (p Problem) Throttle() (Throttle, bool) {
+	if p.throttle == nil {
+		return Throttle{}, false
+	}
+	return *p.throttle, true
 }
 
 // Cause returns the underlying error that represents the problem.
@@ -62,9 +129,45 @@ type ProblemHandler interface {
 // newProblemHandlerErrorResponder is a very simple ProblemHandler that
 // writes problem error message to the response.
 func newProblemHandlerErrorResponder() ProblemHandlerFunc {
+	return newProblemHandlerStatusResponder(http.StatusBadRequest)
+}
+
+// newProblemHandlerDetailsHider wraps the ProblemHandler so that it handles
+// problems without error details, i.e. only with the general message of the
+// MultiError.
+func newProblemHandlerDetailsHider(h ProblemHandler) ProblemHandlerFunc {
+	return func(p Problem) {
+		msg := "request is invalid"
+		if me, ok := p.err.(MultiError); ok && me.Message() != "" {
+			msg = me.Message()
+		}
+		hidden := newProblemOfKind(p.w, p.req, errors.New(msg), p.kind)
+		hidden.throttle = p.throttle
+		h.HandleProblem(hidden)
+	}
+}
+
+// NewProblemKindResponder returns a ProblemHandler that writes the problem
+// error message to the response with the status code by the problem kind:
+// 400 for syntactic problems, e.g. malformed JSON, and 422 for semantic
+// problems, e.g. schema violations. Problems of unspecified kind are
+// responded with 400.
+func NewProblemKindResponder() ProblemHandler {
+	return ProblemHandlerFunc(func(p Problem) {
+		code := http.StatusBadRequest
+		if p.Kind() == ProblemKindSemantic {
+			code = http.StatusUnprocessableEntity
+		}
+		newProblemHandlerStatusResponder(code)(p)
+	})
+}
+
+// newProblemHandlerStatusResponder is a very simple ProblemHandler that
+// writes problem error message to the response with the status code.
+func newProblemHandlerStatusResponder(code int) ProblemHandlerFunc {
 	return func(p Problem) {
 		p.ResponseWriter().Header().Set("Content-Type", "text/plain; charset=utf-8")
-		p.ResponseWriter().WriteHeader(http.StatusBadRequest)
+		p.ResponseWriter().WriteHeader(code)
 		p.ResponseWriter().Write([]byte(p.err.Error())) // nolint
 	}
 }
@@ -73,6 +176,6 @@ func newProblemHandlerErrorResponder() ProblemHandlerFunc {
 // problem error to the standard logger with a warning prefix.
 func newProblemHandlerWarnLogger(kind string) ProblemHandlerFunc {
 	return func(p Problem) {
-		log.Printf("[WARN] oas %s problem on \"%s %s\": %v", kind, p.Request().Method, p.Request().URL.String(), p.Cause())
+		log.Printf("[WARN] oas %s problem on \"%s %s\" from %s: %v", kind, p.Request().Method, p.Request().URL.String(), GetClientIP(p.Request()), p.Cause())
 	}
 }
@@ -0,0 +1,140 @@
+package oas
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-openapi/spec"
+
+	"github.com/hypnoglow/oas2/validate"
+)
+
+// Placeholders of problem values for ProblemMapping.
+const (
+	// ProblemStatus is the response status code.
+	ProblemStatus = "$status"
+
+	// ProblemMessage is the problem error message.
+	ProblemMessage = "$message"
+
+	// ProblemErrors are the problem errors, each rendered as an object with
+	// "message", "field" and "pointer" properties, where "field" and
+	// "pointer" are present only for validation errors. If the schema
+	// property is a string, the messages are joined instead.
+	ProblemErrors = "$errors"
+)
+
+// ProblemMapping maps properties of the error response schema to the problem
+// values. A value is either a placeholder, e.g. ProblemMessage, or
+// a constant that is rendered as is. For example, for the ApiResponse
+// definition of the Petstore spec:
+//
+//  oas.ProblemMapping{
+//      "code":    oas.ProblemStatus,
+//      "type":    "validation",
+//      "message": oas.ProblemMessage,
+//  }
+type ProblemMapping map[string]interface{}
+
+// NewSchemaProblemHandler returns a ProblemHandler that responds with the
+// status code, rendering the problem to the response schema the operation
+// declares for the code (or the default response schema) using the mapping.
+// This way error responses match the API own contract.
+//
+// If there is no operation context or the operation declares no schema for
+// the code, the problem is responded with the plain error message.
+func NewSchemaProblemHandler(code int, mapping ProblemMapping) ProblemHandler {
+	fallback := newProblemHandlerStatusResponder(code)
+
+	return ProblemHandlerFunc(func(p Problem) {
+		oi, ok := getOperationInfo(p.Request())
+		if !ok || oi.operation.Responses == nil {
+			fallback(p)
+			return
+		}
+
+		schema := responseSchema(oi.operation.Responses, code)
+		if schema == nil {
+			fallback(p)
+			return
+		}
+
+		b, err := currentJSONAPI().Marshal(renderProblem(p, code, schema, mapping))
+		if err != nil {
+			fallback(p)
+			return
+		}
+
+		p.ResponseWriter().Header().Set("Content-Type", jsonMediaType(oi.produces))
+		p.ResponseWriter().WriteHeader(code)
+		p.ResponseWriter().Write(b) // nolint
+	})
+}
+
+// responseSchema returns the response schema for the code, or the default
+// response schema.
+func responseSchema(responses *spec.Responses, code int) *spec.Schema {
+	if resp, ok := responses.StatusCodeResponses[code]; ok {
+		return resp.Schema
+	}
+	if responses.Default != nil {
+		return responses.Default.Schema
+	}
+	return nil
+}
+
+// renderProblem renders the problem to an object of the schema.
+func renderProblem(p Problem, code int, schema *spec.Schema, mapping ProblemMapping) map[string]interface{} {
+	obj := make(map[string]interface{}, len(mapping))
+	for name, v := range mapping {
+		prop := schema.Properties[name]
+
+		switch v {
+		case ProblemStatus:
+			if schemaType(&prop) == "string" {
+				obj[name] = fmt.Sprint(code)
+			} else {
+				obj[name] = code
+			}
+		case ProblemMessage:
+			obj[name] = p.Cause().Error()
+		case ProblemErrors:
+			errs := problemErrors(p.Cause())
+			if schemaType(&prop) == "string" {
+				msgs := make([]string, len(errs))
+				for i, e := range errs {
+					msgs[i] = e.Error()
+				}
+				obj[name] = strings.Join(msgs, "; ")
+			} else {
+				obj[name] = renderProblemErrors(errs)
+			}
+		default:
+			obj[name] = v
+		}
+	}
+	return obj
+}
+
+// problemErrors returns the errors the problem consists of.
+func problemErrors(err error) []error {
+	if me, ok := err.(MultiError); ok {
+		return me.Errors()
+	}
+	return []error{err}
+}
+
+func renderProblemErrors(errs []error) []map[string]interface{} {
+	items := make([]map[string]interface{}, len(errs))
+	for i, err := range errs {
+		item := map[string]interface{}{"message": err.Error()}
+		if ve, ok := err.(validate.ValidationError); ok && ve.Field() != "" {
+			item["field"] = ve.Field()
+		}
+		if pe, ok := err.(validate.PointerError); ok && pe.Pointer() != "" {
+			item["pointer"] = pe.Pointer()
+		}
+		items[i] = item
+	}
+	return items
+}
@@ -0,0 +1,116 @@
+package oas
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSchemaProblemHandler(t *testing.T) {
+	doc := loadDocBytes([]byte(`
+swagger: "2.0"
+info:
+  title: "Test"
+  version: "1.0.0"
+basePath: "/v2"
+produces:
+- "application/json"
+paths:
+  /pet:
+    post:
+      operationId: "addPet"
+      parameters:
+      - in: "body"
+        name: "body"
+        required: true
+        schema:
+          type: "object"
+          required: ["name"]
+          properties:
+            name:
+              type: "string"
+            age:
+              type: "integer"
+      responses:
+        200:
+          description: "ok"
+        400:
+          description: "Invalid input"
+          schema:
+            $ref: "#/definitions/ApiResponse"
+  /user/login:
+    get:
+      operationId: "loginUser"
+      parameters:
+      - in: "query"
+        name: "username"
+        type: "string"
+        required: true
+      responses:
+        200:
+          description: "ok"
+definitions:
+  ApiResponse:
+    type: "object"
+    properties:
+      code:
+        type: "integer"
+        format: "int32"
+      type:
+        type: "string"
+      message:
+        type: "string"
+      errors:
+        type: "array"
+        items:
+          type: "object"
+`))
+
+	basis := NewResolvingBasis(SpecAdapterName, doc, BasisStrict(false))
+	ph := NewSchemaProblemHandler(http.StatusBadRequest, ProblemMapping{
+		"code":    ProblemStatus,
+		"type":    "validation",
+		"message": ProblemMessage,
+		"errors":  ProblemErrors,
+	})
+
+	h := SpecMatcherMiddleware(doc)(
+		basis.QueryValidator(WithProblemHandler(ph))(
+			basis.RequestBodyValidator(WithProblemHandler(ph))(
+				http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}),
+			),
+		),
+	)
+
+	t.Run("renders declared schema", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/v2/pet", strings.NewReader(`{"age":"old"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{
+			"code": 400,
+			"type": "validation",
+			"message": "request body does not match the schema: age in body must be of type integer: \"string\", name in body is required",
+			"errors": [
+				{"message": "age in body must be of type integer: \"string\"", "field": "age", "pointer": "/age"},
+				{"message": "name in body is required", "field": "name", "pointer": "/name"}
+			]
+		}`, w.Body.String())
+	})
+
+	t.Run("falls back to plain text", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/v2/user/login", nil)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal(t, "query params do not match the schema: param username is required", w.Body.String())
+	})
+}
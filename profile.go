@@ -0,0 +1,115 @@
+package oas
+
+import (
+	"os"
+	"sync"
+)
+
+// ProfileEnvVar is the environment variable that selects the validation
+// profile for BasisProfileFromEnv.
+const ProfileEnvVar = "OAS_PROFILE"
+
+// Names of the predefined validation profiles.
+const (
+	// ProfileStrict enables all validations with detailed problems. This is
+	// the same as using no profile.
+	ProfileStrict = "strict"
+
+	// ProfileProd rejects invalid requests, but does not validate responses
+	// and does not echo offending values in problem responses.
+	ProfileProd = "prod"
+
+	// ProfileDev validates requests and responses with detailed problems,
+	// but allows unknown query parameters.
+	ProfileDev = "dev"
+)
+
+// Profile is a named set of validation options, so that differences in
+// validation behavior across environments are declared in one place. The
+// zero value of options corresponds to the default middleware behavior.
+type Profile struct {
+	// Name is the profile name.
+	Name string
+
+	// AllowUnknownQueryParams allows query parameters that are not described
+	// in the spec. See WithUnknownQueryParams.
+	AllowUnknownQueryParams bool
+
+	// SkipResponseValidation disables response validators. See
+	// WithResponseValidation.
+	SkipResponseValidation bool
+
+	// HideProblemDetails makes request validators respond without error
+	// details. See WithProblemDetails.
+	HideProblemDetails bool
+}
+
+// MiddlewareOptions returns the middleware options of the profile.
+func (p Profile) MiddlewareOptions() []MiddlewareOption {
+	return []MiddlewareOption{
+		WithUnknownQueryParams(p.AllowUnknownQueryParams),
+		WithResponseValidation(!p.SkipResponseValidation),
+		WithProblemDetails(!p.HideProblemDetails),
+	}
+}
+
+var (
+	profilesMx sync.Mutex
+	profiles   = map[string]Profile{
+		ProfileStrict: {Name: ProfileStrict},
+		ProfileProd:   {Name: ProfileProd, SkipResponseValidation: true, HideProblemDetails: true},
+		ProfileDev:    {Name: ProfileDev, AllowUnknownQueryParams: true},
+	}
+)
+
+// RegisterProfile makes a validation profile available by its name. If this
+// function is called twice with the same name or if the name is empty, it
+// panics.
+func RegisterProfile(p Profile) {
+	profilesMx.Lock()
+	defer profilesMx.Unlock()
+
+	if p.Name == "" {
+		panic("oas: RegisterProfile profile name is empty")
+	}
+	if _, dup := profiles[p.Name]; dup {
+		panic("oas: RegisterProfile called twice for profile " + p.Name)
+	}
+
+	profiles[p.Name] = p
+}
+
+// mustGetProfile returns previously registered profile by the provided name.
+// If no profile is registered by the name, it panics.
+func mustGetProfile(name string) Profile {
+	profilesMx.Lock()
+	defer profilesMx.Unlock()
+
+	p, ok := profiles[name]
+	if !ok {
+		panic("oas: no profile registered for name " + name)
+	}
+	return p
+}
+
+// BasisProfile returns a basis option that applies the named validation
+// profile to middleware derived from the basis. Options passed to the
+// middleware take precedence over the profile. If no profile is registered
+// by the name, it panics.
+func BasisProfile(name string) BasisOption {
+	p := mustGetProfile(name)
+	return func(b *ResolvingBasis) {
+		b.profile = p.MiddlewareOptions()
+		b.profileName = p.Name
+	}
+}
+
+// BasisProfileFromEnv returns a basis option that applies the validation
+// profile named by the OAS_PROFILE environment variable, if it is set.
+func BasisProfileFromEnv() BasisOption {
+	name := os.Getenv(ProfileEnvVar)
+	if name == "" {
+		return func(b *ResolvingBasis) {}
+	}
+	return BasisProfile(name)
+}
@@ -0,0 +1,119 @@
+package oas
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBasisProfile(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+
+	newHandler := func(basis *ResolvingBasis, rh ProblemHandler) http.Handler {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/v2/user/login", func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`123`)) // nolint: errcheck
+		})
+
+		return SpecMatcherMiddleware(doc)(
+			basis.QueryValidator()(
+				basis.ResponseBodyValidator(WithProblemHandler(rh))(mux),
+			),
+		)
+	}
+
+	testCases := map[string]struct {
+		profile                 string
+		query                   string
+		expectedStatus          int
+		expectedBody            string
+		expectedResponseProblem bool
+	}{
+		"strict rejects unknown params": {
+			profile:                 ProfileStrict,
+			query:                   "username=johndoe&password=123&foo=bar",
+			expectedStatus:          http.StatusBadRequest,
+			expectedBody:            "query params do not match the schema: parameter foo is unknown",
+			expectedResponseProblem: false,
+		},
+		"strict validates responses": {
+			profile:                 ProfileStrict,
+			query:                   "username=johndoe&password=123",
+			expectedStatus:          http.StatusOK,
+			expectedBody:            "123",
+			expectedResponseProblem: true,
+		},
+		"dev allows unknown params": {
+			profile:                 ProfileDev,
+			query:                   "username=johndoe&password=123&foo=bar",
+			expectedStatus:          http.StatusOK,
+			expectedBody:            "123",
+			expectedResponseProblem: true,
+		},
+		"prod hides problem details": {
+			profile:                 ProfileProd,
+			query:                   "username=johndoe&password=123&foo=bar",
+			expectedStatus:          http.StatusBadRequest,
+			expectedBody:            "query params do not match the schema",
+			expectedResponseProblem: false,
+		},
+		"prod does not validate responses": {
+			profile:                 ProfileProd,
+			query:                   "username=johndoe&password=123",
+			expectedStatus:          http.StatusOK,
+			expectedBody:            "123",
+			expectedResponseProblem: false,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var responseProblem bool
+			rh := ProblemHandlerFunc(func(p Problem) { responseProblem = true })
+
+			basis := NewResolvingBasis(SpecAdapterName, doc, BasisStrict(false), BasisProfile(tc.profile))
+			h := newHandler(basis, rh)
+
+			req := httptest.NewRequest(http.MethodGet, "/v2/user/login?"+tc.query, nil)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, tc.expectedBody, w.Body.String())
+			assert.Equal(t, tc.expectedResponseProblem, responseProblem)
+		})
+	}
+
+	t.Run("middleware options take precedence", func(t *testing.T) {
+		basis := NewResolvingBasis(SpecAdapterName, doc, BasisStrict(false), BasisProfile(ProfileDev))
+		h := SpecMatcherMiddleware(doc)(basis.QueryValidator(WithUnknownQueryParams(false))(http.HandlerFunc(handleUserLogin)))
+
+		req := httptest.NewRequest(http.MethodGet, "/v2/user/login?username=johndoe&password=123&foo=bar", nil)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("profile from env", func(t *testing.T) {
+		os.Setenv(ProfileEnvVar, ProfileDev)
+		defer os.Unsetenv(ProfileEnvVar)
+
+		basis := NewResolvingBasis(SpecAdapterName, doc, BasisStrict(false), BasisProfileFromEnv())
+		h := SpecMatcherMiddleware(doc)(basis.QueryValidator()(http.HandlerFunc(handleUserLogin)))
+
+		req := httptest.NewRequest(http.MethodGet, "/v2/user/login?username=johndoe&password=123&foo=bar", nil)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("unknown profile panics", func(t *testing.T) {
+		assert.Panics(t, func() { BasisProfile("unknown") })
+	})
+}
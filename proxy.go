@@ -0,0 +1,44 @@
+package oas
+
+import (
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+)
+
+// ProxyUnhandledOperations returns operation handlers that consist of the
+// given handlers plus a reverse proxy to the upstream for each spec operation
+// that has no handler. The given handlers map is not modified.
+//
+// This allows to serve a subset of operations locally and transparently
+// proxy all the rest, which is handy for strangler-pattern migrations:
+//
+//  handlers := oas.ProxyUnhandledOperations(doc, localHandlers, legacyURL)
+//  err := basis.OperationRouter(r).WithOperationHandlers(handlers).Build()
+//
+// Note that the middleware passed to the operation router applies to proxied
+// operations as well, so requests get validated before they reach the
+// upstream.
+func ProxyUnhandledOperations(doc *Document, handlers map[string]http.Handler, upstream *url.URL) map[string]http.Handler {
+	return WithFallbackHandler(doc, handlers, httputil.NewSingleHostReverseProxy(upstream))
+}
+
+// WithFallbackHandler returns operation handlers that consist of the given
+// handlers plus the fallback handler for each spec operation that has no
+// handler. The given handlers map is not modified.
+func WithFallbackHandler(doc *Document, handlers map[string]http.Handler, fallback http.Handler) map[string]http.Handler {
+	hh := make(map[string]http.Handler, len(handlers))
+	for id, h := range handlers {
+		hh[id] = h
+	}
+
+	for _, pathOps := range doc.Analyzer.Operations() {
+		for _, op := range pathOps {
+			if _, ok := hh[op.ID]; !ok {
+				hh[op.ID] = fallback
+			}
+		}
+	}
+
+	return hh
+}
@@ -0,0 +1,41 @@
+package oas
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProxyUnhandledOperations(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		io.WriteString(w, "upstream: "+req.URL.Path)
+	}))
+	defer upstream.Close()
+
+	u, err := url.Parse(upstream.URL)
+	assert.NoError(t, err)
+
+	local := map[string]http.Handler{
+		"getPetById": http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			io.WriteString(w, "local")
+		}),
+	}
+
+	handlers := ProxyUnhandledOperations(doc, local, u)
+	assert.Len(t, handlers, 3)
+	assert.Len(t, local, 1)
+
+	w := httptest.NewRecorder()
+	handlers["getPetById"].ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v2/pet/12", nil))
+	assert.Equal(t, "local", w.Body.String())
+
+	w = httptest.NewRecorder()
+	handlers["loginUser"].ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v2/user/login", nil))
+	assert.Equal(t, "upstream: /v2/user/login", w.Body.String())
+}
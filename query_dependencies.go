@@ -0,0 +1,308 @@
+package oas
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-openapi/spec"
+)
+
+// DependenciesExtension is the operation extension that declares
+// constraints between query parameters, evaluated by QueryValidator along
+// with the parameter schemas:
+//
+//  x-oas-dependencies:
+//  - if: sort
+//    require: [order]
+//  - exclusive: [cursor, page]
+//  - lte: [from, to]
+//
+// The "if" constraint requires (or, with "forbid", forbids) the parameters
+// when the "if" parameter is set. The "exclusive" constraint allows at most
+// one of the parameters to be set. The "lte" constraint requires the first
+// parameter to be less than or equal to the second one when both are set;
+// values are compared as numbers, dates or strings, by the parameter type
+// and format.
+const DependenciesExtension = "x-oas-dependencies"
+
+// QueryDependencyFunc checks a constraint between the query parameters of
+// the request. See RegisterQueryDependency.
+type QueryDependencyFunc func(query url.Values) []error
+
+// QueryDependencyError is an error of a constraint between query
+// parameters.
+type QueryDependencyError struct {
+	// Params are the names of the parameters the constraint is about.
+	Params []string
+
+	Message string
+}
+
+// Error implements error.
+func (e QueryDependencyError) Error() string {
+	return e.Message
+}
+
+// Field implements validate.ValidationError. It returns the names of the
+// parameters, separated by commas.
+func (e QueryDependencyError) Field() string {
+	return strings.Join(e.Params, ",")
+}
+
+// Value implements validate.ValidationError.
+func (e QueryDependencyError) Value() interface{} {
+	return nil
+}
+
+var (
+	queryDependenciesMx sync.RWMutex
+	queryDependencies   = make(map[string][]QueryDependencyFunc)
+)
+
+// RegisterQueryDependency registers the constraint between query parameters
+// of the operation that cannot be declared with DependenciesExtension, e.g.:
+//
+//  oas.RegisterQueryDependency("findPets", func(q url.Values) []error {
+//      if q.Get("near") != "" && q.Get("radius") == "" {
+//          return []error{oas.QueryDependencyError{
+//              Params:  []string{"near", "radius"},
+//              Message: "radius is required with near",
+//          }}
+//      }
+//      return nil
+//  })
+//
+// Registered constraints are evaluated by QueryValidator after the declared
+// ones. It panics if fn is nil.
+func RegisterQueryDependency(operationID string, fn QueryDependencyFunc) {
+	queryDependenciesMx.Lock()
+	defer queryDependenciesMx.Unlock()
+
+	if fn == nil {
+		panic("oas: RegisterQueryDependency fn is nil")
+	}
+	queryDependencies[operationID] = append(queryDependencies[operationID], fn)
+}
+
+// registeredQueryDependencies returns the constraints registered for the
+// operation.
+func registeredQueryDependencies(operationID string) []QueryDependencyFunc {
+	queryDependenciesMx.RLock()
+	defer queryDependenciesMx.RUnlock()
+
+	return queryDependencies[operationID]
+}
+
+// compileQueryDependencies compiles DependenciesExtension of the operation
+// into constraint functions.
+func compileQueryDependencies(op *spec.Operation, params []spec.Parameter) ([]QueryDependencyFunc, error) {
+	ext, ok := op.Extensions[DependenciesExtension]
+	if !ok {
+		return nil, nil
+	}
+	items, ok := ext.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("value is not a list")
+	}
+
+	fns := make([]QueryDependencyFunc, 0, len(items))
+	for i, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("item %d is not an object", i)
+		}
+		fn, err := compileQueryDependency(m, params)
+		if err != nil {
+			return nil, fmt.Errorf("item %d: %s", i, err)
+		}
+		fns = append(fns, fn)
+	}
+	return fns, nil
+}
+
+// compileQueryDependency compiles a single constraint of
+// DependenciesExtension.
+func compileQueryDependency(m map[string]interface{}, params []spec.Parameter) (QueryDependencyFunc, error) {
+	names := func(key string) ([]string, error) {
+		items, ok := m[key].([]interface{})
+		if !ok || len(items) == 0 {
+			return nil, fmt.Errorf("%s must be a non-empty list of parameter names", key)
+		}
+		ss := make([]string, len(items))
+		for i, item := range items {
+			s, _ := item.(string)
+			if _, ok := findParam(params, "query", s); !ok {
+				return nil, fmt.Errorf("%s: %v is not a query parameter", key, item)
+			}
+			ss[i] = s
+		}
+		return ss, nil
+	}
+
+	switch {
+	case m["if"] != nil:
+		cond, _ := m["if"].(string)
+		if _, ok := findParam(params, "query", cond); !ok {
+			return nil, fmt.Errorf("if: %v is not a query parameter", m["if"])
+		}
+		if _, ok := m["require"]; ok {
+			required, err := names("require")
+			if err != nil {
+				return nil, err
+			}
+			return requireQueryDependency(cond, required), nil
+		}
+		forbidden, err := names("forbid")
+		if err != nil {
+			return nil, err
+		}
+		return forbidQueryDependency(cond, forbidden), nil
+
+	case m["exclusive"] != nil:
+		exclusive, err := names("exclusive")
+		if err != nil {
+			return nil, err
+		}
+		return exclusiveQueryDependency(exclusive), nil
+
+	case m["lte"] != nil:
+		pair, err := names("lte")
+		if err != nil {
+			return nil, err
+		}
+		if len(pair) != 2 {
+			return nil, fmt.Errorf("lte must name exactly two parameters")
+		}
+		lo, _ := findParam(params, "query", pair[0])
+		hi, _ := findParam(params, "query", pair[1])
+		return lteQueryDependency(lo, hi), nil
+
+	default:
+		return nil, fmt.Errorf("unknown constraint, expected if, exclusive or lte")
+	}
+}
+
+// isQuerySet checks if the query parameter is set.
+func isQuerySet(q url.Values, name string) bool {
+	_, ok := q[name]
+	return ok
+}
+
+func requireQueryDependency(cond string, required []string) QueryDependencyFunc {
+	return func(q url.Values) []error {
+		if !isQuerySet(q, cond) {
+			return nil
+		}
+		var errs []error
+		for _, name := range required {
+			if !isQuerySet(q, name) {
+				errs = append(errs, QueryDependencyError{
+					Params:  []string{cond, name},
+					Message: fmt.Sprintf("param %s is required when param %s is set", name, cond),
+				})
+			}
+		}
+		return errs
+	}
+}
+
+func forbidQueryDependency(cond string, forbidden []string) QueryDependencyFunc {
+	return func(q url.Values) []error {
+		if !isQuerySet(q, cond) {
+			return nil
+		}
+		var errs []error
+		for _, name := range forbidden {
+			if isQuerySet(q, name) {
+				errs = append(errs, QueryDependencyError{
+					Params:  []string{cond, name},
+					Message: fmt.Sprintf("param %s is not allowed when param %s is set", name, cond),
+				})
+			}
+		}
+		return errs
+	}
+}
+
+func exclusiveQueryDependency(exclusive []string) QueryDependencyFunc {
+	return func(q url.Values) []error {
+		var set []string
+		for _, name := range exclusive {
+			if isQuerySet(q, name) {
+				set = append(set, name)
+			}
+		}
+		if len(set) < 2 {
+			return nil
+		}
+		return []error{QueryDependencyError{
+			Params:  set,
+			Message: fmt.Sprintf("params %s are mutually exclusive", strings.Join(set, ", ")),
+		}}
+	}
+}
+
+func lteQueryDependency(lo, hi spec.Parameter) QueryDependencyFunc {
+	return func(q url.Values) []error {
+		if !isQuerySet(q, lo.Name) || !isQuerySet(q, hi.Name) {
+			return nil
+		}
+		cmp, ok := compareParamValues(lo, q.Get(lo.Name), q.Get(hi.Name))
+		if !ok || cmp <= 0 {
+			// Values that cannot be compared are reported by the
+			// schema validation.
+			return nil
+		}
+		return []error{QueryDependencyError{
+			Params:  []string{lo.Name, hi.Name},
+			Message: fmt.Sprintf("param %s must be less than or equal to param %s", lo.Name, hi.Name),
+		}}
+	}
+}
+
+// compareParamValues compares the values of the parameter by its type and
+// format. It returns false if the values cannot be compared.
+func compareParamValues(p spec.Parameter, a, b string) (int, bool) {
+	switch {
+	case p.Type == "integer" || p.Type == "number":
+		x, err1 := strconv.ParseFloat(a, 64)
+		y, err2 := strconv.ParseFloat(b, 64)
+		if err1 != nil || err2 != nil {
+			return 0, false
+		}
+		switch {
+		case x < y:
+			return -1, true
+		case x > y:
+			return 1, true
+		default:
+			return 0, true
+		}
+
+	case p.Format == "date" || p.Format == "date-time":
+		layout := time.RFC3339
+		if p.Format == "date" {
+			layout = "2006-01-02"
+		}
+		x, err1 := time.Parse(layout, a)
+		y, err2 := time.Parse(layout, b)
+		if err1 != nil || err2 != nil {
+			return 0, false
+		}
+		switch {
+		case x.Before(y):
+			return -1, true
+		case x.After(y):
+			return 1, true
+		default:
+			return 0, true
+		}
+
+	default:
+		return strings.Compare(a, b), true
+	}
+}
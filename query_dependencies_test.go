@@ -0,0 +1,103 @@
+package oas
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryValidator_dependencies(t *testing.T) {
+	doc := loadDocBytes([]byte(`
+swagger: "2.0"
+info:
+  title: Test
+  version: 1.0.0
+paths:
+  /events:
+    get:
+      operationId: findEventsWithDependencies
+      x-oas-dependencies:
+      - if: sort
+        require: [order]
+      - exclusive: [cursor, page]
+      - lte: [from, to]
+      - lte: [minSeats, maxSeats]
+      parameters:
+      - {name: sort, in: query, type: string}
+      - {name: order, in: query, type: string}
+      - {name: cursor, in: query, type: string}
+      - {name: page, in: query, type: integer}
+      - {name: from, in: query, type: string}
+      - {name: to, in: query, type: string}
+      - {name: minSeats, in: query, type: integer}
+      - {name: maxSeats, in: query, type: integer}
+      - {name: near, in: query, type: string}
+      - {name: radius, in: query, type: number}
+      responses:
+        200:
+          description: OK
+`))
+
+	RegisterQueryDependency("findEventsWithDependencies", func(q url.Values) []error {
+		if q.Get("near") != "" && q.Get("radius") == "" {
+			return []error{QueryDependencyError{
+				Params:  []string{"near", "radius"},
+				Message: "radius is required with near",
+			}}
+		}
+		return nil
+	})
+
+	basis := NewResolvingBasis(SpecAdapterName, doc, BasisStrict(false))
+	h := SpecMatcherMiddleware(doc)(
+		basis.QueryValidator()(
+			http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}),
+		),
+	)
+
+	testCases := map[string]struct {
+		query        string
+		expectedCode int
+		expectedBody string
+	}{
+		"satisfied": {
+			query:        "sort=name&order=asc&page=2&from=2020-01-01&to=2020-02-01&minSeats=9&maxSeats=10",
+			expectedCode: http.StatusOK,
+		},
+		"required": {
+			query:        "sort=name",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "query params do not match the schema: param order is required when param sort is set",
+		},
+		"exclusive and ordered": {
+			query:        "cursor=abc&page=2&from=2020-02-01&to=2020-01-01&minSeats=10&maxSeats=9",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "query params do not match the schema: " +
+				"params cursor, page are mutually exclusive, " +
+				"param from must be less than or equal to param to, " +
+				"param minSeats must be less than or equal to param maxSeats",
+		},
+		"registered": {
+			query:        "near=52.5,13.4",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "query params do not match the schema: radius is required with near",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/events?"+tc.query, nil))
+
+			assert.Equal(t, tc.expectedCode, w.Code)
+			assert.Equal(t, tc.expectedBody, strings.TrimSpace(w.Body.String()))
+		})
+	}
+
+	err := QueryDependencyError{Params: []string{"from", "to"}, Message: "m"}
+	assert.Equal(t, "from,to", err.Field())
+}
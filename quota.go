@@ -0,0 +1,248 @@
+package oas
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// QuotaExtension is the operation extension that declares the number of
+// requests a principal may make to the operation per period, enforced by
+// QuotaLimiter:
+//
+//  paths:
+//    /pets:
+//      post:
+//        operationId: addPet
+//        x-oas-quota:
+//          limit: 1000
+//          period: 1h
+//
+// The period is a duration as accepted by time.ParseDuration. Periods are
+// fixed windows, e.g. an hourly quota is reset at the start of every hour.
+const QuotaExtension = "x-oas-quota"
+
+// Quota response headers, set by QuotaLimiter on responses to operations
+// with quotas, along with standard RateLimit-* headers.
+const (
+	// HeaderQuotaLimit is the number of requests allowed per period.
+	HeaderQuotaLimit = "X-RateLimit-Limit"
+
+	// HeaderQuotaRemaining is the number of requests remaining in the
+	// current period.
+	HeaderQuotaRemaining = "X-RateLimit-Remaining"
+
+	// HeaderQuotaReset is the time when the current period ends, in Unix
+	// epoch seconds.
+	HeaderQuotaReset = "X-RateLimit-Reset"
+)
+
+// QuotaStore stores request counters of principals per operation. Counters
+// may be shared between service instances, e.g. by a store backed by Redis.
+type QuotaStore interface {
+	// Increment increments the counter of the key and returns its new
+	// value. The counter is reset to zero when reset time comes, so
+	// a distinct reset time starts a new counter.
+	Increment(key string, reset time.Time) (int64, error)
+}
+
+// MemoryQuotaStore is an in-memory QuotaStore for a single service instance.
+// It is safe for concurrent use.
+type MemoryQuotaStore struct {
+	mx       sync.Mutex
+	counters map[string]quotaCounter
+	sweepAt  time.Time
+	clock    Clock
+}
+
+type quotaCounter struct {
+	count int64
+	reset time.Time
+}
+
+// quotaStoreSweepInterval is the interval between removals of expired
+// counters from MemoryQuotaStore.
+const quotaStoreSweepInterval = time.Minute
+
+// NewMemoryQuotaStore returns a new MemoryQuotaStore.
+func NewMemoryQuotaStore(opts ...StoreOption) *MemoryQuotaStore {
+	return &MemoryQuotaStore{
+		counters: make(map[string]quotaCounter),
+		clock:    parseStoreOptions(opts).clock,
+	}
+}
+
+// Increment implements QuotaStore.
+func (s *MemoryQuotaStore) Increment(key string, reset time.Time) (int64, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	now := s.clock.Now()
+	if now.After(s.sweepAt) {
+		for k, c := range s.counters {
+			if !c.reset.After(now) {
+				delete(s.counters, k)
+			}
+		}
+		s.sweepAt = now.Add(quotaStoreSweepInterval)
+	}
+
+	c := s.counters[key]
+	if !c.reset.Equal(reset) {
+		c = quotaCounter{reset: reset}
+	}
+	c.count++
+	s.counters[key] = c
+	return c.count, nil
+}
+
+// quota is the quota of an operation.
+type quota struct {
+	limit  int64
+	period time.Duration
+}
+
+// parseQuota parses the value of QuotaExtension.
+func parseQuota(ext interface{}) (quota, error) {
+	m, ok := ext.(map[string]interface{})
+	if !ok {
+		return quota{}, fmt.Errorf("value is not an object")
+	}
+
+	limit, ok := m["limit"].(float64)
+	if !ok || limit < 1 || limit != float64(int64(limit)) {
+		return quota{}, fmt.Errorf("limit must be a positive integer")
+	}
+
+	s, ok := m["period"].(string)
+	if !ok {
+		return quota{}, fmt.Errorf("period must be a duration string, e.g. 1h")
+	}
+	period, err := time.ParseDuration(s)
+	if err != nil || period <= 0 {
+		return quota{}, fmt.Errorf("period %s is not a positive duration", s)
+	}
+
+	return quota{limit: int64(limit), period: period}, nil
+}
+
+// QuotaLimiter returns a middleware that counts requests of every principal
+// to operations with QuotaExtension in the store, and rejects requests over
+// the quota with 429 Too Many Requests by default. Responses carry
+// HeaderQuotaLimit, HeaderQuotaRemaining and HeaderQuotaReset headers, and
+// standard RateLimit-* headers. Rejections carry the Retry-After header too,
+// see WithRetryAfter, and are passed to the problem handler as problems with
+// Problem.Throttle describing the quota.
+//
+// The principal is taken from the request context, so the middleware must
+// come after the security middleware that calls WithPrincipal. Requests
+// without principal are not counted. If the store fails, the request is
+// passed and the error is logged.
+//
+// It panics if the store is nil or any quota in the spec is invalid.
+func (b *ResolvingBasis) QuotaLimiter(store QuotaStore, opts ...MiddlewareOption) Middleware {
+	if store == nil {
+		panic("oas: QuotaLimiter store is nil")
+	}
+
+	options := parseMiddlewareOptions(opts...)
+	if options.problemHandler == nil {
+		options.problemHandler = newProblemHandlerStatusResponder(http.StatusTooManyRequests)
+	}
+	if options.retryAfter == nil {
+		options.retryAfter = RetryAfterReset()
+	}
+
+	quotas := make(map[string]quota)
+	for id, oi := range b.cache {
+		ext, ok := oi.operation.Extensions[QuotaExtension]
+		if !ok {
+			continue
+		}
+		q, err := parseQuota(ext)
+		if err != nil {
+			panic(fmt.Sprintf("oas: operation %s: invalid %s: %s", id, QuotaExtension, err))
+		}
+		quotas[id] = q
+	}
+
+	return func(next http.Handler) http.Handler {
+		return &quotaLimiter{
+			next:           next,
+			store:          store,
+			quotas:         quotas,
+			problemHandler: options.problemHandler,
+			retryAfter:     options.retryAfter,
+			clock:          options.clock,
+			strict:         b.strict,
+		}
+	}
+}
+
+// quotaLimiter is a middleware that resolves operation context from the
+// request and enforces operation quotas.
+type quotaLimiter struct {
+	next   http.Handler
+	store  QuotaStore
+	quotas map[string]quota
+
+	problemHandler ProblemHandler
+	retryAfter     RetryAfterFunc
+	clock          Clock
+
+	// strict enforces quotas. If false, then requests without operation
+	// context are passed.
+	strict bool
+}
+
+func (mw *quotaLimiter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	oi, ok := getOperationInfo(req)
+	if !ok {
+		if mw.strict {
+			panic("quota limiter middleware: cannot find operation info in the request context")
+		}
+		mw.next.ServeHTTP(w, req)
+		return
+	}
+
+	q, ok := mw.quotas[oi.operation.ID]
+	if !ok {
+		mw.next.ServeHTTP(w, req)
+		return
+	}
+
+	p, ok := GetPrincipal(req)
+	if !ok {
+		mw.next.ServeHTTP(w, req)
+		return
+	}
+
+	now := mw.clock.Now()
+	reset := now.Truncate(q.period).Add(q.period)
+	count, err := mw.store.Increment(p.ID+" "+oi.operation.ID, reset)
+	if err != nil {
+		log.Printf("[WARN] oas quota store error on \"%s %s\": %v", req.Method, req.URL.String(), err)
+		mw.next.ServeHTTP(w, req)
+		return
+	}
+
+	t := Throttle{Limit: q.limit, Remaining: q.limit - count, Reset: reset}
+	if t.Remaining < 0 {
+		t.Remaining = 0
+	}
+
+	if count > q.limit {
+		t.RetryAfter = mw.retryAfter(t, now)
+		setThrottleHeaders(w.Header(), t, now, true)
+
+		p := NewProblem(w, req, fmt.Errorf("quota of operation %s is exceeded", oi.operation.ID))
+		p.throttle = &t
+		mw.problemHandler.HandleProblem(p)
+		return
+	}
+
+	setThrottleHeaders(w.Header(), t, now, false)
+	mw.next.ServeHTTP(w, req)
+}
@@ -0,0 +1,167 @@
+package oas
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuotaLimiter(t *testing.T) {
+	doc := loadDocBytes([]byte(`
+swagger: "2.0"
+info:
+  title: Test
+  version: 1.0.0
+basePath: /v2
+paths:
+  /pets:
+    get:
+      operationId: findPets
+      x-oas-quota:
+        limit: 2
+        period: 1h
+      responses:
+        200:
+          description: OK
+    post:
+      operationId: addPet
+      responses:
+        200:
+          description: OK
+`))
+
+	basis := NewResolvingBasis(SpecAdapterName, doc, BasisStrict(false))
+	principal := PrincipalContext(func(req *http.Request) (Principal, bool) {
+		id := req.Header.Get("X-API-Key")
+		return Principal{ID: id}, id != ""
+	})
+	h := SpecMatcherMiddleware(doc)(
+		principal(
+			basis.QuotaLimiter(NewMemoryQuotaStore())(
+				http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}),
+			),
+		),
+	)
+
+	serve := func(method, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/v2/pets", nil)
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve(http.MethodGet, "alice")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get(HeaderQuotaLimit))
+	assert.Equal(t, "1", w.Header().Get(HeaderQuotaRemaining))
+	assert.NotEmpty(t, w.Header().Get(HeaderQuotaReset))
+
+	w = serve(http.MethodGet, "alice")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "0", w.Header().Get(HeaderQuotaRemaining))
+
+	w = serve(http.MethodGet, "alice")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "0", w.Header().Get(HeaderQuotaRemaining))
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), "quota of operation findPets is exceeded")
+
+	// Quotas are counted per principal.
+	w = serve(http.MethodGet, "bob")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "1", w.Header().Get(HeaderQuotaRemaining))
+
+	// Requests without principal are not counted.
+	w = serve(http.MethodGet, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get(HeaderQuotaLimit))
+
+	// Operations without quota are not limited.
+	w = serve(http.MethodPost, "alice")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get(HeaderQuotaLimit))
+}
+
+func TestQuotaLimiter_invalid(t *testing.T) {
+	doc := loadDocBytes([]byte(`
+swagger: "2.0"
+info:
+  title: Test
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      operationId: findPets
+      x-oas-quota:
+        limit: 10
+        period: daily
+      responses:
+        200:
+          description: OK
+`))
+
+	basis := NewResolvingBasis(SpecAdapterName, doc, BasisStrict(false))
+	assert.PanicsWithValue(t, `oas: operation findPets: invalid x-oas-quota: period daily is not a positive duration`, func() {
+		basis.QuotaLimiter(NewMemoryQuotaStore())
+	})
+}
+
+func TestQuotaLimiter_throttle(t *testing.T) {
+	doc := loadDocBytes([]byte(`
+swagger: "2.0"
+info:
+  title: Test
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      operationId: findPets
+      x-oas-quota:
+        limit: 1
+        period: 1h
+      responses:
+        200:
+          description: OK
+`))
+
+	var throttle Throttle
+	problems := ProblemHandlerFunc(func(p Problem) {
+		throttle, _ = p.Throttle()
+		p.ResponseWriter().WriteHeader(http.StatusServiceUnavailable)
+	})
+	retryAfter := func(t Throttle, now time.Time) time.Duration { return 90 * time.Second }
+
+	basis := NewResolvingBasis(SpecAdapterName, doc, BasisStrict(false))
+	h := SpecMatcherMiddleware(doc)(
+		basis.QuotaLimiter(NewMemoryQuotaStore(), WithProblemHandler(problems), WithRetryAfter(retryAfter))(
+			http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}),
+		),
+	)
+
+	serve := func() *httptest.ResponseRecorder {
+		req := WithPrincipal(httptest.NewRequest(http.MethodGet, "/pets", nil), Principal{ID: "alice"})
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	assert.NotEmpty(t, w.Header().Get("RateLimit-Reset"))
+	assert.Empty(t, w.Header().Get("Retry-After"))
+
+	w = serve()
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "90", w.Header().Get("Retry-After"))
+	assert.Equal(t, int64(1), throttle.Limit)
+	assert.Equal(t, int64(0), throttle.Remaining)
+	assert.Equal(t, 90*time.Second, throttle.RetryAfter)
+}
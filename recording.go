@@ -0,0 +1,426 @@
+package oas
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ExchangeFormatVersion is the version of the format of recorded exchanges.
+// It is incremented on incompatible changes of Exchange, and LoadExchanges
+// rejects exchanges of other versions.
+const ExchangeFormatVersion = 1
+
+// Exchange is a recorded request to an operation and the response to it.
+type Exchange struct {
+	Version     int              `json:"version"`
+	Time        time.Time        `json:"time"`
+	OperationID string           `json:"operationId"`
+	Request     ExchangeRequest  `json:"request"`
+	Response    ExchangeResponse `json:"response"`
+}
+
+// ExchangeRequest is the request of a recorded exchange.
+type ExchangeRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// ExchangeResponse is the response of a recorded exchange.
+type ExchangeResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// Redacted is the value that redacted headers and body fields are replaced
+// with in recorded exchanges.
+const Redacted = "REDACTED"
+
+// RecorderOption is an option for Recorder.
+type RecorderOption func(*recorder)
+
+// RecordRedactHeaders returns a recorder option that sets the request and
+// response headers whose values are redacted. By default, Authorization,
+// Proxy-Authorization, Cookie and Set-Cookie headers are redacted.
+func RecordRedactHeaders(names ...string) RecorderOption {
+	return func(r *recorder) {
+		r.redactHeaders = make(map[string]bool, len(names))
+		for _, name := range names {
+			r.redactHeaders[http.CanonicalHeaderKey(name)] = true
+		}
+	}
+}
+
+// RecordRedactFields returns a recorder option that sets the names of JSON
+// object fields whose values are redacted in request and response bodies,
+// at any depth, e.g. "password" or "cardNumber".
+func RecordRedactFields(names ...string) RecorderOption {
+	return func(r *recorder) {
+		r.redactFields = make(map[string]bool, len(names))
+		for _, name := range names {
+			r.redactFields[name] = true
+		}
+	}
+}
+
+// Recorder returns a middleware that records exchanges with operations to
+// w as JSON lines, one Exchange per line, e.g. to a file of a regression
+// suite replayed by Replay. Mount it after the validators, so only valid
+// requests reach it; requests with failed checks in the validation report,
+// e.g. of validators in observe mode, are not recorded either.
+//
+// Writes are serialized, so w does not need to be safe for concurrent use.
+// Write errors are ignored.
+func (b *ResolvingBasis) Recorder(w io.Writer, opts ...RecorderOption) Middleware {
+	r := &recorder{
+		enc: json.NewEncoder(w),
+		now: time.Now,
+	}
+	RecordRedactHeaders("Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie")(r)
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			oi, ok := getOperationInfo(req)
+			if !ok {
+				if b.strict {
+					panic("recorder middleware: cannot find operation info in the request context")
+				}
+				next.ServeHTTP(w, req)
+				return
+			}
+			if report, ok := GetValidationReport(req); ok && !report.Passed() {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			body, _ := readRequestBody(req)
+			e := Exchange{
+				Version:     ExchangeFormatVersion,
+				Time:        r.now(),
+				OperationID: oi.operation.ID,
+				Request: ExchangeRequest{
+					Method: req.Method,
+					URL:    req.URL.RequestURI(),
+					Header: r.header(req.Header),
+					Body:   r.body(body),
+				},
+			}
+
+			buf := &bytes.Buffer{}
+			rr := newWrapResponseWriter(w, req.ProtoMajor)
+			rr.Tee(buf)
+			next.ServeHTTP(rr, req)
+
+			e.Response = ExchangeResponse{
+				Status: rr.Status(),
+				Header: r.header(w.Header()),
+				Body:   r.body(buf.Bytes()),
+			}
+			if e.Response.Status == 0 {
+				e.Response.Status = http.StatusOK
+			}
+			r.record(e)
+		})
+	}
+}
+
+type recorder struct {
+	redactHeaders map[string]bool
+	redactFields  map[string]bool
+
+	now func() time.Time
+
+	mx  sync.Mutex
+	enc *json.Encoder
+}
+
+func (r *recorder) record(e Exchange) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	r.enc.Encode(e) // nolint: errcheck
+}
+
+// header returns the redacted copy of the header.
+func (r *recorder) header(h http.Header) http.Header {
+	if len(h) == 0 {
+		return nil
+	}
+	c := make(http.Header, len(h))
+	for name, vals := range h {
+		if r.redactHeaders[http.CanonicalHeaderKey(name)] {
+			c[name] = []string{Redacted}
+			continue
+		}
+		c[name] = append([]string(nil), vals...)
+	}
+	return c
+}
+
+// body returns the body with JSON fields redacted. Bodies that are not
+// JSON are returned as is.
+func (r *recorder) body(data []byte) string {
+	if len(r.redactFields) == 0 || len(data) == 0 {
+		return string(data)
+	}
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return string(data)
+	}
+	redactFields(v, r.redactFields)
+	redacted, err := json.Marshal(v)
+	if err != nil {
+		return string(data)
+	}
+	return string(redacted)
+}
+
+// redactFields replaces values of the fields with Redacted in place.
+func redactFields(v interface{}, fields map[string]bool) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for name, val := range v {
+			if fields[name] {
+				v[name] = Redacted
+				continue
+			}
+			redactFields(val, fields)
+		}
+	case []interface{}:
+		for _, item := range v {
+			redactFields(item, fields)
+		}
+	}
+}
+
+// LoadExchanges loads exchanges recorded by Recorder from r. It returns an
+// error if any exchange is of another format version.
+func LoadExchanges(r io.Reader) ([]Exchange, error) {
+	var exchanges []Exchange
+	dec := json.NewDecoder(bufio.NewReader(r))
+	for i := 1; ; i++ {
+		var e Exchange
+		if err := dec.Decode(&e); err == io.EOF {
+			return exchanges, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("exchange %d: %s", i, err)
+		}
+		if e.Version != ExchangeFormatVersion {
+			return nil, fmt.Errorf("exchange %d: unsupported format version %d, expected %d", i, e.Version, ExchangeFormatVersion)
+		}
+		exchanges = append(exchanges, e)
+	}
+}
+
+// ReplayOption is an option for Replay.
+type ReplayOption func(*replayer)
+
+// ReplayPrepare returns a replay option that sets the function that
+// prepares requests before they are replayed, e.g. to set credentials in
+// place of redacted headers.
+func ReplayPrepare(fn func(req *http.Request)) ReplayOption {
+	return func(r *replayer) {
+		r.prepare = fn
+	}
+}
+
+// ReplayIgnoreFields returns a replay option that sets the names of JSON
+// object fields of response bodies that are not compared, at any depth,
+// e.g. generated ids and timestamps. Redacted fields are never compared.
+func ReplayIgnoreFields(names ...string) ReplayOption {
+	return func(r *replayer) {
+		r.ignore = make(map[string]bool, len(names))
+		for _, name := range names {
+			r.ignore[name] = true
+		}
+	}
+}
+
+// ReplayIgnoreHeaders returns a replay option that sets the response
+// headers that are not compared. By default, Date and Content-Length
+// headers are not compared.
+func ReplayIgnoreHeaders(names ...string) ReplayOption {
+	return func(r *replayer) {
+		r.ignoreHeaders = make(map[string]bool, len(names))
+		for _, name := range names {
+			r.ignoreHeaders[http.CanonicalHeaderKey(name)] = true
+		}
+	}
+}
+
+type replayer struct {
+	prepare       func(req *http.Request)
+	ignore        map[string]bool
+	ignoreHeaders map[string]bool
+}
+
+// ReplayDiff describes the behavioral differences of the response to a
+// replayed exchange.
+type ReplayDiff struct {
+	// Index is the index of the exchange.
+	Index int
+
+	OperationID string
+	Method      string
+	URL         string
+
+	// Differences describe how the response differs from the recorded one,
+	// e.g. "status: expected 200, got 404".
+	Differences []string
+}
+
+// Replay replays the recorded exchanges against the handler, e.g. the
+// router of a new build, in order, and returns the differences of the
+// responses from the recorded ones. Responses are compared by status,
+// headers recorded in the exchange, and body, where JSON bodies are
+// compared semantically. It returns no differences if all the responses
+// match.
+func Replay(h http.Handler, exchanges []Exchange, opts ...ReplayOption) []ReplayDiff {
+	r := &replayer{}
+	ReplayIgnoreHeaders("Date", "Content-Length")(r)
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	var diffs []ReplayDiff
+	for i, e := range exchanges {
+		req := httptest.NewRequest(e.Request.Method, e.Request.URL, strings.NewReader(e.Request.Body))
+		for name, vals := range e.Request.Header {
+			req.Header[name] = append([]string(nil), vals...)
+		}
+		if r.prepare != nil {
+			r.prepare(req)
+		}
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		if d := r.compare(e.Response, w); len(d) > 0 {
+			diffs = append(diffs, ReplayDiff{
+				Index:       i,
+				OperationID: e.OperationID,
+				Method:      e.Request.Method,
+				URL:         e.Request.URL,
+				Differences: d,
+			})
+		}
+	}
+	return diffs
+}
+
+// compare returns the differences of the response from the recorded one.
+func (r *replayer) compare(expected ExchangeResponse, w *httptest.ResponseRecorder) []string {
+	var diffs []string
+	if expected.Status != w.Code {
+		diffs = append(diffs, fmt.Sprintf("status: expected %d, got %d", expected.Status, w.Code))
+	}
+
+	names := make([]string, 0, len(expected.Header))
+	for name := range expected.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if r.ignoreHeaders[http.CanonicalHeaderKey(name)] {
+			continue
+		}
+		exp, got := expected.Header.Get(name), w.Header().Get(name)
+		if exp != Redacted && exp != got {
+			diffs = append(diffs, fmt.Sprintf("header %s: expected %q, got %q", name, exp, got))
+		}
+	}
+
+	var exp, got interface{}
+	if json.Unmarshal([]byte(expected.Body), &exp) != nil || json.Unmarshal(w.Body.Bytes(), &got) != nil {
+		if expected.Body != w.Body.String() {
+			diffs = append(diffs, "body: differs from the recorded one")
+		}
+		return diffs
+	}
+	return append(diffs, diffJSON("body", exp, got, r.ignore)...)
+}
+
+// diffJSON returns the differences of the JSON value got from exp, with
+// the paths of the values as JSON pointers prefixed with the path.
+func diffJSON(path string, exp, got interface{}, ignore map[string]bool) []string {
+	if exp == Redacted {
+		return nil
+	}
+
+	switch e := exp.(type) {
+	case map[string]interface{}:
+		g, ok := got.(map[string]interface{})
+		if !ok {
+			break
+		}
+		names := make([]string, 0, len(e)+len(g))
+		for name := range e {
+			names = append(names, name)
+		}
+		for name := range g {
+			if _, ok := e[name]; !ok {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+
+		var diffs []string
+		for _, name := range names {
+			if ignore[name] {
+				continue
+			}
+			p := path + "/" + escapeJSONPointer(name)
+			ev, eok := e[name]
+			gv, gok := g[name]
+			switch {
+			case !gok:
+				diffs = append(diffs, p+": missing")
+			case !eok:
+				diffs = append(diffs, p+": unexpected")
+			default:
+				diffs = append(diffs, diffJSON(p, ev, gv, ignore)...)
+			}
+		}
+		return diffs
+
+	case []interface{}:
+		g, ok := got.([]interface{})
+		if !ok {
+			break
+		}
+		if len(e) != len(g) {
+			return []string{fmt.Sprintf("%s: expected %d items, got %d", path, len(e), len(g))}
+		}
+		var diffs []string
+		for i := range e {
+			diffs = append(diffs, diffJSON(fmt.Sprintf("%s/%d", path, i), e[i], g[i], ignore)...)
+		}
+		return diffs
+	}
+
+	if reflect.DeepEqual(exp, got) {
+		return nil
+	}
+	ej, _ := json.Marshal(exp)
+	gj, _ := json.Marshal(got)
+	return []string{fmt.Sprintf("%s: expected %s, got %s", path, ej, gj)}
+}
+
+// escapeJSONPointer escapes the reference token of a JSON pointer.
+func escapeJSONPointer(s string) string {
+	return strings.Replace(strings.Replace(s, "~", "~0", -1), "/", "~1", -1)
+}
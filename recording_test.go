@@ -0,0 +1,96 @@
+package oas
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorderAndReplay(t *testing.T) {
+	doc := loadDocBytes([]byte(`
+swagger: "2.0"
+info:
+  title: Test
+  version: 1.0.0
+paths:
+  /accounts:
+    post:
+      operationId: createAccount
+      responses:
+        201:
+          description: Created
+`))
+
+	newHandler := func(status int, name string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			data, _ := ioutil.ReadAll(req.Body)
+			if !bytes.Contains(data, []byte(`"login":"alice"`)) || req.Header.Get("Authorization") != "Bearer t" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			fmt.Fprintf(w, `{"id":%d,"name":%q,"token":"secret"}`, len(data), name)
+		})
+	}
+
+	buf := &bytes.Buffer{}
+	basis := NewResolvingBasis(SpecAdapterName, doc, BasisStrict(false))
+	h := SpecMatcherMiddleware(doc)(
+		basis.Recorder(buf, RecordRedactFields("password", "token"))(newHandler(http.StatusCreated, "Alice")),
+	)
+
+	req := httptest.NewRequest(http.MethodPost, "/accounts?ref=ads", strings.NewReader(`{"login":"alice","password":"p4ss"}`))
+	req.Header.Set("Authorization", "Bearer t")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"token":"secret"`, "response must not be redacted")
+
+	assert.NotContains(t, buf.String(), "p4ss")
+	assert.NotContains(t, buf.String(), "secret")
+	assert.NotContains(t, buf.String(), "Bearer t")
+
+	exchanges, err := LoadExchanges(buf)
+	require.NoError(t, err)
+	require.Len(t, exchanges, 1)
+
+	e := exchanges[0]
+	assert.Equal(t, ExchangeFormatVersion, e.Version)
+	assert.Equal(t, "createAccount", e.OperationID)
+	assert.Equal(t, "/accounts?ref=ads", e.Request.URL)
+	assert.JSONEq(t, `{"login":"alice","password":"REDACTED"}`, e.Request.Body)
+	assert.Equal(t, http.StatusCreated, e.Response.Status)
+	assert.JSONEq(t, `{"id":35,"name":"Alice","token":"REDACTED"}`, e.Response.Body)
+
+	auth := ReplayPrepare(func(req *http.Request) {
+		req.Header.Set("Authorization", "Bearer t")
+	})
+
+	t.Run("same behavior", func(t *testing.T) {
+		diffs := Replay(newHandler(http.StatusCreated, "Alice"), exchanges, auth, ReplayIgnoreFields("id"))
+		assert.Empty(t, diffs)
+	})
+
+	t.Run("changed behavior", func(t *testing.T) {
+		diffs := Replay(newHandler(http.StatusOK, "Bob"), exchanges, auth, ReplayIgnoreFields("id"))
+		require.Len(t, diffs, 1)
+		assert.Equal(t, "createAccount", diffs[0].OperationID)
+		assert.Equal(t, []string{
+			"status: expected 201, got 200",
+			`body/name: expected "Alice", got "Bob"`,
+		}, diffs[0].Differences)
+	})
+
+	t.Run("unsupported version", func(t *testing.T) {
+		_, err := LoadExchanges(strings.NewReader(`{"version": 2}`))
+		assert.EqualError(t, err, "exchange 1: unsupported format version 2, expected 1")
+	})
+}
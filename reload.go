@@ -0,0 +1,63 @@
+package oas
+
+import (
+	"net/http"
+	"sync"
+)
+
+// NewReloadableHandler returns a new handler that serves requests using h
+// until it gets replaced by calling Reload.
+//
+// It is designed to wrap a router built from a spec, so that the spec can be
+// reloaded at runtime: build a new router from the new document and pass it
+// to Reload.
+func NewReloadableHandler(h http.Handler) *ReloadableHandler {
+	return &ReloadableHandler{
+		gen: &handlerGeneration{h: h},
+	}
+}
+
+// ReloadableHandler is a handler that can swap the underlying handler at
+// runtime, letting in-flight requests finish against the previous one.
+type ReloadableHandler struct {
+	mx  sync.RWMutex
+	gen *handlerGeneration
+}
+
+// handlerGeneration is a handler along with its in-flight requests.
+type handlerGeneration struct {
+	h  http.Handler
+	wg sync.WaitGroup
+}
+
+// ServeHTTP implements http.Handler.
+func (rh *ReloadableHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	rh.mx.RLock()
+	gen := rh.gen
+	gen.wg.Add(1)
+	rh.mx.RUnlock()
+
+	defer gen.wg.Done()
+	gen.h.ServeHTTP(w, req)
+}
+
+// Reload replaces the underlying handler with h. All new requests are served
+// by h, while requests that are already in flight finish against the previous
+// handler. When the last of them completes, onDrained is called (if not nil)
+// in a separate goroutine. This allows to sequence rollouts safely, e.g. to
+// release resources bound to the previous spec.
+func (rh *ReloadableHandler) Reload(h http.Handler, onDrained func()) {
+	rh.mx.Lock()
+	prev := rh.gen
+	rh.gen = &handlerGeneration{h: h}
+	rh.mx.Unlock()
+
+	publish(SpecReloaded{})
+
+	go func() {
+		prev.wg.Wait()
+		if onDrained != nil {
+			onDrained()
+		}
+	}()
+}
@@ -0,0 +1,60 @@
+package oas
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReloadableHandler(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	old := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		close(started)
+		<-release
+		io.WriteString(w, "old")
+	})
+	updated := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		io.WriteString(w, "new")
+	})
+
+	h := NewReloadableHandler(old)
+
+	// Start a request that is served by the old handler and blocks.
+	inFlight := httptest.NewRecorder()
+	finished := make(chan struct{})
+	go func() {
+		h.ServeHTTP(inFlight, httptest.NewRequest(http.MethodGet, "/", nil))
+		close(finished)
+	}()
+	<-started
+
+	drained := make(chan struct{})
+	h.Reload(updated, func() { close(drained) })
+
+	// New requests are served by the new handler.
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, "new", w.Body.String())
+
+	select {
+	case <-drained:
+		t.Fatal("Expected old handler not to be drained while request is in flight")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	<-finished
+	assert.Equal(t, "old", inFlight.Body.String())
+
+	select {
+	case <-drained:
+	case <-time.After(time.Second):
+		t.Fatal("Expected drained callback to be called")
+	}
+}
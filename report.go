@@ -0,0 +1,159 @@
+package oas
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/go-openapi/spec"
+
+	"github.com/hypnoglow/oas2/convert"
+)
+
+// Names of the checks recorded to the validation report.
+const (
+	CheckHost                = "host"
+	CheckQuery               = "query"
+	CheckRequestContentType  = "request content type"
+	CheckRequestBody         = "request body"
+	CheckResponseContentType = "response content type"
+	CheckResponseBody        = "response body"
+)
+
+// ValidationCheck describes a single check performed by a validator.
+type ValidationCheck struct {
+	// Name is the check name, e.g. CheckQuery.
+	Name string
+
+	// Passed reports whether the check passed.
+	Passed bool
+
+	// Err is the check error, if the check did not pass.
+	Err error
+
+	// Duration is the time spent on the check.
+	Duration time.Duration
+
+	// Values are the values coerced during the check, by parameter name.
+	Values map[string]interface{}
+}
+
+// ValidationReport describes what was checked by validators while processing
+// a request. It can serve as a proof of validation for audit purposes.
+//
+// The report is safe for concurrent use.
+type ValidationReport struct {
+	mx     sync.Mutex
+	checks []ValidationCheck
+}
+
+// Checks returns all the checks recorded to the report, in order.
+func (r *ValidationReport) Checks() []ValidationCheck {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	checks := make([]ValidationCheck, len(r.checks))
+	copy(checks, r.checks)
+	return checks
+}
+
+// Passed reports whether all the checks recorded to the report passed.
+func (r *ValidationReport) Passed() bool {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	for _, c := range r.checks {
+		if !c.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *ValidationReport) add(c ValidationCheck) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	r.checks = append(r.checks, c)
+}
+
+type contextKeyValidationReport struct{}
+
+// GetValidationReport returns the validation report from the request context.
+// The report is available only when ValidationReportContext middleware is
+// used. Validators record checks to the report as the request is processed,
+// so the report is complete once the downstream handler returns.
+func GetValidationReport(req *http.Request) (*ValidationReport, bool) {
+	r, ok := req.Context().Value(contextKeyValidationReport{}).(*ValidationReport)
+	return r, ok
+}
+
+// ValidationReportContext returns a middleware that attaches an empty
+// validation report to the request context, so validators that come after
+// this middleware record their checks to it. Use GetValidationReport to
+// retrieve the report, e.g. in an audit middleware that comes between this
+// middleware and validators:
+//
+//  func audit(next http.Handler) http.Handler {
+//      return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+//          next.ServeHTTP(w, req)
+//          if report, ok := oas.GetValidationReport(req); ok {
+//              // log report.Checks()
+//          }
+//      })
+//  }
+func ValidationReportContext() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if _, ok := GetValidationReport(req); !ok {
+				req = req.WithContext(context.WithValue(req.Context(), contextKeyValidationReport{}, &ValidationReport{}))
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// recordCheck records the check to the request validation report, if any.
+// Values are computed lazily, only when the report is present.
+func recordCheck(req *http.Request, name string, start time.Time, err error, values func() map[string]interface{}) {
+	if err != nil {
+		publishValidationFailed(req, name, err)
+	}
+
+	r, ok := GetValidationReport(req)
+	if !ok {
+		return
+	}
+
+	c := ValidationCheck{
+		Name:     name,
+		Passed:   err == nil,
+		Err:      err,
+		Duration: time.Since(start),
+	}
+	if values != nil {
+		c.Values = values()
+	}
+	r.add(c)
+}
+
+// coercedQueryValues returns values of query parameters converted by their
+// spec. Values that cannot be converted are skipped.
+func coercedQueryValues(params []spec.Parameter, q url.Values) map[string]interface{} {
+	values := make(map[string]interface{})
+	for _, p := range params {
+		if p.In != "query" {
+			continue
+		}
+		vals, ok := q[p.Name]
+		if !ok {
+			continue
+		}
+		if v, err := convert.Parameter(vals, &p); err == nil {
+			values[p.Name] = v
+		}
+	}
+	return values
+}
@@ -0,0 +1,62 @@
+package oas
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidationReportContext(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+	params := doc.Analyzer.ParametersFor("loginUser")
+
+	qv := &queryValidator{
+		next:           http.HandlerFunc(handleUserLogin),
+		problemHandler: problemHandlerResponseWriter(),
+	}
+
+	var report *ValidationReport
+	audit := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			next.ServeHTTP(w, req)
+			report, _ = GetValidationReport(req)
+		})
+	}
+
+	h := ValidationReportContext()(audit(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		qv.ServeHTTP(w, req, params, true)
+	})))
+
+	t.Run("passed", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/v2/user/login?username=johndoe&password=123", nil)
+		h.ServeHTTP(httptest.NewRecorder(), req)
+
+		assert.NotNil(t, report)
+		assert.True(t, report.Passed())
+
+		checks := report.Checks()
+		assert.Len(t, checks, 1)
+		assert.Equal(t, CheckQuery, checks[0].Name)
+		assert.NoError(t, checks[0].Err)
+		assert.Equal(t, map[string]interface{}{"username": "johndoe", "password": "123"}, checks[0].Values)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/v2/user/login?username=johndoe", nil)
+		h.ServeHTTP(httptest.NewRecorder(), req)
+
+		assert.NotNil(t, report)
+		assert.False(t, report.Passed())
+
+		checks := report.Checks()
+		assert.Len(t, checks, 1)
+		assert.EqualError(t, checks[0].Err, "query params do not match the schema: param password is required")
+	})
+
+	t.Run("no report without middleware", func(t *testing.T) {
+		_, ok := GetValidationReport(httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.False(t, ok)
+	})
+}
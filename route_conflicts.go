@@ -0,0 +1,192 @@
+package oas
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RoutePrecedenceExtension is the operation extension that sets the
+// precedence of the operation route, an integer, 0 by default. When a
+// request path matches several path templates, the route with the higher
+// precedence wins, regardless of the other rules:
+//
+//  /files/{name}:
+//    get:
+//      operationId: getFile
+//  /files/{name}.json:
+//    get:
+//      operationId: getJSONFile
+//      x-oas-route-precedence: 1
+//
+// See RouteConflicts for the other rules.
+const RoutePrecedenceExtension = "x-oas-route-precedence"
+
+// RouteRule is the rule that resolves a conflict of routes.
+type RouteRule string
+
+// Route rules, in order of application.
+const (
+	// RouteRulePrecedence prefers the route with the higher precedence
+	// set by RoutePrecedenceExtension.
+	RouteRulePrecedence RouteRule = "higher precedence"
+
+	// RouteRuleLiterals prefers the route with more literal segments, e.g.
+	// "/pet/findByStatus" over "/pet/{petId}".
+	RouteRuleLiterals RouteRule = "more literal segments"
+
+	// RouteRuleNotGreedy prefers the route without greedy parameters, see
+	// GreedyExtension.
+	RouteRuleNotGreedy RouteRule = "no greedy parameter"
+
+	// RouteRulePath prefers the route with the lexically smaller path
+	// template. This rule is arbitrary, so conflicts resolved by it should
+	// be resolved explicitly with RoutePrecedenceExtension.
+	RouteRulePath RouteRule = "lexical order of templates"
+)
+
+// RouteConflict describes two routes of the same method that match some
+// request paths both, e.g. "/pet/{petId}" and "/pet/findByStatus" both
+// match "/pet/findByStatus".
+type RouteConflict struct {
+	// Method is the method of the routes.
+	Method string
+
+	// Winner is the path template of the route that matches the requests.
+	Winner string
+
+	// WinnerOperationID is the operation id of the Winner route.
+	WinnerOperationID string
+
+	// Loser is the path template of the route that is shadowed.
+	Loser string
+
+	// LoserOperationID is the operation id of the Loser route.
+	LoserOperationID string
+
+	// Rule is the rule the conflict was resolved by.
+	Rule RouteRule
+}
+
+// String returns the description of the conflict.
+func (c RouteConflict) String() string {
+	s := fmt.Sprintf(
+		"%s %s (%s) takes precedence over %s (%s) by %s",
+		c.Method, c.Winner, c.WinnerOperationID, c.Loser, c.LoserOperationID, c.Rule,
+	)
+	if c.Rule == RouteRulePath {
+		s += fmt.Sprintf("; set %s to resolve the conflict explicitly", RoutePrecedenceExtension)
+	}
+	return s
+}
+
+// RouteConflicts returns the conflicts of the document routes: pairs of
+// path templates of the same method that match some request paths both.
+// Such requests are matched by SpecMatcherMiddleware to the route that wins
+// by the first applicable rule: RouteRulePrecedence, RouteRuleLiterals,
+// RouteRuleNotGreedy, and RouteRulePath. Other routers may resolve them
+// differently, so check the conflicts at startup, or in tests:
+//
+//  for _, c := range doc.RouteConflicts() {
+//      log.Printf("route conflict: %s", c)
+//  }
+//
+// Conflicts are also published as RouteConflictDetected events when
+// routing is built by the OperationRouter returned from
+// ResolvingBasis.OperationRouter.
+func (doc *Document) RouteConflicts() []RouteConflict {
+	routes := doc.specMatcher().routes
+
+	var conflicts []RouteConflict
+	for i, w := range routes {
+		for _, l := range routes[i+1:] {
+			if w.method != l.method || !templatesOverlap(w.path, l.path) {
+				continue
+			}
+			conflicts = append(conflicts, RouteConflict{
+				Method:            w.method,
+				Winner:            w.path,
+				WinnerOperationID: w.id,
+				Loser:             l.path,
+				LoserOperationID:  l.id,
+				Rule:              routeRule(w, l),
+			})
+		}
+	}
+	return conflicts
+}
+
+// routeRule returns the rule by which the route w takes precedence over l.
+func routeRule(w, l specRoute) RouteRule {
+	switch {
+	case w.precedence != l.precedence:
+		return RouteRulePrecedence
+	case w.literals != l.literals:
+		return RouteRuleLiterals
+	case w.greedy != l.greedy:
+		return RouteRuleNotGreedy
+	}
+	return RouteRulePath
+}
+
+// templatesOverlap checks if some path matches both path templates.
+func templatesOverlap(a, b string) bool {
+	ta := strings.Split(strings.Trim(a, "/"), "/")
+	tb := strings.Split(strings.Trim(b, "/"), "/")
+	ga, gb := greedySegment(ta), greedySegment(tb)
+
+	// A greedy segment matches one or more segments, so the other template
+	// must have at least as many segments.
+	switch {
+	case ga < 0 && gb < 0 && len(ta) != len(tb):
+		return false
+	case ga >= 0 && gb < 0 && len(tb) < len(ta):
+		return false
+	case gb >= 0 && ga < 0 && len(ta) < len(tb):
+		return false
+	}
+
+	// Compare the segments up to the greedy ones from both ends.
+	for i := 0; i < len(ta) && i < len(tb) && i != ga && i != gb; i++ {
+		if !segmentsOverlap(ta[i], tb[i]) {
+			return false
+		}
+	}
+	for j := 1; j <= len(ta) && j <= len(tb) && len(ta)-j != ga && len(tb)-j != gb; j++ {
+		if !segmentsOverlap(ta[len(ta)-j], tb[len(tb)-j]) {
+			return false
+		}
+	}
+	return true
+}
+
+// segmentsOverlap checks if some path segment matches both template
+// segments.
+func segmentsOverlap(a, b string) bool {
+	pa, sa, isParamA := splitSegment(a)
+	pb, sb, isParamB := splitSegment(b)
+
+	switch {
+	case !isParamA && !isParamB:
+		return a == b
+	case !isParamA:
+		return len(a) > len(pb)+len(sb) && strings.HasPrefix(a, pb) && strings.HasSuffix(a, sb)
+	case !isParamB:
+		return len(b) > len(pa)+len(sa) && strings.HasPrefix(b, pa) && strings.HasSuffix(b, sa)
+	}
+
+	// Both segments are parameters, so they overlap if their literal
+	// prefixes and suffixes are compatible.
+	return (strings.HasPrefix(pa, pb) || strings.HasPrefix(pb, pa)) &&
+		(strings.HasSuffix(sa, sb) || strings.HasSuffix(sb, sa))
+}
+
+// splitSegment returns the literal prefix and suffix of the parameter of
+// the template segment, or false if the segment has no parameter.
+func splitSegment(t string) (prefix, suffix string, ok bool) {
+	start := strings.Index(t, "{")
+	end := strings.LastIndex(t, "}")
+	if start < 0 || end < start {
+		return "", "", false
+	}
+	return t[:start], t[end+1:], true
+}
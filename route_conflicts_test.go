@@ -0,0 +1,167 @@
+package oas
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDocument_RouteConflicts(t *testing.T) {
+	doc := loadDocBytes([]byte(`
+swagger: "2.0"
+info:
+  title: Test
+  version: 1.0.0
+basePath: /v2
+paths:
+  /pet/{petId}:
+    get:
+      operationId: getPetById
+      parameters:
+      - name: petId
+        in: path
+        required: true
+        type: string
+      responses:
+        200:
+          description: OK
+  /pet/findByStatus:
+    get:
+      operationId: findPetsByStatus
+      responses:
+        200:
+          description: OK
+    post:
+      operationId: addPetByStatus
+      responses:
+        200:
+          description: OK
+  /files/{name}:
+    get:
+      operationId: getFile
+      parameters:
+      - name: name
+        in: path
+        required: true
+        type: string
+      responses:
+        200:
+          description: OK
+  /files/{name}.json:
+    get:
+      operationId: getJSONFile
+      x-oas-route-precedence: 1
+      parameters:
+      - name: name
+        in: path
+        required: true
+        type: string
+      responses:
+        200:
+          description: OK
+  /files/{name}.xml:
+    get:
+      operationId: getXMLFile
+      parameters:
+      - name: name
+        in: path
+        required: true
+        type: string
+      responses:
+        200:
+          description: OK
+`))
+
+	conflicts := doc.RouteConflicts()
+	assert.Equal(t, []RouteConflict{
+		{
+			Method:            http.MethodGet,
+			Winner:            "/v2/files/{name}.json",
+			WinnerOperationID: "getJSONFile",
+			Loser:             "/v2/files/{name}",
+			LoserOperationID:  "getFile",
+			Rule:              RouteRulePrecedence,
+		},
+		{
+			Method:            http.MethodGet,
+			Winner:            "/v2/pet/findByStatus",
+			WinnerOperationID: "findPetsByStatus",
+			Loser:             "/v2/pet/{petId}",
+			LoserOperationID:  "getPetById",
+			Rule:              RouteRuleLiterals,
+		},
+		{
+			Method:            http.MethodGet,
+			Winner:            "/v2/files/{name}",
+			WinnerOperationID: "getFile",
+			Loser:             "/v2/files/{name}.xml",
+			LoserOperationID:  "getXMLFile",
+			Rule:              RouteRulePath,
+		},
+	}, conflicts)
+
+	assert.Equal(t,
+		"GET /v2/files/{name} (getFile) takes precedence over /v2/files/{name}.xml (getXMLFile) by lexical order of templates; "+
+			"set x-oas-route-precedence to resolve the conflict explicitly",
+		conflicts[2].String(),
+	)
+
+	op, _, ok := doc.FindOperationByRequest(httptest.NewRequest(http.MethodGet, "/v2/files/a.json", nil))
+	assert.True(t, ok)
+	assert.Equal(t, "getJSONFile", op.ID)
+}
+
+func TestSpecMatcherMiddleware_invalidRoutePrecedence(t *testing.T) {
+	doc := loadDocBytes([]byte(`
+swagger: "2.0"
+info:
+  title: Test
+  version: 1.0.0
+paths:
+  /files/{name}:
+    get:
+      operationId: getFile
+      x-oas-route-precedence: high
+      parameters:
+      - name: name
+        in: path
+        required: true
+        type: string
+      responses:
+        200:
+          description: OK
+`))
+
+	assert.PanicsWithValue(t, "oas: operation getFile: invalid x-oas-route-precedence: value must be an integer", func() {
+		SpecMatcherMiddleware(doc)
+	})
+
+	// The matcher is not left broken by the panic.
+	op, _, ok := doc.FindOperationByRequest(httptest.NewRequest(http.MethodGet, "/files/a.txt", nil))
+	assert.True(t, ok)
+	assert.Equal(t, "getFile", op.ID)
+}
+
+func TestTemplatesOverlap(t *testing.T) {
+	testCases := map[string]struct {
+		a, b     string
+		expected bool
+	}{
+		"literal and parameter":    {a: "/pet/{petId}", b: "/pet/findByStatus", expected: true},
+		"different literals":       {a: "/pet/{petId}", b: "/user/{id}", expected: false},
+		"different lengths":        {a: "/pet/{petId}", b: "/pet/{petId}/photos", expected: false},
+		"incompatible suffixes":    {a: "/files/{name}.json", b: "/files/{name}.xml", expected: false},
+		"greedy parameter":         {a: "/files/{path+}", b: "/files/{name}/raw", expected: true},
+		"greedy with shorter path": {a: "/files/{path+}/raw", b: "/files/raw", expected: false},
+		"greedy with other suffix": {a: "/files/{path+}/raw", b: "/files/{name}/meta", expected: false},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, templatesOverlap(tc.a, tc.b))
+			assert.Equal(t, tc.expected, templatesOverlap(tc.b, tc.a))
+		})
+	}
+}
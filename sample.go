@@ -0,0 +1,332 @@
+package oas
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/go-openapi/spec"
+)
+
+// maxSampleDepth limits the depth of sampled schemas, so that recursive
+// schemas do not produce infinite values.
+const maxSampleDepth = 8
+
+// sampleParam returns a value for the parameter that conforms to its spec.
+// It prefers the "x-example" extension, the default value and the first enum
+// value, in that order, and synthesizes the value otherwise.
+func sampleParam(p spec.Parameter) interface{} {
+	if p.In == "body" {
+		return sampleSchema(p.Schema, 0)
+	}
+
+	if v, ok := p.Extensions["x-example"]; ok {
+		return v
+	}
+	if p.Default != nil {
+		return p.Default
+	}
+	if len(p.Enum) > 0 {
+		return p.Enum[0]
+	}
+
+	if p.Type == "array" {
+		if p.Items == nil {
+			return nil
+		}
+		return []interface{}{sampleItems(p.Items)}
+	}
+
+	return samplePrimitive(p.Type, p.Format, numberBounds{
+		min: p.Minimum, exclMin: p.ExclusiveMinimum,
+		max: p.Maximum, exclMax: p.ExclusiveMaximum,
+	}, p.MinLength, p.MaxLength)
+}
+
+// sampleItems returns a value for the array parameter items.
+func sampleItems(items *spec.Items) interface{} {
+	if items.Default != nil {
+		return items.Default
+	}
+	if len(items.Enum) > 0 {
+		return items.Enum[0]
+	}
+
+	return samplePrimitive(items.Type, items.Format, numberBounds{
+		min: items.Minimum, exclMin: items.ExclusiveMinimum,
+		max: items.Maximum, exclMax: items.ExclusiveMaximum,
+	}, items.MinLength, items.MaxLength)
+}
+
+// sampleSchema returns a value that conforms to the schema. It prefers the
+// schema example, the default value and the first enum value, in that order,
+// and synthesizes the value otherwise.
+func sampleSchema(schema *spec.Schema, depth int) interface{} {
+	return schemaSampler{}.sample(schema, depth)
+}
+
+// schemaSampler synthesizes values that conform to schemas. Unlike
+// sampleSchema, it resolves references to definitions, so it can work on
+// the original (not expanded) spec, and allows to override values for
+// definitions.
+type schemaSampler struct {
+	// definitions are the spec definitions to resolve references against.
+	definitions spec.Definitions
+
+	// overrides produce values for definitions by name. If an override
+	// returns nil, the value is synthesized.
+	overrides map[string]func() interface{}
+}
+
+func (s schemaSampler) sample(schema *spec.Schema, depth int) interface{} {
+	if schema == nil || depth > maxSampleDepth {
+		return nil
+	}
+
+	if ref := schema.Ref.String(); ref != "" {
+		name := strings.TrimPrefix(ref, "#/definitions/")
+		if name == ref {
+			// Only local references are supported.
+			return nil
+		}
+		if fn, ok := s.overrides[name]; ok {
+			if v := fn(); v != nil {
+				return v
+			}
+		}
+		def, ok := s.definitions[name]
+		if !ok {
+			return nil
+		}
+		v := s.sample(&def, depth+1)
+		if t, ok := lookupModel(name); ok {
+			v = shapeByModel(v, t)
+		}
+		return v
+	}
+
+	if schema.Example != nil {
+		return schema.Example
+	}
+	if schema.Default != nil {
+		return schema.Default
+	}
+	if len(schema.Enum) > 0 {
+		return schema.Enum[0]
+	}
+
+	if len(schema.AllOf) > 0 {
+		obj := make(map[string]interface{})
+		for i := range schema.AllOf {
+			if m, ok := s.sample(&schema.AllOf[i], depth+1).(map[string]interface{}); ok {
+				for k, v := range m {
+					obj[k] = v
+				}
+			}
+		}
+		return obj
+	}
+
+	switch schemaType(schema) {
+	case "object":
+		obj := make(map[string]interface{})
+		for _, name := range sortedPropertyNames(schema) {
+			prop := schema.Properties[name]
+			if v := s.sample(&prop, depth+1); v != nil {
+				obj[name] = v
+			}
+		}
+		return obj
+	case "array":
+		n := 1
+		if schema.MinItems != nil && *schema.MinItems > 1 {
+			n = int(*schema.MinItems)
+		}
+		arr := make([]interface{}, 0, n)
+		if schema.Items != nil && schema.Items.Schema != nil {
+			for i := 0; i < n; i++ {
+				arr = append(arr, s.sample(schema.Items.Schema, depth+1))
+			}
+		}
+		return arr
+	default:
+		return samplePrimitive(schemaType(schema), schema.Format, numberBounds{
+			min: schema.Minimum, exclMin: schema.ExclusiveMinimum,
+			max: schema.Maximum, exclMax: schema.ExclusiveMaximum,
+		}, schema.MinLength, schema.MaxLength)
+	}
+}
+
+// shapeByModel converts the value to a value of the model type by JSON
+// round trip. If the conversion fails, the value is returned as is.
+func shapeByModel(v interface{}, t reflect.Type) interface{} {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	m := reflect.New(t).Interface()
+	if err := json.Unmarshal(b, m); err != nil {
+		return v
+	}
+	return m
+}
+
+// schemaType returns the schema type. Schemas without type but with
+// properties are considered objects.
+func schemaType(schema *spec.Schema) string {
+	if len(schema.Type) > 0 {
+		return schema.Type[0]
+	}
+	if len(schema.Properties) > 0 {
+		return "object"
+	}
+	return ""
+}
+
+// sortedPropertyNames returns the schema property names in a stable order.
+func sortedPropertyNames(schema *spec.Schema) []string {
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// numberBounds describes the number range constraints.
+type numberBounds struct {
+	min, max         *float64
+	exclMin, exclMax bool
+}
+
+// lowest returns the lowest number that is in the bounds, if any.
+func (b numberBounds) lowest(integer bool) (float64, bool) {
+	if b.min == nil {
+		return 0, false
+	}
+	v := *b.min
+	if b.exclMin {
+		if integer {
+			v = math.Floor(v) + 1
+		} else {
+			v = math.Nextafter(v, math.Inf(1))
+		}
+	} else if integer {
+		v = math.Ceil(v)
+	}
+	return v, true
+}
+
+// highest returns the highest number that is in the bounds, if any.
+func (b numberBounds) highest(integer bool) (float64, bool) {
+	if b.max == nil {
+		return 0, false
+	}
+	v := *b.max
+	if b.exclMax {
+		if integer {
+			v = math.Ceil(v) - 1
+		} else {
+			v = math.Nextafter(v, math.Inf(-1))
+		}
+	} else if integer {
+		v = math.Floor(v)
+	}
+	return v, true
+}
+
+// samplePrimitive synthesizes a value of the primitive type and format.
+func samplePrimitive(typ, format string, bounds numberBounds, minLength, maxLength *int64) interface{} {
+	switch typ {
+	case "integer":
+		v := float64(1)
+		if low, ok := bounds.lowest(true); ok {
+			v = low
+		} else if high, ok := bounds.highest(true); ok && high < v {
+			v = high
+		}
+		if format == "int32" {
+			return int32(v)
+		}
+		return int64(v)
+	case "number":
+		v := 1.5
+		if low, ok := bounds.lowest(false); ok {
+			v = low
+		} else if high, ok := bounds.highest(false); ok && high < v {
+			v = high
+		}
+		if format == "float" {
+			return float32(v)
+		}
+		return v
+	case "boolean":
+		return true
+	case "string":
+		return sampleString(format, minLength, maxLength)
+	default:
+		return nil
+	}
+}
+
+// sampleFormats are the sample values of the known string formats.
+var sampleFormats = map[string]string{
+	"date":      "2018-01-02",
+	"date-time": "2018-01-02T15:04:05Z",
+	"email":     "user@example.com",
+	"hostname":  "example.com",
+	"ipv4":      "192.0.2.1",
+	"ipv6":      "2001:db8::1",
+	"uri":       "http://example.com/",
+	"uuid":      "6ba7b810-9dad-11d1-80b4-00c04fd430c8",
+	"byte":      "c2FtcGxl",
+	"password":  "secret",
+	// formats package
+	"partial-time": "15:04:05",
+}
+
+// sampleString synthesizes a string of the format within the length bounds.
+func sampleString(format string, minLength, maxLength *int64) string {
+	if s, ok := sampleFormats[format]; ok {
+		return s
+	}
+
+	s := "sample"
+	if minLength != nil && int64(len(s)) < *minLength {
+		s += strings.Repeat("x", int(*minLength)-len(s))
+	}
+	if maxLength != nil && int64(len(s)) > *maxLength {
+		s = s[:*maxLength]
+	}
+	return s
+}
+
+// formatParamValue formats the parameter value for use in a query string,
+// a header or a path.
+func formatParamValue(p spec.Parameter, v interface{}) []string {
+	arr, ok := v.([]interface{})
+	if !ok {
+		return []string{fmt.Sprint(v)}
+	}
+
+	vals := make([]string, len(arr))
+	for i, item := range arr {
+		vals[i] = fmt.Sprint(item)
+	}
+
+	switch p.CollectionFormat {
+	case "multi":
+		return vals
+	case "ssv":
+		return []string{strings.Join(vals, " ")}
+	case "tsv":
+		return []string{strings.Join(vals, "\t")}
+	case "pipes":
+		return []string{strings.Join(vals, "|")}
+	default:
+		return []string{strings.Join(vals, ",")}
+	}
+}
@@ -0,0 +1,195 @@
+package oas
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ResponseSampler limits the cost of response body validation. It validates
+// only a fraction of responses per operation and, when a budget is set,
+// disables validation of an operation whose 99th percentile of validation
+// time exceeds the budget. Disabled operations are validated again after
+// a cooldown period.
+//
+// Use it with WithResponseSampler. ResponseSampler is safe for concurrent use.
+type ResponseSampler struct {
+	rate     float64
+	budget   time.Duration
+	window   int
+	cooldown time.Duration
+
+	now func() time.Time
+
+	mx  sync.Mutex
+	rnd *rand.Rand
+	ops map[string]*responseSampling
+}
+
+// ResponseSamplerOption is an option for ResponseSampler.
+type ResponseSamplerOption func(*ResponseSampler)
+
+// SampleRate returns an option that sets the fraction of responses to
+// validate per operation, from 0 to 1. By default, all responses are
+// validated.
+func SampleRate(rate float64) ResponseSamplerOption {
+	return func(s *ResponseSampler) {
+		s.rate = math.Max(0, math.Min(1, rate))
+	}
+}
+
+// SampleBudget returns an option that sets the budget of validation time per
+// response. When the 99th percentile of validation time of an operation
+// exceeds the budget, validation of the operation is disabled for the
+// cooldown period. By default, there is no budget.
+func SampleBudget(budget, cooldown time.Duration) ResponseSamplerOption {
+	return func(s *ResponseSampler) {
+		s.budget = budget
+		s.cooldown = cooldown
+	}
+}
+
+// SampleWindow returns an option that sets the number of the latest
+// validations of an operation the percentile is computed on. The percentile
+// is checked against the budget only when the window is full. By default,
+// the window is 100.
+func SampleWindow(n int) ResponseSamplerOption {
+	return func(s *ResponseSampler) {
+		s.window = n
+	}
+}
+
+// NewResponseSampler returns a new ResponseSampler.
+func NewResponseSampler(opts ...ResponseSamplerOption) *ResponseSampler {
+	s := &ResponseSampler{
+		rate:   1,
+		window: 100,
+		now:    time.Now,
+		rnd:    rand.New(rand.NewSource(time.Now().UnixNano())),
+		ops:    make(map[string]*responseSampling),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.window < 1 {
+		s.window = 1
+	}
+	return s
+}
+
+// ResponseSamplingStats describe sampling decisions of ResponseSampler for
+// an operation.
+type ResponseSamplingStats struct {
+	// Validated is the number of responses selected for validation.
+	Validated int64
+
+	// Skipped is the number of responses not selected for validation,
+	// either by the sample rate or because validation is disabled.
+	Skipped int64
+
+	// Disabled is the number of times validation was disabled because
+	// the budget was exceeded.
+	Disabled int64
+
+	// P99 is the 99th percentile of validation time in the current window.
+	P99 time.Duration
+
+	// DisabledUntil is the time until which validation is disabled, or zero.
+	DisabledUntil time.Time
+}
+
+// Stats returns sampling stats by operation id.
+func (s *ResponseSampler) Stats() map[string]ResponseSamplingStats {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	stats := make(map[string]ResponseSamplingStats, len(s.ops))
+	for id, op := range s.ops {
+		st := op.stats
+		st.P99 = op.p99()
+		stats[id] = st
+	}
+	return stats
+}
+
+// responseSampling is the sampling state of an operation.
+type responseSampling struct {
+	stats     ResponseSamplingStats
+	durations []time.Duration
+	next      int
+}
+
+// p99 returns the 99th percentile of the durations.
+func (op *responseSampling) p99() time.Duration {
+	if len(op.durations) == 0 {
+		return 0
+	}
+	d := append([]time.Duration(nil), op.durations...)
+	sort.Slice(d, func(i, j int) bool { return d[i] < d[j] })
+	return d[int(math.Ceil(0.99*float64(len(d))))-1]
+}
+
+func (s *ResponseSampler) operation(id string) *responseSampling {
+	op, ok := s.ops[id]
+	if !ok {
+		op = &responseSampling{}
+		s.ops[id] = op
+	}
+	return op
+}
+
+// sample decides if the response of the operation should be validated.
+func (s *ResponseSampler) sample(id string) bool {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	op := s.operation(id)
+
+	if !op.stats.DisabledUntil.IsZero() {
+		if s.now().Before(op.stats.DisabledUntil) {
+			op.stats.Skipped++
+			return false
+		}
+		op.stats.DisabledUntil = time.Time{}
+		op.durations = op.durations[:0]
+		op.next = 0
+	}
+
+	if s.rate < 1 && s.rnd.Float64() >= s.rate {
+		op.stats.Skipped++
+		return false
+	}
+
+	op.stats.Validated++
+	return true
+}
+
+// observe records the validation time of the response of the operation.
+func (s *ResponseSampler) observe(id string, d time.Duration) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	op := s.operation(id)
+
+	if len(op.durations) < s.window {
+		op.durations = append(op.durations, d)
+	} else {
+		op.durations[op.next] = d
+	}
+	op.next = (op.next + 1) % s.window
+
+	if s.budget > 0 && len(op.durations) == s.window && op.p99() > s.budget {
+		op.stats.Disabled++
+		op.stats.DisabledUntil = s.now().Add(s.cooldown)
+	}
+}
+
+// WithResponseSampler returns a middleware option that makes response body
+// validator validate only responses selected by the sampler.
+func WithResponseSampler(s *ResponseSampler) MiddlewareOption {
+	return func(opts *MiddlewareOptions) {
+		opts.responseSampler = s
+	}
+}
@@ -0,0 +1,71 @@
+package oas
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseSampler(t *testing.T) {
+	t.Run("validates sampled responses", func(t *testing.T) {
+		doc := loadDocFile(t, "testdata/petstore_1.yml")
+
+		var problems int
+		ph := ProblemHandlerFunc(func(p Problem) { problems++ })
+
+		s := NewResponseSampler(SampleRate(0))
+		basis := NewResolvingBasis(SpecAdapterName, doc, BasisStrict(false))
+		h := SpecMatcherMiddleware(doc)(
+			basis.ResponseBodyValidator(WithProblemHandler(ph), WithResponseSampler(s))(
+				http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+					w.Header().Set("X-Rate-Limit", "100")
+					w.Header().Set("X-Expires-After", "2018-01-02T15:04:05Z")
+					w.Header().Set("Content-Type", "application/json")
+					w.Write([]byte(`123`)) // nolint: errcheck
+				}),
+			),
+		)
+
+		for i := 0; i < 3; i++ {
+			req := httptest.NewRequest(http.MethodGet, "/v2/user/login?username=johndoe&password=123", nil)
+			h.ServeHTTP(httptest.NewRecorder(), req)
+		}
+
+		assert.Equal(t, 0, problems)
+		assert.Equal(t, ResponseSamplingStats{Skipped: 3}, s.Stats()["loginUser"])
+
+		SampleRate(1)(s)
+		req := httptest.NewRequest(http.MethodGet, "/v2/user/login?username=johndoe&password=123", nil)
+		h.ServeHTTP(httptest.NewRecorder(), req)
+
+		assert.Equal(t, 1, problems)
+		assert.Equal(t, int64(1), s.Stats()["loginUser"].Validated)
+	})
+
+	t.Run("disables validation over budget", func(t *testing.T) {
+		now := time.Date(2018, 1, 2, 15, 4, 5, 0, time.UTC)
+		s := NewResponseSampler(SampleBudget(10*time.Millisecond, time.Minute), SampleWindow(2))
+		s.now = func() time.Time { return now }
+
+		assert.True(t, s.sample("foo"))
+		s.observe("foo", 5*time.Millisecond)
+		assert.True(t, s.sample("foo"))
+		s.observe("foo", 20*time.Millisecond)
+
+		assert.False(t, s.sample("foo"))
+		assert.Equal(t, ResponseSamplingStats{
+			Validated:     2,
+			Skipped:       1,
+			Disabled:      1,
+			P99:           20 * time.Millisecond,
+			DisabledUntil: now.Add(time.Minute),
+		}, s.Stats()["foo"])
+
+		now = now.Add(time.Minute)
+		assert.True(t, s.sample("foo"))
+		assert.Equal(t, time.Duration(0), s.Stats()["foo"].P99)
+	})
+}
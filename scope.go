@@ -0,0 +1,115 @@
+package oas
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// PrincipalScope describes the subset of the API a principal may invoke.
+// An operation is in the scope if its id is listed in Operations, or any of
+// its tags is listed in Tags.
+type PrincipalScope struct {
+	Tags       []string
+	Operations []string
+}
+
+// allows checks if the operation is in the scope.
+func (s PrincipalScope) allows(id string, tags []string) bool {
+	for _, op := range s.Operations {
+		if op == id {
+			return true
+		}
+	}
+	for _, t := range s.Tags {
+		for _, tag := range tags {
+			if t == tag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// PrincipalScopeFunc returns the scope of the principal, e.g. the tags
+// included in the API product the client key is issued for.
+type PrincipalScopeFunc func(p Principal) PrincipalScope
+
+// ScopeEnforcer returns a middleware that rejects requests of principals to
+// operations out of their scopes. In case of rejection, this middleware
+// responds with 403 by default, naming the denied operation. This allows to
+// issue API keys for subsets of the API:
+//
+//  products := map[string]oas.PrincipalScope{
+//      "store-readonly": {Tags: []string{"store"}, Operations: []string{"getPetById"}},
+//  }
+//  scope := basis.ScopeEnforcer(func(p oas.Principal) oas.PrincipalScope {
+//      return products[keys[p.ID].Product]
+//  })
+//
+// The principal is taken from the request context, so the middleware must
+// come after the security middleware that calls WithPrincipal. Requests
+// without principal are passed, as the security middleware is responsible
+// for rejecting unauthenticated requests.
+//
+// It panics if fn is nil.
+func (b *ResolvingBasis) ScopeEnforcer(fn PrincipalScopeFunc, opts ...MiddlewareOption) Middleware {
+	if fn == nil {
+		panic("oas: ScopeEnforcer scope func is nil")
+	}
+
+	options := b.middlewareOptions(opts)
+	if options.problemHandler == nil {
+		options.problemHandler = newProblemHandlerStatusResponder(http.StatusForbidden)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return &scopeEnforcer{
+			next:              next,
+			scope:             fn,
+			strict:            b.strict,
+			problemHandler:    options.problemHandler,
+			continueOnProblem: options.continueOnProblem,
+		}
+	}
+}
+
+// scopeEnforcer is a middleware that resolves operation context from the
+// request and rejects requests out of principal scopes.
+type scopeEnforcer struct {
+	next  http.Handler
+	scope PrincipalScopeFunc
+
+	// strict enforces scopes. If false, then requests without operation
+	// context are passed.
+	strict bool
+
+	problemHandler    ProblemHandler
+	continueOnProblem bool
+}
+
+func (mw *scopeEnforcer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	oi, ok := getOperationInfo(req)
+	if !ok {
+		if mw.strict {
+			panic("scope enforcer middleware: cannot find operation info in the request context")
+		}
+		mw.next.ServeHTTP(w, req)
+		return
+	}
+
+	p, ok := GetPrincipal(req)
+	if !ok {
+		mw.next.ServeHTTP(w, req)
+		return
+	}
+
+	if !mw.scope(p).allows(oi.operation.ID, oi.operation.Tags) {
+		e := fmt.Errorf("operation %s is not allowed", oi.operation.ID)
+		mw.problemHandler.HandleProblem(NewProblem(w, req, e))
+		if !mw.continueOnProblem {
+			return
+		}
+	}
+
+	mw.next.ServeHTTP(w, req)
+}
@@ -0,0 +1,114 @@
+package oas
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScopeEnforcer(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+
+	scopes := map[string]PrincipalScope{
+		"alice": {Tags: []string{"pet"}},
+		"bob":   {Operations: []string{"loginUser"}},
+	}
+
+	basis := NewResolvingBasis(SpecAdapterName, doc, BasisStrict(false))
+	principal := PrincipalContext(func(req *http.Request) (Principal, bool) {
+		id := req.Header.Get("X-API-Key")
+		return Principal{ID: id}, id != ""
+	})
+	h := SpecMatcherMiddleware(doc)(
+		principal(
+			basis.ScopeEnforcer(func(p Principal) PrincipalScope { return scopes[p.ID] })(
+				http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}),
+			),
+		),
+	)
+
+	testCases := map[string]struct {
+		key          string
+		path         string
+		expectedCode int
+		expectedBody string
+	}{
+		"allowed by tag": {
+			key:          "alice",
+			path:         "/v2/pet/12",
+			expectedCode: http.StatusOK,
+		},
+		"denied by tag": {
+			key:          "alice",
+			path:         "/v2/user/login?username=johndoe&password=123",
+			expectedCode: http.StatusForbidden,
+			expectedBody: "operation loginUser is not allowed",
+		},
+		"allowed by operation": {
+			key:          "bob",
+			path:         "/v2/user/login?username=johndoe&password=123",
+			expectedCode: http.StatusOK,
+		},
+		"denied by operation": {
+			key:          "bob",
+			path:         "/v2/pet/12",
+			expectedCode: http.StatusForbidden,
+			expectedBody: "operation getPetById is not allowed",
+		},
+		"unknown principal": {
+			key:          "eve",
+			path:         "/v2/pet/12",
+			expectedCode: http.StatusForbidden,
+			expectedBody: "operation getPetById is not allowed",
+		},
+		"no principal": {
+			path:         "/v2/pet/12",
+			expectedCode: http.StatusOK,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			if tc.key != "" {
+				req.Header.Set("X-API-Key", tc.key)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedCode, w.Code)
+			assert.Equal(t, tc.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestScopeEnforcer_problemHandler(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+
+	var problems []Problem
+	var nextCalled bool
+	basis := NewResolvingBasis(SpecAdapterName, doc, BasisStrict(false))
+	principal := PrincipalContext(func(req *http.Request) (Principal, bool) {
+		return Principal{ID: "bob"}, true
+	})
+	h := SpecMatcherMiddleware(doc)(
+		principal(
+			basis.ScopeEnforcer(
+				func(p Principal) PrincipalScope { return PrincipalScope{} },
+				WithProblemHandlerFunc(func(p Problem) { problems = append(problems, p) }),
+				WithContinueOnProblem(true),
+			)(
+				http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) { nextCalled = true }),
+			),
+		),
+	)
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v2/pet/12", nil))
+
+	assert.True(t, nextCalled)
+	if assert.Len(t, problems, 1) {
+		assert.Equal(t, "operation getPetById is not allowed", problems[0].Cause().Error())
+	}
+}
@@ -0,0 +1,109 @@
+package oas
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+)
+
+type contextKeySelfCheck struct{}
+
+// SelfCheckHandler marks the operation handler as opted in to SelfCheck.
+// Only handlers that have no side effects on synthetic requests should be
+// marked, or they should check IsSelfCheckRequest. The returned handler
+// serves requests with h as is.
+func SelfCheckHandler(h http.Handler) http.Handler {
+	return selfCheckHandler{Handler: h}
+}
+
+// selfCheckHandler is an operation handler opted in to SelfCheck.
+type selfCheckHandler struct {
+	http.Handler
+}
+
+// IsSelfCheckRequest checks if the request is a synthetic request made by
+// SelfCheck, so the handler can skip side effects, e.g. writes to storage.
+func IsSelfCheckRequest(req *http.Request) bool {
+	return req.Context().Value(contextKeySelfCheck{}) != nil
+}
+
+// SelfCheck exercises every operation handler marked with SelfCheckHandler
+// with a synthetic valid request and verifies that the handler responds
+// with a status code declared for the operation. This catches wiring
+// mistakes, e.g. a handler registered for the wrong operation, before
+// the traffic arrives. Call it at startup with the same handlers passed to
+// the operation router:
+//
+//  handlers := map[string]http.Handler{
+//      "getPetById": oas.SelfCheckHandler(http.HandlerFunc(getPetByID)),
+//      "addPet":     http.HandlerFunc(addPet), // not checked
+//  }
+//  if err := oas.SelfCheck(doc, handlers); err != nil {
+//      log.Fatal(err)
+//  }
+//
+// Requests are built from spec examples, defaults and enums, like the
+// valid cases of GenerateOperationTests, and carry the operation context
+// and path parameters. Responses are recorded and discarded.
+//
+// Handlers for operations missing from the spec are reported as errors
+// regardless of the mark. The errors are returned as MultiError.
+func SelfCheck(doc *Document, handlers map[string]http.Handler) error {
+	ids := make([]string, 0, len(handlers))
+	for id := range handlers {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	mw := SpecMatcherMiddleware(doc)
+
+	var errs []error
+	for _, id := range ids {
+		method, path, op, ok := doc.Analyzer.OperationForName(id)
+		if !ok {
+			errs = append(errs, fmt.Errorf("operation %s: handler is registered, but the operation is not found in the spec", id))
+			continue
+		}
+
+		h, ok := handlers[id].(selfCheckHandler)
+		if !ok {
+			continue
+		}
+
+		oi := newOperationInfo(doc, path, op)
+		g := opTestGenerator{method: strings.ToUpper(method), oi: oi, consumes: oi.consumes}
+		tc := g.generate()[0]
+
+		req := tc.NewRequest()
+		req = req.WithContext(context.WithValue(req.Context(), contextKeySelfCheck{}, true))
+
+		w := httptest.NewRecorder()
+		if err := serveSelfCheck(mw(h), w, req); err != nil {
+			errs = append(errs, fmt.Errorf("operation %s: %s", id, err))
+			continue
+		}
+		if !documentedStatus(oi, w.Code) {
+			errs = append(errs, fmt.Errorf("operation %s: handler responded with status %d, which is not declared for the operation", id, w.Code))
+		}
+	}
+
+	if len(errs) > 0 {
+		return newMultiError("self check failed", errs...)
+	}
+	return nil
+}
+
+// serveSelfCheck serves the request, recovering from panics in the handler.
+func serveSelfCheck(h http.Handler, w http.ResponseWriter, req *http.Request) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panicked: %v", r)
+		}
+	}()
+
+	h.ServeHTTP(w, req)
+	return nil
+}
@@ -0,0 +1,49 @@
+package oas
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelfCheck(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+
+	t.Run("declared statuses", func(t *testing.T) {
+		var selfCheck, hasPetID bool
+		handlers := map[string]http.Handler{
+			"getPetById": SelfCheckHandler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				selfCheck = IsSelfCheckRequest(req)
+				_, hasPetID = GetPathParam(req, "petId").(int64)
+				handleGetPetByID(w, req)
+			})),
+			"addPet": http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				t.Error("handler is not opted in, but it is called")
+			}),
+		}
+
+		err := SelfCheck(doc, handlers)
+		assert.NoError(t, err)
+		assert.True(t, selfCheck)
+		assert.True(t, hasPetID)
+	})
+
+	t.Run("undeclared status", func(t *testing.T) {
+		handlers := map[string]http.Handler{
+			"loginUser": SelfCheckHandler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.WriteHeader(http.StatusCreated)
+			})),
+			"getPetById": SelfCheckHandler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				panic("not wired")
+			})),
+			"deletePet": http.HandlerFunc(handleGetPetByID),
+		}
+
+		err := SelfCheck(doc, handlers)
+		assert.EqualError(t, err, "self check failed: "+
+			"operation deletePet: handler is registered, but the operation is not found in the spec, "+
+			"operation getPetById: handler panicked: not wired, "+
+			"operation loginUser: handler responded with status 201, which is not declared for the operation")
+	})
+}
@@ -0,0 +1,384 @@
+package oas
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// SessionExtension is the security scheme extension that marks the scheme
+// as cookie session authentication, satisfied by SessionAuthenticator.
+// As Swagger 2.0 has no cookie schemes, the scheme is declared as an API
+// key, which is ignored:
+//
+//  securityDefinitions:
+//    session:
+//      type: apiKey
+//      in: header
+//      name: X-Session
+//      x-oas-session: true
+//      x-oas-csrf:
+//        cookie: session_id
+//        mode: synchronizer
+//
+// Combine it with CSRFExtension to protect state-changing operations, using
+// Sessions.CSRFToken as the tokens function of CSRFProtector.
+const SessionExtension = "x-oas-session"
+
+// Session is an authenticated session of a principal.
+type Session struct {
+	ID        string    `json:"id"`
+	Principal Principal `json:"principal"`
+
+	// CSRFToken is the synchronizer CSRF token of the session.
+	CSRFToken string `json:"csrfToken"`
+
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// SessionStore stores sessions. Sessions may be shared between service
+// instances, e.g. by a store backed by Redis with expiring keys.
+type SessionStore interface {
+	// Save creates or updates the session.
+	Save(s Session) error
+
+	// Load returns the session by id. It returns false if there is no such
+	// session or it is expired.
+	Load(id string) (Session, bool, error)
+
+	// Delete deletes the session by id. Deleting a missing session is not
+	// an error.
+	Delete(id string) error
+}
+
+// MemorySessionStore is an in-memory SessionStore for a single service
+// instance. It is safe for concurrent use.
+type MemorySessionStore struct {
+	mx       sync.Mutex
+	sessions map[string]Session
+	clock    Clock
+}
+
+// NewMemorySessionStore returns a new MemorySessionStore.
+func NewMemorySessionStore(opts ...StoreOption) *MemorySessionStore {
+	return &MemorySessionStore{
+		sessions: make(map[string]Session),
+		clock:    parseStoreOptions(opts).clock,
+	}
+}
+
+// Save implements SessionStore.
+func (s *MemorySessionStore) Save(session Session) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	s.sessions[session.ID] = session
+	return nil
+}
+
+// Load implements SessionStore. Expired sessions are removed on load.
+func (s *MemorySessionStore) Load(id string) (Session, bool, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	session, ok := s.sessions[id]
+	if ok && !session.ExpiresAt.After(s.clock.Now()) {
+		delete(s.sessions, id)
+		return Session{}, false, nil
+	}
+	return session, ok, nil
+}
+
+// Delete implements SessionStore.
+func (s *MemorySessionStore) Delete(id string) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	delete(s.sessions, id)
+	return nil
+}
+
+// SessionOption is an option for Sessions.
+type SessionOption func(*Sessions)
+
+// SessionCookieName returns a session option that sets the name of the
+// session cookie, "session_id" by default.
+func SessionCookieName(name string) SessionOption {
+	return func(s *Sessions) {
+		s.cookieName = name
+	}
+}
+
+// SessionCookiePath returns a session option that sets the path of the
+// session cookie, "/" by default.
+func SessionCookiePath(path string) SessionOption {
+	return func(s *Sessions) {
+		s.cookiePath = path
+	}
+}
+
+// SessionCookieDomain returns a session option that sets the domain of the
+// session cookie. By default, the cookie is a host-only cookie.
+func SessionCookieDomain(domain string) SessionOption {
+	return func(s *Sessions) {
+		s.cookieDomain = domain
+	}
+}
+
+// SessionInsecureCookie returns a session option that allows the session
+// cookie to be sent over plain HTTP, e.g. in development. By default, the
+// cookie is secure.
+func SessionInsecureCookie() SessionOption {
+	return func(s *Sessions) {
+		s.insecure = true
+	}
+}
+
+// SessionMaxAge returns a session option that sets the lifetime of
+// sessions, 24 hours by default.
+func SessionMaxAge(d time.Duration) SessionOption {
+	return func(s *Sessions) {
+		s.maxAge = d
+	}
+}
+
+// SessionClock returns a session option that sets the clock the expiration
+// of new sessions is computed by. By default, SystemClock is used.
+func SessionClock(c Clock) SessionOption {
+	return func(s *Sessions) {
+		s.clock = c
+	}
+}
+
+// Sessions manages cookie sessions of server-rendered apps, so they can
+// satisfy security requirements without tokens:
+//
+//  sessions := oas.NewSessions(oas.NewMemorySessionStore())
+//
+//  func login(w http.ResponseWriter, req *http.Request) {
+//      user, ok := checkPassword(req)
+//      if !ok {
+//          http.Error(w, "invalid credentials", http.StatusUnauthorized)
+//          return
+//      }
+//      sessions.Login(w, req, oas.Principal{ID: user.ID})
+//  }
+//
+// The session cookie is HttpOnly.
+type Sessions struct {
+	store SessionStore
+
+	cookieName   string
+	cookiePath   string
+	cookieDomain string
+	insecure     bool
+	maxAge       time.Duration
+	clock        Clock
+}
+
+// NewSessions returns a new Sessions that stores sessions in the store.
+func NewSessions(store SessionStore, opts ...SessionOption) *Sessions {
+	s := &Sessions{
+		store:      store,
+		cookieName: "session_id",
+		cookiePath: "/",
+		maxAge:     24 * time.Hour,
+		clock:      SystemClock,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Login starts a new session of the principal and sets the session cookie.
+// The previous session of the request, if any, is deleted, so session ids
+// are never reused across logins.
+func (s *Sessions) Login(w http.ResponseWriter, req *http.Request, p Principal) (Session, error) {
+	if err := s.deleteSession(req); err != nil {
+		return Session{}, fmt.Errorf("login: %s", err)
+	}
+
+	id, err := randomToken()
+	if err != nil {
+		return Session{}, fmt.Errorf("login: %s", err)
+	}
+	token, err := NewCSRFToken()
+	if err != nil {
+		return Session{}, fmt.Errorf("login: %s", err)
+	}
+
+	session := Session{
+		ID:        id,
+		Principal: p,
+		CSRFToken: token,
+		ExpiresAt: s.clock.Now().Add(s.maxAge),
+	}
+	if err := s.store.Save(session); err != nil {
+		return Session{}, fmt.Errorf("login: %s", err)
+	}
+
+	http.SetCookie(w, s.cookie(id, session.ExpiresAt))
+	return session, nil
+}
+
+// Logout deletes the session of the request and expires the session
+// cookie.
+func (s *Sessions) Logout(w http.ResponseWriter, req *http.Request) error {
+	if err := s.deleteSession(req); err != nil {
+		return fmt.Errorf("logout: %s", err)
+	}
+
+	c := s.cookie("", time.Unix(0, 0))
+	c.MaxAge = -1
+	http.SetCookie(w, c)
+	return nil
+}
+
+// Session returns the session of the request. It returns false if the
+// request has no valid session.
+func (s *Sessions) Session(req *http.Request) (Session, bool, error) {
+	c, err := req.Cookie(s.cookieName)
+	if err != nil || c.Value == "" {
+		return Session{}, false, nil
+	}
+	return s.store.Load(c.Value)
+}
+
+// CSRFToken returns the CSRF token of the session of the request. It is
+// a CSRFTokenFunc for the synchronizer mode of CSRFProtector.
+func (s *Sessions) CSRFToken(req *http.Request) (string, bool) {
+	session, ok, err := s.Session(req)
+	if err != nil || !ok {
+		return "", false
+	}
+	return session.CSRFToken, true
+}
+
+// deleteSession deletes the session of the request, if any.
+func (s *Sessions) deleteSession(req *http.Request) error {
+	c, err := req.Cookie(s.cookieName)
+	if err != nil || c.Value == "" {
+		return nil
+	}
+	return s.store.Delete(c.Value)
+}
+
+// cookie returns the session cookie.
+func (s *Sessions) cookie(value string, expires time.Time) *http.Cookie {
+	return &http.Cookie{
+		Name:     s.cookieName,
+		Value:    value,
+		Path:     s.cookiePath,
+		Domain:   s.cookieDomain,
+		Expires:  expires,
+		Secure:   !s.insecure,
+		HttpOnly: true,
+	}
+}
+
+// SessionAuthenticator returns a middleware that authenticates requests to
+// operations whose security requirements include schemes with
+// SessionExtension by the session cookie, and adds the principal of the
+// session to the request context.
+//
+// Requests without a valid session are passed if the operation allows
+// alternative security requirements, so other security middleware can
+// authenticate them. Otherwise, this middleware responds with 401 by
+// default.
+//
+// It panics if sessions is nil or any SessionExtension in the spec is not
+// a boolean.
+func (b *ResolvingBasis) SessionAuthenticator(sessions *Sessions, opts ...MiddlewareOption) Middleware {
+	if sessions == nil {
+		panic("oas: SessionAuthenticator sessions is nil")
+	}
+
+	options := parseMiddlewareOptions(opts...)
+	if options.problemHandler == nil {
+		options.problemHandler = newProblemHandlerStatusResponder(http.StatusUnauthorized)
+	}
+
+	schemes := make(map[string]bool)
+	for name, ss := range b.doc.Spec().SecurityDefinitions {
+		v, ok := ss.Extensions[SessionExtension]
+		if !ok {
+			continue
+		}
+		enabled, ok := v.(bool)
+		if !ok {
+			panic(fmt.Sprintf("oas: security scheme %s: invalid %s: value is not a boolean", name, SessionExtension))
+		}
+		schemes[name] = enabled
+	}
+
+	// operations maps operation ids to whether alternative security
+	// requirements are allowed.
+	operations := make(map[string]bool)
+	for id, oi := range b.cache {
+		names, optional := securityUsage(b.doc, oi.operation, func(name string) bool {
+			return schemes[name]
+		})
+		if len(names) > 0 {
+			operations[id] = optional
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return &sessionAuthenticator{
+			next:           next,
+			sessions:       sessions,
+			operations:     operations,
+			problemHandler: options.problemHandler,
+			strict:         b.strict,
+		}
+	}
+}
+
+// sessionAuthenticator is a middleware that resolves operation context from
+// the request and authenticates the request by the session cookie.
+type sessionAuthenticator struct {
+	next       http.Handler
+	sessions   *Sessions
+	operations map[string]bool
+
+	problemHandler ProblemHandler
+
+	// strict enforces authentication. If false, then requests without
+	// operation context are passed.
+	strict bool
+}
+
+func (mw *sessionAuthenticator) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	oi, ok := getOperationInfo(req)
+	if !ok {
+		if mw.strict {
+			panic("session authenticator middleware: cannot find operation info in the request context")
+		}
+		mw.next.ServeHTTP(w, req)
+		return
+	}
+
+	optional, ok := mw.operations[oi.operation.ID]
+	if !ok {
+		mw.next.ServeHTTP(w, req)
+		return
+	}
+
+	session, ok, err := mw.sessions.Session(req)
+	if err != nil {
+		mw.problemHandler.HandleProblem(NewProblem(w, req, fmt.Errorf("load session: %s", err)))
+		return
+	}
+	if !ok {
+		if optional {
+			mw.next.ServeHTTP(w, req)
+			return
+		}
+		mw.problemHandler.HandleProblem(NewProblem(w, req, fmt.Errorf("session is required")))
+		return
+	}
+
+	mw.next.ServeHTTP(w, WithPrincipal(req, session.Principal))
+}
@@ -0,0 +1,108 @@
+package oas
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessions(t *testing.T) {
+	doc := loadDocBytes([]byte(`
+swagger: "2.0"
+info:
+  title: Test
+  version: 1.0.0
+securityDefinitions:
+  session:
+    type: apiKey
+    in: header
+    name: X-Session
+    x-oas-session: true
+    x-oas-csrf:
+      cookie: sid
+      mode: synchronizer
+security:
+- session: []
+paths:
+  /profile:
+    get:
+      operationId: getProfile
+      responses:
+        200:
+          description: OK
+    put:
+      operationId: updateProfile
+      responses:
+        200:
+          description: OK
+`))
+
+	sessions := NewSessions(NewMemorySessionStore(), SessionCookieName("sid"))
+
+	basis := NewResolvingBasis(SpecAdapterName, doc, BasisStrict(false))
+	h := SpecMatcherMiddleware(doc)(
+		basis.SessionAuthenticator(sessions)(
+			basis.CSRFProtector(sessions.CSRFToken)(
+				http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+					p, _ := GetPrincipal(req)
+					w.Write([]byte(p.ID)) // nolint
+				}),
+			),
+		),
+	)
+
+	serve := func(method string, cookies []*http.Cookie, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/profile", nil)
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		if token != "" {
+			req.Header.Set("X-CSRF-Token", token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve(http.MethodGet, nil, "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, "session is required", strings.TrimSpace(w.Body.String()))
+
+	w = httptest.NewRecorder()
+	session, err := sessions.Login(w, httptest.NewRequest(http.MethodPost, "/login", nil), Principal{ID: "alice"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	cookies := w.Result().Cookies()
+	if assert.Len(t, cookies, 1) {
+		assert.Equal(t, "sid", cookies[0].Name)
+		assert.Equal(t, session.ID, cookies[0].Value)
+		assert.True(t, cookies[0].HttpOnly)
+		assert.True(t, cookies[0].Secure)
+	}
+
+	w = serve(http.MethodGet, cookies, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "alice", w.Body.String())
+
+	w = serve(http.MethodPut, cookies, "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = serve(http.MethodPut, cookies, session.CSRFToken)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	req := httptest.NewRequest(http.MethodPost, "/logout", nil)
+	req.AddCookie(cookies[0])
+	w = httptest.NewRecorder()
+	assert.NoError(t, sessions.Logout(w, req))
+	if expired := w.Result().Cookies(); assert.Len(t, expired, 1) {
+		assert.Equal(t, "", expired[0].Value)
+		assert.True(t, expired[0].MaxAge < 0)
+	}
+
+	w = serve(http.MethodGet, cookies, "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
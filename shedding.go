@@ -0,0 +1,176 @@
+package oas
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// PriorityExtension is the operation extension that sets the priority of
+// the operation for LoadShedder, either "low" or "normal":
+//
+//  paths:
+//    /recommendations:
+//      get:
+//        operationId: getRecommendations
+//        x-oas-priority: low
+//
+// Operations without the extension are of normal priority and are never
+// shed.
+const PriorityExtension = "x-oas-priority"
+
+// Priorities of operations.
+const (
+	PriorityLow    = "low"
+	PriorityNormal = "normal"
+)
+
+// ValidationLoad is the depth of the request body validation queue, i.e.
+// the number of request bodies being read and validated at the moment.
+// Pass it to RequestBodyValidator with WithValidationLoad to track the
+// depth, and to LoadShedder to shed requests when validation is saturated.
+type ValidationLoad struct {
+	// depth is accessed atomically, so it goes first to be 64-bit aligned.
+	depth    int64
+	capacity int64
+}
+
+// NewValidationLoad returns a new validation load that is saturated when
+// the depth reaches the capacity. It panics if the capacity is not
+// positive.
+func NewValidationLoad(capacity int) *ValidationLoad {
+	if capacity < 1 {
+		panic(fmt.Sprintf("oas: NewValidationLoad capacity %d is not positive", capacity))
+	}
+	return &ValidationLoad{capacity: int64(capacity)}
+}
+
+// Depth returns the number of request bodies being validated.
+func (l *ValidationLoad) Depth() int {
+	return int(atomic.LoadInt64(&l.depth))
+}
+
+// Saturated checks if the depth reached the capacity.
+func (l *ValidationLoad) Saturated() bool {
+	return atomic.LoadInt64(&l.depth) >= l.capacity
+}
+
+// enter marks the start of a validation. It is a no-op for nil load.
+func (l *ValidationLoad) enter() {
+	if l != nil {
+		atomic.AddInt64(&l.depth, 1)
+	}
+}
+
+// leave marks the end of a validation. It is a no-op for nil load.
+func (l *ValidationLoad) leave() {
+	if l != nil {
+		atomic.AddInt64(&l.depth, -1)
+	}
+}
+
+// WithValidationLoad returns a middleware option that makes the request
+// body validator track the depth of its queue in the load.
+func WithValidationLoad(l *ValidationLoad) MiddlewareOption {
+	return func(opts *MiddlewareOptions) {
+		opts.validationLoad = l
+	}
+}
+
+// defaultShedRetryAfter is the Retry-After value of requests shed by
+// LoadShedder.
+const defaultShedRetryAfter = time.Second
+
+// LoadShedder returns a middleware that sheds requests to operations of low
+// priority, set by PriorityExtension, while the validation load is
+// saturated, so the capacity is left for more important operations. Mount
+// it before the request body validator that tracks the load:
+//
+//  load := oas.NewValidationLoad(64)
+//  shed := basis.LoadShedder(load)
+//  validate := basis.RequestBodyValidator(oas.WithValidationLoad(load))
+//
+// Every shed request is published as RequestShed event. In case of
+// shedding, this middleware sets the Retry-After header to one second and
+// responds with 503 by default. It panics if any PriorityExtension in the
+// spec is invalid.
+func (b *ResolvingBasis) LoadShedder(load *ValidationLoad, opts ...MiddlewareOption) Middleware {
+	if load == nil {
+		panic("oas: LoadShedder load is nil")
+	}
+
+	options := parseMiddlewareOptions(opts...)
+	if options.problemHandler == nil {
+		options.problemHandler = newProblemHandlerStatusResponder(http.StatusServiceUnavailable)
+	}
+
+	low := make(map[string]bool)
+	for id, oi := range b.cache {
+		ext, ok := oi.operation.Extensions[PriorityExtension]
+		if !ok {
+			continue
+		}
+		switch p, _ := ext.(string); p {
+		case PriorityLow:
+			low[id] = true
+		case PriorityNormal:
+		default:
+			panic(fmt.Sprintf("oas: operation %s: invalid %s: value must be %q or %q", id, PriorityExtension, PriorityLow, PriorityNormal))
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return &loadShedder{
+			next:           next,
+			load:           load,
+			low:            low,
+			problemHandler: options.problemHandler,
+			strict:         b.strict,
+		}
+	}
+}
+
+// loadShedder is a middleware that resolves operation context from the
+// request and sheds low priority requests.
+type loadShedder struct {
+	next http.Handler
+	load *ValidationLoad
+
+	// low is the set of ids of low priority operations.
+	low map[string]bool
+
+	problemHandler ProblemHandler
+
+	// strict enforces shedding. If false, then requests without operation
+	// context are passed.
+	strict bool
+}
+
+func (mw *loadShedder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	oi, ok := getOperationInfo(req)
+	if !ok {
+		if mw.strict {
+			panic("load shedder middleware: cannot find operation info in the request context")
+		}
+		mw.next.ServeHTTP(w, req)
+		return
+	}
+
+	if !mw.low[oi.operation.ID] || !mw.load.Saturated() {
+		mw.next.ServeHTTP(w, req)
+		return
+	}
+
+	if hasSubscribers() {
+		publish(RequestShed{
+			OperationID: oi.operation.ID,
+			Priority:    PriorityLow,
+			Depth:       mw.load.Depth(),
+		})
+	}
+
+	w.Header().Set("Retry-After", strconv.FormatInt(ceilSeconds(defaultShedRetryAfter), 10))
+	mw.problemHandler.HandleProblem(NewProblem(w, req, fmt.Errorf("operation %s is shed due to high load", oi.operation.ID)))
+}
@@ -0,0 +1,122 @@
+package oas
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolvingBasis_LoadShedder(t *testing.T) {
+	doc := loadDocBytes([]byte(`
+swagger: "2.0"
+info:
+  title: Test
+  version: 1.0.0
+paths:
+  /recommendations:
+    post:
+      operationId: getRecommendations
+      x-oas-priority: low
+      parameters:
+      - name: body
+        in: body
+        schema:
+          type: object
+      responses:
+        200:
+          description: OK
+  /orders:
+    post:
+      operationId: createOrder
+      responses:
+        200:
+          description: OK
+`))
+
+	var events []Event
+	unsubscribe := Subscribe(SubscriberFunc(func(e Event) {
+		if _, ok := e.(RequestShed); ok {
+			events = append(events, e)
+		}
+	}))
+	defer unsubscribe()
+
+	load := NewValidationLoad(1)
+	basis := NewResolvingBasis(SpecAdapterName, doc, BasisStrict(false))
+	h := SpecMatcherMiddleware(doc)(
+		basis.LoadShedder(load)(
+			basis.RequestBodyValidator(WithValidationLoad(load))(
+				http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}),
+			),
+		),
+	)
+
+	serve := func(path string, body io.Reader) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, body)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("not saturated", func(t *testing.T) {
+		w := serve("/recommendations", strings.NewReader(`{}`))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, 0, load.Depth())
+	})
+
+	pr, pw := io.Pipe()
+	done := make(chan *httptest.ResponseRecorder, 1)
+	go func() {
+		done <- serve("/recommendations", pr)
+	}()
+	for i := 0; i < 100 && !load.Saturated(); i++ {
+		time.Sleep(time.Millisecond * 10)
+	}
+	assert.Equal(t, 1, load.Depth())
+
+	t.Run("low priority is shed", func(t *testing.T) {
+		w := serve("/recommendations", strings.NewReader(`{}`))
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Equal(t, "1", w.Header().Get("Retry-After"))
+		assert.Equal(t, "operation getRecommendations is shed due to high load", strings.TrimSpace(w.Body.String()))
+		assert.Equal(t, []Event{RequestShed{OperationID: "getRecommendations", Priority: PriorityLow, Depth: 1}}, events)
+	})
+
+	t.Run("normal priority is served", func(t *testing.T) {
+		w := serve("/orders", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	pw.Write([]byte(`{}`)) // nolint: errcheck
+	pw.Close()             // nolint: errcheck
+	assert.Equal(t, http.StatusOK, (<-done).Code)
+	assert.Equal(t, 0, load.Depth())
+}
+
+func TestResolvingBasis_LoadShedder_invalidPriority(t *testing.T) {
+	doc := loadDocBytes([]byte(`
+swagger: "2.0"
+info:
+  title: Test
+  version: 1.0.0
+paths:
+  /recommendations:
+    get:
+      operationId: getRecommendations
+      x-oas-priority: lowest
+      responses:
+        200:
+          description: OK
+`))
+
+	basis := NewResolvingBasis(SpecAdapterName, doc)
+	assert.Panics(t, func() {
+		basis.LoadShedder(NewValidationLoad(1))
+	})
+}
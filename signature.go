@@ -0,0 +1,385 @@
+package oas
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-openapi/spec"
+)
+
+// SignatureExtension is the security scheme extension that marks the
+// scheme as HMAC request signing, verified by SignatureVerifier. The scheme
+// is an API key in the header that carries the signature:
+//
+//  securityDefinitions:
+//    partnerSignature:
+//      type: apiKey
+//      in: header
+//      name: Signature
+//      x-oas-signature:
+//        algorithm: hmac-sha256
+//        maxSkew: 5m
+//
+// The algorithm is hmac-sha256 (default) or hmac-sha512. The max skew is
+// the maximum difference between the Date header of the request and the
+// server clock, 5 minutes by default.
+//
+// The header value is a list of parameters:
+//
+//  Signature: keyId="partner-1",algorithm="hmac-sha256",signature="<base64>"
+//
+// where the signature is computed over the request method, the request URI,
+// the Date header and the hex encoded SHA-256 hash of the body, separated by
+// newlines. See SignRequest.
+const SignatureExtension = "x-oas-signature"
+
+// Signature algorithms of SignatureExtension.
+const (
+	SignatureHMACSHA256 = "hmac-sha256"
+	SignatureHMACSHA512 = "hmac-sha512"
+)
+
+// defaultSignatureMaxSkew is the default max clock skew of signed requests.
+const defaultSignatureMaxSkew = 5 * time.Minute
+
+// SignatureKeyFunc returns the secret key by the key id of a signed
+// request. It returns false if there is no such key.
+type SignatureKeyFunc func(keyID string) (key []byte, ok bool)
+
+// signatureScheme is a security scheme with SignatureExtension.
+type signatureScheme struct {
+	header    string
+	algorithm string
+	maxSkew   time.Duration
+}
+
+// parseSignatureScheme parses the security scheme with SignatureExtension.
+func parseSignatureScheme(ss *spec.SecurityScheme) (signatureScheme, error) {
+	m, ok := ss.Extensions[SignatureExtension].(map[string]interface{})
+	if !ok {
+		return signatureScheme{}, fmt.Errorf("invalid %s: value is not an object", SignatureExtension)
+	}
+	if ss.Type != "apiKey" || ss.In != "header" || ss.Name == "" {
+		return signatureScheme{}, fmt.Errorf("scheme must be an apiKey in header")
+	}
+
+	s := signatureScheme{
+		header:    ss.Name,
+		algorithm: SignatureHMACSHA256,
+		maxSkew:   defaultSignatureMaxSkew,
+	}
+
+	if v, ok := m["algorithm"]; ok {
+		alg, _ := v.(string)
+		if signatureHash(alg) == nil {
+			return signatureScheme{}, fmt.Errorf("invalid %s: unsupported algorithm %v", SignatureExtension, v)
+		}
+		s.algorithm = alg
+	}
+
+	if v, ok := m["maxSkew"]; ok {
+		str, _ := v.(string)
+		d, err := time.ParseDuration(str)
+		if err != nil || d <= 0 {
+			return signatureScheme{}, fmt.Errorf("invalid %s: maxSkew %v is not a positive duration", SignatureExtension, v)
+		}
+		s.maxSkew = d
+	}
+
+	return s, nil
+}
+
+// signatureHash returns the hash function of the algorithm, or nil if the
+// algorithm is not supported.
+func signatureHash(algorithm string) func() hash.Hash {
+	switch algorithm {
+	case SignatureHMACSHA256:
+		return sha256.New
+	case SignatureHMACSHA512:
+		return sha512.New
+	default:
+		return nil
+	}
+}
+
+// signingString returns the string the signature of the request is
+// computed over.
+func signingString(req *http.Request, body []byte) string {
+	sum := sha256.Sum256(body)
+	return strings.Join([]string{
+		req.Method,
+		req.URL.RequestURI(),
+		req.Header.Get("Date"),
+		hex.EncodeToString(sum[:]),
+	}, "\n")
+}
+
+// computeSignature computes the signature of the request.
+func computeSignature(algorithm string, key []byte, req *http.Request, body []byte) []byte {
+	mac := hmac.New(signatureHash(algorithm), key)
+	mac.Write([]byte(signingString(req, body))) // nolint: errcheck
+	return mac.Sum(nil)
+}
+
+// SignRequest signs the request with the key by the algorithm, setting the
+// signature to the header, e.g. on the client side or in tests. The Date
+// header is set to the current time, unless it is already set.
+func SignRequest(req *http.Request, header, algorithm, keyID string, key []byte) error {
+	if signatureHash(algorithm) == nil {
+		return fmt.Errorf("sign request: unsupported algorithm %s", algorithm)
+	}
+
+	body, err := readRequestBody(req)
+	if err != nil {
+		return fmt.Errorf("sign request: %s", err)
+	}
+
+	if req.Header.Get("Date") == "" {
+		req.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+	}
+
+	sig := computeSignature(algorithm, key, req, body)
+	req.Header.Set(header, fmt.Sprintf(
+		"keyId=%q,algorithm=%q,signature=%q",
+		keyID, algorithm, base64.StdEncoding.EncodeToString(sig),
+	))
+	return nil
+}
+
+// readRequestBody reads the request body and restores it, so it can be
+// read again.
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	req.Body.Close() // nolint: errcheck
+	if err != nil {
+		return nil, err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// parseSignatureParams parses the parameters of the signature header value.
+func parseSignatureParams(value string) map[string]string {
+	params := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		params[kv[0]] = strings.Trim(kv[1], `"`)
+	}
+	return params
+}
+
+// verify verifies the signature of the request, and returns the key id.
+func (s signatureScheme) verify(req *http.Request, keys SignatureKeyFunc, now time.Time) (string, error) {
+	params := parseSignatureParams(req.Header.Get(s.header))
+	keyID := params["keyId"]
+	if keyID == "" || params["signature"] == "" {
+		return "", fmt.Errorf("header %s must contain keyId and signature", s.header)
+	}
+	if alg, ok := params["algorithm"]; ok && alg != s.algorithm {
+		return "", fmt.Errorf("signature algorithm %s is not allowed", alg)
+	}
+
+	date, err := http.ParseTime(req.Header.Get("Date"))
+	if err != nil {
+		return "", fmt.Errorf("header Date is missing or invalid")
+	}
+	if skew := now.Sub(date); skew > s.maxSkew || -skew > s.maxSkew {
+		return "", fmt.Errorf("request date is out of the allowed clock skew of %s", s.maxSkew)
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(params["signature"])
+	if err != nil {
+		return "", fmt.Errorf("signature is not valid base64")
+	}
+
+	key, ok := keys(keyID)
+	if !ok {
+		return "", fmt.Errorf("unknown signature key %s", keyID)
+	}
+
+	body, err := readRequestBody(req)
+	if err != nil {
+		return "", fmt.Errorf("read body: %s", err)
+	}
+
+	if !hmac.Equal(sig, computeSignature(s.algorithm, key, req, body)) {
+		return "", fmt.Errorf("signature does not match")
+	}
+	return keyID, nil
+}
+
+// operationSecurity returns the security requirements of the operation:
+// its own, or the global ones if the operation does not declare any.
+func operationSecurity(doc *Document, op *spec.Operation) []map[string][]string {
+	if op.Security != nil {
+		return op.Security
+	}
+	return doc.Spec().Security
+}
+
+// securityUsage returns the names of the schemes matched by the match
+// function among the security requirements of the operation, and whether
+// the operation can be satisfied by a requirement without such schemes.
+func securityUsage(doc *Document, op *spec.Operation, match func(name string) bool) (names []string, optional bool) {
+	for _, requirement := range operationSecurity(doc, op) {
+		matched := false
+		for name := range requirement {
+			if match(name) {
+				names = append(names, name)
+				matched = true
+			}
+		}
+		if !matched {
+			optional = true
+		}
+	}
+	sort.Strings(names)
+	return names, optional
+}
+
+// signatureRequirement is the signature requirement of an operation.
+type signatureRequirement struct {
+	schemes []signatureScheme
+
+	// optional is true if the operation can be satisfied by a security
+	// requirement without signature schemes.
+	optional bool
+}
+
+// SignatureVerifier returns a middleware that verifies HMAC signatures of
+// requests to operations whose security requirements include schemes with
+// SignatureExtension. Keys are looked up by the keys function. Once the
+// signature is verified, the key id becomes the request principal, unless
+// there is a principal already.
+//
+// Requests without the signature header are passed if the operation allows
+// alternative security requirements, so other security middleware can
+// authenticate them. Otherwise, this middleware responds with 401 by
+// default.
+//
+// It panics if the keys function is nil or any signature scheme in the spec
+// is invalid.
+func (b *ResolvingBasis) SignatureVerifier(keys SignatureKeyFunc, opts ...MiddlewareOption) Middleware {
+	if keys == nil {
+		panic("oas: SignatureVerifier keys function is nil")
+	}
+
+	options := parseMiddlewareOptions(opts...)
+	if options.problemHandler == nil {
+		options.problemHandler = newProblemHandlerStatusResponder(http.StatusUnauthorized)
+	}
+
+	schemes := make(map[string]signatureScheme)
+	for name, ss := range b.doc.Spec().SecurityDefinitions {
+		if _, ok := ss.Extensions[SignatureExtension]; !ok {
+			continue
+		}
+		s, err := parseSignatureScheme(ss)
+		if err != nil {
+			panic(fmt.Sprintf("oas: security scheme %s: %s", name, err))
+		}
+		schemes[name] = s
+	}
+
+	operations := make(map[string]signatureRequirement)
+	for id, oi := range b.cache {
+		names, optional := securityUsage(b.doc, oi.operation, func(name string) bool {
+			_, ok := schemes[name]
+			return ok
+		})
+		if len(names) == 0 {
+			continue
+		}
+		r := signatureRequirement{optional: optional}
+		for _, name := range names {
+			r.schemes = append(r.schemes, schemes[name])
+		}
+		operations[id] = r
+	}
+
+	return func(next http.Handler) http.Handler {
+		return &signatureVerifier{
+			next:           next,
+			keys:           keys,
+			operations:     operations,
+			problemHandler: options.problemHandler,
+			clock:          options.clock,
+			strict:         b.strict,
+		}
+	}
+}
+
+// signatureVerifier is a middleware that resolves operation context from
+// the request and verifies request signatures.
+type signatureVerifier struct {
+	next       http.Handler
+	keys       SignatureKeyFunc
+	operations map[string]signatureRequirement
+
+	problemHandler ProblemHandler
+	clock          Clock
+
+	// strict enforces verification. If false, then requests without
+	// operation context are passed.
+	strict bool
+}
+
+func (mw *signatureVerifier) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	oi, ok := getOperationInfo(req)
+	if !ok {
+		if mw.strict {
+			panic("signature verifier middleware: cannot find operation info in the request context")
+		}
+		mw.next.ServeHTTP(w, req)
+		return
+	}
+
+	r, ok := mw.operations[oi.operation.ID]
+	if !ok {
+		mw.next.ServeHTTP(w, req)
+		return
+	}
+
+	var err error
+	for _, s := range r.schemes {
+		if req.Header.Get(s.header) == "" {
+			continue
+		}
+		var keyID string
+		keyID, err = s.verify(req, mw.keys, mw.clock.Now())
+		if err != nil {
+			continue
+		}
+		if _, ok := GetPrincipal(req); !ok {
+			req = WithPrincipal(req, Principal{ID: keyID})
+		}
+		mw.next.ServeHTTP(w, req)
+		return
+	}
+
+	if err == nil {
+		if r.optional {
+			mw.next.ServeHTTP(w, req)
+			return
+		}
+		err = fmt.Errorf("request is not signed")
+	}
+	mw.problemHandler.HandleProblem(NewProblem(w, req, fmt.Errorf("invalid signature: %s", err)))
+}
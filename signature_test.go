@@ -0,0 +1,141 @@
+package oas
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignatureVerifier(t *testing.T) {
+	doc := loadDocBytes([]byte(`
+swagger: "2.0"
+info:
+  title: Test
+  version: 1.0.0
+securityDefinitions:
+  partnerSignature:
+    type: apiKey
+    in: header
+    name: Signature
+    x-oas-signature:
+      maxSkew: 1m
+  apiKey:
+    type: apiKey
+    in: header
+    name: X-API-Key
+paths:
+  /orders:
+    post:
+      operationId: createOrder
+      security:
+      - partnerSignature: []
+      responses:
+        200:
+          description: OK
+  /orders/{id}:
+    get:
+      operationId: getOrder
+      security:
+      - partnerSignature: []
+      - apiKey: []
+      parameters:
+      - name: id
+        in: path
+        type: string
+        required: true
+      responses:
+        200:
+          description: OK
+`))
+
+	keys := func(keyID string) ([]byte, bool) {
+		if keyID == "partner-1" {
+			return []byte("secret"), true
+		}
+		return nil, false
+	}
+
+	basis := NewResolvingBasis(SpecAdapterName, doc, BasisStrict(false))
+	h := SpecMatcherMiddleware(doc)(
+		basis.SignatureVerifier(keys)(
+			http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				body, _ := ioutil.ReadAll(req.Body)
+				p, _ := GetPrincipal(req)
+				w.Write([]byte(p.ID + " " + string(body))) // nolint
+			}),
+		),
+	)
+
+	newRequest := func(method, path, body string) *http.Request {
+		return httptest.NewRequest(method, path, strings.NewReader(body))
+	}
+	sign := func(req *http.Request, keyID string, key string) *http.Request {
+		assert.NoError(t, SignRequest(req, "Signature", SignatureHMACSHA256, keyID, []byte(key)))
+		return req
+	}
+
+	testCases := map[string]struct {
+		req          *http.Request
+		expectedCode int
+		expectedBody string
+	}{
+		"signed": {
+			req:          sign(newRequest(http.MethodPost, "/orders?dry=1", `{"qty":1}`), "partner-1", "secret"),
+			expectedCode: http.StatusOK,
+			expectedBody: `partner-1 {"qty":1}`,
+		},
+		"not signed": {
+			req:          newRequest(http.MethodPost, "/orders", `{}`),
+			expectedCode: http.StatusUnauthorized,
+			expectedBody: "invalid signature: request is not signed",
+		},
+		"alternative requirement": {
+			req:          newRequest(http.MethodGet, "/orders/1", ""),
+			expectedCode: http.StatusOK,
+			expectedBody: "",
+		},
+		"wrong key": {
+			req:          sign(newRequest(http.MethodPost, "/orders", `{}`), "partner-1", "guess"),
+			expectedCode: http.StatusUnauthorized,
+			expectedBody: "invalid signature: signature does not match",
+		},
+		"unknown key": {
+			req:          sign(newRequest(http.MethodPost, "/orders", `{}`), "partner-2", "secret"),
+			expectedCode: http.StatusUnauthorized,
+			expectedBody: "invalid signature: unknown signature key partner-2",
+		},
+		"tampered body": {
+			req: func() *http.Request {
+				req := sign(newRequest(http.MethodPost, "/orders", `{"qty":1}`), "partner-1", "secret")
+				req.Body = ioutil.NopCloser(strings.NewReader(`{"qty":100}`))
+				return req
+			}(),
+			expectedCode: http.StatusUnauthorized,
+			expectedBody: "invalid signature: signature does not match",
+		},
+		"clock skew": {
+			req: func() *http.Request {
+				req := newRequest(http.MethodPost, "/orders", `{}`)
+				req.Header.Set("Date", time.Now().Add(-2*time.Minute).UTC().Format(http.TimeFormat))
+				return sign(req, "partner-1", "secret")
+			}(),
+			expectedCode: http.StatusUnauthorized,
+			expectedBody: "invalid signature: request date is out of the allowed clock skew of 1m0s",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, tc.req)
+
+			assert.Equal(t, tc.expectedCode, w.Code)
+			assert.Equal(t, tc.expectedBody, strings.TrimSpace(w.Body.String()))
+		})
+	}
+}
@@ -19,10 +19,96 @@ const (
 	SpecHandlerTypeStatic
 )
 
+// SpecHandlerOption is an option for spec handlers.
+type SpecHandlerOption func(*specHandlerOptions)
+
+type specHandlerOptions struct {
+	errorResponses []int
+	jobStatusPath  string
+}
+
+// SpecWithErrorResponses returns a spec handler option that augments the
+// served spec with responses for the status codes, e.g. 400 for validation
+// errors, on every operation that declares neither the code nor the default
+// response. This way the served spec documents the error responses of oas
+// validators. By default, only 400 is added.
+func SpecWithErrorResponses(codes ...int) SpecHandlerOption {
+	return func(o *specHandlerOptions) {
+		if len(codes) == 0 {
+			codes = []int{http.StatusBadRequest}
+		}
+		o.errorResponses = codes
+	}
+}
+
+// servedSpec returns the spec to serve, applying the options.
+func servedSpec(doc *Document, opts ...SpecHandlerOption) *spec.Swagger {
+	var options specHandlerOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	if len(options.errorResponses) == 0 && options.jobStatusPath == "" {
+		return doc.Spec()
+	}
+
+	s, err := copySpec(doc.Spec())
+	if err != nil {
+		panic(fmt.Sprintf("oas: copy spec: %s", err))
+	}
+	if options.jobStatusPath != "" {
+		addJobStatusOperation(s, options.jobStatusPath)
+	}
+	addErrorResponses(s, options.errorResponses)
+	return s
+}
+
+// addErrorResponses adds responses for the status codes to the spec
+// operations that do not declare them.
+func addErrorResponses(s *spec.Swagger, codes []int) {
+	if s.Paths == nil {
+		return
+	}
+
+	for path, item := range s.Paths.Paths {
+		for _, op := range []*spec.Operation{item.Get, item.Put, item.Post, item.Delete, item.Options, item.Head, item.Patch} {
+			if op == nil {
+				continue
+			}
+			for _, code := range codes {
+				if declaresResponse(op, code) {
+					continue
+				}
+				if op.Responses == nil {
+					op.Responses = &spec.Responses{}
+				}
+				if op.Responses.StatusCodeResponses == nil {
+					op.Responses.StatusCodeResponses = make(map[int]spec.Response)
+				}
+				op.Responses.StatusCodeResponses[code] = *spec.NewResponse().WithDescription(http.StatusText(code))
+			}
+		}
+		s.Paths.Paths[path] = item
+	}
+}
+
+// declaresResponse checks if the operation declares the response for the
+// status code, either explicitly or by the default response.
+func declaresResponse(op *spec.Operation, code int) bool {
+	if op.Responses == nil {
+		return false
+	}
+	if op.Responses.Default != nil {
+		return true
+	}
+	_, ok := op.Responses.StatusCodeResponses[code]
+	return ok
+}
+
 // NewDynamicSpecHandler returns HTTP handler for OpenAPI spec that
 // changes its host and schemes dynamically based on incoming request.
-func NewDynamicSpecHandler(doc *Document) http.Handler {
-	return &dynamicSpecHandler{s: doc.Spec()}
+func NewDynamicSpecHandler(doc *Document, opts ...SpecHandlerOption) http.Handler {
+	return &dynamicSpecHandler{s: servedSpec(doc, opts...)}
 }
 
 type dynamicSpecHandler struct {
@@ -54,8 +140,8 @@ func (h *dynamicSpecHandler) ServeHTTP(w http.ResponseWriter, req *http.Request)
 }
 
 // NewStaticSpecHandler returns HTTP handler for static OpenAPI spec.
-func NewStaticSpecHandler(doc *Document) http.Handler {
-	return &staticSpecHandler{s: doc.Spec()}
+func NewStaticSpecHandler(doc *Document, opts ...SpecHandlerOption) http.Handler {
+	return &staticSpecHandler{s: servedSpec(doc, opts...)}
 }
 
 type staticSpecHandler struct {
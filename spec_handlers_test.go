@@ -68,3 +68,32 @@ func TestStaticSpecHandler(t *testing.T) {
 		t.Errorf("Expected schemes to be [https] but got %v", writtenDoc.Spec().Schemes)
 	}
 }
+
+func TestSpecWithErrorResponses(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+
+	h := NewStaticSpecHandler(doc, SpecWithErrorResponses(400, 422))
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/foo", nil)
+
+	h.ServeHTTP(rr, req)
+
+	writtenDoc := loadDocBytes(rr.Body.Bytes())
+
+	addPet := writtenDoc.Spec().Paths.Paths["/pet"].Post.Responses.StatusCodeResponses
+	if addPet[400].Description != "Bad Request" || addPet[422].Description != "Unprocessable Entity" {
+		t.Errorf("Expected error responses to be added but got %v", addPet)
+	}
+
+	getPet := writtenDoc.Spec().Paths.Paths["/pet/{petId}"].Get.Responses.StatusCodeResponses
+	if getPet[400].Description != "Invalid ID supplied" {
+		t.Errorf("Expected declared response to remain same but got %q", getPet[400].Description)
+	}
+
+	// check that original spec remains same
+
+	if _, ok := doc.Spec().Paths.Paths["/pet"].Post.Responses.StatusCodeResponses[400]; ok {
+		t.Errorf("Expected original spec responses hasn't changed")
+	}
+}
@@ -0,0 +1,569 @@
+package oas
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/go-openapi/spec"
+)
+
+// SpecAdapterName is the name of the built-in adapter that resolves
+// operations by matching requests against the spec path templates by itself.
+// It does not depend on any router, so middleware derived from a basis
+// created with this adapter can be used with any mux:
+//
+//  basis := oas.NewResolvingBasis(oas.SpecAdapterName, doc, oas.BasisStrict(false))
+//  mux := http.NewServeMux()
+//  // ...
+//  h := basis.OperationContext()(basis.QueryValidator()(mux))
+//
+// Note that this adapter cannot build routing, so OperationRouter is not
+// supported.
+const SpecAdapterName = "spec"
+
+func init() {
+	RegisterAdapter(SpecAdapterName, specAdapter{})
+}
+
+// SpecMatcherMiddleware returns a middleware that matches the request method
+// and path against the document path templates, and adds the operation
+// context and path parameters to the request. Requests that do not match any
+// operation are passed through as is.
+//
+// This allows to use oas middleware, e.g. QueryValidator, with any router.
+//
+// By default, paths are matched case-sensitively, and trailing slashes are
+// ignored. Use MatchTrailingSlash and MatchCaseInsensitive to change this.
+func SpecMatcherMiddleware(doc *Document, opts ...MatcherOption) Middleware {
+	checkRoutePrecedence(doc)
+
+	options := parseMatcherOptions(opts)
+
+	b := NewResolvingBasis(SpecAdapterName, doc, BasisStrict(false))
+	oc := b.OperationContext()
+	pp := b.PathParamsContext()
+
+	return func(next http.Handler) http.Handler {
+		h := oc(pp(next))
+		if options == (matcherOptions{}) {
+			return h
+		}
+		return &specRouting{next: h, matcher: doc.specMatcher(), options: options}
+	}
+}
+
+// RoutingPolicies returns a middleware that applies the routing policies
+// set by MatchTrailingSlash and MatchCaseInsensitive to the requests that
+// match the spec, and passes them on with the path rewritten to the form of
+// the path template. Wrap the router that routing is built on by
+// ResolvingBasis.OperationRouter with it, so that a strict router, e.g. chi
+// or gorilla/mux, routes the requests the policies accept:
+//
+//  r := chi.NewRouter()
+//  err := basis.OperationRouter(r).WithOperationHandlers(handlers).Build()
+//  // ...
+//  h := oas.RoutingPolicies(doc, oas.MatchCaseInsensitive(true))(r)
+//
+// Unlike SpecMatcherMiddleware, it does not add the operation context, since
+// the operation router does.
+func RoutingPolicies(doc *Document, opts ...MatcherOption) Middleware {
+	checkRoutePrecedence(doc)
+
+	options := parseMatcherOptions(opts)
+
+	return func(next http.Handler) http.Handler {
+		return &specRouting{next: next, matcher: doc.specMatcher(), options: options}
+	}
+}
+
+// TrailingSlashPolicy defines how SpecMatcherMiddleware and RoutingPolicies
+// handle requests with paths that differ from the path template only by
+// a trailing slash, e.g. "/pets/" for "/pets".
+type TrailingSlashPolicy int
+
+// Trailing slash policies.
+const (
+	// TrailingSlashMatch matches such requests to the operation, with the
+	// path rewritten to the form of the template for the next router. This
+	// is the default policy.
+	TrailingSlashMatch TrailingSlashPolicy = iota
+
+	// TrailingSlashMovedPermanently redirects such requests to the path of
+	// the template with 301 Moved Permanently. Clients may change the
+	// method of the redirected request to GET.
+	TrailingSlashMovedPermanently
+
+	// TrailingSlashPermanentRedirect redirects such requests to the path
+	// of the template with 308 Permanent Redirect, which preserves the
+	// method and the body.
+	TrailingSlashPermanentRedirect
+
+	// TrailingSlashStrict responds to such requests with 404 Not Found.
+	TrailingSlashStrict
+)
+
+// MatcherOption is an option for SpecMatcherMiddleware and RoutingPolicies.
+type MatcherOption func(*matcherOptions)
+
+type matcherOptions struct {
+	trailingSlash   TrailingSlashPolicy
+	caseInsensitive bool
+}
+
+func parseMatcherOptions(opts []MatcherOption) matcherOptions {
+	options := matcherOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// MatchTrailingSlash returns a matcher option that sets the policy for
+// requests with paths that differ from the path template only by a trailing
+// slash.
+func MatchTrailingSlash(policy TrailingSlashPolicy) MatcherOption {
+	return func(o *matcherOptions) {
+		o.trailingSlash = policy
+	}
+}
+
+// MatchCaseInsensitive returns a matcher option that defines if the literal
+// parts of path templates should be matched case-insensitively, e.g.
+// "/Pet/FindByStatus" to "/pet/findByStatus". The path of matched requests
+// is rewritten to the case of the template, so the next router, if it is
+// case-sensitive, routes them as well. Parameter values are not changed.
+func MatchCaseInsensitive(enabled bool) MatcherOption {
+	return func(o *matcherOptions) {
+		o.caseInsensitive = enabled
+	}
+}
+
+// specRouting is a middleware that applies the routing policies of
+// SpecMatcherMiddleware and RoutingPolicies to the requests that match the
+// spec.
+type specRouting struct {
+	next    http.Handler
+	matcher *specMatcher
+	options matcherOptions
+}
+
+func (mw *specRouting) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	path := req.URL.EscapedPath()
+	route, _, ok := mw.matcher.matchCase(req.Method, path, mw.options.caseInsensitive)
+	if !ok {
+		mw.next.ServeHTTP(w, req)
+		return
+	}
+
+	target := path
+	if mw.options.caseInsensitive {
+		target = templateCase(route.path, path)
+	}
+
+	if path != "/" && strings.HasSuffix(path, "/") != strings.HasSuffix(route.path, "/") {
+		// Put the trailing slash in the form of the template, so the next
+		// router matches the path as well.
+		if strings.HasSuffix(target, "/") {
+			target = strings.TrimSuffix(target, "/")
+		} else {
+			target += "/"
+		}
+
+		switch mw.options.trailingSlash {
+		case TrailingSlashStrict:
+			http.NotFound(w, req)
+			return
+		case TrailingSlashMovedPermanently, TrailingSlashPermanentRedirect:
+			code := http.StatusMovedPermanently
+			if mw.options.trailingSlash == TrailingSlashPermanentRedirect {
+				code = http.StatusPermanentRedirect
+			}
+			if req.URL.RawQuery != "" {
+				target += "?" + req.URL.RawQuery
+			}
+			http.Redirect(w, req, target, code)
+			return
+		}
+	}
+
+	if target != path {
+		u := *req.URL
+		u.Path, _ = url.PathUnescape(target)
+		u.RawPath = target
+
+		r := req.WithContext(req.Context())
+		r.URL = &u
+		req = r
+	}
+
+	mw.next.ServeHTTP(w, req)
+}
+
+// specAdapter implements Adapter by matching requests against the spec path
+// templates.
+type specAdapter struct{}
+
+// Resolver returns a resolver that matches requests against the spec.
+func (a specAdapter) Resolver(meta interface{}) Resolver {
+	doc, ok := meta.(*Document)
+	if !ok {
+		panic("oas: spec adapter Resolver meta is not *oas.Document")
+	}
+
+	checkRoutePrecedence(doc)
+	return &specResolver{matcher: doc.specMatcher()}
+}
+
+// OperationRouter is not supported by the spec adapter.
+func (a specAdapter) OperationRouter(meta interface{}) OperationRouter {
+	panic("oas: spec adapter does not support OperationRouter")
+}
+
+// PathParamExtractor returns a path param extractor that extracts
+// parameters by matching the request path against the path template of the
+// operation in the request context.
+func (a specAdapter) PathParamExtractor() PathParamExtractor {
+	return PathParamExtractorFunc(func(req *http.Request, key string) string {
+		oi, ok := getOperationInfo(req)
+		if !ok {
+			return ""
+		}
+
+		params, ok := MatchPath(oi.path, req.URL.EscapedPath())
+		if !ok {
+			return ""
+		}
+		return params[key]
+	})
+}
+
+// FindOperationByRequest returns the operation matching the request method
+// and path, along with the path parameter values by their names.
+func (doc *Document) FindOperationByRequest(req *http.Request) (*Operation, map[string]string, bool) {
+	route, params, ok := doc.specMatcher().match(req.Method, req.URL.EscapedPath())
+	if !ok {
+		return nil, nil, false
+	}
+
+	_, _, op, ok := doc.Analyzer.OperationForName(route.id)
+	if !ok {
+		return nil, nil, false
+	}
+	return wrapOperation(op), params, true
+}
+
+// specMatcher returns the document spec matcher, which is initialized once.
+func (doc *Document) specMatcher() *specMatcher {
+	doc.matcherOnce.Do(func() {
+		doc.matcher = newSpecMatcher(doc)
+	})
+	return doc.matcher
+}
+
+// specResolver resolves operation id by matching the request against
+// the spec.
+type specResolver struct {
+	matcher *specMatcher
+}
+
+// Resolve implements Resolver.
+func (r *specResolver) Resolve(req *http.Request) (string, bool) {
+	route, _, ok := r.matcher.match(req.Method, req.URL.EscapedPath())
+	if !ok {
+		return "", false
+	}
+	return route.id, true
+}
+
+// GreedyExtension is the path parameter extension that makes the parameter
+// greedy, i.e. match the remainder of the path with any number of segments:
+//
+//  /files/{filePath}:
+//    get:
+//      parameters:
+//      - name: filePath
+//        in: path
+//        required: true
+//        type: string
+//        x-oas-greedy: true
+//
+// A request to "/files/docs/a.txt" matches the operation with filePath
+// "docs/a.txt". This is the same as the "/files/{filePath+}" template of
+// MatchPath, which does not pass the spec validation, so the extension is
+// the way to declare greedy parameters in the spec. Greedy parameters are
+// matched by the spec adapter, see SpecAdapterName.
+const GreedyExtension = "x-oas-greedy"
+
+// specRoute is an operation route described in the spec.
+type specRoute struct {
+	method string
+	path   string
+	id     string
+
+	// literals is the count of path segments without parameters.
+	literals int
+
+	// greedy reports whether the path has a greedy parameter.
+	greedy bool
+
+	// precedence is the precedence set by RoutePrecedenceExtension.
+	precedence int
+}
+
+// specMatcher matches requests against the spec operation routes.
+type specMatcher struct {
+	routes []specRoute
+}
+
+func newSpecMatcher(doc *Document) *specMatcher {
+	var routes []specRoute
+	for method, pathOps := range doc.Analyzer.Operations() {
+		for path, op := range pathOps {
+			p := greedyTemplate(joinBasePath(doc.BasePath(), path), doc.parametersFor(path, op))
+			// Invalid precedence is reported by checkRoutePrecedence when
+			// the matcher middleware is created.
+			precedence, _ := routePrecedence(op)
+			routes = append(routes, specRoute{
+				method:     strings.ToUpper(method),
+				path:       p,
+				id:         op.ID,
+				literals:   countLiteralSegments(p),
+				greedy:     strings.Contains(p, "+}"),
+				precedence: precedence,
+			})
+		}
+	}
+
+	// Routes with higher precedence set in the spec go first. Otherwise,
+	// routes with more literal segments take precedence, so that
+	// "/pet/findByStatus" wins over "/pet/{petId}", and routes without
+	// greedy parameters take precedence over greedy ones, so that
+	// "/files/{name}" wins over "/files/{path+}". Ties are broken by path
+	// to make matching deterministic. See RouteConflicts.
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].precedence != routes[j].precedence {
+			return routes[i].precedence > routes[j].precedence
+		}
+		if routes[i].literals != routes[j].literals {
+			return routes[i].literals > routes[j].literals
+		}
+		if routes[i].greedy != routes[j].greedy {
+			return !routes[i].greedy
+		}
+		return routes[i].path < routes[j].path
+	})
+
+	return &specMatcher{routes: routes}
+}
+
+// routePrecedence returns the precedence of the operation route set by
+// RoutePrecedenceExtension.
+func routePrecedence(op *spec.Operation) (int, error) {
+	ext, ok := op.Extensions[RoutePrecedenceExtension]
+	if !ok {
+		return 0, nil
+	}
+	f, ok := ext.(float64)
+	if !ok || f != float64(int(f)) {
+		return 0, errors.New("value must be an integer")
+	}
+	return int(f), nil
+}
+
+// checkRoutePrecedence panics if RoutePrecedenceExtension of any operation
+// is invalid. The spec matcher is built lazily and only once, so the
+// extension is checked before, when the matcher middleware is created.
+func checkRoutePrecedence(doc *Document) {
+	for _, pathOps := range doc.Analyzer.Operations() {
+		for _, op := range pathOps {
+			if _, err := routePrecedence(op); err != nil {
+				panic(fmt.Sprintf("oas: operation %s: invalid %s: %s", op.ID, RoutePrecedenceExtension, err))
+			}
+		}
+	}
+}
+
+// match returns the route matching the method and the escaped path, along
+// with the path parameters.
+func (m *specMatcher) match(method, path string) (specRoute, map[string]string, bool) {
+	return m.matchCase(method, path, false)
+}
+
+// matchCase is like match, but matches the literal parts of the path
+// templates case-insensitively if fold is set.
+func (m *specMatcher) matchCase(method, path string, fold bool) (specRoute, map[string]string, bool) {
+	method = strings.ToUpper(method)
+	for _, route := range m.routes {
+		if route.method != method {
+			continue
+		}
+		if params, ok := matchPath(route.path, path, fold); ok {
+			return route, params, true
+		}
+	}
+	return specRoute{}, nil, false
+}
+
+// MatchPath matches the escaped path against the path template and returns
+// path parameter values by their names. Each path segment can contain at
+// most one parameter, optionally surrounded by literal prefix and suffix,
+// e.g. "/files/{name}.json". Parameter values are unescaped.
+//
+// A segment that consists of a single parameter with a "+" suffix, e.g.
+// "/files/{filePath+}", is greedy: it matches one or more segments, and its
+// value is the matched segments joined with "/". A template can contain at
+// most one greedy parameter.
+//
+// These are the same matching semantics used by SpecMatcherMiddleware, so
+// it can be used to implement custom routing.
+func MatchPath(template, path string) (map[string]string, bool) {
+	return matchPath(template, path, false)
+}
+
+// matchPath is like MatchPath, but matches the literal parts of the
+// template case-insensitively if fold is set.
+func matchPath(template, path string, fold bool) (map[string]string, bool) {
+	tt := strings.Split(strings.Trim(template, "/"), "/")
+	pp := strings.Split(strings.Trim(path, "/"), "/")
+
+	params := make(map[string]string)
+	if g := greedySegment(tt); g >= 0 {
+		// Match the greedy segment against the remainder of the path that
+		// is left after the segments before and after it.
+		n := len(pp) - len(tt) + 1
+		if n < 1 {
+			return nil, false
+		}
+		value, err := url.PathUnescape(strings.Join(pp[g:g+n], "/"))
+		if err != nil || value == "" {
+			return nil, false
+		}
+		params[tt[g][1:len(tt[g])-2]] = value
+
+		tt = append(tt[:g:g], tt[g+1:]...)
+		pp = append(pp[:g:g], pp[g+n:]...)
+	}
+	if len(tt) != len(pp) {
+		return nil, false
+	}
+
+	for i, t := range tt {
+		start := strings.Index(t, "{")
+		end := strings.LastIndex(t, "}")
+		if start < 0 || end < start {
+			if !equalFold(t, pp[i], fold) {
+				return nil, false
+			}
+			continue
+		}
+
+		prefix, suffix := t[:start], t[end+1:]
+		seg := pp[i]
+		if len(seg) <= len(prefix)+len(suffix) ||
+			!equalFold(seg[:len(prefix)], prefix, fold) ||
+			!equalFold(seg[len(seg)-len(suffix):], suffix, fold) {
+			return nil, false
+		}
+
+		value, err := url.PathUnescape(seg[len(prefix) : len(seg)-len(suffix)])
+		if err != nil {
+			return nil, false
+		}
+		params[t[start+1:end]] = value
+	}
+
+	return params, true
+}
+
+// equalFold reports whether the strings are equal, ignoring case if fold is
+// set.
+func equalFold(a, b string, fold bool) bool {
+	if fold {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
+
+// templateCase returns the escaped path with the literal parts in the case
+// of the path template. The path must match the template.
+func templateCase(template, path string) string {
+	tt := strings.Split(strings.Trim(template, "/"), "/")
+	pp := strings.Split(strings.Trim(path, "/"), "/")
+
+	// Segments after the greedy one are shifted by the count of the extra
+	// segments it matches.
+	g, shift := greedySegment(tt), 0
+	for i, t := range tt {
+		if i == g {
+			shift = len(pp) - len(tt)
+			continue
+		}
+		j := i + shift
+
+		start := strings.Index(t, "{")
+		end := strings.LastIndex(t, "}")
+		if start < 0 || end < start {
+			pp[j] = t
+			continue
+		}
+		prefix, suffix := t[:start], t[end+1:]
+		pp[j] = prefix + pp[j][len(prefix):len(pp[j])-len(suffix)] + suffix
+	}
+
+	result := "/" + strings.Join(pp, "/")
+	if strings.HasSuffix(path, "/") && result != "/" {
+		result += "/"
+	}
+	return result
+}
+
+// greedySegment returns the index of the greedy parameter segment of the
+// template segments, or -1 if there is no such segment.
+func greedySegment(tt []string) int {
+	for i, t := range tt {
+		if strings.HasPrefix(t, "{") && strings.HasSuffix(t, "+}") && strings.Count(t, "{") == 1 {
+			return i
+		}
+	}
+	return -1
+}
+
+// greedyTemplate returns the path template with the path parameters marked
+// with GreedyExtension turned to greedy ones, e.g. "{filePath}" to
+// "{filePath+}".
+func greedyTemplate(template string, params []spec.Parameter) string {
+	for _, p := range params {
+		if p.In != "path" {
+			continue
+		}
+		if greedy, _ := p.Extensions[GreedyExtension].(bool); greedy {
+			template = strings.Replace(template, "{"+p.Name+"}", "{"+p.Name+"+}", 1)
+		}
+	}
+	return template
+}
+
+// countLiteralSegments returns count of path template segments without
+// parameters.
+func countLiteralSegments(template string) int {
+	n := 0
+	for _, seg := range strings.Split(strings.Trim(template, "/"), "/") {
+		if !strings.Contains(seg, "{") {
+			n++
+		}
+	}
+	return n
+}
+
+// joinBasePath returns the path prefixed with the base path.
+func joinBasePath(basePath, path string) string {
+	basePath = strings.TrimSuffix(basePath, "/")
+	if basePath == "" {
+		return path
+	}
+	return basePath + path
+}
@@ -0,0 +1,351 @@
+package oas
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpecMatcherMiddleware(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+	basis := NewResolvingBasis(SpecAdapterName, doc, BasisStrict(false))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/pet/", handleGetPetByID)
+	mux.HandleFunc("/v2/user/login", handleUserLogin)
+	mux.HandleFunc("/health", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("ok"))
+	})
+
+	h := SpecMatcherMiddleware(doc)(
+		basis.QueryValidator(WithProblemHandler(problemHandlerResponseWriter()))(mux),
+	)
+
+	testCases := map[string]struct {
+		url            string
+		expectedStatus int
+		expectedBody   string
+	}{
+		"extracts path params": {
+			url:            "/v2/pet/12",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "pet by id: 12",
+		},
+		"validates query": {
+			url:            "/v2/user/login?username=johndoe",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"errors":[{"message":"param password is required","field":"password"}]}`,
+		},
+		"passes requests not described in the spec": {
+			url:            "/health",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "ok",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.url, nil)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, tc.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestMatchPath(t *testing.T) {
+	testCases := map[string]struct {
+		template       string
+		path           string
+		expectedOK     bool
+		expectedParams map[string]string
+	}{
+		"static path": {
+			template:       "/v2/user/login",
+			path:           "/v2/user/login",
+			expectedOK:     true,
+			expectedParams: map[string]string{},
+		},
+		"path parameter": {
+			template:       "/v2/pet/{petId}",
+			path:           "/v2/pet/12",
+			expectedOK:     true,
+			expectedParams: map[string]string{"petId": "12"},
+		},
+		"parameter with prefix and suffix": {
+			template:       "/files/v{version}.json",
+			path:           "/files/v1%2F2.json",
+			expectedOK:     true,
+			expectedParams: map[string]string{"version": "1/2"},
+		},
+		"empty parameter": {
+			template:   "/v2/pet/{petId}",
+			path:       "/v2/pet/",
+			expectedOK: false,
+		},
+		"segments count mismatch": {
+			template:   "/v2/pet/{petId}",
+			path:       "/v2/pet/12/photos",
+			expectedOK: false,
+		},
+		"literal mismatch": {
+			template:   "/v2/pet/{petId}",
+			path:       "/v2/user/12",
+			expectedOK: false,
+		},
+		"greedy parameter": {
+			template:       "/files/{filePath+}",
+			path:           "/files/docs/a%20b.txt",
+			expectedOK:     true,
+			expectedParams: map[string]string{"filePath": "docs/a b.txt"},
+		},
+		"greedy parameter in the middle": {
+			template:       "/proxy/{host}/{rest+}/raw",
+			path:           "/proxy/example.com/a/b/raw",
+			expectedOK:     true,
+			expectedParams: map[string]string{"host": "example.com", "rest": "a/b"},
+		},
+		"empty greedy parameter": {
+			template:   "/files/{filePath+}",
+			path:       "/files/",
+			expectedOK: false,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			params, ok := MatchPath(tc.template, tc.path)
+			assert.Equal(t, tc.expectedOK, ok)
+			assert.Equal(t, tc.expectedParams, params)
+		})
+	}
+}
+
+func TestDocument_FindOperationByRequest(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+
+	op, params, ok := doc.FindOperationByRequest(httptest.NewRequest(http.MethodGet, "/v2/pet/12?debug=true", nil))
+	assert.True(t, ok)
+	assert.Equal(t, "getPetById", op.ID)
+	assert.Equal(t, map[string]string{"petId": "12"}, params)
+
+	op, params, ok = doc.FindOperationByRequest(httptest.NewRequest(http.MethodPost, "/v2/pet", nil))
+	assert.True(t, ok)
+	assert.Equal(t, "addPet", op.ID)
+	assert.Empty(t, params)
+
+	_, _, ok = doc.FindOperationByRequest(httptest.NewRequest(http.MethodDelete, "/v2/pet/12", nil))
+	assert.False(t, ok)
+}
+
+func TestSpecMatcherMiddleware_greedy(t *testing.T) {
+	doc := loadDocBytes([]byte(`
+swagger: "2.0"
+info:
+  title: Test
+  version: 1.0.0
+paths:
+  /files/{name}:
+    get:
+      operationId: getFile
+      parameters:
+      - name: name
+        in: path
+        required: true
+        type: string
+      responses:
+        200:
+          description: OK
+  /files/{filePath}/raw:
+    get:
+      operationId: getRawFile
+      parameters:
+      - name: filePath
+        in: path
+        required: true
+        type: string
+        x-oas-greedy: true
+      responses:
+        200:
+          description: OK
+  /proxy/{rest}:
+    get:
+      operationId: proxy
+      parameters:
+      - name: rest
+        in: path
+        required: true
+        type: string
+        x-oas-greedy: true
+      responses:
+        200:
+          description: OK
+`))
+
+	testCases := map[string]struct {
+		url            string
+		expectedOpID   string
+		expectedParams map[string]string
+	}{
+		"single segment": {
+			url:            "/files/a.txt",
+			expectedOpID:   "getFile",
+			expectedParams: map[string]string{"name": "a.txt"},
+		},
+		"greedy parameter in the middle": {
+			url:            "/files/docs/a.txt/raw",
+			expectedOpID:   "getRawFile",
+			expectedParams: map[string]string{"filePath": "docs/a.txt"},
+		},
+		"greedy parameter": {
+			url:            "/proxy/v1/users/12",
+			expectedOpID:   "proxy",
+			expectedParams: map[string]string{"rest": "v1/users/12"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			op, params, ok := doc.FindOperationByRequest(httptest.NewRequest(http.MethodGet, tc.url, nil))
+			assert.True(t, ok)
+			assert.Equal(t, tc.expectedOpID, op.ID)
+			assert.Equal(t, tc.expectedParams, params)
+		})
+	}
+
+	var filePath interface{}
+	h := SpecMatcherMiddleware(doc)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		filePath = GetPathParam(req, "filePath")
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/files/docs/a.txt/raw", nil))
+	assert.Equal(t, "docs/a.txt", filePath)
+}
+
+func TestSpecMatcherMiddleware_routing(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+
+	testCases := map[string]struct {
+		opts             []MatcherOption
+		url              string
+		expectedStatus   int
+		expectedBody     string
+		expectedLocation string
+	}{
+		"trailing slash matched by default": {
+			url:            "/v2/pet/12/",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "pet by id: 12",
+		},
+		"trailing slash moved permanently": {
+			opts:             []MatcherOption{MatchTrailingSlash(TrailingSlashMovedPermanently)},
+			url:              "/v2/pet/12/?debug=true",
+			expectedStatus:   http.StatusMovedPermanently,
+			expectedLocation: "/v2/pet/12?debug=true",
+		},
+		"trailing slash permanent redirect": {
+			opts:             []MatcherOption{MatchTrailingSlash(TrailingSlashPermanentRedirect)},
+			url:              "/v2/pet/12/",
+			expectedStatus:   http.StatusPermanentRedirect,
+			expectedLocation: "/v2/pet/12",
+		},
+		"trailing slash strict": {
+			opts:           []MatcherOption{MatchTrailingSlash(TrailingSlashStrict)},
+			url:            "/v2/pet/12/",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "404 page not found\n",
+		},
+		"exact path with strict trailing slash": {
+			opts:           []MatcherOption{MatchTrailingSlash(TrailingSlashStrict)},
+			url:            "/v2/pet/12",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "pet by id: 12",
+		},
+		"case-sensitive by default": {
+			url:            "/V2/Pet/12",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "404 page not found\n",
+		},
+		"case-insensitive": {
+			opts:           []MatcherOption{MatchCaseInsensitive(true)},
+			url:            "/V2/Pet/12",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "pet by id: 12",
+		},
+		"case-insensitive redirect": {
+			opts: []MatcherOption{
+				MatchCaseInsensitive(true),
+				MatchTrailingSlash(TrailingSlashMovedPermanently),
+			},
+			url:              "/V2/Pet/12/",
+			expectedStatus:   http.StatusMovedPermanently,
+			expectedLocation: "/v2/pet/12",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/v2/pet/", handleGetPetByID)
+			h := SpecMatcherMiddleware(doc, tc.opts...)(mux)
+
+			req := httptest.NewRequest(http.MethodGet, tc.url, nil)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, tc.expectedLocation, w.Header().Get("Location"))
+			if tc.expectedBody != "" {
+				assert.Equal(t, tc.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRoutingPolicies(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+
+	testCases := map[string]struct {
+		opts           []MatcherOption
+		url            string
+		expectedStatus int
+	}{
+		"trailing slash rewritten by default": {
+			url:            "/v2/pet/12/",
+			expectedStatus: http.StatusOK,
+		},
+		"trailing slash strict": {
+			opts:           []MatcherOption{MatchTrailingSlash(TrailingSlashStrict)},
+			url:            "/v2/pet/12/",
+			expectedStatus: http.StatusNotFound,
+		},
+		"case and trailing slash rewritten": {
+			opts:           []MatcherOption{MatchCaseInsensitive(true)},
+			url:            "/V2/Pet/12/",
+			expectedStatus: http.StatusOK,
+		},
+		"unknown path passed as is": {
+			opts:           []MatcherOption{MatchCaseInsensitive(true)},
+			url:            "/v2/unknown/",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			// ServeMux matches the exact path only, like a strict router.
+			mux := http.NewServeMux()
+			mux.HandleFunc("/v2/pet/12", func(w http.ResponseWriter, req *http.Request) {})
+			h := RoutingPolicies(doc, tc.opts...)(mux)
+
+			req := httptest.NewRequest(http.MethodGet, tc.url, nil)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
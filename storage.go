@@ -0,0 +1,117 @@
+package oas
+
+import (
+	"fmt"
+)
+
+// FieldDescriptor describes a property of a definition as a storage field,
+// e.g. a table column or a document field. Descriptors allow to keep storage
+// schemas aligned with the API contract, e.g. to generate table DDL or BSON
+// validators from the spec.
+type FieldDescriptor struct {
+	// Name is the property name.
+	Name string
+
+	// Type is the property type, e.g. "string" or "integer".
+	Type string
+
+	// Format is the property format, e.g. "int64" or "date-time".
+	Format string
+
+	// Nullable reports whether the property is not required or is marked
+	// with "x-nullable" extension.
+	Nullable bool
+
+	// MaxLength is the maximum length of a string property, or zero.
+	MaxLength int64
+
+	// Enum are the allowed values of the property, if any.
+	Enum []interface{}
+}
+
+// Fields returns descriptors of the schema properties, ordered by name.
+func (s Schema) Fields() []FieldDescriptor {
+	required := make(map[string]bool, len(s.Required))
+	for _, name := range s.Required {
+		required[name] = true
+	}
+
+	fields := make([]FieldDescriptor, 0, len(s.Properties))
+	for _, name := range sortedPropertyNames(s.Schema) {
+		prop := s.Properties[name]
+
+		f := FieldDescriptor{
+			Name:     name,
+			Type:     schemaType(&prop),
+			Format:   prop.Format,
+			Nullable: !required[name],
+			Enum:     prop.Enum,
+		}
+		if nullable, ok := prop.Extensions.GetBool("x-nullable"); ok && nullable {
+			f.Nullable = true
+		}
+		if prop.MaxLength != nil {
+			f.MaxLength = *prop.MaxLength
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// SQLType returns the standard SQL type of the field, e.g. "BIGINT" or
+// "VARCHAR(64)". Objects and arrays have no SQL type, so they are mapped
+// to "JSON".
+func (f FieldDescriptor) SQLType() string {
+	switch f.Type {
+	case "integer":
+		if f.Format == "int32" {
+			return "INTEGER"
+		}
+		return "BIGINT"
+	case "number":
+		if f.Format == "float" {
+			return "REAL"
+		}
+		return "DOUBLE PRECISION"
+	case "boolean":
+		return "BOOLEAN"
+	case "string":
+		switch f.Format {
+		case "date":
+			return "DATE"
+		case "date-time":
+			return "TIMESTAMP"
+		}
+		if f.MaxLength > 0 {
+			return fmt.Sprintf("VARCHAR(%d)", f.MaxLength)
+		}
+		return "TEXT"
+	default:
+		return "JSON"
+	}
+}
+
+// BSONType returns the BSON type alias of the field, as used by "bsonType"
+// keyword of MongoDB schema validators, e.g. "long" or "date".
+func (f FieldDescriptor) BSONType() string {
+	switch f.Type {
+	case "integer":
+		if f.Format == "int32" {
+			return "int"
+		}
+		return "long"
+	case "number":
+		return "double"
+	case "boolean":
+		return "bool"
+	case "string":
+		if f.Format == "date" || f.Format == "date-time" {
+			return "date"
+		}
+		return "string"
+	case "array":
+		return "array"
+	default:
+		return "object"
+	}
+}
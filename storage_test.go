@@ -0,0 +1,32 @@
+package oas
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchema_Fields(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+
+	schema, ok := doc.Definition("Pet")
+	if !assert.True(t, ok) {
+		return
+	}
+
+	fields := schema.Fields()
+	assert.Equal(t, []FieldDescriptor{
+		{Name: "age", Type: "integer", Format: "int32"},
+		{Name: "id", Type: "integer", Format: "int64", Nullable: true},
+		{Name: "name", Type: "string"},
+		{Name: "status", Type: "string", Nullable: true, Enum: []interface{}{"available", "pending", "sold"}},
+	}, fields)
+
+	var sqlTypes, bsonTypes []string
+	for _, f := range fields {
+		sqlTypes = append(sqlTypes, f.SQLType())
+		bsonTypes = append(bsonTypes, f.BSONType())
+	}
+	assert.Equal(t, []string{"INTEGER", "BIGINT", "TEXT", "TEXT"}, sqlTypes)
+	assert.Equal(t, []string{"int", "long", "string", "string"}, bsonTypes)
+}
@@ -0,0 +1,298 @@
+package oas
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Store is a key-value store with expiring keys that backs stateful
+// middleware. Configure persistence once and adapt the store to the
+// middleware stores with StoreNonces, StoreQuotas and StoreSessions:
+//
+//  store := oas.NewRedisStore(client, "api:")
+//  nonces := basis.NonceValidator(oas.StoreNonces(store))
+//  quotas := basis.QuotaLimiter(oas.StoreQuotas(store))
+//  sessions := oas.NewSessions(oas.StoreSessions(store))
+//
+// The ttl arguments are the time to live of keys; keys with zero ttl do not
+// expire. Implementations must be safe for concurrent use, and operations
+// on a single key must be atomic.
+type Store interface {
+	// Get returns the value of the key. It returns false if there is no
+	// such key or it is expired.
+	Get(key string) ([]byte, bool, error)
+
+	// Set sets the value of the key.
+	Set(key string, value []byte, ttl time.Duration) error
+
+	// Incr increments the integer value of the key and returns its new
+	// value. A missing key is created with the value of 1 and the ttl;
+	// the ttl of an existing key is not changed.
+	Incr(key string, ttl time.Duration) (int64, error)
+
+	// CompareAndSwap sets the value of the key if its current value is
+	// old, or, if old is nil, if there is no such key. It returns false if
+	// the value is not set.
+	CompareAndSwap(key string, old, value []byte, ttl time.Duration) (bool, error)
+
+	// Delete deletes the key. Deleting a missing key is not an error.
+	Delete(key string) error
+}
+
+// MemoryStore is an in-memory Store for a single service instance. It also
+// implements StoreInspector. It is safe for concurrent use.
+type MemoryStore struct {
+	mx      sync.Mutex
+	items   map[string]memoryStoreItem
+	sweepAt time.Time
+	clock   Clock
+}
+
+type memoryStoreItem struct {
+	value   []byte
+	expires time.Time
+}
+
+// expired checks if the item is expired at the time.
+func (it memoryStoreItem) expired(now time.Time) bool {
+	return !it.expires.IsZero() && !it.expires.After(now)
+}
+
+// memoryStoreSweepInterval is the interval between removals of expired
+// keys from MemoryStore.
+const memoryStoreSweepInterval = time.Minute
+
+// NewMemoryStore returns a new MemoryStore.
+func NewMemoryStore(opts ...StoreOption) *MemoryStore {
+	return &MemoryStore{
+		items: make(map[string]memoryStoreItem),
+		clock: parseStoreOptions(opts).clock,
+	}
+}
+
+// item returns the item of the key if it is not expired, sweeping expired
+// items from time to time. It must be called with the lock held.
+func (s *MemoryStore) item(key string, now time.Time) (memoryStoreItem, bool) {
+	if now.After(s.sweepAt) {
+		for k, it := range s.items {
+			if it.expired(now) {
+				delete(s.items, k)
+			}
+		}
+		s.sweepAt = now.Add(memoryStoreSweepInterval)
+	}
+
+	it, ok := s.items[key]
+	if !ok || it.expired(now) {
+		return memoryStoreItem{}, false
+	}
+	return it, true
+}
+
+// newMemoryStoreItem returns a new item with the value expiring after ttl.
+func newMemoryStoreItem(value []byte, ttl time.Duration, now time.Time) memoryStoreItem {
+	it := memoryStoreItem{value: append([]byte(nil), value...)}
+	if ttl > 0 {
+		it.expires = now.Add(ttl)
+	}
+	return it
+}
+
+// Get implements Store.
+func (s *MemoryStore) Get(key string) ([]byte, bool, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	it, ok := s.item(key, s.clock.Now())
+	if !ok {
+		return nil, false, nil
+	}
+	return append([]byte(nil), it.value...), true, nil
+}
+
+// Set implements Store.
+func (s *MemoryStore) Set(key string, value []byte, ttl time.Duration) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	s.items[key] = newMemoryStoreItem(value, ttl, s.clock.Now())
+	return nil
+}
+
+// Incr implements Store.
+func (s *MemoryStore) Incr(key string, ttl time.Duration) (int64, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	now := s.clock.Now()
+	it, ok := s.item(key, now)
+	if !ok {
+		s.items[key] = newMemoryStoreItem([]byte("1"), ttl, now)
+		return 1, nil
+	}
+
+	n, err := strconv.ParseInt(string(it.value), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("store key %s: value is not an integer", key)
+	}
+	n++
+	it.value = []byte(strconv.FormatInt(n, 10))
+	s.items[key] = it
+	return n, nil
+}
+
+// CompareAndSwap implements Store.
+func (s *MemoryStore) CompareAndSwap(key string, old, value []byte, ttl time.Duration) (bool, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	now := s.clock.Now()
+	it, ok := s.item(key, now)
+	if old == nil && ok || old != nil && (!ok || !bytes.Equal(it.value, old)) {
+		return false, nil
+	}
+	s.items[key] = newMemoryStoreItem(value, ttl, now)
+	return true, nil
+}
+
+// Delete implements Store.
+func (s *MemoryStore) Delete(key string) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	delete(s.items, key)
+	return nil
+}
+
+// Entries implements StoreInspector. Keys that do not expire are reported
+// with zero expiration time.
+func (s *MemoryStore) Entries(prefix string) ([]StoreEntry, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	now := s.clock.Now()
+	var entries []StoreEntry
+	for k, it := range s.items {
+		if strings.HasPrefix(k, prefix) && !it.expired(now) {
+			entries = append(entries, StoreEntry{Key: k, Expires: it.expires})
+		}
+	}
+	sortStoreEntries(entries)
+	return entries, nil
+}
+
+// Purge implements StoreInspector.
+func (s *MemoryStore) Purge(prefix string) (int, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	n := 0
+	for k := range s.items {
+		if strings.HasPrefix(k, prefix) {
+			delete(s.items, k)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// Key prefixes of the stores adapted from Store, so the adapted stores can
+// share a Store without key collisions.
+const (
+	storePrefixNonce   = "nonce:"
+	storePrefixQuota   = "quota:"
+	storePrefixSession = "session:"
+)
+
+// StoreNonces returns a NonceStore backed by the store. Keys are prefixed
+// with "nonce:".
+func StoreNonces(s Store, opts ...StoreOption) NonceStore {
+	return storeNonces{s, parseStoreOptions(opts).clock}
+}
+
+type storeNonces struct {
+	store Store
+	clock Clock
+}
+
+// Use implements NonceStore.
+func (s storeNonces) Use(key string, expires time.Time) (bool, error) {
+	ttl := expires.Sub(s.clock.Now())
+	if ttl <= 0 {
+		return true, nil
+	}
+	return s.store.CompareAndSwap(storePrefixNonce+key, nil, []byte("1"), ttl)
+}
+
+// StoreQuotas returns a QuotaStore backed by the store. Keys are prefixed
+// with "quota:" and suffixed with the reset time, so every period has its
+// own counter.
+func StoreQuotas(s Store, opts ...StoreOption) QuotaStore {
+	return storeQuotas{s, parseStoreOptions(opts).clock}
+}
+
+type storeQuotas struct {
+	store Store
+	clock Clock
+}
+
+// Increment implements QuotaStore.
+func (s storeQuotas) Increment(key string, reset time.Time) (int64, error) {
+	ttl := reset.Sub(s.clock.Now())
+	if ttl <= 0 {
+		ttl = time.Millisecond
+	}
+	return s.store.Incr(storePrefixQuota+key+" "+strconv.FormatInt(reset.Unix(), 10), ttl)
+}
+
+// StoreSessions returns a SessionStore backed by the store. Sessions are
+// stored as JSON under keys prefixed with "session:" and expire with the
+// sessions.
+func StoreSessions(s Store, opts ...StoreOption) SessionStore {
+	return storeSessions{s, parseStoreOptions(opts).clock}
+}
+
+type storeSessions struct {
+	store Store
+	clock Clock
+}
+
+// Save implements SessionStore.
+func (s storeSessions) Save(session Session) error {
+	ttl := session.ExpiresAt.Sub(s.clock.Now())
+	if ttl <= 0 {
+		return s.store.Delete(storePrefixSession + session.ID)
+	}
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	return s.store.Set(storePrefixSession+session.ID, data, ttl)
+}
+
+// Load implements SessionStore.
+func (s storeSessions) Load(id string) (Session, bool, error) {
+	data, ok, err := s.store.Get(storePrefixSession + id)
+	if err != nil || !ok {
+		return Session{}, false, err
+	}
+
+	var session Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return Session{}, false, fmt.Errorf("store key %s%s: invalid session: %s", storePrefixSession, id, err)
+	}
+	if !session.ExpiresAt.After(s.clock.Now()) {
+		return Session{}, false, nil
+	}
+	return session, true, nil
+}
+
+// Delete implements SessionStore.
+func (s storeSessions) Delete(id string) error {
+	return s.store.Delete(storePrefixSession + id)
+}
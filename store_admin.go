@@ -0,0 +1,159 @@
+package oas
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// StoreEntry is an entry of a store of stateful middleware, e.g. a used
+// nonce or a quota counter.
+type StoreEntry struct {
+	// Key is the key of the entry. Keys of NonceStore are
+	// "<operationId> <principal> <nonce>", keys of QuotaStore are
+	// "<principal> <operationId>".
+	Key string `json:"key"`
+
+	// Expires is the time when the entry expires.
+	Expires time.Time `json:"expires"`
+
+	// Count is the counter value of counting stores, e.g. QuotaStore.
+	Count int64 `json:"count,omitempty"`
+}
+
+// StoreInspector is implemented by stores of stateful middleware that can be
+// inspected and purged by operators, e.g. to unblock a client after an
+// incident. Stores backed by Redis implement it with SCAN and DEL by key
+// prefix, so one client serves all the stores.
+type StoreInspector interface {
+	// Entries returns the entries that are not expired with keys that
+	// start with the prefix, sorted by key.
+	Entries(prefix string) ([]StoreEntry, error)
+
+	// Purge removes the entries with keys that start with the prefix and
+	// returns the number of removed entries.
+	Purge(prefix string) (int, error)
+}
+
+// Entries implements StoreInspector.
+func (s *MemoryNonceStore) Entries(prefix string) ([]StoreEntry, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	now := s.clock.Now()
+	var entries []StoreEntry
+	for k, exp := range s.nonces {
+		if strings.HasPrefix(k, prefix) && exp.After(now) {
+			entries = append(entries, StoreEntry{Key: k, Expires: exp})
+		}
+	}
+	sortStoreEntries(entries)
+	return entries, nil
+}
+
+// Purge implements StoreInspector.
+func (s *MemoryNonceStore) Purge(prefix string) (int, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	n := 0
+	for k := range s.nonces {
+		if strings.HasPrefix(k, prefix) {
+			delete(s.nonces, k)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// Entries implements StoreInspector.
+func (s *MemoryQuotaStore) Entries(prefix string) ([]StoreEntry, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	now := s.clock.Now()
+	var entries []StoreEntry
+	for k, c := range s.counters {
+		if strings.HasPrefix(k, prefix) && c.reset.After(now) {
+			entries = append(entries, StoreEntry{Key: k, Expires: c.reset, Count: c.count})
+		}
+	}
+	sortStoreEntries(entries)
+	return entries, nil
+}
+
+// Purge implements StoreInspector.
+func (s *MemoryQuotaStore) Purge(prefix string) (int, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	n := 0
+	for k := range s.counters {
+		if strings.HasPrefix(k, prefix) {
+			delete(s.counters, k)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func sortStoreEntries(entries []StoreEntry) {
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+}
+
+// RegisterStore registers the store under the name, so it is inspected and
+// purged by the admin handler:
+//
+//  GET    /stores/{name}?prefix=   entries of the store
+//  DELETE /stores/{name}?prefix=   purges entries of the store
+//
+// The prefix is optional; without it, all entries are listed or purged.
+// It panics if the store is nil.
+func (a *Admin) RegisterStore(name string, s StoreInspector) {
+	if s == nil {
+		panic("oas: Admin.RegisterStore store is nil")
+	}
+
+	a.mx.Lock()
+	defer a.mx.Unlock()
+
+	if a.stores == nil {
+		a.stores = make(map[string]StoreInspector)
+	}
+	a.stores[name] = s
+}
+
+// serveStore serves the endpoints of the store with the name.
+func (a *Admin) serveStore(w http.ResponseWriter, req *http.Request, name string) {
+	a.mx.RLock()
+	s, ok := a.stores[name]
+	a.mx.RUnlock()
+	if !ok {
+		http.Error(w, "store "+name+" is not registered", http.StatusNotFound)
+		return
+	}
+
+	prefix := req.URL.Query().Get("prefix")
+	switch req.Method {
+	case http.MethodGet:
+		entries, err := s.Entries(prefix)
+		if err != nil {
+			http.Error(w, "store "+name+": "+err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		if entries == nil {
+			entries = []StoreEntry{}
+		}
+		writeJSON(w, http.StatusOK, entries)
+	case http.MethodDelete:
+		n, err := s.Purge(prefix)
+		if err != nil {
+			http.Error(w, "store "+name+": "+err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]int{"purged": n})
+	default:
+		http.NotFound(w, req)
+	}
+}
@@ -0,0 +1,116 @@
+package oas
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdmin_RegisterStore(t *testing.T) {
+	doc := loadDocBytes([]byte(`
+swagger: "2.0"
+info:
+  title: Test
+  version: 1.0.0
+paths:
+  /payments:
+    post:
+      operationId: createPayment
+      x-oas-nonce: true
+      responses:
+        200:
+          description: OK
+  /transfers:
+    post:
+      operationId: createTransfer
+      x-oas-nonce:
+        ttl: 1h
+      responses:
+        200:
+          description: OK
+`))
+
+	store := NewMemoryNonceStore()
+	basis := NewResolvingBasis(SpecAdapterName, doc, BasisStrict(false))
+	h := SpecMatcherMiddleware(doc)(
+		basis.NonceValidator(store, WithNonceTTL(24*time.Hour))(
+			http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}),
+		),
+	)
+
+	admin := NewAdmin(basis, func(req *http.Request) bool { return true })
+	admin.RegisterStore("nonces", store)
+
+	use := func(path, nonce string) int {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		req.Header.Set("X-Nonce", nonce)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, WithPrincipal(req, Principal{ID: "alice"}))
+		return w.Code
+	}
+	call := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		admin.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+
+	start := time.Now()
+	require.Equal(t, http.StatusOK, use("/payments", "n1"))
+	require.Equal(t, http.StatusOK, use("/payments", "n2"))
+	require.Equal(t, http.StatusOK, use("/transfers", "n1"))
+
+	t.Run("ttl", func(t *testing.T) {
+		entries, err := store.Entries("")
+		require.NoError(t, err)
+		require.Len(t, entries, 3)
+		assert.Equal(t, "createPayment alice n1", entries[0].Key)
+		assert.WithinDuration(t, start.Add(24*time.Hour), entries[0].Expires, time.Minute)
+		assert.Equal(t, "createTransfer alice n1", entries[2].Key)
+		assert.WithinDuration(t, start.Add(time.Hour), entries[2].Expires, time.Minute)
+	})
+
+	t.Run("inspect", func(t *testing.T) {
+		w := call(http.MethodGet, "/stores/nonces?prefix=createPayment+")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"key":"createPayment alice n1"`)
+		assert.Contains(t, w.Body.String(), `"key":"createPayment alice n2"`)
+		assert.NotContains(t, w.Body.String(), "createTransfer")
+	})
+
+	t.Run("purge", func(t *testing.T) {
+		w := call(http.MethodDelete, "/stores/nonces?prefix=createPayment+alice+n1")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"purged": 1}`, w.Body.String())
+
+		assert.Equal(t, http.StatusOK, use("/payments", "n1"))
+		assert.Equal(t, http.StatusConflict, use("/payments", "n2"))
+	})
+
+	t.Run("unknown store", func(t *testing.T) {
+		w := call(http.MethodGet, "/stores/sessions")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestMemoryQuotaStore_inspect(t *testing.T) {
+	store := NewMemoryQuotaStore()
+	reset := time.Now().Add(time.Hour)
+	store.Increment("alice addPet", reset) // nolint: errcheck
+	store.Increment("alice addPet", reset) // nolint: errcheck
+	store.Increment("bob addPet", reset)   // nolint: errcheck
+
+	entries, err := store.Entries("alice ")
+	require.NoError(t, err)
+	assert.Equal(t, []StoreEntry{{Key: "alice addPet", Expires: reset, Count: 2}}, entries)
+
+	n, err := store.Purge("")
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	count, _ := store.Increment("alice addPet", reset)
+	assert.Equal(t, int64(1), count)
+}
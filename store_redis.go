@@ -0,0 +1,246 @@
+package oas
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RedisClient executes Redis commands. It is implemented with a thin
+// adapter by any Redis client, e.g. for github.com/gomodule/redigo:
+//
+//  type redigoClient struct{ pool *redis.Pool }
+//
+//  func (c redigoClient) Do(args ...interface{}) (interface{}, error) {
+//      conn := c.pool.Get()
+//      defer conn.Close()
+//      return conn.Do(args[0].(string), args[1:]...)
+//  }
+//
+// The first argument is the command name. Do returns nil for nil replies,
+// int64 for integer replies, string or []byte for status and bulk string
+// replies, and []interface{} for array replies.
+type RedisClient interface {
+	Do(args ...interface{}) (interface{}, error)
+}
+
+// RedisStore is a Store backed by Redis, so stateful middleware is shared
+// between service instances. It also implements StoreInspector with SCAN.
+type RedisStore struct {
+	client RedisClient
+	prefix string
+	clock  Clock
+}
+
+// NewRedisStore returns a new RedisStore that prefixes keys with the
+// prefix, e.g. "api:". It panics if the client is nil.
+//
+// Redis expires keys by itself, so the clock set by StoreClock only tells
+// the expiration times of the entries returned by Entries.
+func NewRedisStore(client RedisClient, prefix string, opts ...StoreOption) *RedisStore {
+	if client == nil {
+		panic("oas: NewRedisStore client is nil")
+	}
+	return &RedisStore{
+		client: client,
+		prefix: prefix,
+		clock:  parseStoreOptions(opts).clock,
+	}
+}
+
+// Scripts that make multi-command operations of RedisStore atomic.
+const (
+	redisIncrScript = `local n = redis.call('INCR', KEYS[1])
+if n == 1 and tonumber(ARGV[1]) > 0 then redis.call('PEXPIRE', KEYS[1], ARGV[1]) end
+return n`
+
+	redisCompareAndSwapScript = `if redis.call('GET', KEYS[1]) ~= ARGV[1] then return 0 end
+if tonumber(ARGV[3]) > 0 then redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+else redis.call('SET', KEYS[1], ARGV[2]) end
+return 1`
+)
+
+// Get implements Store.
+func (s *RedisStore) Get(key string) ([]byte, bool, error) {
+	reply, err := s.client.Do("GET", s.prefix+key)
+	if err != nil || reply == nil {
+		return nil, false, err
+	}
+	data, err := redisBytes(reply)
+	if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+// Set implements Store.
+func (s *RedisStore) Set(key string, value []byte, ttl time.Duration) error {
+	args := []interface{}{"SET", s.prefix + key, value}
+	if ttl > 0 {
+		args = append(args, "PX", redisMillis(ttl))
+	}
+	_, err := s.client.Do(args...)
+	return err
+}
+
+// Incr implements Store.
+func (s *RedisStore) Incr(key string, ttl time.Duration) (int64, error) {
+	reply, err := s.client.Do("EVAL", redisIncrScript, 1, s.prefix+key, redisMillis(ttl))
+	if err != nil {
+		return 0, err
+	}
+	return redisInt(reply)
+}
+
+// CompareAndSwap implements Store.
+func (s *RedisStore) CompareAndSwap(key string, old, value []byte, ttl time.Duration) (bool, error) {
+	if old == nil {
+		args := []interface{}{"SET", s.prefix + key, value}
+		if ttl > 0 {
+			args = append(args, "PX", redisMillis(ttl))
+		}
+		reply, err := s.client.Do(append(args, "NX")...)
+		return reply != nil, err
+	}
+
+	reply, err := s.client.Do("EVAL", redisCompareAndSwapScript, 1, s.prefix+key, old, value, redisMillis(ttl))
+	if err != nil {
+		return false, err
+	}
+	n, err := redisInt(reply)
+	return n == 1, err
+}
+
+// Delete implements Store.
+func (s *RedisStore) Delete(key string) error {
+	_, err := s.client.Do("DEL", s.prefix+key)
+	return err
+}
+
+// Entries implements StoreInspector.
+func (s *RedisStore) Entries(prefix string) ([]StoreEntry, error) {
+	keys, err := s.scan(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now()
+	var entries []StoreEntry
+	for _, key := range keys {
+		reply, err := s.client.Do("PTTL", key)
+		if err != nil {
+			return nil, err
+		}
+		ms, err := redisInt(reply)
+		if err != nil {
+			return nil, err
+		}
+
+		e := StoreEntry{Key: strings.TrimPrefix(key, s.prefix)}
+		switch {
+		case ms == -2:
+			// The key has expired since the scan.
+			continue
+		case ms >= 0:
+			e.Expires = now.Add(time.Duration(ms) * time.Millisecond)
+		}
+		entries = append(entries, e)
+	}
+	sortStoreEntries(entries)
+	return entries, nil
+}
+
+// Purge implements StoreInspector.
+func (s *RedisStore) Purge(prefix string) (int, error) {
+	keys, err := s.scan(prefix)
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, key := range keys {
+		reply, err := s.client.Do("DEL", key)
+		if err != nil {
+			return n, err
+		}
+		deleted, err := redisInt(reply)
+		if err != nil {
+			return n, err
+		}
+		n += int(deleted)
+	}
+	return n, nil
+}
+
+// scan returns the full keys that start with the prefix.
+func (s *RedisStore) scan(prefix string) ([]string, error) {
+	pattern := redisGlobEscaper.Replace(s.prefix+prefix) + "*"
+
+	var keys []string
+	cursor := "0"
+	for {
+		reply, err := s.client.Do("SCAN", cursor, "MATCH", pattern, "COUNT", 100)
+		if err != nil {
+			return nil, err
+		}
+		arr, ok := reply.([]interface{})
+		if !ok || len(arr) != 2 {
+			return nil, fmt.Errorf("redis: unexpected SCAN reply %v", reply)
+		}
+		next, err := redisBytes(arr[0])
+		if err != nil {
+			return nil, err
+		}
+		batch, _ := arr[1].([]interface{})
+		for _, k := range batch {
+			key, err := redisBytes(k)
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, string(key))
+		}
+
+		cursor = string(next)
+		if cursor == "0" {
+			return keys, nil
+		}
+	}
+}
+
+// redisGlobEscaper escapes special characters of Redis glob patterns.
+var redisGlobEscaper = strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`, `]`, `\]`)
+
+// redisMillis returns the duration in milliseconds, rounded up.
+func redisMillis(d time.Duration) int64 {
+	if d <= 0 {
+		return 0
+	}
+	return int64((d + time.Millisecond - 1) / time.Millisecond)
+}
+
+// redisBytes converts the bulk string reply to bytes.
+func redisBytes(reply interface{}) ([]byte, error) {
+	switch v := reply.(type) {
+	case []byte:
+		return v, nil
+	case string:
+		return []byte(v), nil
+	default:
+		return nil, fmt.Errorf("redis: unexpected reply %v, expected a string", reply)
+	}
+}
+
+// redisInt converts the integer reply to int64.
+func redisInt(reply interface{}) (int64, error) {
+	switch v := reply.(type) {
+	case int64:
+		return v, nil
+	case []byte:
+		return strconv.ParseInt(string(v), 10, 64)
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	default:
+		return 0, fmt.Errorf("redis: unexpected reply %v, expected an integer", reply)
+	}
+}
@@ -0,0 +1,151 @@
+package oas
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRedis is a RedisClient that implements the commands used by
+// RedisStore in memory.
+type fakeRedis struct {
+	mx      sync.Mutex
+	values  map[string][]byte
+	expires map[string]time.Time
+}
+
+func newFakeRedis() *fakeRedis {
+	return &fakeRedis{
+		values:  make(map[string][]byte),
+		expires: make(map[string]time.Time),
+	}
+}
+
+func (r *fakeRedis) Do(args ...interface{}) (interface{}, error) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	arg := func(i int) []byte {
+		if b, ok := args[i].([]byte); ok {
+			return b
+		}
+		return []byte(fmt.Sprint(args[i]))
+	}
+	str := func(i int) string { return string(arg(i)) }
+	num := func(i int) int64 {
+		n, _ := strconv.ParseInt(str(i), 10, 64)
+		return n
+	}
+
+	for k, exp := range r.expires {
+		if !exp.After(time.Now()) {
+			delete(r.values, k)
+			delete(r.expires, k)
+		}
+	}
+
+	set := func(key string, value []byte, px int64) {
+		r.values[key] = value
+		delete(r.expires, key)
+		if px > 0 {
+			r.expires[key] = time.Now().Add(time.Duration(px) * time.Millisecond)
+		}
+	}
+
+	switch cmd := str(0); cmd {
+	case "GET":
+		v, ok := r.values[str(1)]
+		if !ok {
+			return nil, nil
+		}
+		return v, nil
+	case "SET":
+		var px int64
+		nx := false
+		for i := 3; i < len(args); i++ {
+			switch str(i) {
+			case "PX":
+				px = num(i + 1)
+				i++
+			case "NX":
+				nx = true
+			}
+		}
+		if _, ok := r.values[str(1)]; ok && nx {
+			return nil, nil
+		}
+		set(str(1), arg(2), px)
+		return "OK", nil
+	case "DEL":
+		_, ok := r.values[str(1)]
+		delete(r.values, str(1))
+		delete(r.expires, str(1))
+		if ok {
+			return int64(1), nil
+		}
+		return int64(0), nil
+	case "PTTL":
+		if _, ok := r.values[str(1)]; !ok {
+			return int64(-2), nil
+		}
+		exp, ok := r.expires[str(1)]
+		if !ok {
+			return int64(-1), nil
+		}
+		return int64(time.Until(exp) / time.Millisecond), nil
+	case "SCAN":
+		var keys []interface{}
+		for k := range r.values {
+			if ok, _ := path.Match(str(3), k); ok {
+				keys = append(keys, []byte(k))
+			}
+		}
+		return []interface{}{[]byte("0"), keys}, nil
+	case "EVAL":
+		key := str(3)
+		switch str(1) {
+		case redisIncrScript:
+			n, _ := strconv.ParseInt(string(r.values[key]), 10, 64)
+			n++
+			if n == 1 {
+				set(key, []byte("1"), num(4))
+			} else {
+				r.values[key] = []byte(strconv.FormatInt(n, 10))
+			}
+			return n, nil
+		case redisCompareAndSwapScript:
+			if v, ok := r.values[key]; !ok || !bytes.Equal(v, arg(4)) {
+				return int64(0), nil
+			}
+			set(key, arg(5), num(6))
+			return int64(1), nil
+		}
+	}
+	return nil, fmt.Errorf("ERR unknown command %v", args)
+}
+
+func TestRedisStore(t *testing.T) {
+	client := newFakeRedis()
+	testStore(t, NewRedisStore(client, "api:"))
+
+	_, ok := client.values["api:counter"]
+	assert.True(t, ok, "keys must be prefixed")
+}
+
+func TestRedisStore_clock(t *testing.T) {
+	clock := NewFrozenClock(time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC))
+	store := NewRedisStore(newFakeRedis(), "api:", StoreClock(clock))
+	require.NoError(t, store.Set("user:1", []byte("a"), time.Hour))
+
+	entries, err := store.Entries("user:")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.WithinDuration(t, clock.Now().Add(time.Hour), entries[0].Expires, time.Minute)
+}
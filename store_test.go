@@ -0,0 +1,143 @@
+package oas
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testStore tests the Store implementation.
+func testStore(t *testing.T, s Store) {
+	t.Run("get and set", func(t *testing.T) {
+		_, ok, err := s.Get("k1")
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		require.NoError(t, s.Set("k1", []byte("v1"), time.Hour))
+		v, ok, err := s.Get("k1")
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, []byte("v1"), v)
+
+		require.NoError(t, s.Delete("k1"))
+		_, ok, _ = s.Get("k1")
+		assert.False(t, ok)
+	})
+
+	t.Run("incr", func(t *testing.T) {
+		for i := int64(1); i <= 3; i++ {
+			n, err := s.Incr("counter", time.Hour)
+			require.NoError(t, err)
+			assert.Equal(t, i, n)
+		}
+	})
+
+	t.Run("compare and swap", func(t *testing.T) {
+		ok, err := s.CompareAndSwap("cas", nil, []byte("a"), time.Hour)
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		ok, _ = s.CompareAndSwap("cas", nil, []byte("b"), time.Hour)
+		assert.False(t, ok, "key exists")
+
+		ok, _ = s.CompareAndSwap("cas", []byte("x"), []byte("b"), time.Hour)
+		assert.False(t, ok, "value differs")
+
+		ok, err = s.CompareAndSwap("cas", []byte("a"), []byte("b"), time.Hour)
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		v, _, _ := s.Get("cas")
+		assert.Equal(t, []byte("b"), v)
+	})
+
+	t.Run("inspect", func(t *testing.T) {
+		si, ok := s.(StoreInspector)
+		require.True(t, ok)
+
+		require.NoError(t, s.Set("user:1", []byte("a"), time.Hour))
+		require.NoError(t, s.Set("user:2", []byte("b"), 0))
+
+		entries, err := si.Entries("user:")
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+		assert.Equal(t, "user:1", entries[0].Key)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), entries[0].Expires, time.Minute)
+		assert.Equal(t, StoreEntry{Key: "user:2"}, entries[1])
+
+		n, err := si.Purge("user:")
+		require.NoError(t, err)
+		assert.Equal(t, 2, n)
+		_, ok, _ = s.Get("user:1")
+		assert.False(t, ok)
+	})
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+
+	t.Run("expiration", func(t *testing.T) {
+		s := NewMemoryStore()
+		require.NoError(t, s.Set("k", []byte("v"), time.Millisecond))
+		time.Sleep(5 * time.Millisecond)
+		_, ok, _ := s.Get("k")
+		assert.False(t, ok)
+
+		n, _ := s.Incr("k", time.Hour)
+		assert.Equal(t, int64(1), n, "expired key must be recreated")
+	})
+}
+
+func TestStoreAdapters(t *testing.T) {
+	store := NewMemoryStore()
+
+	t.Run("nonces", func(t *testing.T) {
+		nonces := StoreNonces(store)
+		fresh, err := nonces.Use("createPayment alice n1", time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		assert.True(t, fresh)
+
+		fresh, _ = nonces.Use("createPayment alice n1", time.Now().Add(time.Hour))
+		assert.False(t, fresh)
+	})
+
+	t.Run("quotas", func(t *testing.T) {
+		quotas := StoreQuotas(store)
+		reset := time.Now().Add(time.Hour)
+		quotas.Increment("alice addPet", reset) // nolint: errcheck
+		n, err := quotas.Increment("alice addPet", reset)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), n)
+
+		n, _ = quotas.Increment("alice addPet", reset.Add(time.Hour))
+		assert.Equal(t, int64(1), n, "next period must have its own counter")
+	})
+
+	t.Run("sessions", func(t *testing.T) {
+		sessions := StoreSessions(store)
+		s := Session{
+			ID:        "s1",
+			Principal: Principal{ID: "alice", Scopes: []string{"read"}},
+			CSRFToken: "t1",
+			ExpiresAt: time.Now().Add(time.Hour).Round(time.Second),
+		}
+		require.NoError(t, sessions.Save(s))
+
+		loaded, ok, err := sessions.Load("s1")
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, s.Principal, loaded.Principal)
+		assert.True(t, s.ExpiresAt.Equal(loaded.ExpiresAt))
+
+		require.NoError(t, sessions.Delete("s1"))
+		_, ok, _ = sessions.Load("s1")
+		assert.False(t, ok)
+	})
+
+	entries, err := store.Entries("")
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, "nonce:createPayment alice n1", entries[0].Key)
+}
@@ -0,0 +1,205 @@
+package oas
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/go-openapi/spec"
+	"github.com/pkg/errors"
+)
+
+const refPrefixDefinitions = "#/definitions/"
+
+// SubsetByTags returns a new document that contains only operations tagged
+// with any of the given tags. Paths left without operations are removed, as
+// well as definitions not referenced by the remaining operations.
+//
+// This is useful when a service implements only its own slice of a large
+// shared spec: the subset can be used for routing and validation exactly as
+// the whole document. The original document is left intact.
+func (doc *Document) SubsetByTags(tags ...string) (*Document, error) {
+	want := make(map[string]bool, len(tags))
+	for _, t := range tags {
+		want[t] = true
+	}
+
+	orig, err := copySpec(doc.OrigSpec())
+	if err != nil {
+		return nil, errors.Wrap(err, "copy original spec")
+	}
+	flat, err := copySpec(doc.Spec())
+	if err != nil {
+		return nil, errors.Wrap(err, "copy expanded spec")
+	}
+
+	for _, s := range []*spec.Swagger{orig, flat} {
+		filterPathsByTags(s, want)
+		filterTags(s, want)
+	}
+
+	// Definitions are referenced only in the original spec, as the expanded
+	// one has all the references resolved.
+	used, err := usedDefinitions(orig)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range []*spec.Swagger{orig, flat} {
+		for name := range s.Definitions {
+			if !used[name] {
+				delete(s.Definitions, name)
+			}
+		}
+	}
+
+	origBytes, err := json.Marshal(orig)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal original spec")
+	}
+	flatBytes, err := json.Marshal(flat)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal expanded spec")
+	}
+
+	sub, err := embeddedAnalyzed(origBytes, flatBytes)
+	if err != nil {
+		return nil, err
+	}
+	return wrapDocument(sub), nil
+}
+
+// copySpec returns a deep copy of the spec.
+func copySpec(s *spec.Swagger) (*spec.Swagger, error) {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+
+	var cp spec.Swagger
+	if err := json.Unmarshal(b, &cp); err != nil {
+		return nil, err
+	}
+	return &cp, nil
+}
+
+// filterPathsByTags removes operations not tagged with any of the wanted tags
+// from the spec, along with paths left without operations.
+func filterPathsByTags(s *spec.Swagger, want map[string]bool) {
+	if s.Paths == nil {
+		return
+	}
+
+	for path, item := range s.Paths.Paths {
+		ops := []**spec.Operation{
+			&item.Get, &item.Put, &item.Post, &item.Delete,
+			&item.Options, &item.Head, &item.Patch,
+		}
+
+		keep := false
+		for _, op := range ops {
+			if *op == nil {
+				continue
+			}
+			if !hasAnyTag(*op, want) {
+				*op = nil
+				continue
+			}
+			keep = true
+		}
+
+		if !keep {
+			delete(s.Paths.Paths, path)
+			continue
+		}
+		s.Paths.Paths[path] = item
+	}
+}
+
+// filterTags removes tag descriptions not in the wanted tags.
+func filterTags(s *spec.Swagger, want map[string]bool) {
+	var tags []spec.Tag
+	for _, t := range s.Tags {
+		if want[t.Name] {
+			tags = append(tags, t)
+		}
+	}
+	s.Tags = tags
+}
+
+func hasAnyTag(op *spec.Operation, want map[string]bool) bool {
+	for _, t := range op.Tags {
+		if want[t] {
+			return true
+		}
+	}
+	return false
+}
+
+// usedDefinitions returns names of definitions referenced from the spec paths,
+// including definitions referenced transitively from other definitions.
+func usedDefinitions(s *spec.Swagger) (map[string]bool, error) {
+	used := make(map[string]bool)
+
+	var queue []string
+	enqueue := func(v interface{}) error {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return errors.Wrap(err, "marshal spec part")
+		}
+		var raw interface{}
+		if err := json.Unmarshal(b, &raw); err != nil {
+			return errors.Wrap(err, "unmarshal spec part")
+		}
+		for _, name := range collectDefinitionRefs(raw, nil) {
+			if !used[name] {
+				used[name] = true
+				queue = append(queue, name)
+			}
+		}
+		return nil
+	}
+
+	// Shared parameters and responses are kept as is, so definitions
+	// they reference are considered used too.
+	for _, part := range []interface{}{s.Paths, s.Parameters, s.Responses} {
+		if err := enqueue(part); err != nil {
+			return nil, err
+		}
+	}
+
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+
+		def, ok := s.Definitions[name]
+		if !ok {
+			continue
+		}
+		if err := enqueue(def); err != nil {
+			return nil, err
+		}
+	}
+
+	return used, nil
+}
+
+// collectDefinitionRefs walks the raw JSON value and appends names of all
+// referenced definitions to names.
+func collectDefinitionRefs(v interface{}, names []string) []string {
+	switch tv := v.(type) {
+	case map[string]interface{}:
+		for key, val := range tv {
+			if ref, ok := val.(string); ok && key == "$ref" {
+				if i := strings.Index(ref, refPrefixDefinitions); i >= 0 {
+					names = append(names, ref[i+len(refPrefixDefinitions):])
+				}
+				continue
+			}
+			names = collectDefinitionRefs(val, names)
+		}
+	case []interface{}:
+		for _, val := range tv {
+			names = collectDefinitionRefs(val, names)
+		}
+	}
+	return names
+}
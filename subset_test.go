@@ -0,0 +1,49 @@
+package oas
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDocument_SubsetByTags(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+
+	t.Run("pet", func(t *testing.T) {
+		sub, err := doc.SubsetByTags("pet")
+		assert.NoError(t, err)
+
+		assert.ElementsMatch(t, []string{"addPet", "getPetById"}, operationIDs(sub))
+		assert.Contains(t, sub.Spec().Definitions, "Pet")
+		assert.NotContains(t, sub.Spec().Definitions, "ApiResponse")
+		assert.Contains(t, sub.OrigSpec().Definitions, "Pet")
+
+		// Validation info stays exact for the subset.
+		params := sub.Analyzer.ParametersFor("addPet")
+		assert.Len(t, params, 2)
+	})
+
+	t.Run("user", func(t *testing.T) {
+		sub, err := doc.SubsetByTags("user")
+		assert.NoError(t, err)
+
+		assert.ElementsMatch(t, []string{"loginUser"}, operationIDs(sub))
+		assert.Empty(t, sub.Spec().Definitions)
+		assert.Empty(t, sub.Spec().Tags)
+	})
+
+	t.Run("original document is intact", func(t *testing.T) {
+		assert.ElementsMatch(t, []string{"addPet", "getPetById", "loginUser"}, operationIDs(doc))
+		assert.Len(t, doc.Spec().Definitions, 2)
+	})
+}
+
+func operationIDs(doc *Document) []string {
+	var ids []string
+	for _, pathOps := range doc.Analyzer.Operations() {
+		for _, op := range pathOps {
+			ids = append(ids, op.ID)
+		}
+	}
+	return ids
+}
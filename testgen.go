@@ -0,0 +1,436 @@
+package oas
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/go-openapi/spec"
+)
+
+// OperationTestCase is a smoke test case for an operation, generated from
+// the spec by GenerateOperationTests.
+type OperationTestCase struct {
+	// Name describes the test case, e.g. "loginUser: query username missing".
+	Name string
+
+	// OperationID is the id of the operation under test.
+	OperationID string
+
+	// Method is the request method.
+	Method string
+
+	// URL is the request path with query, prefixed with the spec base path.
+	URL string
+
+	// Header is the request header.
+	Header http.Header
+
+	// Body is the request body, if any.
+	Body []byte
+
+	// Valid reports whether the request conforms to the spec, i.e. whether
+	// it is expected to pass validation.
+	Valid bool
+}
+
+// NewRequest returns a new incoming server request for the test case,
+// suitable for passing to an http.Handler for testing.
+func (tc OperationTestCase) NewRequest() *http.Request {
+	var body io.Reader
+	if tc.Body != nil {
+		body = bytes.NewReader(tc.Body)
+	}
+
+	req := httptest.NewRequest(tc.Method, tc.URL, body)
+	for k, vs := range tc.Header {
+		req.Header[k] = append([]string(nil), vs...)
+	}
+	return req
+}
+
+// CheckStatus checks the response status code the handler responded with.
+// Valid requests must not be rejected with 400 Bad Request or 422
+// Unprocessable Entity, and must not cause server errors. Invalid requests
+// must be rejected with 400 Bad Request or 422 Unprocessable Entity.
+func (tc OperationTestCase) CheckStatus(code int) error {
+	rejected := code == http.StatusBadRequest || code == http.StatusUnprocessableEntity
+
+	switch {
+	case tc.Valid && rejected:
+		return fmt.Errorf("%s: valid request is rejected with status %d", tc.Name, code)
+	case tc.Valid && code >= 500:
+		return fmt.Errorf("%s: valid request caused server error with status %d", tc.Name, code)
+	case !tc.Valid && !rejected:
+		return fmt.Errorf("%s: invalid request is not rejected, got status %d", tc.Name, code)
+	}
+	return nil
+}
+
+// GenerateOperationTests generates smoke test cases for all operations in
+// the document. For every operation there is a valid request built from spec
+// examples, defaults and enums, and valid and invalid variants of it that
+// exercise required parameters, enum values, range boundaries and types of
+// query parameters and request body properties.
+//
+// The cases can be run against a handler, e.g. the router with validators,
+// in a table-driven test:
+//
+//  for _, tc := range oas.GenerateOperationTests(doc) {
+//      t.Run(tc.Name, func(t *testing.T) {
+//          w := httptest.NewRecorder()
+//          router.ServeHTTP(w, tc.NewRequest())
+//          if err := tc.CheckStatus(w.Code); err != nil {
+//              t.Error(err)
+//          }
+//      })
+//  }
+//
+// Test cases are returned ordered by operation id.
+func GenerateOperationTests(doc *Document) []OperationTestCase {
+	var ops []opTestGenerator
+	for method, paths := range doc.Analyzer.Operations() {
+		for path, op := range paths {
+			oi := newOperationInfo(doc, path, op)
+			ops = append(ops, opTestGenerator{
+				method:   strings.ToUpper(method),
+				oi:       oi,
+				consumes: oi.consumes,
+			})
+		}
+	}
+	sort.Slice(ops, func(i, j int) bool {
+		return ops[i].oi.operation.ID < ops[j].oi.operation.ID
+	})
+
+	var cases []OperationTestCase
+	for _, g := range ops {
+		cases = append(cases, g.generate()...)
+	}
+	return cases
+}
+
+// opTestGenerator generates test cases for a single operation.
+type opTestGenerator struct {
+	method   string
+	oi       operationInfo
+	consumes []string
+}
+
+// testRequest is a request under construction.
+type testRequest struct {
+	path    map[string]string
+	query   url.Values
+	header  http.Header
+	form    url.Values
+	body    interface{}
+	rawBody []byte
+	hasBody bool
+}
+
+func (r testRequest) clone() testRequest {
+	c := r
+	c.path = make(map[string]string, len(r.path))
+	for k, v := range r.path {
+		c.path[k] = v
+	}
+	c.query = cloneValues(r.query)
+	c.header = http.Header(cloneValues(url.Values(r.header)))
+	c.form = cloneValues(r.form)
+	if obj, ok := r.body.(map[string]interface{}); ok {
+		m := make(map[string]interface{}, len(obj))
+		for k, v := range obj {
+			m[k] = v
+		}
+		c.body = m
+	}
+	return c
+}
+
+func cloneValues(vals url.Values) url.Values {
+	c := make(url.Values, len(vals))
+	for k, vs := range vals {
+		c[k] = append([]string(nil), vs...)
+	}
+	return c
+}
+
+func (g opTestGenerator) generate() []OperationTestCase {
+	base := testRequest{
+		path:   make(map[string]string),
+		query:  make(url.Values),
+		header: make(http.Header),
+		form:   make(url.Values),
+	}
+
+	var bodyParam *spec.Parameter
+	for i, p := range g.oi.params {
+		switch p.In {
+		case "path":
+			base.path[p.Name] = formatParamValue(p, sampleParam(p))[0]
+		case "query":
+			base.query[p.Name] = formatParamValue(p, sampleParam(p))
+		case "header":
+			base.header[http.CanonicalHeaderKey(p.Name)] = formatParamValue(p, sampleParam(p))
+		case "formData":
+			base.form[p.Name] = formatParamValue(p, sampleParam(p))
+		case "body":
+			bodyParam = &g.oi.params[i]
+			base.body = sampleParam(p)
+			base.hasBody = true
+		}
+	}
+
+	opID := g.oi.operation.ID
+	cases := []OperationTestCase{g.newCase(opID+": valid", true, base.clone())}
+
+	for _, p := range g.oi.params {
+		if p.In != "query" {
+			continue
+		}
+
+		if p.Required {
+			r := base.clone()
+			delete(r.query, p.Name)
+			cases = append(cases, g.newCase(fmt.Sprintf("%s: query %s missing", opID, p.Name), false, r))
+		}
+
+		if p.Type == "array" {
+			continue
+		}
+		bounds := numberBounds{
+			min: p.Minimum, exclMin: p.ExclusiveMinimum,
+			max: p.Maximum, exclMax: p.ExclusiveMaximum,
+		}
+		for _, v := range valueVariants(p.Type, p.Enum, bounds, false) {
+			r := base.clone()
+			r.query[p.Name] = []string{fmt.Sprint(v.value)}
+			cases = append(cases, g.newCase(fmt.Sprintf("%s: query %s %s", opID, p.Name, v.name), v.valid, r))
+		}
+	}
+
+	r := base.clone()
+	r.query.Set("unknown_parameter", "1")
+	cases = append(cases, g.newCase(opID+": query unknown parameter", false, r))
+
+	if bodyParam != nil {
+		cases = append(cases, g.bodyCases(base, bodyParam)...)
+	}
+
+	return cases
+}
+
+// bodyCases generates test cases for the request body.
+func (g opTestGenerator) bodyCases(base testRequest, p *spec.Parameter) []OperationTestCase {
+	opID := g.oi.operation.ID
+
+	var cases []OperationTestCase
+
+	if p.Required {
+		r := base.clone()
+		r.hasBody = false
+		cases = append(cases, g.newCase(opID+": body missing", false, r))
+	}
+
+	r := base.clone()
+	r.rawBody = []byte(`{`)
+	cases = append(cases, g.newCase(opID+": body malformed", false, r))
+
+	if _, ok := base.body.(map[string]interface{}); !ok || p.Schema == nil {
+		return cases
+	}
+
+	for _, name := range p.Schema.Required {
+		r := base.clone()
+		delete(r.body.(map[string]interface{}), name)
+		cases = append(cases, g.newCase(fmt.Sprintf("%s: body %s missing", opID, name), false, r))
+	}
+
+	for _, name := range sortedPropertyNames(p.Schema) {
+		prop := p.Schema.Properties[name]
+		typ := schemaType(&prop)
+		if typ == "object" || typ == "array" || typ == "" {
+			continue
+		}
+		bounds := numberBounds{
+			min: prop.Minimum, exclMin: prop.ExclusiveMinimum,
+			max: prop.Maximum, exclMax: prop.ExclusiveMaximum,
+		}
+		for _, v := range valueVariants(typ, prop.Enum, bounds, true) {
+			r := base.clone()
+			r.body.(map[string]interface{})[name] = v.value
+			cases = append(cases, g.newCase(fmt.Sprintf("%s: body %s %s", opID, name, v.name), v.valid, r))
+		}
+	}
+
+	return cases
+}
+
+// newCase builds the test case from the request.
+func (g opTestGenerator) newCase(name string, valid bool, r testRequest) OperationTestCase {
+	path := g.oi.path
+	for k, v := range r.path {
+		path = strings.Replace(path, "{"+k+"}", url.PathEscape(v), -1)
+		path = strings.Replace(path, "{"+k+"+}", url.PathEscape(v), -1)
+	}
+	if len(r.query) > 0 {
+		path += "?" + r.query.Encode()
+	}
+
+	tc := OperationTestCase{
+		Name:        name,
+		OperationID: g.oi.operation.ID,
+		Method:      g.method,
+		URL:         path,
+		Header:      r.header,
+		Valid:       valid,
+	}
+
+	switch {
+	case r.rawBody != nil:
+		tc.Body = r.rawBody
+		tc.Header.Set("Content-Type", g.contentType())
+	case r.hasBody:
+		b, err := json.Marshal(r.body)
+		if err != nil {
+			panic(fmt.Sprintf("oas: marshal sample body for operation %s: %s", g.oi.operation.ID, err))
+		}
+		tc.Body = b
+		tc.Header.Set("Content-Type", g.contentType())
+	case len(r.form) > 0:
+		tc.Body = []byte(r.form.Encode())
+		tc.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	return tc
+}
+
+// contentType returns the JSON media type the operation consumes.
+func (g opTestGenerator) contentType() string {
+	for _, ct := range g.consumes {
+		if strings.Contains(ct, "json") {
+			return ct
+		}
+	}
+	return "application/json"
+}
+
+// valueVariant is a named variant of a primitive value.
+type valueVariant struct {
+	name  string
+	value interface{}
+	valid bool
+}
+
+// valueVariants returns valid and invalid variants of a primitive value
+// of the type, exercising enum values, range boundaries and the type itself.
+// When typed is true, the values are for a JSON document, so string type
+// can be violated too.
+func valueVariants(typ string, enum []interface{}, bounds numberBounds, typed bool) []valueVariant {
+	var vs []valueVariant
+
+	if len(enum) > 0 {
+		vs = append(vs, valueVariant{name: "enum first", value: enum[0], valid: true})
+		if len(enum) > 1 {
+			vs = append(vs, valueVariant{name: "enum last", value: enum[len(enum)-1], valid: true})
+		}
+		vs = append(vs, valueVariant{name: "not in enum", value: notInEnum(typ, enum), valid: false})
+	}
+
+	if typ == "integer" || typ == "number" {
+		integer := typ == "integer"
+		if low, ok := bounds.lowest(integer); ok {
+			vs = append(vs,
+				valueVariant{name: "minimum", value: numberValue(low, integer), valid: true},
+				valueVariant{name: "below minimum", value: numberValue(below(low, integer), integer), valid: false},
+			)
+		}
+		if high, ok := bounds.highest(integer); ok {
+			vs = append(vs,
+				valueVariant{name: "maximum", value: numberValue(high, integer), valid: true},
+				valueVariant{name: "above maximum", value: numberValue(above(high, integer), integer), valid: false},
+			)
+		}
+	}
+
+	switch {
+	case typ == "integer" || typ == "number" || typ == "boolean":
+		vs = append(vs, valueVariant{name: "wrong type", value: "abc", valid: false})
+	case typ == "string" && typed:
+		vs = append(vs, valueVariant{name: "wrong type", value: 1, valid: false})
+	}
+
+	return vs
+}
+
+// notInEnum returns a value of the type that is not in the enum.
+func notInEnum(typ string, enum []interface{}) interface{} {
+	if typ == "integer" || typ == "number" {
+		max := 0.0
+		for _, e := range enum {
+			if f, ok := toFloat(e); ok && f > max {
+				max = f
+			}
+		}
+		return numberValue(math.Floor(max)+1, typ == "integer")
+	}
+
+	s := "not_in_enum"
+	for {
+		found := false
+		for _, e := range enum {
+			if fmt.Sprint(e) == s {
+				found = true
+			}
+		}
+		if !found {
+			return s
+		}
+		s += "_"
+	}
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	default:
+		return 0, false
+	}
+}
+
+func below(v float64, integer bool) float64 {
+	if integer {
+		return v - 1
+	}
+	return math.Nextafter(v, math.Inf(-1))
+}
+
+func above(v float64, integer bool) float64 {
+	if integer {
+		return v + 1
+	}
+	return math.Nextafter(v, math.Inf(1))
+}
+
+func numberValue(v float64, integer bool) interface{} {
+	if integer {
+		return int64(v)
+	}
+	return v
+}
@@ -0,0 +1,81 @@
+package oas
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateOperationTests(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+
+	cases := GenerateOperationTests(doc)
+
+	valid := make(map[string]bool)
+	for _, tc := range cases {
+		valid[tc.Name] = tc.Valid
+	}
+
+	expected := map[string]bool{
+		"addPet: valid":                       true,
+		"addPet: query debug wrong type":      false,
+		"addPet: body missing":                false,
+		"addPet: body malformed":              false,
+		"addPet: body name missing":           false,
+		"addPet: body status enum first":      true,
+		"addPet: body status enum last":       true,
+		"addPet: body status not in enum":     false,
+		"addPet: body age wrong type":         false,
+		"getPetById: valid":                   true,
+		"getPetById: query unknown parameter": false,
+		"loginUser: valid":                    true,
+		"loginUser: query username missing":   false,
+		"loginUser: query password missing":   false,
+	}
+	for name, v := range expected {
+		got, ok := valid[name]
+		if assert.True(t, ok, "case %q is not generated", name) {
+			assert.Equal(t, v, got, name)
+		}
+	}
+
+	assert.Equal(t, "addPet", cases[0].OperationID)
+	assert.Equal(t, "loginUser", cases[len(cases)-1].OperationID)
+
+	basis := NewResolvingBasis(SpecAdapterName, doc, BasisStrict(false))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/pet", handleAddPet)
+	mux.HandleFunc("/v2/pet/", handleGetPetByID)
+	mux.HandleFunc("/v2/user/login", handleUserLogin)
+
+	h := SpecMatcherMiddleware(doc)(
+		basis.QueryValidator(WithProblemHandler(problemHandlerResponseWriter()))(
+			basis.RequestBodyValidator(WithProblemHandler(problemHandlerResponseWriter()))(mux),
+		),
+	)
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, tc.NewRequest())
+
+			assert.NoError(t, tc.CheckStatus(w.Code))
+		})
+	}
+}
+
+func TestOperationTestCase_CheckStatus(t *testing.T) {
+	valid := OperationTestCase{Name: "valid", Valid: true}
+	assert.NoError(t, valid.CheckStatus(http.StatusOK))
+	assert.NoError(t, valid.CheckStatus(http.StatusNotFound))
+	assert.EqualError(t, valid.CheckStatus(http.StatusBadRequest), "valid: valid request is rejected with status 400")
+	assert.EqualError(t, valid.CheckStatus(http.StatusInternalServerError), "valid: valid request caused server error with status 500")
+
+	invalid := OperationTestCase{Name: "invalid"}
+	assert.NoError(t, invalid.CheckStatus(http.StatusBadRequest))
+	assert.NoError(t, invalid.CheckStatus(http.StatusUnprocessableEntity))
+	assert.EqualError(t, invalid.CheckStatus(http.StatusOK), "invalid: invalid request is not rejected, got status 200")
+}
@@ -0,0 +1,89 @@
+package oas
+
+import (
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Throttle describes the limit a request is counted against, e.g. a quota.
+type Throttle struct {
+	// Limit is the number of requests allowed per period.
+	Limit int64
+
+	// Remaining is the number of requests remaining in the current period.
+	Remaining int64
+
+	// Reset is the time when the current period ends.
+	Reset time.Time
+
+	// RetryAfter is the time the client should wait before retrying the
+	// rejected request, as computed by RetryAfterFunc.
+	RetryAfter time.Duration
+}
+
+// RetryAfterFunc computes the time the client should wait before retrying
+// the request rejected by the throttle. The returned duration is rounded up
+// to seconds for the Retry-After header.
+type RetryAfterFunc func(t Throttle, now time.Time) time.Duration
+
+// RetryAfterReset returns a RetryAfterFunc that tells clients to retry when
+// the current period ends. It is the default strategy.
+func RetryAfterReset() RetryAfterFunc {
+	return func(t Throttle, now time.Time) time.Duration {
+		return t.Reset.Sub(now)
+	}
+}
+
+// RetryAfterJitter returns a RetryAfterFunc that tells clients to retry when
+// the current period ends plus a random delay of up to max, so rejected
+// clients do not retry all at once when the period ends.
+func RetryAfterJitter(max time.Duration) RetryAfterFunc {
+	return func(t Throttle, now time.Time) time.Duration {
+		d := t.Reset.Sub(now)
+		if max > 0 {
+			d += time.Duration(rand.Int63n(int64(max)))
+		}
+		return d
+	}
+}
+
+// WithRetryAfter returns a middleware option that sets the strategy of
+// Retry-After header values of throttled requests. By default,
+// RetryAfterReset is used.
+func WithRetryAfter(fn RetryAfterFunc) MiddlewareOption {
+	return func(opts *MiddlewareOptions) {
+		opts.retryAfter = fn
+	}
+}
+
+// setThrottleHeaders sets the rate limit headers of the throttle: both
+// X-RateLimit-* headers, where the reset is Unix epoch seconds, and standard
+// RateLimit-* headers, where the reset is seconds until the period ends.
+// Retry-After is set only for rejected requests.
+func setThrottleHeaders(h http.Header, t Throttle, now time.Time, rejected bool) {
+	limit := strconv.FormatInt(t.Limit, 10)
+	remaining := strconv.FormatInt(t.Remaining, 10)
+
+	h.Set(HeaderQuotaLimit, limit)
+	h.Set(HeaderQuotaRemaining, remaining)
+	h.Set(HeaderQuotaReset, strconv.FormatInt(t.Reset.Unix(), 10))
+
+	h.Set("RateLimit-Limit", limit)
+	h.Set("RateLimit-Remaining", remaining)
+	h.Set("RateLimit-Reset", strconv.FormatInt(ceilSeconds(t.Reset.Sub(now)), 10))
+
+	if rejected {
+		h.Set("Retry-After", strconv.FormatInt(ceilSeconds(t.RetryAfter), 10))
+	}
+}
+
+// ceilSeconds returns the duration in seconds, rounded up. Negative
+// durations are zero.
+func ceilSeconds(d time.Duration) int64 {
+	if d <= 0 {
+		return 0
+	}
+	return int64((d + time.Second - 1) / time.Second)
+}
@@ -0,0 +1,130 @@
+package oas
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+
+	"github.com/hypnoglow/oas2/validate"
+)
+
+// ValidatingTransport is an http.RoundTripper that validates outgoing
+// requests against the document before sending them. The request is matched
+// to an operation by its method and URL path template. Requests that do not
+// match any operation or do not conform to the operation are not sent,
+// and an error is returned instead.
+//
+// This allows to catch contract violations on the producer side, e.g. when
+// sending webhooks described by the document:
+//
+//  client := &http.Client{
+//      Transport: oas.NewValidatingTransport(doc, http.DefaultTransport),
+//  }
+type ValidatingTransport struct {
+	doc  *Document
+	base http.RoundTripper
+
+	jsonSelectors []*regexp.Regexp
+}
+
+// NewValidatingTransport returns a new ValidatingTransport that sends valid
+// requests using the base transport. If base is nil, http.DefaultTransport
+// is used.
+func NewValidatingTransport(doc *Document, base http.RoundTripper) *ValidatingTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &ValidatingTransport{
+		doc:           doc,
+		base:          base,
+		jsonSelectors: parseMiddlewareOptions().jsonSelectors,
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *ValidatingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	route, _, ok := t.doc.specMatcher().match(req.Method, req.URL.EscapedPath())
+	if !ok {
+		closeBody(req)
+		return nil, fmt.Errorf("oas: outgoing request %s %s does not match any operation", req.Method, req.URL.Path)
+	}
+
+	_, path, op, _ := t.doc.Analyzer.OperationForName(route.id)
+	oi := newOperationInfo(t.doc, path, op)
+
+	req, errs, err := t.validate(req, oi)
+	if err != nil {
+		closeBody(req)
+		return nil, err
+	}
+	if len(errs) > 0 {
+		closeBody(req)
+		return nil, newMultiError(fmt.Sprintf("oas: outgoing request does not match operation %s", op.ID), errs...)
+	}
+
+	return t.base.RoundTrip(req)
+}
+
+// validate validates the request. As the request body has to be read for
+// validation, validate returns a shallow copy of the request with the body
+// that can be read again.
+func (t *ValidatingTransport) validate(req *http.Request, oi operationInfo) (*http.Request, []error, error) {
+	errs := validate.Query(oi.params, req.URL.Query())
+
+	if req.Body == nil || req.Body == http.NoBody {
+		for _, p := range oi.params {
+			if p.In == "body" && p.Required {
+				errs = append(errs, fmt.Errorf("request body is empty, but the operation requires non-empty body"))
+			}
+		}
+		return req, errs, nil
+	}
+
+	contentType := req.Header.Get("Content-Type")
+	if !matchMediaType(contentType, oi.consumes) {
+		errs = append(errs, fmt.Errorf("Content-Type header of the request does not match any of the media types the operation can consume"))
+	}
+
+	if !t.matchJSON(contentType) {
+		return req, errs, nil
+	}
+
+	b, err := ioutil.ReadAll(req.Body)
+	req.Body.Close() // nolint: errcheck
+	if err != nil {
+		return req, nil, fmt.Errorf("oas: read outgoing request body: %s", err)
+	}
+
+	r := new(http.Request)
+	*r = *req
+	r.Body = ioutil.NopCloser(bytes.NewReader(b))
+
+	var payload interface{}
+	if err := json.Unmarshal(b, &payload); err != nil {
+		return r, append(errs, fmt.Errorf("request body contains invalid json: %s", err)), nil
+	}
+
+	return r, append(errs, validate.Body(oi.params, payload)...), nil
+}
+
+// matchJSON checks if the content type matches any JSON selector.
+func (t *ValidatingTransport) matchJSON(contentType string) bool {
+	for _, selector := range t.jsonSelectors {
+		if selector.MatchString(contentType) {
+			return true
+		}
+	}
+	return false
+}
+
+// closeBody closes the request body, as RoundTripper must always close it,
+// even on errors.
+func closeBody(req *http.Request) {
+	if req.Body != nil {
+		req.Body.Close() // nolint: errcheck
+	}
+}
@@ -0,0 +1,103 @@
+package oas
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidatingTransport(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+
+	var received string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		b, _ := ioutil.ReadAll(req.Body)
+		received = req.Method + " " + req.URL.RequestURI() + " " + string(b)
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: NewValidatingTransport(doc, nil)}
+
+	testCases := map[string]struct {
+		method           string
+		url              string
+		contentType      string
+		body             string
+		expectedError    string
+		expectedReceived string
+	}{
+		"valid request": {
+			method:           http.MethodPost,
+			url:              "/v2/pet?debug=true",
+			contentType:      "application/json",
+			body:             `{"name":"Rex","age":3}`,
+			expectedReceived: `POST /v2/pet?debug=true {"name":"Rex","age":3}`,
+		},
+		"invalid query": {
+			method:        http.MethodGet,
+			url:           "/v2/user/login?username=john",
+			expectedError: "oas: outgoing request does not match operation loginUser: param password is required",
+		},
+		"invalid body": {
+			method:        http.MethodPost,
+			url:           "/v2/pet",
+			contentType:   "application/json",
+			body:          `{"name":"Rex"}`,
+			expectedError: "oas: outgoing request does not match operation addPet: age in body is required",
+		},
+		"missing body": {
+			method:        http.MethodPost,
+			url:           "/v2/pet",
+			expectedError: "oas: outgoing request does not match operation addPet: request body is empty, but the operation requires non-empty body",
+		},
+		"wrong content type": {
+			method:        http.MethodPost,
+			url:           "/v2/pet",
+			contentType:   "text/plain",
+			body:          `Rex`,
+			expectedError: "oas: outgoing request does not match operation addPet: Content-Type header of the request does not match any of the media types the operation can consume",
+		},
+		"unknown operation": {
+			method:        http.MethodDelete,
+			url:           "/v2/pet/1",
+			expectedError: "oas: outgoing request DELETE /v2/pet/1 does not match any operation",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			received = ""
+
+			var body *strings.Reader
+			if tc.body != "" {
+				body = strings.NewReader(tc.body)
+			}
+			var req *http.Request
+			if body != nil {
+				req, _ = http.NewRequest(tc.method, srv.URL+tc.url, body)
+			} else {
+				req, _ = http.NewRequest(tc.method, srv.URL+tc.url, nil)
+			}
+			if tc.contentType != "" {
+				req.Header.Set("Content-Type", tc.contentType)
+			}
+
+			resp, err := client.Do(req)
+			if tc.expectedError != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tc.expectedError)
+				}
+				assert.Empty(t, received)
+				return
+			}
+
+			assert.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, tc.expectedReceived, received)
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package oas
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/go-openapi/spec"
+	"golang.org/x/text/unicode/norm"
+)
+
+// UnicodeNormalizationExtension is the parameter extension that overrides
+// WithUnicodeNormalization and DecodeNormalizeUnicode for the parameter:
+//
+//  parameters:
+//  - name: password
+//    in: query
+//    type: string
+//    x-oas-unicode-normalization: false
+//
+// The value is a boolean. For body parameters, it applies to all the string
+// values of the body.
+const UnicodeNormalizationExtension = "x-oas-unicode-normalization"
+
+// normalizesUnicode checks if the values of the parameter should be
+// normalized, either by UnicodeNormalizationExtension of the parameter, or
+// by def if the parameter has no such extension.
+func normalizesUnicode(p *spec.Parameter, def bool) bool {
+	if v, ok := p.Extensions[UnicodeNormalizationExtension].(bool); ok {
+		return v
+	}
+	return def
+}
+
+// normalizeValues normalizes the values to NFC in place. It returns true if
+// any value was changed.
+func normalizeValues(vals []string) bool {
+	changed := false
+	for i, v := range vals {
+		if !norm.NFC.IsNormalString(v) {
+			vals[i] = norm.NFC.String(v)
+			changed = true
+		}
+	}
+	return changed
+}
+
+// normalizeUnicodeQuery rewrites values of the query params that should be
+// normalized in the request query to NFC.
+func normalizeUnicodeQuery(params []spec.Parameter, req *http.Request, def bool) {
+	query := req.URL.Query()
+	changed := false
+	for i := range params {
+		p := &params[i]
+		if p.In != "query" || !normalizesUnicode(p, def) {
+			continue
+		}
+		if normalizeValues(query[p.Name]) {
+			changed = true
+		}
+	}
+	if changed {
+		req.URL.RawQuery = query.Encode()
+	}
+}
+
+// normalizeUnicodeBody normalizes the string values of the JSON request
+// body to NFC. The body is rewritten only if any value is changed, so
+// numbers are kept as is, but the properties of rewritten objects are
+// sorted. The body that is not valid JSON is left as is for the validator
+// to report. Request body can be read again later.
+func normalizeUnicodeBody(req *http.Request) error {
+	data, err := ioutil.ReadAll(req.Body)
+	req.Body.Close() // nolint: errcheck
+	req.Body = ioutil.NopCloser(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("read request body: %s", err)
+	}
+
+	// Escaped characters can be in any form, so only bodies that have no
+	// escapes and are normalized as a whole are skipped.
+	if norm.NFC.IsNormal(data) && !bytes.Contains(data, []byte(`\u`)) {
+		return nil
+	}
+
+	var payload interface{}
+	if err := newJSONDecoder(bytes.NewReader(data), true).Decode(&payload); err != nil {
+		return nil
+	}
+	payload, changed := normalizeJSONStrings(payload)
+	if !changed {
+		return nil
+	}
+
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(payload); err != nil {
+		return fmt.Errorf("encode normalized request body: %s", err)
+	}
+
+	data = bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+	req.Body = ioutil.NopCloser(bytes.NewReader(data))
+	req.ContentLength = int64(len(data))
+	req.Header.Set("Content-Length", strconv.Itoa(len(data)))
+	return nil
+}
+
+// normalizeJSONStrings normalizes the string values of the decoded JSON to
+// NFC. It returns true if any value was changed. Property names are not
+// changed, since they are defined by the schema.
+func normalizeJSONStrings(v interface{}) (interface{}, bool) {
+	switch v := v.(type) {
+	case string:
+		if norm.NFC.IsNormalString(v) {
+			return v, false
+		}
+		return norm.NFC.String(v), true
+	case map[string]interface{}:
+		changed := false
+		for k, val := range v {
+			if n, ok := normalizeJSONStrings(val); ok {
+				v[k] = n
+				changed = true
+			}
+		}
+		return v, changed
+	case []interface{}:
+		changed := false
+		for i, val := range v {
+			if n, ok := normalizeJSONStrings(val); ok {
+				v[i] = n
+				changed = true
+			}
+		}
+		return v, changed
+	}
+	return v, false
+}
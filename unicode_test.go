@@ -0,0 +1,136 @@
+package oas
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryValidator_unicodeNormalization(t *testing.T) {
+	doc := loadDocBytes([]byte(`
+swagger: "2.0"
+info:
+  title: Test
+  version: 1.0.0
+paths:
+  /items:
+    get:
+      operationId: findItems
+      parameters:
+      - name: q
+        in: query
+        type: string
+        maxLength: 4
+      - name: raw
+        in: query
+        type: string
+        x-oas-unicode-normalization: false
+      responses:
+        200:
+          description: OK
+`))
+	params := doc.Analyzer.ParametersFor("findItems")
+
+	testCases := map[string]struct {
+		normalize      bool
+		expectedStatus int
+		expectedBody   string
+	}{
+		"normalized": {
+			normalize:      true,
+			expectedStatus: http.StatusOK,
+			expectedBody:   fmt.Sprintf("q: %q, raw: %q", "caf\u00e9", "cafe\u0301"),
+		},
+		"not normalized": {
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "{\"errors\":[{\"message\":\"q in query should be at most 4 chars long\",\"field\":\"q\",\"value\":\"cafe\u0301\"}]}",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			v := &queryValidator{
+				next: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+					fmt.Fprintf(w, "q: %q, raw: %q", req.URL.Query().Get("q"), req.URL.Query().Get("raw"))
+				}),
+				problemHandler:   problemHandlerResponseWriter(),
+				normalizeUnicode: tc.normalize,
+			}
+
+			q := url.Values{"q": {"cafe\u0301"}, "raw": {"cafe\u0301"}}
+			req := httptest.NewRequest(http.MethodGet, "/items?"+q.Encode(), nil)
+			w := httptest.NewRecorder()
+			v.ServeHTTP(w, req, params, true)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, tc.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestRequestBodyValidator_unicodeNormalization(t *testing.T) {
+	testCases := map[string]struct {
+		normalize    bool
+		body         string
+		expectedBody string
+	}{
+		"normalized": {
+			normalize:    true,
+			body:         "{\"name\":\"cafe\u0301\",\"age\":7}",
+			expectedBody: "pet name: caf\u00e9",
+		},
+		"normalized escape": {
+			normalize:    true,
+			body:         `{"name":"cafe\u0301","age":7}`,
+			expectedBody: "pet name: caf\u00e9",
+		},
+		"not normalized": {
+			body:         "{\"name\":\"cafe\u0301\",\"age\":7}",
+			expectedBody: "pet name: cafe\u0301",
+		},
+	}
+
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+	params := doc.Analyzer.ParametersFor("addPet")
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			v := &requestBodyValidator{
+				next:             http.HandlerFunc(handleAddPet),
+				jsonSelectors:    []*regexp.Regexp{contentTypeSelectorRegexJSON},
+				normalizeUnicode: tc.normalize,
+				problemHandler:   newProblemHandlerErrorResponder(),
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/v2/pet", bytes.NewBufferString(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			v.ServeHTTP(w, req, params, true)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tc.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestDecodeNormalizeUnicode(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+	params := doc.Analyzer.ParametersFor("loginUser")
+
+	var in struct {
+		Username string `oas:"username"`
+	}
+	q := url.Values{"username": {"jose\u0301"}}
+	if err := DecodeQueryParams(params, q, &in, DecodeNormalizeUnicode(true)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	assert.Equal(t, "jos\u00e9", in.Username)
+	assert.Equal(t, "jose\u0301", q.Get("username"))
+}
@@ -0,0 +1,258 @@
+package oas
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// UsageRecord is the usage of an operation by a principal within a time
+// window.
+type UsageRecord struct {
+	WindowStart   time.Time `json:"windowStart"`
+	WindowEnd     time.Time `json:"windowEnd"`
+	Principal     string    `json:"principal"`
+	OperationID   string    `json:"operationId"`
+	Calls         int64     `json:"calls"`
+	RequestBytes  int64     `json:"requestBytes"`
+	ResponseBytes int64     `json:"responseBytes"`
+}
+
+// UsageExporter exports usage records, e.g. to a billing system.
+type UsageExporter interface {
+	Export(ctx context.Context, records []UsageRecord) error
+}
+
+// UsageExporterFunc is a function that implements UsageExporter.
+type UsageExporterFunc func(ctx context.Context, records []UsageRecord) error
+
+// Export implements UsageExporter.
+func (f UsageExporterFunc) Export(ctx context.Context, records []UsageRecord) error {
+	return f(ctx, records)
+}
+
+// usageKey is the key of a usage record.
+type usageKey struct {
+	windowStart time.Time
+	principal   string
+	operationID string
+}
+
+// UsageRecorder is a Subscriber that aggregates BytesTransferred events,
+// published by ByteAccounting middleware, into usage records per principal
+// and operation over time windows, and flushes them to the exporter:
+//
+//  recorder := oas.NewUsageRecorder(oas.NewCSVUsageExporter(f), time.Hour)
+//  defer oas.Subscribe(recorder)()
+//  go recorder.Run(ctx, time.Minute)
+//
+// Requests without a principal are recorded with an empty principal.
+type UsageRecorder struct {
+	exporter UsageExporter
+	window   time.Duration
+
+	// now returns the current time. It is replaced in tests.
+	now func() time.Time
+
+	mx      sync.Mutex
+	records map[usageKey]*UsageRecord
+}
+
+// NewUsageRecorder returns a new usage recorder that aggregates usage over
+// windows of the duration. It panics if the exporter is nil or the window
+// is not positive.
+func NewUsageRecorder(exporter UsageExporter, window time.Duration) *UsageRecorder {
+	if exporter == nil {
+		panic("oas: NewUsageRecorder exporter is nil")
+	}
+	if window <= 0 {
+		panic(fmt.Sprintf("oas: NewUsageRecorder window %s is not positive", window))
+	}
+	return &UsageRecorder{
+		exporter: exporter,
+		window:   window,
+		now:      time.Now,
+		records:  make(map[usageKey]*UsageRecord),
+	}
+}
+
+// HandleEvent implements Subscriber.
+func (r *UsageRecorder) HandleEvent(e Event) {
+	bt, ok := e.(BytesTransferred)
+	if !ok {
+		return
+	}
+
+	start := r.now().Truncate(r.window)
+
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	r.add(UsageRecord{
+		WindowStart:   start,
+		WindowEnd:     start.Add(r.window),
+		Principal:     bt.Principal,
+		OperationID:   bt.OperationID,
+		Calls:         1,
+		RequestBytes:  bt.RequestBytes,
+		ResponseBytes: bt.ResponseBytes,
+	})
+}
+
+// add merges the record into the aggregated records.
+func (r *UsageRecorder) add(rec UsageRecord) {
+	key := usageKey{windowStart: rec.WindowStart, principal: rec.Principal, operationID: rec.OperationID}
+	agg, ok := r.records[key]
+	if !ok {
+		r.records[key] = &rec
+		return
+	}
+	agg.Calls += rec.Calls
+	agg.RequestBytes += rec.RequestBytes
+	agg.ResponseBytes += rec.ResponseBytes
+}
+
+// Flush exports the records of the windows that are over. If the export
+// fails, the records are kept to be exported by the next flush.
+func (r *UsageRecorder) Flush(ctx context.Context) error {
+	return r.flush(ctx, false)
+}
+
+// flush exports the records of the windows that are over, or all the
+// records if all is true.
+func (r *UsageRecorder) flush(ctx context.Context, all bool) error {
+	now := r.now()
+
+	r.mx.Lock()
+	var records []UsageRecord
+	for key, rec := range r.records {
+		if all || !rec.WindowEnd.After(now) {
+			records = append(records, *rec)
+			delete(r.records, key)
+		}
+	}
+	r.mx.Unlock()
+
+	if len(records) == 0 {
+		return nil
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		a, b := records[i], records[j]
+		if !a.WindowStart.Equal(b.WindowStart) {
+			return a.WindowStart.Before(b.WindowStart)
+		}
+		if a.Principal != b.Principal {
+			return a.Principal < b.Principal
+		}
+		return a.OperationID < b.OperationID
+	})
+
+	if err := r.exporter.Export(ctx, records); err != nil {
+		r.mx.Lock()
+		for _, rec := range records {
+			r.add(rec)
+		}
+		r.mx.Unlock()
+		return fmt.Errorf("export usage: %s", err)
+	}
+	return nil
+}
+
+// Run flushes the records every interval until the context is done, then
+// flushes all the records, including the ones of the current window.
+// Export errors are logged to the standard logger.
+func (r *UsageRecorder) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := r.Flush(ctx); err != nil {
+				log.Printf("[WARN] oas usage recorder: %v", err)
+			}
+		case <-ctx.Done():
+			if err := r.flush(context.Background(), true); err != nil {
+				log.Printf("[WARN] oas usage recorder: %v", err)
+			}
+			return
+		}
+	}
+}
+
+// NewCSVUsageExporter returns a usage exporter that writes records to w as
+// CSV, with the header before the first record.
+func NewCSVUsageExporter(w io.Writer) UsageExporter {
+	var (
+		mx     sync.Mutex
+		header bool
+	)
+	return UsageExporterFunc(func(ctx context.Context, records []UsageRecord) error {
+		mx.Lock()
+		defer mx.Unlock()
+
+		cw := csv.NewWriter(w)
+		if !header {
+			cw.Write([]string{"window_start", "window_end", "principal", "operation_id", "calls", "request_bytes", "response_bytes"}) // nolint: errcheck
+			header = true
+		}
+		for _, rec := range records {
+			cw.Write([]string{ // nolint: errcheck
+				rec.WindowStart.UTC().Format(time.RFC3339),
+				rec.WindowEnd.UTC().Format(time.RFC3339),
+				rec.Principal,
+				rec.OperationID,
+				strconv.FormatInt(rec.Calls, 10),
+				strconv.FormatInt(rec.RequestBytes, 10),
+				strconv.FormatInt(rec.ResponseBytes, 10),
+			})
+		}
+		cw.Flush()
+		return cw.Error()
+	})
+}
+
+// NewWebhookUsageExporter returns a usage exporter that posts records to
+// the url as JSON:
+//
+//  {"records": [{"windowStart": "...", "principal": "...", ...}]}
+//
+// Responses with status other than 2xx are errors. If client is nil,
+// http.DefaultClient is used.
+func NewWebhookUsageExporter(url string, client *http.Client) UsageExporter {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return UsageExporterFunc(func(ctx context.Context, records []UsageRecord) error {
+		data, err := currentJSONAPI().Marshal(map[string]interface{}{"records": records})
+		if err != nil {
+			return fmt.Errorf("encode records: %s", err)
+		}
+
+		req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := client.Do(req.WithContext(ctx))
+		if err != nil {
+			return err
+		}
+		resp.Body.Close() // nolint: errcheck
+
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,107 @@
+package oas
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsageRecorder(t *testing.T) {
+	var (
+		exported []UsageRecord
+		fail     bool
+	)
+	exporter := UsageExporterFunc(func(ctx context.Context, records []UsageRecord) error {
+		if fail {
+			return errors.New("unavailable")
+		}
+		exported = append(exported, records...)
+		return nil
+	})
+
+	now := time.Date(2018, 5, 1, 10, 15, 0, 0, time.UTC)
+	r := NewUsageRecorder(exporter, time.Hour)
+	r.now = func() time.Time { return now }
+
+	r.HandleEvent(BytesTransferred{OperationID: "getPet", Principal: "alice", RequestBytes: 0, ResponseBytes: 100})
+	r.HandleEvent(BytesTransferred{OperationID: "getPet", Principal: "alice", RequestBytes: 0, ResponseBytes: 50})
+	r.HandleEvent(BytesTransferred{OperationID: "addPet", Principal: "alice", RequestBytes: 30, ResponseBytes: 10})
+	r.HandleEvent(SpecReloaded{})
+
+	require.NoError(t, r.Flush(context.Background()))
+	assert.Empty(t, exported, "current window must not be exported")
+
+	now = now.Add(time.Hour)
+	r.HandleEvent(BytesTransferred{OperationID: "getPet", Principal: "bob", ResponseBytes: 70})
+
+	fail = true
+	assert.EqualError(t, r.Flush(context.Background()), "export usage: unavailable")
+
+	fail = false
+	require.NoError(t, r.Flush(context.Background()))
+
+	start := time.Date(2018, 5, 1, 10, 0, 0, 0, time.UTC)
+	assert.Equal(t, []UsageRecord{
+		{WindowStart: start, WindowEnd: start.Add(time.Hour), Principal: "alice", OperationID: "addPet", Calls: 1, RequestBytes: 30, ResponseBytes: 10},
+		{WindowStart: start, WindowEnd: start.Add(time.Hour), Principal: "alice", OperationID: "getPet", Calls: 2, ResponseBytes: 150},
+	}, exported)
+
+	exported = nil
+	require.NoError(t, r.flush(context.Background(), true))
+	assert.Equal(t, []UsageRecord{
+		{WindowStart: start.Add(time.Hour), WindowEnd: start.Add(2 * time.Hour), Principal: "bob", OperationID: "getPet", Calls: 1, ResponseBytes: 70},
+	}, exported)
+}
+
+func TestNewCSVUsageExporter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	e := NewCSVUsageExporter(buf)
+
+	start := time.Date(2018, 5, 1, 10, 0, 0, 0, time.UTC)
+	rec := UsageRecord{WindowStart: start, WindowEnd: start.Add(time.Hour), Principal: "alice", OperationID: "getPet", Calls: 2, ResponseBytes: 150}
+	require.NoError(t, e.Export(context.Background(), []UsageRecord{rec}))
+	require.NoError(t, e.Export(context.Background(), []UsageRecord{rec}))
+
+	expected := "window_start,window_end,principal,operation_id,calls,request_bytes,response_bytes\n" +
+		"2018-05-01T10:00:00Z,2018-05-01T11:00:00Z,alice,getPet,2,0,150\n" +
+		"2018-05-01T10:00:00Z,2018-05-01T11:00:00Z,alice,getPet,2,0,150\n"
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestNewWebhookUsageExporter(t *testing.T) {
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		data, _ := ioutil.ReadAll(req.Body)
+		body = string(data)
+		if req.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+
+	start := time.Date(2018, 5, 1, 10, 0, 0, 0, time.UTC)
+	records := []UsageRecord{{WindowStart: start, WindowEnd: start.Add(time.Hour), Principal: "alice", OperationID: "getPet", Calls: 1}}
+
+	err := NewWebhookUsageExporter(srv.URL+"/usage", nil).Export(context.Background(), records)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"records": [{
+		"windowStart": "2018-05-01T10:00:00Z",
+		"windowEnd": "2018-05-01T11:00:00Z",
+		"principal": "alice",
+		"operationId": "getPet",
+		"calls": 1,
+		"requestBytes": 0,
+		"responseBytes": 0
+	}]}`, body)
+
+	err = NewWebhookUsageExporter(srv.URL+"/fail", nil).Export(context.Background(), records)
+	assert.EqualError(t, err, "webhook responded with status 502")
+}
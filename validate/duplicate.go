@@ -0,0 +1,89 @@
+package validate
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-openapi/spec"
+)
+
+// ErrDuplicate is matched by DuplicateParamError.
+var ErrDuplicate = errors.New("value is duplicated")
+
+// DuplicateParamError describes a non-array parameter that appears more
+// than once, e.g. "?age=1&age=2".
+type DuplicateParamError struct {
+	// Param is the parameter name.
+	Param string
+
+	// Values are the values of all the occurrences of the parameter.
+	Values []string
+}
+
+func (e DuplicateParamError) Error() string {
+	return fmt.Sprintf("param %s must be passed once, got %d values", e.Param, len(e.Values))
+}
+
+// Field implements ValidationError.
+func (e DuplicateParamError) Field() string {
+	return e.Param
+}
+
+// Value implements ValidationError.
+func (e DuplicateParamError) Value() interface{} {
+	return e.Values
+}
+
+// Is reports whether the target is ErrDuplicate.
+func (e DuplicateParamError) Is(target error) bool {
+	return target == ErrDuplicate
+}
+
+// DuplicatePolicy defines how the values of a non-array parameter that
+// appears more than once are handled.
+type DuplicatePolicy int
+
+// Duplicate policies.
+const (
+	// DuplicateReject rejects the parameter with DuplicateParamError. This
+	// is the default policy.
+	DuplicateReject DuplicatePolicy = iota
+
+	// DuplicateFirstWins takes the value of the first occurrence.
+	DuplicateFirstWins
+
+	// DuplicateLastWins takes the value of the last occurrence.
+	DuplicateLastWins
+)
+
+// Deduplicate returns the values of the parameter collapsed to a single
+// value by the policy if the parameter is not an array and appears more
+// than once. Otherwise, or if the policy is DuplicateReject, the values are
+// returned as is, so the validation and decoding reject them with
+// DuplicateParamError.
+func Deduplicate(p *spec.Parameter, vals []string, policy DuplicatePolicy) []string {
+	if !isDuplicate(p, vals) {
+		return vals
+	}
+
+	switch policy {
+	case DuplicateFirstWins:
+		return vals[:1]
+	case DuplicateLastWins:
+		return vals[len(vals)-1:]
+	}
+	return vals
+}
+
+// DuplicateError returns DuplicateParamError if the parameter is not an
+// array and appears more than once, or nil otherwise.
+func DuplicateError(p *spec.Parameter, vals []string) error {
+	if !isDuplicate(p, vals) {
+		return nil
+	}
+	return DuplicateParamError{Param: p.Name, Values: vals}
+}
+
+func isDuplicate(p *spec.Parameter, vals []string) bool {
+	return p.Type != "array" && p.Type != "file" && len(vals) > 1
+}
@@ -0,0 +1,97 @@
+package validate
+
+import (
+	"errors"
+
+	oaerrors "github.com/go-openapi/errors"
+
+	"github.com/hypnoglow/oas2/convert"
+)
+
+// Sentinel errors that validation errors of the corresponding kind match,
+// so on Go 1.13+ callers can check the kind without type assertions:
+//
+//  if errors.Is(err, validate.ErrRequired) {
+//      // ...
+//  }
+var (
+	// ErrRequired is matched by RequiredError.
+	ErrRequired = errors.New("value is required")
+
+	// ErrType is matched by TypeError.
+	ErrType = errors.New("value is of invalid type")
+
+	// ErrRange is matched by RangeError.
+	ErrRange = errors.New("value is out of range")
+)
+
+// RequiredError describes a missing required parameter or property.
+type RequiredError struct {
+	valErr
+}
+
+// Is reports whether the target is ErrRequired.
+func (e RequiredError) Is(target error) bool {
+	return target == ErrRequired
+}
+
+// TypeError describes a value of a type that does not match the spec, e.g.
+// a string passed to an integer parameter.
+type TypeError struct {
+	valErr
+}
+
+// Is reports whether the target is ErrType.
+func (e TypeError) Is(target error) bool {
+	return target == ErrType
+}
+
+// RangeError describes a value that violates bounds of the spec: minimum
+// and maximum of numbers, lengths of strings, and numbers of array items or
+// object properties.
+type RangeError struct {
+	valErr
+}
+
+// Is reports whether the target is ErrRange.
+func (e RangeError) Is(target error) bool {
+	return target == ErrRange
+}
+
+// classify returns the error as the exported type of its kind by the
+// go-openapi validation code, or as is if the kind has no exported type.
+func classify(code int32, e valErr) ValidationError {
+	switch code {
+	case oaerrors.RequiredFailCode:
+		return RequiredError{e}
+	case oaerrors.InvalidTypeCode:
+		return TypeError{e}
+	case oaerrors.MaxFailCode, oaerrors.MinFailCode,
+		oaerrors.TooLongFailCode, oaerrors.TooShortFailCode,
+		oaerrors.MaxItemsFailCode, oaerrors.MinItemsFailCode,
+		oaerrors.TooManyPropertiesCode, oaerrors.TooFewPropertiesCode:
+		return RangeError{e}
+	}
+	return e
+}
+
+// classifyError is like classify for errors reported by go-openapi
+// validators.
+func classifyError(err error, e valErr) ValidationError {
+	if ce, ok := err.(oaerrors.Error); ok {
+		return classify(ce.Code(), e)
+	}
+	return e
+}
+
+// classifyConversion returns the error of conversion of a parameter value as
+// TypeError with the hint of the conversion error if the value is not of the
+// parameter type, or as is if the conversion failed for another reason,
+// e.g. a format that is not supported.
+func classifyConversion(err error, e valErr) ValidationError {
+	if ne, ok := err.(*convert.NumberError); ok {
+		e.hint = ne.Hint
+		return TypeError{e}
+	}
+	return e
+}
@@ -0,0 +1,78 @@
+//go:build go1.13
+// +build go1.13
+
+package validate
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/go-openapi/spec"
+)
+
+func TestErrors_kinds(t *testing.T) {
+	var sch spec.Schema
+	err := json.Unmarshal([]byte(`{
+		"type": "object",
+		"required": ["name"],
+		"properties": {
+			"age": {"type": "integer", "maximum": 200},
+			"tag": {"type": "string"}
+		}
+	}`), &sch)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var data interface{}
+	if err = json.Unmarshal([]byte(`{"age":300,"tag":1}`), &data); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	errs := BySchema(&sch, data)
+	if len(errs) != 3 {
+		t.Fatalf("Expected 3 errors, got %v", errs)
+	}
+
+	var re RangeError
+	if !errors.As(errs[0], &re) || !errors.Is(errs[0], ErrRange) || re.Pointer() != "/age" {
+		t.Errorf("Expected range error at /age, got %#v", errs[0])
+	}
+	var rq RequiredError
+	if !errors.As(errs[1], &rq) || !errors.Is(errs[1], ErrRequired) || rq.Pointer() != "/name" {
+		t.Errorf("Expected required error at /name, got %#v", errs[1])
+	}
+	var te TypeError
+	if !errors.As(errs[2], &te) || !errors.Is(errs[2], ErrType) || te.Field() != "tag" {
+		t.Errorf("Expected type error of tag, got %#v", errs[2])
+	}
+	if errors.Is(errs[2], ErrRequired) {
+		t.Errorf("Expected type error not to match ErrRequired")
+	}
+}
+
+func TestErrors_query(t *testing.T) {
+	name := spec.QueryParam("name").Typed("string", "").AsRequired()
+	limit := spec.QueryParam("limit").Typed("integer", "int32").WithMaximum(100, false)
+	ps := []spec.Parameter{*name, *limit}
+
+	errs := Query(ps, url.Values{"limit": {"x"}})
+	if len(errs) != 2 || !errors.Is(errs[0], ErrRequired) || !errors.Is(errs[1], ErrType) {
+		t.Errorf("Expected required and type errors, got %v", errs)
+	}
+
+	errs = Query(ps, url.Values{"name": {"john"}, "limit": {"101"}})
+	if len(errs) != 1 || !errors.Is(errs[0], ErrRange) {
+		t.Errorf("Expected range error, got %v", errs)
+	}
+
+	// Conversion failures that are not caused by the value are not type
+	// errors.
+	tag := spec.QueryParam("tag").Typed("string", "binary")
+	errs = Query([]spec.Parameter{*tag}, url.Values{"tag": {"x"}})
+	if len(errs) != 1 || errors.Is(errs[0], ErrType) {
+		t.Errorf("Expected unclassified error, got %v", errs)
+	}
+}
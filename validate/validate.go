@@ -11,11 +11,33 @@
 //          // ...
 //      }
 //  }
+//
+// Errors of body and schema validation also implement PointerError, so the
+// exact location of the error in the JSON document can be retrieved.
+//
+// Errors of values that cannot be converted to the type of the parameter
+// implement HintError, which tells how to fix numbers in locale formats,
+// e.g. "185,5".
+//
+// Errors of the common kinds are of types RequiredError, TypeError and
+// RangeError, which also match the sentinels ErrRequired, ErrType and
+// ErrRange with errors.Is.
+//
+// Non-array query parameters that appear more than once are rejected with
+// DuplicateParamError. Use Deduplicate to collapse them by DuplicatePolicy
+// before validation instead.
+//
+// Errors are returned in a stable order: errors of parameters follow the
+// order of parameters, and errors of body and schema validation are sorted
+// by JSON Pointer, see SortByPointer.
 package validate
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/url"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/go-openapi/errors"
@@ -43,7 +65,12 @@ func Query(ps []spec.Parameter, q url.Values) []error {
 	}
 
 	// Check that no additional parameters passed.
+	unknown := make([]string, 0, len(q))
 	for name := range q {
+		unknown = append(unknown, name)
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
 		errs = append(errs, ValidationErrorf(name, q.Get(name), "parameter %s is unknown", name))
 	}
 
@@ -63,12 +90,12 @@ func Body(ps []spec.Parameter, data interface{}) []error {
 		errs = append(errs, validateBodyParam(p, data)...)
 	}
 
-	return errs.Errors()
+	return SortByPointer(errs.Errors())
 }
 
 // BySchema validates data by spec and returns errors if any.
 func BySchema(sch *spec.Schema, data interface{}) []error {
-	return validatebySchema(sch, data).Errors()
+	return SortByPointer(validatebySchema(sch, data).Errors())
 }
 
 // ValidationError describes validation error.
@@ -83,8 +110,128 @@ type ValidationError interface {
 	Value() interface{}
 }
 
+// PointerError describes an error that occurred at a specific location
+// of a JSON document.
+type PointerError interface {
+	error
+
+	// Pointer returns RFC 6901 JSON Pointer to the location of the error
+	// in the document, e.g. "/pets/3/age". Empty pointer refers to the whole
+	// document.
+	Pointer() string
+}
+
+// SortByPointer sorts errors that implement PointerError by their pointers,
+// see ComparePointers. The sort is stable, so errors at the same location
+// keep their relative order. Errors without pointers are placed after the
+// others in their original order. It returns errs for convenience.
+func SortByPointer(errs []error) []error {
+	sort.SliceStable(errs, func(i, j int) bool {
+		pi, iok := errs[i].(PointerError)
+		pj, jok := errs[j].(PointerError)
+		if !iok || !jok {
+			return iok && !jok
+		}
+		return ComparePointers(pi.Pointer(), pj.Pointer()) < 0
+	})
+	return errs
+}
+
+// ComparePointers compares two JSON Pointers token by token and returns
+// -1, 0 or +1. Tokens are compared unescaped; tokens that are array indexes
+// are compared numerically,
+// so "/pets/2" goes before "/pets/10", and a pointer goes before pointers
+// to its descendants.
+func ComparePointers(a, b string) int {
+	if a == b {
+		return 0
+	}
+
+	at, bt := pointerTokens(a), pointerTokens(b)
+	for i := 0; i < len(at) && i < len(bt); i++ {
+		if c := compareTokens(at[i], bt[i]); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case len(at) < len(bt):
+		return -1
+	case len(at) > len(bt):
+		return 1
+	}
+	return 0
+}
+
+func pointerTokens(pointer string) []string {
+	if pointer == "" {
+		return nil
+	}
+	tokens := strings.Split(strings.TrimPrefix(pointer, "/"), "/")
+	for i, token := range tokens {
+		tokens[i] = unescapePointerToken(token)
+	}
+	return tokens
+}
+
+func compareTokens(a, b string) int {
+	an, aerr := strconv.ParseUint(a, 10, 64)
+	bn, berr := strconv.ParseUint(b, 10, 64)
+	switch {
+	case aerr == nil && berr == nil:
+		if an != bn {
+			if an < bn {
+				return -1
+			}
+			return 1
+		}
+		return 0
+	case aerr == nil:
+		// Indexes go before property names.
+		return -1
+	case berr == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// Header validates values of the response header by spec and returns errors
+// if any. String values are checked against the header format, e.g.
+// date-time.
+func Header(name string, h spec.Header, vals []string) []error {
+	p := spec.Parameter{
+		ParamProps:        spec.ParamProps{Name: name, In: "header"},
+		SimpleSchema:      h.SimpleSchema,
+		CommonValidations: h.CommonValidations,
+	}
+
+	var value interface{}
+	if p.Type == "string" && len(vals) == 1 {
+		// Formats of strings are checked by the validator,
+		// so the value does not need conversion.
+		value = vals[0]
+	} else {
+		v, err := convert.Parameter(vals, &p)
+		if err != nil {
+			return []error{classifyConversion(err, newValErr(name, strings.Join(vals, ","), "header %s: %s", name, err))}
+		}
+		value = v
+	}
+
+	errs := make(ValidationErrors, 0)
+	if result := validate.NewParamValidator(&p, formatRegistry).Validate(value); result != nil {
+		for _, e := range result.Errors {
+			errs = append(errs, classifyError(e, newValErr(name, value, e.Error())))
+		}
+	}
+	return errs.Errors()
+}
+
 // ValidationErrorf returns a new formatted ValidationError.
 func ValidationErrorf(field string, value interface{}, format string, args ...interface{}) ValidationError {
+	return newValErr(field, value, format, args...)
+}
+
+func newValErr(field string, value interface{}, format string, args ...interface{}) valErr {
 	return valErr{
 		message: fmt.Sprintf(format, args...),
 		field:   field,
@@ -112,20 +259,24 @@ func validateQueryParam(p spec.Parameter, q url.Values) (errs ValidationErrors)
 	_, ok := q[p.Name]
 	if !ok {
 		if p.Required {
-			errs = append(errs, ValidationErrorf(p.Name, nil, "param %s is required", p.Name))
+			errs = append(errs, RequiredError{newValErr(p.Name, nil, "param %s is required", p.Name)})
 		}
 		return errs
 	}
 
+	if isDuplicate(&p, q[p.Name]) {
+		return append(errs, DuplicateParamError{Param: p.Name, Values: q[p.Name]})
+	}
+
 	value, err := convert.Parameter(q[p.Name], &p)
 	if err != nil {
 		// TODO: q.Get(p.Name) relies on type that is not array/file.
-		return append(errs, ValidationErrorf(p.Name, q.Get(p.Name), "param %s: %s", p.Name, err))
+		return append(errs, classifyConversion(err, newValErr(p.Name, q.Get(p.Name), "param %s: %s", p.Name, err)))
 	}
 
 	if result := validate.NewParamValidator(&p, formatRegistry).Validate(value); result != nil {
 		for _, e := range result.Errors {
-			errs = append(errs, ValidationErrorf(p.Name, value, e.Error()))
+			errs = append(errs, classifyError(e, newValErr(p.Name, value, e.Error())))
 		}
 	}
 
@@ -137,23 +288,142 @@ func validateBodyParam(p spec.Parameter, data interface{}) (errs ValidationError
 }
 
 func validatebySchema(sch *spec.Schema, data interface{}) (errs ValidationErrors) {
-	err := validate.AgainstSchema(sch, data, formatRegistry)
-	ves, ok := err.(*errors.CompositeError)
-	if ok && len(ves.Errors) > 0 {
-		for _, e := range ves.Errors {
-			ve := e.(*errors.Validation)
-			errs = append(errs, ValidationErrorf(strings.TrimPrefix(ve.Name, "."), nil, strings.TrimPrefix(ve.Error(), ".")))
+	return validateAt(sch, data, "", "")
+}
+
+// validateAt validates data located by the pointer in the document. Name is
+// the dotted path of the data used by go-openapi in error messages, which
+// lacks array indexes, so the pointer is tracked separately: objects and
+// arrays are validated level by level when possible.
+func validateAt(sch *spec.Schema, data interface{}, name, pointer string) (errs ValidationErrors) {
+	obj, isObject := data.(map[string]interface{})
+	arr, isArray := data.([]interface{})
+
+	descend := !isComplexSchema(sch) &&
+		((isObject && len(sch.Properties) > 0) || (isArray && sch.Items != nil && sch.Items.Schema != nil))
+	if !descend {
+		return validateSchemaAt(sch, data, name, pointer)
+	}
+
+	shallow := *sch
+	shallow.Properties = nil
+	shallow.Items = nil
+	errs = validateSchemaAt(&shallow, data, name, pointer)
+
+	if isObject {
+		names := make([]string, 0, len(sch.Properties))
+		for k := range sch.Properties {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+
+		for _, k := range names {
+			v, ok := obj[k]
+			if !ok {
+				continue
+			}
+			prop := sch.Properties[k]
+			errs = append(errs, validateAt(&prop, v, joinName(name, k), pointer+"/"+escapePointerToken(k))...)
+		}
+	}
+
+	if isArray {
+		for i, v := range arr {
+			errs = append(errs, validateAt(sch.Items.Schema, v, name, pointer+"/"+strconv.Itoa(i))...)
 		}
 	}
 
 	return errs
 }
 
-// valErr implements ValidationError.
+// isComplexSchema checks if the schema cannot be validated level by level.
+func isComplexSchema(sch *spec.Schema) bool {
+	return sch.Ref.String() != "" ||
+		len(sch.AllOf) > 0 || len(sch.AnyOf) > 0 || len(sch.OneOf) > 0 || sch.Not != nil ||
+		sch.AdditionalProperties != nil || len(sch.PatternProperties) > 0 ||
+		(sch.Items != nil && len(sch.Items.Schemas) > 0)
+}
+
+// validateSchemaAt validates data by the schema as a whole.
+func validateSchemaAt(sch *spec.Schema, data interface{}, name, pointer string) (errs ValidationErrors) {
+	var verrs []error
+	if num, ok := data.(json.Number); ok && sch.Type.Contains("integer") {
+		// go-openapi silently accepts json.Number that is not an integer
+		// for integer schemas.
+		if _, err := num.Int64(); err != nil {
+			verrs = append(verrs, errors.InvalidType(name, "body", "integer", "number"))
+		}
+	}
+	if res := validate.NewSchemaValidator(sch, nil, name, formatRegistry).Validate(data); res != nil {
+		verrs = append(verrs, res.Errors...)
+	}
+
+	for _, e := range verrs {
+		ve, ok := e.(*errors.Validation)
+		if !ok {
+			continue
+		}
+		field := strings.TrimPrefix(ve.Name, ".")
+		errs = append(errs, classify(ve.Code(), valErr{
+			message: strings.TrimPrefix(ve.Error(), "."),
+			field:   field,
+			pointer: pointerAt(field, name, pointer),
+		}))
+	}
+
+	return errs
+}
+
+// pointerAt returns the pointer to the field, given the pointer to the data
+// with the name. Fields below the data are assumed to be object properties.
+func pointerAt(field, name, pointer string) string {
+	rel := field
+	if name != "" {
+		rel = strings.TrimPrefix(strings.TrimPrefix(field, name), ".")
+	}
+	if rel == "" {
+		return pointer
+	}
+
+	for _, token := range strings.Split(rel, ".") {
+		pointer += "/" + escapePointerToken(token)
+	}
+	return pointer
+}
+
+func joinName(name, k string) string {
+	if name == "" {
+		return k
+	}
+	return name + "." + k
+}
+
+// escapePointerToken escapes the JSON Pointer reference token, see
+// https://tools.ietf.org/html/rfc6901#section-3
+func escapePointerToken(token string) string {
+	return strings.Replace(strings.Replace(token, "~", "~0", -1), "/", "~1", -1)
+}
+
+func unescapePointerToken(token string) string {
+	return strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+}
+
+// HintError describes an error that can tell how to fix the value, e.g.
+// a number in a locale format.
+type HintError interface {
+	error
+
+	// Hint returns the hint on how to fix the value, or an empty string.
+	Hint() string
+}
+
+// valErr implements ValidationError, PointerError and HintError.
 type valErr struct {
 	message string
 	field   string
 	value   interface{}
+	pointer string
+	hint    string
 }
 
 func (v valErr) Error() string {
@@ -167,3 +437,11 @@ func (v valErr) Field() string {
 func (v valErr) Value() interface{} {
 	return v.value
 }
+
+func (v valErr) Pointer() string {
+	return v.pointer
+}
+
+func (v valErr) Hint() string {
+	return v.hint
+}
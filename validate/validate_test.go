@@ -72,6 +72,25 @@ func TestQuery(t *testing.T) {
 				ValidationErrorf("age", "johndoe", "param age: cannot convert johndoe to int32"),
 			},
 		},
+		// error on duplicate parameter
+		{
+			ps: []spec.Parameter{
+				{
+					ParamProps: spec.ParamProps{
+						Name: "age",
+						In:   "query",
+					},
+					SimpleSchema: spec.SimpleSchema{
+						Type:   "integer",
+						Format: "int32",
+					},
+				},
+			},
+			q: url.Values{"age": {"1", "2"}},
+			expectedErrors: []error{
+				DuplicateParamError{Param: "age", Values: []string{"1", "2"}},
+			},
+		},
 		// error on parameter validation
 		{
 			ps: []spec.Parameter{
@@ -119,7 +138,7 @@ func TestQuery(t *testing.T) {
 
 	for _, c := range cases {
 		errs := Query(c.ps, c.q)
-		if !reflect.DeepEqual(c.expectedErrors, errs) {
+		if !reflect.DeepEqual(c.expectedErrors, unclassified(errs)) {
 			t.Errorf("Expected errors to be\n%#v\n but got\n%#v", c.expectedErrors, errs)
 		}
 	}
@@ -223,18 +242,125 @@ func TestBySchema(t *testing.T) {
 				},
 			},
 			data:           testhelperMakeUserData("Max"),
-			expectedErrors: []error{ValidationErrorf("name", nil, "name in body should be at least 4 chars long")},
+			expectedErrors: []error{valErr{message: "name in body should be at least 4 chars long", field: "name", pointer: "/name"}},
 		},
 	}
 
 	for _, c := range cases {
 		errs := BySchema(c.sch, c.data)
-		if !reflect.DeepEqual(c.expectedErrors, errs) {
+		if !reflect.DeepEqual(c.expectedErrors, unclassified(errs)) {
 			t.Errorf("Expected errors to be %#v but got %#v", c.expectedErrors, errs)
 		}
 	}
 }
 
+func TestBySchema_pointer(t *testing.T) {
+	var sch spec.Schema
+	err := json.Unmarshal([]byte(`{
+		"type": "object",
+		"required": ["pets"],
+		"properties": {
+			"pets": {
+				"type": "array",
+				"items": {
+					"type": "object",
+					"required": ["name"],
+					"properties": {
+						"name": {"type": "string"},
+						"age": {"type": "integer"},
+						"a/b": {"type": "string"}
+					}
+				}
+			}
+		}
+	}`), &sch)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var data interface{}
+	if err = json.Unmarshal([]byte(`{"pets":[{"name":"Rex"},{"age":"old","a/b":1}]}`), &data); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	type located struct {
+		message string
+		pointer string
+	}
+	var actual []located
+	for _, err := range BySchema(&sch, data) {
+		actual = append(actual, located{err.Error(), err.(PointerError).Pointer()})
+	}
+
+	expected := []located{
+		{"pets.a/b in body must be of type string: \"number\"", "/pets/1/a~1b"},
+		{"pets.age in body must be of type integer: \"string\"", "/pets/1/age"},
+		{"pets.name in body is required", "/pets/1/name"},
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Expected errors to be\n%v\n but got\n%v", expected, actual)
+	}
+}
+
+func TestQuery_unknownOrder(t *testing.T) {
+	q := url.Values{"zeta": {"1"}, "alpha": {"2"}, "mu": {"3"}}
+
+	var actual []string
+	for _, err := range Query(nil, q) {
+		actual = append(actual, err.Error())
+	}
+
+	expected := []string{
+		"parameter alpha is unknown",
+		"parameter mu is unknown",
+		"parameter zeta is unknown",
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Expected errors to be\n%v\n but got\n%v", expected, actual)
+	}
+}
+
+func TestComparePointers(t *testing.T) {
+	cases := []struct {
+		a, b     string
+		expected int
+	}{
+		{"", "", 0},
+		{"", "/a", -1},
+		{"/a", "/a/b", -1},
+		{"/pets/2", "/pets/10", -1},
+		{"/pets/10/name", "/pets/2/name", 1},
+		{"/pets/0", "/pets/name", -1},
+		{"/b", "/a/c", 1},
+	}
+
+	for _, c := range cases {
+		if actual := ComparePointers(c.a, c.b); actual != c.expected {
+			t.Errorf("ComparePointers(%q, %q): expected %d, got %d", c.a, c.b, c.expected, actual)
+		}
+	}
+}
+
+func TestSortByPointer(t *testing.T) {
+	errs := []error{
+		valErr{message: "b", pointer: "/pets/10"},
+		fmt.Errorf("no pointer"),
+		valErr{message: "c", pointer: "/pets/2"},
+		valErr{message: "a", pointer: ""},
+		valErr{message: "d", pointer: "/pets/2"},
+	}
+
+	var actual []string
+	for _, err := range SortByPointer(errs) {
+		actual = append(actual, err.Error())
+	}
+
+	expected := []string{"a", "c", "d", "b", "no pointer"}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Expected errors to be\n%v\n but got\n%v", expected, actual)
+	}
+}
+
 func TestValidationError(t *testing.T) {
 	ve := ValidationErrorf("name", nil, "name cannot be empty")
 
@@ -260,6 +386,23 @@ func testhelperMakeUserData(name string) interface{} {
 	return v
 }
 
+// unclassified returns the errors with their kinds stripped, so they can be
+// compared to errors made by ValidationErrorf. The kinds are tested by
+// TestErrors_kinds and TestErrors_query.
+func unclassified(errs []error) []error {
+	for i, err := range errs {
+		switch e := err.(type) {
+		case RequiredError:
+			errs[i] = e.valErr
+		case TypeError:
+			errs[i] = e.valErr
+		case RangeError:
+			errs[i] = e.valErr
+		}
+	}
+	return errs
+}
+
 func int64Ptr(f int64) *int64 {
 	return &f
 }
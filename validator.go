@@ -0,0 +1,197 @@
+package oas
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// Validator is a custom validator of requests, e.g. a profanity filter or
+// geo restrictions, shipped as a plugin. Validators are registered on the
+// basis with BasisValidator and run by ResolvingBasis.CustomValidator.
+type Validator interface {
+	// Name is the name of the validator. It is the name of the check the
+	// validator performs, so validators are recorded to validation reports
+	// and toggled by flag providers by this name.
+	Name() string
+
+	// Validate validates the request to the operation. The returned error
+	// is passed to the problem handler; return a MultiError to report
+	// several errors.
+	Validate(ctx context.Context, req *http.Request, op *Operation) error
+}
+
+// ValidatorOption is an option for BasisValidator.
+type ValidatorOption func(*validatorPlugin)
+
+// ValidatorOrder returns a validator option that sets the order of the
+// validator. Validators run in ascending order; validators of the same order
+// run in order of registration. The default order is 0.
+func ValidatorOrder(order int) ValidatorOption {
+	return func(v *validatorPlugin) {
+		v.info.Order = order
+	}
+}
+
+// ValidatorTags returns a validator option that restricts the validator to
+// operations with any of the tags. By default, the validator runs for all
+// operations.
+func ValidatorTags(tags ...string) ValidatorOption {
+	return func(v *validatorPlugin) {
+		v.info.Tags = tags
+	}
+}
+
+// ValidatorProblemKind returns a validator option that sets the kind of
+// problems of the validator. By default, problems are semantic.
+func ValidatorProblemKind(kind ProblemKind) ValidatorOption {
+	return func(v *validatorPlugin) {
+		v.info.ProblemKind = kind
+	}
+}
+
+// ValidatorInfo describes a registered validator.
+type ValidatorInfo struct {
+	Name        string
+	Order       int
+	Tags        []string
+	ProblemKind ProblemKind
+}
+
+// validatorPlugin is a registered validator.
+type validatorPlugin struct {
+	v    Validator
+	info ValidatorInfo
+}
+
+// appliesTo checks if the validator applies to the operation.
+func (p validatorPlugin) appliesTo(op *Operation) bool {
+	if len(p.info.Tags) == 0 {
+		return true
+	}
+	for _, tag := range p.info.Tags {
+		for _, opTag := range op.Tags {
+			if tag == opTag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// BasisValidator returns a basis option that registers the validator on the
+// basis, so CustomValidator middleware derived from the basis runs it. It
+// panics if the validator is nil.
+func BasisValidator(v Validator, opts ...ValidatorOption) BasisOption {
+	if v == nil {
+		panic("oas: BasisValidator validator is nil")
+	}
+
+	p := validatorPlugin{
+		v:    v,
+		info: ValidatorInfo{Name: v.Name(), ProblemKind: ProblemKindSemantic},
+	}
+	for _, opt := range opts {
+		opt(&p)
+	}
+
+	return func(b *ResolvingBasis) {
+		b.validators = append(b.validators, p)
+		sort.SliceStable(b.validators, func(i, j int) bool {
+			return b.validators[i].info.Order < b.validators[j].info.Order
+		})
+	}
+}
+
+// Validators returns the validators registered on the basis, in order they
+// run.
+func (b *ResolvingBasis) Validators() []ValidatorInfo {
+	infos := make([]ValidatorInfo, len(b.validators))
+	for i, p := range b.validators {
+		infos[i] = p.info
+	}
+	return infos
+}
+
+// CustomValidator returns a middleware that runs the validators registered
+// on the basis with BasisValidator. Validators run in order until one of
+// them fails, and their failures are handled by the problem handler, which
+// responds with 400 by default. Every validator is recorded to the
+// validation report as a check named after the validator, and can be
+// toggled by the flag provider by this name.
+func (b *ResolvingBasis) CustomValidator(opts ...MiddlewareOption) Middleware {
+	options := b.middlewareOptions(opts)
+	if options.problemHandler == nil {
+		options.problemHandler = newProblemHandlerErrorResponder()
+		if options.hideProblemDetails {
+			options.problemHandler = newProblemHandlerDetailsHider(options.problemHandler)
+		}
+	}
+
+	validators := append([]validatorPlugin(nil), b.validators...)
+
+	return func(next http.Handler) http.Handler {
+		return &customValidator{
+			next:              next,
+			validators:        validators,
+			problemHandler:    options.problemHandler,
+			continueOnProblem: options.continueOnProblem,
+			flags:             options.flagProvider,
+			strict:            b.strict,
+		}
+	}
+}
+
+// customValidator is a middleware that resolves operation context from the
+// request and runs custom validators.
+type customValidator struct {
+	next       http.Handler
+	validators []validatorPlugin
+
+	problemHandler    ProblemHandler
+	continueOnProblem bool
+
+	flags FlagProvider
+
+	// strict enforces validation. If false, then validation is not
+	// applied to requests without operation context.
+	strict bool
+}
+
+func (mw *customValidator) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	oi, ok := getOperationInfo(req)
+	if !ok {
+		if mw.strict {
+			panic("custom validator middleware: cannot find operation info in the request context")
+		}
+		mw.next.ServeHTTP(w, req)
+		return
+	}
+
+	op := wrapOperation(oi.operation)
+	for _, p := range mw.validators {
+		if !p.appliesTo(op) {
+			continue
+		}
+
+		mode := validatorMode(mw.flags, req, p.info.Name)
+		if mode == ValidatorOff {
+			continue
+		}
+
+		start := time.Now()
+		err := p.v.Validate(req.Context(), req, op)
+		recordCheck(req, p.info.Name, start, err, nil)
+		if err == nil {
+			continue
+		}
+
+		mw.problemHandler.HandleProblem(newProblemOfKind(w, req, err, p.info.ProblemKind))
+		if !mw.continueOnProblem && mode != ValidatorObserve {
+			return
+		}
+	}
+
+	mw.next.ServeHTTP(w, req)
+}
@@ -0,0 +1,82 @@
+package oas
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testValidator struct {
+	name  string
+	calls *[]string
+	err   error
+}
+
+func (v testValidator) Name() string {
+	return v.name
+}
+
+func (v testValidator) Validate(ctx context.Context, req *http.Request, op *Operation) error {
+	*v.calls = append(*v.calls, v.name+" "+op.ID)
+	return v.err
+}
+
+func TestResolvingBasis_CustomValidator(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+
+	var calls []string
+	basis := NewResolvingBasis(SpecAdapterName, doc,
+		BasisStrict(false),
+		BasisValidator(testValidator{name: "geo", calls: &calls}, ValidatorOrder(10)),
+		BasisValidator(testValidator{name: "profanity", calls: &calls, err: errors.New("name contains profanity")}, ValidatorTags("pet")),
+		BasisValidator(testValidator{name: "audit", calls: &calls}, ValidatorOrder(-1)),
+	)
+
+	assert.Equal(t, []ValidatorInfo{
+		{Name: "audit", Order: -1, ProblemKind: ProblemKindSemantic},
+		{Name: "profanity", Tags: []string{"pet"}, ProblemKind: ProblemKindSemantic},
+		{Name: "geo", Order: 10, ProblemKind: ProblemKindSemantic},
+	}, basis.Validators())
+
+	serve := func(method, path string, opts ...MiddlewareOption) (*httptest.ResponseRecorder, *ValidationReport) {
+		calls = nil
+		var report *ValidationReport
+		h := SpecMatcherMiddleware(doc)(
+			ValidationReportContext()(
+				http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+					report, _ = GetValidationReport(req)
+					basis.CustomValidator(opts...)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {})).ServeHTTP(w, req)
+				}),
+			),
+		)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(`{}`)))
+		return w, report
+	}
+
+	w, report := serve(http.MethodGet, "/v2/user/login")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"audit loginUser", "geo loginUser"}, calls, "profanity validator applies to pet operations only")
+	assert.Len(t, report.Checks(), 2)
+
+	w, report = serve(http.MethodPost, "/v2/pet")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "name contains profanity", strings.TrimSpace(w.Body.String()))
+	assert.Equal(t, []string{"audit addPet", "profanity addPet"}, calls)
+	assert.False(t, report.Passed())
+
+	flags := FlagProviderFunc(func(req *http.Request, check string) ValidatorMode {
+		if check == "profanity" {
+			return ValidatorObserve
+		}
+		return ValidatorEnforce
+	})
+	w, _ = serve(http.MethodPost, "/v2/pet", WithFlagProvider(flags), WithProblemHandler(ProblemHandlerFunc(func(p Problem) {})))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"audit addPet", "profanity addPet", "geo addPet"}, calls)
+}
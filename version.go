@@ -0,0 +1,192 @@
+package oas
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// VersionResolver resolves the spec version requested by the request.
+// It returns an empty string if the request does not specify the version.
+type VersionResolver func(req *http.Request) string
+
+// HeaderVersionResolver returns a VersionResolver that takes the version
+// from the request header, e.g. "Accept-Version".
+func HeaderVersionResolver(header string) VersionResolver {
+	return func(req *http.Request) string {
+		return strings.TrimSpace(req.Header.Get(header))
+	}
+}
+
+// PinVersion returns a VersionResolver that resolves the version for
+// requests selected by pin, e.g. canary clients, and uses next for other
+// requests. This allows to pin specific clients to a candidate Document
+// served alongside the primary one:
+//
+//  oas.NewVersionedHandler(
+//      map[string]http.Handler{"primary": primary, "candidate": candidate},
+//      oas.WithVersionResolver(oas.PinVersion("candidate", oas.HeaderPin("X-Client-ID", "canary-1"), nil)),
+//      oas.WithDefaultVersion("primary"),
+//  )
+//
+// If next is nil, other requests do not specify the version.
+func PinVersion(version string, pin func(req *http.Request) bool, next VersionResolver) VersionResolver {
+	return func(req *http.Request) string {
+		if pin(req) {
+			return version
+		}
+		if next == nil {
+			return ""
+		}
+		return next(req)
+	}
+}
+
+// HeaderPin returns a pin function for PinVersion that selects requests
+// with the header having any of the values, e.g. client ids. To pin by
+// principal, use a custom function that takes the principal from the
+// request context.
+func HeaderPin(header string, values ...string) func(req *http.Request) bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return func(req *http.Request) bool {
+		return set[req.Header.Get(header)]
+	}
+}
+
+// VersionOption is an option for NewVersionedHandler.
+type VersionOption func(*VersionedHandler)
+
+// WithVersionResolver returns an option that sets the resolver of requested
+// versions. By default, versions are taken from Accept-Version header.
+func WithVersionResolver(r VersionResolver) VersionOption {
+	return func(h *VersionedHandler) {
+		h.resolve = r
+	}
+}
+
+// WithDefaultVersion returns an option that sets the version to serve
+// requests that do not specify the version. By default, such requests are
+// rejected.
+func WithDefaultVersion(version string) VersionOption {
+	return func(h *VersionedHandler) {
+		h.defaultVersion = version
+	}
+}
+
+// NewVersionedHandler returns a handler that serves multiple spec versions on
+// the same paths. Handlers are keyed by versions; each one is usually built
+// from its own Document, with its own basis and middleware. This allows to
+// run behaviors of several spec versions side by side, e.g. during
+// migrations.
+//
+// Requests for unknown versions are rejected with 406.
+func NewVersionedHandler(handlers map[string]http.Handler, opts ...VersionOption) *VersionedHandler {
+	h := &VersionedHandler{
+		handlers: handlers,
+		resolve:  HeaderVersionResolver("Accept-Version"),
+		stats:    make(map[string]*versionStats, len(handlers)),
+	}
+	for version := range handlers {
+		h.stats[version] = &versionStats{}
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// VersionedHandler dispatches requests to handlers of spec versions.
+type VersionedHandler struct {
+	handlers       map[string]http.Handler
+	resolve        VersionResolver
+	defaultVersion string
+
+	stats map[string]*versionStats
+}
+
+// VersionStats describe responses served with a spec version, so the
+// behavior of versions can be compared, e.g. during canary rollouts.
+type VersionStats struct {
+	// Requests is the number of served requests.
+	Requests int64
+
+	// ClientErrors is the number of 4xx responses, e.g. validation errors.
+	ClientErrors int64
+
+	// ServerErrors is the number of 5xx responses.
+	ServerErrors int64
+}
+
+type versionStats struct {
+	requests     int64
+	clientErrors int64
+	serverErrors int64
+}
+
+// Stats returns the stats by spec version.
+func (h *VersionedHandler) Stats() map[string]VersionStats {
+	stats := make(map[string]VersionStats, len(h.stats))
+	for version, st := range h.stats {
+		stats[version] = VersionStats{
+			Requests:     atomic.LoadInt64(&st.requests),
+			ClientErrors: atomic.LoadInt64(&st.clientErrors),
+			ServerErrors: atomic.LoadInt64(&st.serverErrors),
+		}
+	}
+	return stats
+}
+
+// ServeHTTP implements http.Handler.
+func (h *VersionedHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	version := h.resolve(req)
+	if version == "" {
+		version = h.defaultVersion
+	}
+
+	next, ok := h.handlers[version]
+	if !ok {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusNotAcceptable)
+		fmt.Fprintf(w, "requested spec version %q is not available, available versions: %s", version, strings.Join(h.versions(), ", "))
+		return
+	}
+
+	w.Header().Set("Content-Version", version)
+
+	ww := newWrapResponseWriter(w, req.ProtoMajor)
+	next.ServeHTTP(ww, req.WithContext(context.WithValue(req.Context(), contextKeySpecVersion{}, version)))
+
+	st := h.stats[version]
+	atomic.AddInt64(&st.requests, 1)
+	switch status := ww.Status(); {
+	case status >= 500:
+		atomic.AddInt64(&st.serverErrors, 1)
+	case status >= 400:
+		atomic.AddInt64(&st.clientErrors, 1)
+	}
+}
+
+// versions returns the available versions in order.
+func (h *VersionedHandler) versions() []string {
+	vv := make([]string, 0, len(h.handlers))
+	for v := range h.handlers {
+		vv = append(vv, v)
+	}
+	sort.Strings(vv)
+	return vv
+}
+
+type contextKeySpecVersion struct{}
+
+// GetSpecVersion returns the spec version the request is served with by
+// VersionedHandler.
+func GetSpecVersion(req *http.Request) (string, bool) {
+	v, ok := req.Context().Value(contextKeySpecVersion{}).(string)
+	return v, ok
+}
@@ -0,0 +1,94 @@
+package oas
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersionedHandler(t *testing.T) {
+	version := func(name string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			v, _ := GetSpecVersion(req)
+			fmt.Fprintf(w, "%s %s", name, v)
+		})
+	}
+
+	handlers := map[string]http.Handler{
+		"1.0.0": version("v1"),
+		"2.0.0": version("v2"),
+	}
+
+	testCases := map[string]struct {
+		opts           []VersionOption
+		header         http.Header
+		expectedStatus int
+		expectedBody   string
+	}{
+		"requested version": {
+			header:         http.Header{"Accept-Version": []string{"2.0.0"}},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "v2 2.0.0",
+		},
+		"default version": {
+			opts:           []VersionOption{WithDefaultVersion("1.0.0")},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "v1 1.0.0",
+		},
+		"unknown version": {
+			header:         http.Header{"Accept-Version": []string{"3.0.0"}},
+			expectedStatus: http.StatusNotAcceptable,
+			expectedBody:   `requested spec version "3.0.0" is not available, available versions: 1.0.0, 2.0.0`,
+		},
+		"custom resolver": {
+			opts:           []VersionOption{WithVersionResolver(HeaderVersionResolver("X-API-Version"))},
+			header:         http.Header{"X-Api-Version": []string{"1.0.0"}},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "v1 1.0.0",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			h := NewVersionedHandler(handlers, tc.opts...)
+
+			req := httptest.NewRequest(http.MethodGet, "/v2/pet/1", nil)
+			for k, v := range tc.header {
+				req.Header[k] = v
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, tc.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestPinVersion(t *testing.T) {
+	handlers := map[string]http.Handler{
+		"primary": http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}),
+		"candidate": http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+		}),
+	}
+
+	h := NewVersionedHandler(handlers,
+		WithVersionResolver(PinVersion("candidate", HeaderPin("X-Client-ID", "canary"), nil)),
+		WithDefaultVersion("primary"),
+	)
+
+	for _, client := range []string{"canary", "regular", "regular"} {
+		req := httptest.NewRequest(http.MethodGet, "/v2/pet/1", nil)
+		req.Header.Set("X-Client-ID", client)
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	assert.Equal(t, map[string]VersionStats{
+		"primary":   {Requests: 2},
+		"candidate": {Requests: 1, ClientErrors: 1},
+	}, h.Stats())
+}
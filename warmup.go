@@ -0,0 +1,83 @@
+package oas
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+)
+
+type contextKeyWarmup struct{}
+
+// IsWarmupRequest checks if the request is a synthetic request made by
+// ResolvingBasis.Warmup, e.g. to exclude it from metrics.
+func IsWarmupRequest(req *http.Request) bool {
+	return req.Context().Value(contextKeyWarmup{}) != nil
+}
+
+// Warmup runs a synthetic valid request for every operation in the basis
+// document through the middleware once, so that lazily initialized
+// validation internals, e.g. compiled patterns and formats, are ready
+// before the first real request. This eliminates first-request latency
+// spikes after deploys. Warmup should be called with the same middleware
+// as the one serving the requests:
+//
+//  qv, bv := basis.QueryValidator(), basis.RequestBodyValidator()
+//  if err := basis.Warmup(ctx, qv, bv); err != nil {
+//      log.Printf("warmup: %s", err)
+//  }
+//
+// Requests are built from spec examples, defaults and enums, like the
+// valid cases of GenerateOperationTests, and carry the operation context,
+// so the middleware must not resolve operations on their own. Requests
+// reaching the end of the middleware are responded by mock handlers, so
+// response validators are warmed up too, and no actual handler is called.
+//
+// Warmup returns an error for operations whose synthetic requests are
+// rejected, which usually means the spec examples are invalid, or the
+// context error if the context is done before all operations are warmed up.
+func (b *ResolvingBasis) Warmup(ctx context.Context, mws ...Middleware) error {
+	mocks := MockHandlers(b.doc)
+
+	methods := make(map[string]string, len(b.cache))
+	ids := make([]string, 0, len(b.cache))
+	for method, paths := range b.doc.Analyzer.Operations() {
+		for _, op := range paths {
+			methods[op.ID] = strings.ToUpper(method)
+			ids = append(ids, op.ID)
+		}
+	}
+	sort.Strings(ids)
+
+	var errs []error
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		oi := b.cache[id]
+		g := opTestGenerator{method: methods[id], oi: oi, consumes: oi.consumes}
+		tc := g.generate()[0]
+
+		var h http.Handler = mocks[id]
+		for i := len(mws) - 1; i >= 0; i-- {
+			h = mws[i](h)
+		}
+
+		req := tc.NewRequest()
+		req = req.WithContext(context.WithValue(contextWithOperationInfo(ctx, oi), contextKeyWarmup{}, true))
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code == http.StatusBadRequest || w.Code == http.StatusUnprocessableEntity || w.Code >= 500 {
+			errs = append(errs, fmt.Errorf("operation %s: synthetic request is responded with status %d", id, w.Code))
+		}
+	}
+
+	if len(errs) > 0 {
+		return newMultiError("warmup requests are rejected", errs...)
+	}
+	return nil
+}
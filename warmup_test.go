@@ -0,0 +1,41 @@
+package oas
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolvingBasis_Warmup(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+	b := NewResolvingBasis(SpecAdapterName, doc)
+
+	var seen []string
+	track := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			oi, _ := getOperationInfo(req)
+			assert.True(t, IsWarmupRequest(req))
+			seen = append(seen, oi.operation.ID)
+			next.ServeHTTP(w, req)
+		})
+	}
+
+	t.Run("warms up all operations", func(t *testing.T) {
+		seen = nil
+		err := b.Warmup(context.Background(), track, b.QueryValidator(), b.RequestBodyValidator())
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"addPet", "getPetById", "loginUser"}, seen)
+	})
+
+	t.Run("context is done", func(t *testing.T) {
+		seen = nil
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := b.Warmup(ctx, track)
+		assert.Equal(t, context.Canceled, err)
+		assert.Empty(t, seen)
+	})
+}